@@ -9,12 +9,15 @@ import (
 type (
 	// Config -.
 	Config struct {
-		App    `yaml:"app"`
-		HTTP   `yaml:"http"`
-		Log    `yaml:"logger"`
-		PG     `yaml:"postgres"`
-		JWT    `yaml:"jwt"`
-		Hasher `yaml:"hasher"`
+		App       `yaml:"app"`
+		HTTP      `yaml:"http"`
+		Log       `yaml:"logger"`
+		PG        `yaml:"postgres"`
+		JWT       `yaml:"jwt"`
+		Hasher    `yaml:"hasher"`
+		Auth      `yaml:"auth"`
+		Payment   `yaml:"payment"`
+		Scheduler `yaml:"scheduler"`
 	}
 
 	// App -.
@@ -37,17 +40,157 @@ type (
 	PG struct {
 		PoolMax int    `env-required:"true" yaml:"pool_max" env:"PG_POOL_MAX"`
 		URL     string `env-required:"true" env:"PG_URL"`
+		// IsolationLevel of transactions: "read committed", "repeatable read" or "serializable".
+		IsolationLevel string `env-default:"read committed" yaml:"isolation_level" env:"PG_ISOLATION_LEVEL"`
+		// TxRetries is how many times a transaction is retried after a serialization failure or a deadlock.
+		TxRetries    int           `env-default:"3" yaml:"tx_retries" env:"PG_TX_RETRIES"`
+		TxRetryDelay time.Duration `env-default:"10ms" yaml:"tx_retry_delay" env:"PG_TX_RETRY_DELAY"`
 	}
 
 	// JWT -.
 	JWT struct {
-		SignKey  string        `env-required:"true" env:"JWT_SIGN_KEY"`
-		TokenTTL time.Duration `env-required:"true" yaml:"token_ttl" env:"JWT_TOKEN_TTL"`
+		// KeysDir contains <kid>.pem files, RSA or Ed25519. Public-only keys are used for verification.
+		KeysDir string   `yaml:"keys_dir" env:"JWT_KEYS_DIR"`
+		Keys    []JWTKey `yaml:"keys"`
+		// SigningKeyId may be omitted if there is only one private key.
+		SigningKeyId string `yaml:"signing_key_id" env:"JWT_SIGNING_KEY_ID"`
+		// AllowEphemeralKey signs tokens with a key generated on start if no keys are configured.
+		// The tokens do not survive a restart and are not accepted by other instances.
+		AllowEphemeralKey bool          `env-default:"false" yaml:"allow_ephemeral_key" env:"JWT_ALLOW_EPHEMERAL_KEY"`
+		TokenTTL          time.Duration `env-required:"true" yaml:"token_ttl" env:"JWT_TOKEN_TTL"`
+		RefreshTokenTTL   time.Duration `env-required:"true" yaml:"refresh_token_ttl" env:"JWT_REFRESH_TOKEN_TTL"`
+		// RevocationSyncInterval bounds how long a logout on one instance takes to reach the others.
+		RevocationSyncInterval time.Duration `env-default:"10s" yaml:"revocation_sync_interval" env:"JWT_REVOCATION_SYNC_INTERVAL"`
+	}
+
+	// JWTKey -.
+	JWTKey struct {
+		Id  string `yaml:"id"`
+		PEM string `yaml:"pem"`
+	}
+
+	// Auth -.
+	Auth struct {
+		// Mode is one of auto_register, explicit, invite_only.
+		Mode      string        `env-required:"true" yaml:"mode" env:"AUTH_MODE"`
+		InviteTTL time.Duration `env-default:"168h" yaml:"invite_ttl" env:"AUTH_INVITE_TTL"`
+		// PasswordResetTTL is how long a password reset token issued by an admin is valid.
+		PasswordResetTTL time.Duration      `env-default:"24h" yaml:"password_reset_ttl" env:"AUTH_PASSWORD_RESET_TTL"`
+		Lockout          AuthLockout        `yaml:"lockout"`
+		Proxy            AuthProxy          `yaml:"proxy"`
+		PasswordPolicy   AuthPasswordPolicy `yaml:"password_policy"`
+	}
+
+	// AuthProxy -.
+	AuthProxy struct {
+		// Enabled trusts UserHeader of requests coming directly from TrustedCIDRs, such as an SSO proxy.
+		Enabled      bool     `yaml:"enabled" env:"AUTH_PROXY_ENABLED"`
+		UserHeader   string   `env-default:"X-Forwarded-User" yaml:"user_header" env:"AUTH_PROXY_USER_HEADER"`
+		TrustedCIDRs []string `yaml:"trusted_cidrs" env:"AUTH_PROXY_TRUSTED_CIDRS" env-separator:","`
+		// AutoProvision creates unknown users on their first request.
+		AutoProvision bool `yaml:"auto_provision" env:"AUTH_PROXY_AUTO_PROVISION"`
+	}
+
+	// AuthLockout -.
+	AuthLockout struct {
+		// Threshold is the number of failed logins per username before lockout, 0 disables lockouts.
+		Threshold   int           `env-default:"5" yaml:"threshold" env:"AUTH_LOCKOUT_THRESHOLD"`
+		IPThreshold int           `env-default:"50" yaml:"ip_threshold" env:"AUTH_LOCKOUT_IP_THRESHOLD"`
+		BaseLockout time.Duration `env-default:"30s" yaml:"base_lockout" env:"AUTH_LOCKOUT_BASE"`
+		MaxLockout  time.Duration `env-default:"15m" yaml:"max_lockout" env:"AUTH_LOCKOUT_MAX"`
+		Window      time.Duration `env-default:"15m" yaml:"window" env:"AUTH_LOCKOUT_WINDOW"`
+	}
+
+	// AuthPasswordPolicy -.
+	AuthPasswordPolicy struct {
+		MinLength int `env-default:"8" yaml:"min_length" env:"AUTH_PASSWORD_MIN_LENGTH"`
+		// MaxLength of 0 means no limit.
+		MaxLength int `yaml:"max_length" env:"AUTH_PASSWORD_MAX_LENGTH"`
+		MinLower  int `yaml:"min_lower" env:"AUTH_PASSWORD_MIN_LOWER"`
+		MinUpper  int `yaml:"min_upper" env:"AUTH_PASSWORD_MIN_UPPER"`
+		MinDigit  int `yaml:"min_digit" env:"AUTH_PASSWORD_MIN_DIGIT"`
+		MinSymbol int `yaml:"min_symbol" env:"AUTH_PASSWORD_MIN_SYMBOL"`
+		// Symbols counted by MinSymbol, any punctuation if empty.
+		Symbols string `yaml:"symbols" env:"AUTH_PASSWORD_SYMBOLS"`
+		// PassphraseMinLength waives the character class requirements for long passwords, 0 disables it.
+		PassphraseMinLength int `yaml:"passphrase_min_length" env:"AUTH_PASSWORD_PASSPHRASE_MIN_LENGTH"`
+		// DenylistFile lists common passwords that are rejected, one per line.
+		DenylistFile string `yaml:"denylist_file" env:"AUTH_PASSWORD_DENYLIST_FILE"`
+	}
+
+	// Payment -.
+	Payment struct {
+		// ReturnWindow is how long after a purchase the items can be returned.
+		ReturnWindow time.Duration `env-default:"336h" yaml:"return_window" env:"PAYMENT_RETURN_WINDOW"`
+		// PendingTransferTTL is how long the receiver has to accept a pending transfer.
+		PendingTransferTTL time.Duration `env-default:"72h" yaml:"pending_transfer_ttl" env:"PAYMENT_PENDING_TRANSFER_TTL"`
+		// IdempotencyKeyTTL is how long the response to a request with an Idempotency-Key is replayed.
+		IdempotencyKeyTTL time.Duration         `env-default:"24h" yaml:"idempotency_key_ttl" env:"PAYMENT_IDEMPOTENCY_KEY_TTL"`
+		TransferLimits    PaymentTransferLimits `yaml:"transfer_limits"`
+	}
+
+	// PaymentTransferLimits -. A limit of 0 is not enforced, days are counted in UTC.
+	PaymentTransferLimits struct {
+		MaxAmount      int `yaml:"max_amount" env:"PAYMENT_TRANSFER_MAX_AMOUNT"`
+		MaxDailyAmount int `yaml:"max_daily_amount" env:"PAYMENT_TRANSFER_MAX_DAILY_AMOUNT"`
+		MaxDailyCount  int `yaml:"max_daily_count" env:"PAYMENT_TRANSFER_MAX_DAILY_COUNT"`
+		// MaxDailyReceived caps the coins a single user can receive by transfers per day.
+		MaxDailyReceived int `yaml:"max_daily_received" env:"PAYMENT_TRANSFER_MAX_DAILY_RECEIVED"`
+	}
+
+	// Scheduler -. Schedules are cron expressions in UTC.
+	Scheduler struct {
+		Allowance        SchedulerAllowance        `yaml:"allowance"`
+		Expiration       SchedulerExpiration       `yaml:"expiration"`
+		PendingTransfers SchedulerPendingTransfers `yaml:"pending_transfers"`
+		IdempotencyKeys  SchedulerIdempotencyKeys  `yaml:"idempotency_keys"`
+	}
+
+	// SchedulerAllowance -.
+	SchedulerAllowance struct {
+		// Amount is credited to every user and admin, 0 disables the job.
+		Amount   int    `yaml:"amount" env:"SCHEDULER_ALLOWANCE_AMOUNT"`
+		Schedule string `env-default:"0 0 1 * *" yaml:"schedule" env:"SCHEDULER_ALLOWANCE_SCHEDULE"`
+	}
+
+	// SchedulerExpiration -.
+	SchedulerExpiration struct {
+		// Months after which received coins expire, oldest first. 0 disables the job.
+		Months   int    `yaml:"months" env:"SCHEDULER_EXPIRATION_MONTHS"`
+		Schedule string `env-default:"0 1 1 * *" yaml:"schedule" env:"SCHEDULER_EXPIRATION_SCHEDULE"`
+	}
+
+	// SchedulerPendingTransfers -.
+	SchedulerPendingTransfers struct {
+		// Schedule of returning expired pending transfers to their senders.
+		Schedule string `env-default:"*/10 * * * *" yaml:"schedule" env:"SCHEDULER_PENDING_TRANSFERS_SCHEDULE"`
+	}
+
+	// SchedulerIdempotencyKeys -.
+	SchedulerIdempotencyKeys struct {
+		// Schedule of deleting expired idempotency keys.
+		Schedule string `env-default:"0 * * * *" yaml:"schedule" env:"SCHEDULER_IDEMPOTENCY_KEYS_SCHEDULE"`
 	}
 
 	// Hasher -.
 	Hasher struct {
-		Salt string `env-required:"true" env:"HASHER_SALT"`
+		Algorithm string         `env-required:"true" yaml:"algorithm" env:"HASHER_ALGORITHM"`
+		Argon2id  HasherArgon2id `yaml:"argon2id"`
+		Bcrypt    HasherBcrypt   `yaml:"bcrypt"`
+		// LegacySalt is used only to verify SHA-1 hashes created before the argon2id/bcrypt migration.
+		LegacySalt string `env:"HASHER_SALT"`
+	}
+
+	// HasherArgon2id -.
+	HasherArgon2id struct {
+		Memory      uint32 `env-default:"65536" yaml:"memory" env:"HASHER_ARGON2ID_MEMORY"`
+		Iterations  uint32 `env-default:"3" yaml:"iterations" env:"HASHER_ARGON2ID_ITERATIONS"`
+		Parallelism uint8  `env-default:"2" yaml:"parallelism" env:"HASHER_ARGON2ID_PARALLELISM"`
+	}
+
+	// HasherBcrypt -.
+	HasherBcrypt struct {
+		Cost int `env-default:"12" yaml:"cost" env:"HASHER_BCRYPT_COST"`
 	}
 )
 
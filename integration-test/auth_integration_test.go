@@ -5,12 +5,12 @@ import (
 	"github.com/brianvoe/gofakeit/v7"
 	"net/http"
 	"testing"
+	"time"
 )
 
 // HTTP POST: /auth
 func TestAuth(t *testing.T) {
-	testUsername := "test_" + gofakeit.Username()
-	testPassword := gofakeit.Password(true, true, true, true, false, 12) + "aA1!"
+	testUsername, testPassword, _ := getValidAuthData(defaultAttempts)
 	testWrongPassword := gofakeit.Password(true, false, false, true, false, 12) + "bB1!"
 	testBadPassword := gofakeit.Password(true, false, true, false, false, 12)
 
@@ -21,22 +21,22 @@ func TestAuth(t *testing.T) {
 		expectedResponse IStep
 	}{
 		{
-			description: "registration success",
+			description: "authorization success",
 			body: map[string]any{
 				"username": testUsername,
 				"password": testPassword,
 			},
 			expectedStatus:   Expect().Status().Equal(http.StatusOK),
-			expectedResponse: Expect().Body().JSON().JQ(".token").Len().GreaterThan(0),
+			expectedResponse: Expect().Body().JSON().JQ(".refreshToken").Len().GreaterThan(0),
 		},
 		{
-			description: "authorization success",
+			description: "unknown user is not registered implicitly",
 			body: map[string]any{
-				"username": testUsername,
+				"username": "test_" + gofakeit.Username(),
 				"password": testPassword,
 			},
-			expectedStatus:   Expect().Status().Equal(http.StatusOK),
-			expectedResponse: Expect().Body().JSON().JQ(".token").Len().GreaterThan(0),
+			expectedStatus:   Expect().Status().Equal(http.StatusBadRequest),
+			expectedResponse: Expect().Body().JSON().JQ(".errors").Equal("invalid username or password"),
 		},
 		{
 			description: "authorization wrong password",
@@ -45,10 +45,10 @@ func TestAuth(t *testing.T) {
 				"password": testWrongPassword,
 			},
 			expectedStatus:   Expect().Status().Equal(http.StatusBadRequest),
-			expectedResponse: Expect().Body().JSON().JQ(".errors").Equal("wrong password"),
+			expectedResponse: Expect().Body().JSON().JQ(".errors").Equal("invalid username or password"),
 		},
 		{
-			description: "registration bad password",
+			description: "bad password",
 			body: map[string]any{
 				"username": "test_" + gofakeit.Username(),
 				"password": testBadPassword,
@@ -86,3 +86,274 @@ func TestAuth(t *testing.T) {
 		)
 	}
 }
+
+// HTTP POST: /auth/register
+func TestRegister(t *testing.T) {
+	testUsername := "test_" + gofakeit.Username()
+	testPassword := gofakeit.Password(true, true, true, true, false, 12) + "aA1!"
+	testBadPassword := gofakeit.Password(true, false, true, false, false, 12)
+
+	testCases := []struct {
+		description      string
+		body             map[string]any
+		expectedStatus   IStep
+		expectedResponse IStep
+	}{
+		{
+			description: "registration success",
+			body: map[string]any{
+				"username": testUsername,
+				"password": testPassword,
+			},
+			expectedStatus:   Expect().Status().Equal(http.StatusCreated),
+			expectedResponse: Expect().Body().JSON().JQ(".token").Len().GreaterThan(0),
+		},
+		{
+			description: "user already exists",
+			body: map[string]any{
+				"username": testUsername,
+				"password": testPassword,
+			},
+			expectedStatus:   Expect().Status().Equal(http.StatusConflict),
+			expectedResponse: Expect().Body().JSON().JQ(".errors").Equal("user already exists"),
+		},
+		{
+			description: "bad password",
+			body: map[string]any{
+				"username": "test_" + gofakeit.Username(),
+				"password": testBadPassword,
+			},
+			expectedStatus:   Expect().Status().Equal(http.StatusBadRequest),
+			expectedResponse: Expect().Body().JSON().JQ(".errors").Len().GreaterThan(0),
+		},
+		{
+			description: "all invalid fields are reported",
+			body: map[string]any{
+				"username": "abc",
+				"password": testBadPassword,
+			},
+			expectedStatus:   Expect().Status().Equal(http.StatusBadRequest),
+			expectedResponse: Expect().Body().JSON().JQ(".fields").Len().Equal(2),
+		},
+	}
+
+	for _, tc := range testCases {
+		Test(t,
+			Description(tc.description),
+			Post(basePath+"/auth/register"),
+			Send().Headers("Content-Type").Add("application/json"),
+			Send().Body().JSON(tc.body),
+			tc.expectedStatus,
+			tc.expectedResponse,
+		)
+	}
+}
+
+// HTTP POST: /auth/refresh
+func TestRefreshToken(t *testing.T) {
+	testUsername, testPassword, _ := getValidAuthData(defaultAttempts)
+
+	var refreshToken, rotatedRefreshToken string
+	Test(t,
+		Description("login"),
+		Post(basePath+"/auth"),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Body().JSON(map[string]any{"username": testUsername, "password": testPassword}),
+		Expect().Status().Equal(http.StatusOK),
+		Store().Response().Body().JSON().JQ(".refreshToken").In(&refreshToken),
+	)
+
+	Test(t,
+		Description("refresh success"),
+		Post(basePath+"/auth/refresh"),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Body().JSON(map[string]any{"refreshToken": refreshToken}),
+		Expect().Status().Equal(http.StatusOK),
+		Expect().Body().JSON().JQ(".token").Len().GreaterThan(0),
+		Store().Response().Body().JSON().JQ(".refreshToken").In(&rotatedRefreshToken),
+	)
+
+	Test(t,
+		Description("reused refresh token"),
+		Post(basePath+"/auth/refresh"),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Body().JSON(map[string]any{"refreshToken": refreshToken}),
+		Expect().Status().Equal(http.StatusUnauthorized),
+	)
+
+	Test(t,
+		Description("rotated token revoked together with its family"),
+		Post(basePath+"/auth/refresh"),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Body().JSON(map[string]any{"refreshToken": rotatedRefreshToken}),
+		Expect().Status().Equal(http.StatusUnauthorized),
+	)
+
+	Test(t,
+		Description("unknown refresh token"),
+		Post(basePath+"/auth/refresh"),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Body().JSON(map[string]any{"refreshToken": "unknown"}),
+		Expect().Status().Equal(http.StatusUnauthorized),
+	)
+}
+
+// HTTP POST: /auth/logout
+func TestLogout(t *testing.T) {
+	_, _, token := getValidAuthData(defaultAttempts)
+
+	Test(t,
+		Description("token works before logout"),
+		Get(basePath+"/info"),
+		Send().Headers("Authorization").Add("Bearer "+token),
+		Expect().Status().Equal(http.StatusOK),
+	)
+
+	Test(t,
+		Description("logout success"),
+		Post(basePath+"/auth/logout"),
+		Send().Headers("Authorization").Add("Bearer "+token),
+		Expect().Status().Equal(http.StatusNoContent),
+	)
+
+	Test(t,
+		Description("token rejected after logout"),
+		Get(basePath+"/info"),
+		Send().Headers("Authorization").Add("Bearer "+token),
+		Expect().Status().Equal(http.StatusUnauthorized),
+	)
+
+	Test(t,
+		Description("logout without token"),
+		Post(basePath+"/auth/logout"),
+		Expect().Status().Equal(http.StatusUnauthorized),
+	)
+}
+
+// HTTP GET: /.well-known/jwks.json
+func TestJWKS(t *testing.T) {
+	Test(t,
+		Description("public keys are published"),
+		Get(jwksPath),
+		Expect().Status().Equal(http.StatusOK),
+		Expect().Body().JSON().JQ(".keys").Len().GreaterThan(0),
+		Expect().Body().JSON().JQ(".keys[0].d").Equal(nil),
+	)
+}
+
+// HTTP PUT: /admin/users/{username}/role
+func TestSetRoleForbidden(t *testing.T) {
+	testUsername, _, testToken := getValidAuthData(defaultAttempts)
+
+	Test(t,
+		Description("regular user cannot change roles"),
+		Put(basePath+"/admin/users/"+testUsername+"/role"),
+		Send().Headers("Authorization").Add("Bearer "+testToken),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Body().JSON(map[string]any{"role": "admin"}),
+		Expect().Status().Equal(http.StatusForbidden),
+		Expect().Body().JSON().JQ(".errors").Equal("forbidden"),
+	)
+}
+
+// HTTP POST: /auth
+func TestLockout(t *testing.T) {
+	testUsername, _, _ := getValidAuthData(defaultAttempts)
+	wrongCredentials := map[string]any{
+		"username": testUsername,
+		"password": gofakeit.Password(true, true, true, true, false, 12) + "aA1!",
+	}
+
+	const threshold = 5
+	for range threshold {
+		Test(t,
+			Description("wrong password"),
+			Post(basePath+"/auth"),
+			Send().Headers("Content-Type").Add("application/json"),
+			Send().Body().JSON(wrongCredentials),
+			Expect().Status().Equal(http.StatusBadRequest),
+		)
+	}
+
+	Test(t,
+		Description("account is locked out"),
+		Post(basePath+"/auth"),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Body().JSON(wrongCredentials),
+		Expect().Status().Equal(http.StatusTooManyRequests),
+		Expect().Headers("Retry-After").NotEmpty(),
+	)
+}
+
+// HTTP POST: /auth/password
+func TestChangePassword(t *testing.T) {
+	testUsername, testPassword, testToken := getValidAuthData(defaultAttempts)
+	newPassword := gofakeit.Password(true, true, true, true, false, 12) + "aA1!"
+
+	// iat has a one-second resolution, tokens issued within the second of the change stay valid.
+	time.Sleep(time.Second)
+
+	Test(t,
+		Description("wrong old password"),
+		Post(basePath+"/auth/password"),
+		Send().Headers("Authorization").Add("Bearer "+testToken),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Body().JSON(map[string]any{"oldPassword": newPassword, "newPassword": newPassword}),
+		Expect().Status().Equal(http.StatusBadRequest),
+	)
+
+	Test(t,
+		Description("password changed"),
+		Post(basePath+"/auth/password"),
+		Send().Headers("Authorization").Add("Bearer "+testToken),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Body().JSON(map[string]any{"oldPassword": testPassword, "newPassword": newPassword}),
+		Expect().Status().Equal(http.StatusNoContent),
+	)
+
+	Test(t,
+		Description("old token rejected"),
+		Get(basePath+"/info"),
+		Send().Headers("Authorization").Add("Bearer "+testToken),
+		Expect().Status().Equal(http.StatusUnauthorized),
+	)
+
+	Test(t,
+		Description("login with new password"),
+		Post(basePath+"/auth"),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Body().JSON(map[string]any{"username": testUsername, "password": newPassword}),
+		Expect().Status().Equal(http.StatusOK),
+	)
+}
+
+// HTTP POST: /auth/password/reset
+func TestResetPasswordInvalidToken(t *testing.T) {
+	Test(t,
+		Description("unknown reset token"),
+		Post(basePath+"/auth/password/reset"),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Body().JSON(map[string]any{"token": "unknown", "newPassword": "Reset_Passw0rd!"}),
+		Expect().Status().Equal(http.StatusBadRequest),
+	)
+}
+
+// HTTP GET: /auth/password-policy
+func TestPasswordPolicy(t *testing.T) {
+	Test(t,
+		Description("policy is published"),
+		Get(basePath+"/auth/password-policy"),
+		Expect().Status().Equal(http.StatusOK),
+		Expect().Body().JSON().JQ(".minLength").Equal(8),
+		Expect().Body().JSON().JQ(".denylist").Equal(true),
+	)
+
+	Test(t,
+		Description("common password is rejected"),
+		Post(basePath+"/auth/register"),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Body().JSON(map[string]any{"username": "test_" + gofakeit.Username(), "password": "P@ssw0rd"}),
+		Expect().Status().Equal(http.StatusBadRequest),
+		Expect().Body().JSON().JQ(".fields[0].param").Equal("denylist"),
+	)
+}
@@ -56,3 +56,60 @@ func TestBuyItem(t *testing.T) {
 		)
 	}
 }
+
+// HTTP POST: /v1/purchases
+func TestPurchase(t *testing.T) {
+	_, _, userToken := getValidAuthData(defaultAttempts)
+
+	testCases := []struct {
+		description      string
+		body             map[string]any
+		expectedStatus   IStep
+		expectedResponse IStep
+	}{
+		{
+			description:      "success",
+			body:             map[string]any{"item": "socks", "quantity": 3},
+			expectedStatus:   Expect().Status().Equal(http.StatusCreated),
+			expectedResponse: Expect().Body().JSON().JQ(".total").Equal(30),
+		},
+		{
+			description:      "zero quantity",
+			body:             map[string]any{"item": "socks", "quantity": 0},
+			expectedStatus:   Expect().Status().Equal(http.StatusBadRequest),
+			expectedResponse: Expect().Body().JSON().JQ(".errors").Len().GreaterThan(0),
+		},
+		{
+			description:      "not enough coins",
+			body:             map[string]any{"item": "pink-hoody", "quantity": 3},
+			expectedStatus:   Expect().Status().Equal(http.StatusBadRequest),
+			expectedResponse: Expect().Body().JSON().JQ(".errors").Len().GreaterThan(0),
+		},
+	}
+
+	for _, tc := range testCases {
+		Test(t,
+			Description(tc.description),
+			Post(basePath+"/v1/purchases"),
+			Send().Headers("Content-Type").Add("application/json"),
+			Send().Headers("Authorization").Add("Bearer "+userToken),
+			Send().Body().JSON(tc.body),
+			tc.expectedStatus,
+			tc.expectedResponse,
+		)
+	}
+}
+
+// HTTP POST: /admin/items/{name}/restock
+func TestRestockForbidden(t *testing.T) {
+	_, _, testToken := getValidAuthData(defaultAttempts)
+
+	Test(t,
+		Description("regular user cannot restock items"),
+		Post(basePath+"/admin/items/cup/restock"),
+		Send().Headers("Authorization").Add("Bearer "+testToken),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Body().JSON(map[string]any{"quantity": 10}),
+		Expect().Status().Equal(http.StatusForbidden),
+	)
+}
@@ -0,0 +1,76 @@
+package integration_test
+
+import (
+	. "github.com/Eun/go-hit"
+	"net/http"
+	"testing"
+)
+
+// HTTP /v1/cart
+func TestCartCheckout(t *testing.T) {
+	_, _, userToken := getValidAuthData(defaultAttempts)
+	authHeader := Send().Headers("Authorization").Add("Bearer " + userToken)
+
+	for _, body := range []map[string]any{
+		{"item": "t-shirt", "quantity": 1},
+		{"item": "socks", "quantity": 2},
+		{"item": "cup", "quantity": 1},
+	} {
+		MustDo(
+			Description("add item"),
+			Post(basePath+"/v1/cart/items"),
+			Send().Headers("Content-Type").Add("application/json"),
+			authHeader,
+			Send().Body().JSON(body),
+			Expect().Status().Equal(http.StatusNoContent),
+		)
+	}
+
+	Test(t,
+		Description("list cart"),
+		Get(basePath+"/v1/cart"),
+		authHeader,
+		Expect().Status().Equal(http.StatusOK),
+		Expect().Body().JSON().JQ(".total").Equal(120),
+	)
+
+	Test(t,
+		Description("checkout"),
+		Post(basePath+"/v1/cart/checkout"),
+		authHeader,
+		Expect().Status().Equal(http.StatusCreated),
+		Expect().Body().JSON().JQ(".total").Equal(120),
+	)
+
+	Test(t,
+		Description("checkout of empty cart"),
+		Post(basePath+"/v1/cart/checkout"),
+		authHeader,
+		Expect().Status().Equal(http.StatusBadRequest),
+	)
+
+	MustDo(
+		Description("add more than the balance"),
+		Post(basePath+"/v1/cart/items"),
+		Send().Headers("Content-Type").Add("application/json"),
+		authHeader,
+		Send().Body().JSON(map[string]any{"item": "pink-hoody", "quantity": 2}),
+		Expect().Status().Equal(http.StatusNoContent),
+	)
+
+	Test(t,
+		Description("failed line is named"),
+		Post(basePath+"/v1/cart/checkout"),
+		authHeader,
+		Expect().Status().Equal(http.StatusBadRequest),
+		Expect().Body().JSON().JQ(".item").Equal("pink-hoody"),
+	)
+
+	Test(t,
+		Description("cart is kept after failed checkout"),
+		Get(basePath+"/v1/cart"),
+		authHeader,
+		Expect().Status().Equal(http.StatusOK),
+		Expect().Body().JSON().JQ(".total").Equal(1000),
+	)
+}
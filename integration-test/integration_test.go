@@ -13,6 +13,7 @@ import (
 const (
 	host            = "app:8080"
 	healthPath      = "http://" + host + "/health"
+	jwksPath        = "http://" + host + "/.well-known/jwks.json"
 	defaultAttempts = 20
 
 	basePath = "http://" + host + "/api"
@@ -74,10 +75,10 @@ func getAuthToken(username, password string) (string, error) {
 		"password": password,
 	}
 	err = Do(
-		Post(basePath+"/auth"),
+		Post(basePath+"/auth/register"),
 		Send().Headers("Content-Type").Add("application/json"),
 		Send().Body().JSON(body),
-		Expect().Status().Equal(http.StatusOK),
+		Expect().Status().Equal(http.StatusCreated),
 		Store().Response().Body().JSON().JQ(".token").In(&token),
 	)
 	if err != nil {
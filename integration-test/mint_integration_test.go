@@ -0,0 +1,32 @@
+package integration_test
+
+import (
+	. "github.com/Eun/go-hit"
+	"net/http"
+	"testing"
+)
+
+// HTTP POST: /admin/mint
+func TestMintForbidden(t *testing.T) {
+	testUsername, _, testToken := getValidAuthData(defaultAttempts)
+
+	Test(t,
+		Description("regular user cannot mint coins"),
+		Post(basePath+"/admin/mint"),
+		Send().Headers("Authorization").Add("Bearer "+testToken),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Body().JSON(map[string]any{"users": []string{testUsername}, "amount": 200, "reason": "hackathon"}),
+		Expect().Status().Equal(http.StatusForbidden),
+	)
+}
+
+// HTTP POST: /auth
+func TestSystemAccountLogin(t *testing.T) {
+	Test(t,
+		Description("system account cannot log in"),
+		Post(basePath+"/auth"),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Body().JSON(map[string]any{"username": "system", "password": "Passw0rd!"}),
+		Expect().Status().Equal(http.StatusBadRequest),
+	)
+}
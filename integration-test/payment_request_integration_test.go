@@ -0,0 +1,86 @@
+package integration_test
+
+import (
+	. "github.com/Eun/go-hit"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+// HTTP /payment-requests
+func TestPaymentRequest(t *testing.T) {
+	firstUsername, _, firstToken := getValidAuthData(defaultAttempts)
+	secondUsername, _, secondToken := getValidAuthData(defaultAttempts)
+
+	Test(t,
+		Description("request from yourself"),
+		Post(basePath+"/payment-requests"),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Headers("Authorization").Add("Bearer "+firstToken),
+		Send().Body().JSON(map[string]any{"users": []string{firstUsername}, "amount": 25}),
+		Expect().Status().Equal(http.StatusBadRequest),
+	)
+
+	var requestId int
+	MustDo(
+		Description("create request"),
+		Post(basePath+"/payment-requests"),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Headers("Authorization").Add("Bearer "+firstToken),
+		Send().Body().JSON(map[string]any{"users": []string{secondUsername}, "amount": 25, "note": "gift"}),
+		Expect().Status().Equal(http.StatusCreated),
+		Expect().Body().JSON().JQ(".requests[0].status").Equal("pending"),
+		Store().Response().Body().JSON().JQ(".requests[0].id").In(&requestId),
+	)
+
+	Test(t,
+		Description("list incoming"),
+		Get(basePath+"/payment-requests/incoming"),
+		Send().Headers("Authorization").Add("Bearer "+secondToken),
+		Expect().Status().Equal(http.StatusOK),
+		Expect().Body().JSON().JQ(".requests[0].requester").Equal(firstUsername),
+		Expect().Body().JSON().JQ(".requests[0].note").Equal("gift"),
+	)
+
+	Test(t,
+		Description("requester cannot pay"),
+		Post(basePath+"/payment-requests/"+strconv.Itoa(requestId)+"/pay"),
+		Send().Headers("Authorization").Add("Bearer "+firstToken),
+		Expect().Status().Equal(http.StatusNotFound),
+	)
+
+	Test(t,
+		Description("pay"),
+		Post(basePath+"/payment-requests/"+strconv.Itoa(requestId)+"/pay"),
+		Send().Headers("Authorization").Add("Bearer "+secondToken),
+		Expect().Status().Equal(http.StatusNoContent),
+	)
+
+	Test(t,
+		Description("already paid"),
+		Post(basePath+"/payment-requests/"+strconv.Itoa(requestId)+"/pay"),
+		Send().Headers("Authorization").Add("Bearer "+secondToken),
+		Expect().Status().Equal(http.StatusNotFound),
+	)
+
+	Test(t,
+		Description("list outgoing"),
+		Get(basePath+"/payment-requests/outgoing"),
+		Send().Headers("Authorization").Add("Bearer "+firstToken),
+		Expect().Status().Equal(http.StatusOK),
+		Expect().Body().JSON().JQ(".requests[0].status").Equal("paid"),
+	)
+
+	var response entity.UserReport
+	MustDo(
+		Description("get info"),
+		Get(basePath+"/info"),
+		Send().Headers("Authorization").Add("Bearer "+firstToken),
+		Expect().Status().Equal(http.StatusOK),
+		Store().Response().Body().JSON().In(&response),
+	)
+
+	assert.Equal(t, 1025, response.Coins)
+}
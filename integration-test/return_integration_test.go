@@ -0,0 +1,68 @@
+package integration_test
+
+import (
+	. "github.com/Eun/go-hit"
+	"net/http"
+	"testing"
+)
+
+// HTTP /v1/returns
+func TestReturnRequest(t *testing.T) {
+	_, _, userToken := getValidAuthData(defaultAttempts)
+	authHeader := Send().Headers("Authorization").Add("Bearer " + userToken)
+
+	var purchaseId int
+	MustDo(
+		Description("buy items"),
+		Post(basePath+"/v1/purchases"),
+		Send().Headers("Content-Type").Add("application/json"),
+		authHeader,
+		Send().Body().JSON(map[string]any{"item": "socks", "quantity": 2}),
+		Expect().Status().Equal(http.StatusCreated),
+		Store().Response().Body().JSON().JQ(".id").In(&purchaseId),
+	)
+
+	Test(t,
+		Description("request return"),
+		Post(basePath+"/v1/returns"),
+		Send().Headers("Content-Type").Add("application/json"),
+		authHeader,
+		Send().Body().JSON(map[string]any{"purchaseId": purchaseId, "item": "socks", "quantity": 1, "reason": "wrong size"}),
+		Expect().Status().Equal(http.StatusCreated),
+		Expect().Body().JSON().JQ(".status").Equal("pending"),
+		Expect().Body().JSON().JQ(".amount").Equal(10),
+	)
+
+	Test(t,
+		Description("return more than bought"),
+		Post(basePath+"/v1/returns"),
+		Send().Headers("Content-Type").Add("application/json"),
+		authHeader,
+		Send().Body().JSON(map[string]any{"purchaseId": purchaseId, "item": "socks", "quantity": 2}),
+		Expect().Status().Equal(http.StatusUnprocessableEntity),
+	)
+
+	Test(t,
+		Description("return of another item"),
+		Post(basePath+"/v1/returns"),
+		Send().Headers("Content-Type").Add("application/json"),
+		authHeader,
+		Send().Body().JSON(map[string]any{"purchaseId": purchaseId, "item": "cup", "quantity": 1}),
+		Expect().Status().Equal(http.StatusNotFound),
+	)
+
+	Test(t,
+		Description("list returns"),
+		Get(basePath+"/v1/returns"),
+		authHeader,
+		Expect().Status().Equal(http.StatusOK),
+		Expect().Body().JSON().JQ(".returns | length").Equal(1),
+	)
+
+	Test(t,
+		Description("users cannot approve returns"),
+		Post(basePath+"/admin/returns/1/approve"),
+		authHeader,
+		Expect().Status().Equal(http.StatusForbidden),
+	)
+}
@@ -2,7 +2,11 @@ package integration_test
 
 import (
 	. "github.com/Eun/go-hit"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/stretchr/testify/assert"
 	"net/http"
+	"strconv"
 	"testing"
 )
 
@@ -48,6 +52,16 @@ func TestTransfer(t *testing.T) {
 			expectedStatus:   Expect().Status().Equal(http.StatusBadRequest),
 			expectedResponse: Expect().Body().JSON().JQ(".errors").Len().GreaterThan(0),
 		},
+		{
+			description: "amount over limit",
+			body: map[string]any{
+				"toUser": secondUsername,
+				"amount": 501,
+			},
+			authToken:        firstToken,
+			expectedStatus:   Expect().Status().Equal(http.StatusUnprocessableEntity),
+			expectedResponse: Expect().Body().JSON().JQ(".limit").Equal("transfer_amount"),
+		},
 		{
 			description: "self transfer",
 			body: map[string]any{
@@ -91,3 +105,162 @@ func TestTransfer(t *testing.T) {
 		)
 	}
 }
+
+func TestTransferIdempotency(t *testing.T) {
+	_, _, firstToken := getValidAuthData(defaultAttempts)
+	secondUsername, _, secondToken := getValidAuthData(defaultAttempts)
+	idempotencyKey := "transfer-" + gofakeit.UUID()
+	var response entity.UserReport
+
+	for _, description := range []string{"first request", "retry"} {
+		MustDo(
+			Description(description),
+			Post(basePath+"/sendCoin"),
+			Send().Headers("Content-Type").Add("application/json"),
+			Send().Headers("Authorization").Add("Bearer "+firstToken),
+			Send().Headers("Idempotency-Key").Add(idempotencyKey),
+			Send().Body().JSON(map[string]any{"toUser": secondUsername, "amount": 13}),
+			Expect().Status().Equal(http.StatusOK),
+		)
+	}
+
+	Test(t,
+		Description("key reused with another body"),
+		Post(basePath+"/sendCoin"),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Headers("Authorization").Add("Bearer "+firstToken),
+		Send().Headers("Idempotency-Key").Add(idempotencyKey),
+		Send().Body().JSON(map[string]any{"toUser": secondUsername, "amount": 14}),
+		Expect().Status().Equal(http.StatusUnprocessableEntity),
+	)
+
+	MustDo(
+		Description("get info"),
+		Get(basePath+"/info"),
+		Send().Headers("Authorization").Add("Bearer "+secondToken),
+		Expect().Status().Equal(http.StatusOK),
+		Store().Response().Body().JSON().In(&response),
+	)
+
+	assert.Equal(t, 1013, response.Coins)
+}
+
+// HTTP POST: /sendCoin/batch
+func TestTransferBatch(t *testing.T) {
+	firstUsername, _, firstToken := getValidAuthData(defaultAttempts)
+	secondUsername, _, secondToken := getValidAuthData(defaultAttempts)
+	thirdUsername, _, _ := getValidAuthData(defaultAttempts)
+
+	transfers := []map[string]any{
+		{"toUser": secondUsername, "amount": 10},
+		{"toUser": thirdUsername, "amount": 20},
+		{"toUser": secondUsername, "amount": 30},
+		{"toUser": firstUsername, "amount": 40},
+	}
+
+	Test(t,
+		Description("all or nothing rejects the batch"),
+		Post(basePath+"/sendCoin/batch"),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Headers("Authorization").Add("Bearer "+firstToken),
+		Send().Body().JSON(map[string]any{"transfers": transfers, "allOrNothing": true}),
+		Expect().Status().Equal(http.StatusBadRequest),
+		Expect().Body().JSON().JQ(".results[0].status").Equal("skipped"),
+		Expect().Body().JSON().JQ(".results[2].status").Equal("failed"),
+		Expect().Body().JSON().JQ(".results[3].status").Equal("failed"),
+	)
+
+	Test(t,
+		Description("invalid transfers are skipped"),
+		Post(basePath+"/sendCoin/batch"),
+		Send().Headers("Content-Type").Add("application/json"),
+		Send().Headers("Authorization").Add("Bearer "+firstToken),
+		Send().Body().JSON(map[string]any{"transfers": transfers}),
+		Expect().Status().Equal(http.StatusOK),
+		Expect().Body().JSON().JQ(".total").Equal(30),
+		Expect().Body().JSON().JQ(".results[1].status").Equal("sent"),
+		Expect().Body().JSON().JQ(".results[2].error").Equal("duplicate recipient"),
+	)
+
+	var response entity.UserReport
+	MustDo(
+		Description("get info"),
+		Get(basePath+"/info"),
+		Send().Headers("Authorization").Add("Bearer "+secondToken),
+		Expect().Status().Equal(http.StatusOK),
+		Store().Response().Body().JSON().In(&response),
+	)
+
+	assert.Equal(t, 1010, response.Coins)
+}
+
+// HTTP POST: /v1/transfers/:id/accept, /v1/transfers/:id/decline
+func TestPendingTransfer(t *testing.T) {
+	_, _, firstToken := getValidAuthData(defaultAttempts)
+	secondUsername, _, secondToken := getValidAuthData(defaultAttempts)
+
+	var acceptedId, declinedId int
+	for _, id := range []*int{&acceptedId, &declinedId} {
+		MustDo(
+			Description("send pending transfer"),
+			Post(basePath+"/sendCoin"),
+			Send().Headers("Content-Type").Add("application/json"),
+			Send().Headers("Authorization").Add("Bearer "+firstToken),
+			Send().Body().JSON(map[string]any{"toUser": secondUsername, "amount": 20, "pending": true}),
+			Expect().Status().Equal(http.StatusAccepted),
+			Expect().Body().JSON().JQ(".status").Equal("pending"),
+			Store().Response().Body().JSON().JQ(".id").In(id),
+		)
+	}
+
+	var response entity.UserReport
+	MustDo(
+		Description("get recipient info"),
+		Get(basePath+"/info"),
+		Send().Headers("Authorization").Add("Bearer "+secondToken),
+		Expect().Status().Equal(http.StatusOK),
+		Store().Response().Body().JSON().In(&response),
+	)
+
+	assert.Equal(t, 1000, response.Coins)
+	assert.Len(t, response.PendingTransfers.Incoming, 2)
+
+	Test(t,
+		Description("sender cannot accept"),
+		Post(basePath+"/v1/transfers/"+strconv.Itoa(acceptedId)+"/accept"),
+		Send().Headers("Authorization").Add("Bearer "+firstToken),
+		Expect().Status().Equal(http.StatusNotFound),
+	)
+
+	Test(t,
+		Description("accept"),
+		Post(basePath+"/v1/transfers/"+strconv.Itoa(acceptedId)+"/accept"),
+		Send().Headers("Authorization").Add("Bearer "+secondToken),
+		Expect().Status().Equal(http.StatusNoContent),
+	)
+
+	Test(t,
+		Description("decline"),
+		Post(basePath+"/v1/transfers/"+strconv.Itoa(declinedId)+"/decline"),
+		Send().Headers("Authorization").Add("Bearer "+secondToken),
+		Expect().Status().Equal(http.StatusNoContent),
+	)
+
+	Test(t,
+		Description("already resolved"),
+		Post(basePath+"/v1/transfers/"+strconv.Itoa(acceptedId)+"/decline"),
+		Send().Headers("Authorization").Add("Bearer "+secondToken),
+		Expect().Status().Equal(http.StatusNotFound),
+	)
+
+	MustDo(
+		Description("get sender info"),
+		Get(basePath+"/info"),
+		Send().Headers("Authorization").Add("Bearer "+firstToken),
+		Expect().Status().Equal(http.StatusOK),
+		Store().Response().Body().JSON().In(&response),
+	)
+
+	assert.Equal(t, 980, response.Coins)
+	assert.Empty(t, response.PendingTransfers.Outgoing)
+}
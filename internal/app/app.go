@@ -1,17 +1,17 @@
 package app
 
 import (
+	"context"
 	"fmt"
+	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
 	log "github.com/sirupsen/logrus"
 	"github.com/spanwalla/merch-store/config"
 	v1 "github.com/spanwalla/merch-store/internal/controller/http/v1"
 	"github.com/spanwalla/merch-store/internal/repository"
 	"github.com/spanwalla/merch-store/internal/service"
-	"github.com/spanwalla/merch-store/pkg/hasher"
 	"github.com/spanwalla/merch-store/pkg/httpserver"
 	"github.com/spanwalla/merch-store/pkg/postgres"
-	"github.com/spanwalla/merch-store/pkg/validator"
 	"os"
 	"os/signal"
 	"syscall"
@@ -36,27 +36,117 @@ func Run() {
 
 	// Postgres
 	log.Info("Connecting to postgres...")
-	pg, err := postgres.New(cfg.PG.URL, postgres.MaxPoolSize(cfg.PG.PoolMax))
+	pg, err := postgres.New(cfg.PG.URL,
+		postgres.MaxPoolSize(cfg.PG.PoolMax),
+		postgres.IsoLevel(pgx.TxIsoLevel(cfg.PG.IsolationLevel)),
+		postgres.TxRetries(cfg.PG.TxRetries),
+		postgres.TxRetryDelay(cfg.PG.TxRetryDelay),
+	)
 	if err != nil {
 		log.Fatal(fmt.Errorf("app - Run - postgres.New: %w", err))
 	}
 	defer pg.Close()
 
+	// Password hasher
+	passwordHasher, err := newPasswordHasher(cfg.Hasher)
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - newPasswordHasher: %w", err))
+	}
+
+	// JWT keys
+	keySet, err := newKeySet(cfg.JWT)
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - newKeySet: %w", err))
+	}
+	log.Infof("JWT signing key: %s", keySet.SigningKey().Id)
+
+	// Auth mode
+	authMode, err := service.ParseAuthMode(cfg.Auth.Mode)
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - service.ParseAuthMode: %w", err))
+	}
+
+	// Background workers are stopped on shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	// Services and repos
 	log.Info("Initializing services and repos...")
+	repos := repository.NewRepositories(pg)
+
+	revocations := service.NewTokenRevocationStore(repos.RevokedToken, repos.RevokedUser)
+	err = revocations.Sync(ctx)
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - revocations.Sync: %w", err))
+	}
+	go revocations.Run(ctx, cfg.JWT.RevocationSyncInterval)
+
+	customValidator, err := newValidator(cfg.Auth.PasswordPolicy, cfg.Hasher.Algorithm)
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - newValidator: %w", err))
+	}
+
 	services := service.NewServices(service.Dependencies{
-		Repos:      repository.NewRepositories(pg),
-		Hasher:     hasher.NewSHA1Hasher(cfg.Hasher.Salt),
-		SignKey:    cfg.JWT.SignKey,
-		TokenTTL:   cfg.JWT.TokenTTL,
-		Transactor: pg,
+		Repos:  repos,
+		Hasher: passwordHasher,
+		AuthConfig: service.AuthServiceConfig{
+			Mode:               authMode,
+			Keys:               keySet,
+			TokenTTL:           cfg.JWT.TokenTTL,
+			RefreshTokenTTL:    cfg.JWT.RefreshTokenTTL,
+			InviteTTL:          cfg.Auth.InviteTTL,
+			PasswordResetTTL:   cfg.Auth.PasswordResetTTL,
+			ProxyAutoProvision: cfg.Auth.Proxy.AutoProvision,
+			CheckPassword: func(password string) error {
+				return customValidator.CheckPassword("password", password)
+			},
+			Lockout: service.LoginThrottleConfig{
+				Threshold:   cfg.Auth.Lockout.Threshold,
+				IPThreshold: cfg.Auth.Lockout.IPThreshold,
+				BaseLockout: cfg.Auth.Lockout.BaseLockout,
+				MaxLockout:  cfg.Auth.Lockout.MaxLockout,
+				Window:      cfg.Auth.Lockout.Window,
+			},
+		},
+		PaymentConfig: service.PaymentServiceConfig{
+			MaxTransferAmount:      cfg.Payment.TransferLimits.MaxAmount,
+			MaxDailyTransferAmount: cfg.Payment.TransferLimits.MaxDailyAmount,
+			MaxDailyTransfers:      cfg.Payment.TransferLimits.MaxDailyCount,
+			MaxDailyReceivedAmount: cfg.Payment.TransferLimits.MaxDailyReceived,
+			PendingTransferTTL:     cfg.Payment.PendingTransferTTL,
+		},
+		ReturnConfig: service.ReturnServiceConfig{
+			Window: cfg.Payment.ReturnWindow,
+		},
+		IdempotencyConfig: service.IdempotencyServiceConfig{
+			KeyTTL: cfg.Payment.IdempotencyKeyTTL,
+		},
+		Revocations: revocations,
+		Transactor:  pg,
 	})
 
+	scheduler, err := newScheduler(cfg.Scheduler, repos, services.Idempotency, pg)
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - newScheduler: %w", err))
+	}
+	go scheduler.Run(ctx)
+
 	// Echo handler
 	log.Info("Initializing handlers and routes...")
 	handler := echo.New()
-	handler.Validator = validator.NewCustomValidator()
-	v1.ConfigureRouter(handler, services)
+	handler.Validator = customValidator
+	// Client IP is used for login throttling, so X-Forwarded-For from clients must not be trusted.
+	handler.IPExtractor = echo.ExtractIPDirect()
+	var proxy *v1.TrustedProxy
+	if cfg.Auth.Proxy.Enabled {
+		proxy, err = v1.NewTrustedProxy(cfg.Auth.Proxy.UserHeader, cfg.Auth.Proxy.TrustedCIDRs)
+		if err != nil {
+			log.Fatal(fmt.Errorf("app - Run - v1.NewTrustedProxy: %w", err))
+		}
+		handler.IPExtractor = proxy.IPExtractor()
+		log.Infof("Trusted proxy authentication enabled, header: %s", cfg.Auth.Proxy.UserHeader)
+	}
+	v1.ConfigureRouter(handler, services, proxy, customValidator.PasswordRules())
 
 	// HTTP Server
 	log.Info("Starting HTTP server...")
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"fmt"
+	"github.com/spanwalla/merch-store/config"
+	"github.com/spanwalla/merch-store/pkg/hasher"
+)
+
+func newPasswordHasher(cfg config.Hasher) (hasher.PasswordHasher, error) {
+	argon2id := hasher.DefaultArgon2idParams()
+	argon2id.Memory = cfg.Argon2id.Memory
+	argon2id.Iterations = cfg.Argon2id.Iterations
+	argon2id.Parallelism = cfg.Argon2id.Parallelism
+
+	algorithms := map[string]hasher.Algorithm{
+		"argon2id": hasher.NewArgon2idHasher(argon2id),
+		"bcrypt":   hasher.NewBcryptHasher(cfg.Bcrypt.Cost),
+	}
+
+	primary, ok := algorithms[cfg.Algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unknown hasher algorithm %q", cfg.Algorithm)
+	}
+
+	// Hashes of the non-primary algorithms are still verified and upgraded on login.
+	var legacy []hasher.Algorithm
+	for name, algorithm := range algorithms {
+		if name != cfg.Algorithm {
+			legacy = append(legacy, algorithm)
+		}
+	}
+	if len(cfg.LegacySalt) > 0 {
+		legacy = append(legacy, hasher.NewSHA1Hasher(cfg.LegacySalt))
+	}
+
+	return hasher.NewVersionedHasher(primary, legacy...), nil
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spanwalla/merch-store/config"
+	"github.com/spanwalla/merch-store/pkg/jwks"
+)
+
+const ephemeralKeyId = "ephemeral"
+
+func newKeySet(cfg config.JWT) (*jwks.KeySet, error) {
+	var keys []jwks.Key
+
+	if len(cfg.KeysDir) > 0 {
+		dirKeys, err := jwks.LoadDir(cfg.KeysDir)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, dirKeys...)
+	}
+
+	for _, k := range cfg.Keys {
+		key, err := jwks.ParsePEM(k.Id, []byte(k.PEM))
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Id, err)
+		}
+		keys = append(keys, key)
+	}
+
+	// Tokens signed by an ephemeral key do not survive a restart and are not
+	// accepted by other instances, which is only acceptable for local runs.
+	if len(keys) == 0 {
+		if !cfg.AllowEphemeralKey {
+			return nil, errors.New("no JWT keys configured: set JWT_KEYS_DIR or jwt.keys, or JWT_ALLOW_EPHEMERAL_KEY for local runs")
+		}
+		log.Warn("app - newKeySet: no JWT keys configured, generating an ephemeral Ed25519 key")
+		key, err := jwks.GenerateEd25519(ephemeralKeyId)
+		if err != nil {
+			return nil, err
+		}
+		return jwks.NewKeySet(key.Id, key)
+	}
+
+	return jwks.NewKeySet(cfg.SigningKeyId, keys...)
+}
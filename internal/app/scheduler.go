@@ -0,0 +1,50 @@
+package app
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spanwalla/merch-store/config"
+	"github.com/spanwalla/merch-store/internal/repository"
+	"github.com/spanwalla/merch-store/internal/service"
+	"github.com/spanwalla/merch-store/pkg/cron"
+)
+
+func newScheduler(cfg config.Scheduler, repos *repository.Repositories, idempotency service.Idempotency, transactor repository.Transactor) (*service.Scheduler, error) {
+	scheduler := service.NewScheduler(repos.Job, transactor)
+	coinJobs := service.NewCoinJobs(repos.User, repos.Ledger, repos.PendingTransfer, service.CoinJobsConfig{
+		AllowanceAmount:  cfg.Allowance.Amount,
+		ExpirationMonths: cfg.Expiration.Months,
+	})
+
+	if cfg.Allowance.Amount > 0 {
+		schedule, err := cron.Parse(cfg.Allowance.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("allowance: %w", err)
+		}
+		scheduler.Add(service.Job{Name: service.JobAllowance, Schedule: schedule, Run: coinJobs.Allowance})
+		log.Infof("Allowance of %d coins scheduled at %q", cfg.Allowance.Amount, schedule)
+	}
+
+	if cfg.Expiration.Months > 0 {
+		schedule, err := cron.Parse(cfg.Expiration.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("expiration: %w", err)
+		}
+		scheduler.Add(service.Job{Name: service.JobCoinExpiration, Schedule: schedule, Run: coinJobs.Expire})
+		log.Infof("Expiration of coins older than %d months scheduled at %q", cfg.Expiration.Months, schedule)
+	}
+
+	schedule, err := cron.Parse(cfg.PendingTransfers.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("pending transfers: %w", err)
+	}
+	scheduler.Add(service.Job{Name: service.JobPendingTransferExpiration, Schedule: schedule, Run: coinJobs.ReturnExpiredTransfers})
+
+	schedule, err = cron.Parse(cfg.IdempotencyKeys.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("idempotency keys: %w", err)
+	}
+	scheduler.Add(service.Job{Name: service.JobIdempotencyKeyExpiration, Schedule: schedule, Run: idempotency.DeleteExpiredKeys})
+
+	return scheduler, nil
+}
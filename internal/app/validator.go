@@ -0,0 +1,37 @@
+package app
+
+import (
+	"fmt"
+	"github.com/spanwalla/merch-store/config"
+	"github.com/spanwalla/merch-store/pkg/hasher"
+	"github.com/spanwalla/merch-store/pkg/validator"
+)
+
+func newValidator(cfg config.AuthPasswordPolicy, hasherAlgorithm string) (*validator.CustomValidator, error) {
+	policy := validator.PasswordPolicy{
+		MinLength:           cfg.MinLength,
+		MaxLength:           cfg.MaxLength,
+		MinLower:            cfg.MinLower,
+		MinUpper:            cfg.MinUpper,
+		MinDigit:            cfg.MinDigit,
+		MinSymbol:           cfg.MinSymbol,
+		Symbols:             cfg.Symbols,
+		PassphraseMinLength: cfg.PassphraseMinLength,
+	}
+	// New passwords are hashed by the primary algorithm only, bcrypt cannot hash longer ones.
+	if hasherAlgorithm == "bcrypt" {
+		policy.MaxBytes = hasher.BcryptMaxPasswordBytes
+	}
+
+	opts := []validator.Option{validator.Policy(policy)}
+
+	if len(cfg.DenylistFile) > 0 {
+		denylist, err := validator.ReadDenylist(cfg.DenylistFile)
+		if err != nil {
+			return nil, fmt.Errorf("newValidator - validator.ReadDenylist: %w", err)
+		}
+		opts = append(opts, validator.Denylist(denylist))
+	}
+
+	return validator.NewCustomValidator(opts...), nil
+}
@@ -0,0 +1,121 @@
+package v1
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/spanwalla/merch-store/internal/service"
+	"net/http"
+	"time"
+)
+
+type adminRoutes struct {
+	authService service.Auth
+}
+
+type setRoleInput struct {
+	Username string `param:"username" validate:"required,min=4,max=64"`
+	Role     string `json:"role" validate:"required,oneof=user admin"`
+}
+
+type usernameInput struct {
+	Username string `param:"username" validate:"required,min=4,max=64"`
+}
+
+func newAdminRoutes(g *echo.Group, authService service.Auth) {
+	r := &adminRoutes{authService}
+
+	g.PUT("/users/:username/role", r.setRole)
+	g.DELETE("/users/:username/lockout", r.unlock)
+	g.POST("/users/:username/password-reset", r.createPasswordReset)
+}
+
+func (r *adminRoutes) setRole(c echo.Context) error {
+	var input setRoleInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	err := r.authService.SetRole(c.Request().Context(), service.AuthSetRoleInput{
+		UserName: input.Username,
+		Role:     input.Role,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+		case errors.Is(err, service.ErrSystemAccountRole), errors.Is(err, service.ErrServiceAccountRole):
+			newErrorResponse(c, http.StatusUnprocessableEntity, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (r *adminRoutes) unlock(c echo.Context) error {
+	var input usernameInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid params")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	err := r.authService.Unlock(c.Request().Context(), input.Username)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (r *adminRoutes) createPasswordReset(c echo.Context) error {
+	var input usernameInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid params")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	reset, err := r.authService.CreatePasswordReset(c.Request().Context(), service.AuthCreatePasswordResetInput{
+		UserName:  input.Username,
+		CreatedBy: c.Get(userIdCtx).(int),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+		case errors.Is(err, service.ErrPasswordNotAllowed):
+			newErrorResponse(c, http.StatusUnprocessableEntity, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	type response struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expiresAt"`
+	}
+
+	return c.JSON(http.StatusCreated, response{reset.Token, reset.ExpiresAt})
+}
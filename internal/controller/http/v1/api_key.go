@@ -0,0 +1,181 @@
+package v1
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/spanwalla/merch-store/internal/service"
+	"net/http"
+	"time"
+)
+
+type apiKeyRoutes struct {
+	apiKeyService service.APIKey
+}
+
+type createServiceAccountInput struct {
+	Username string `json:"username" validate:"required,min=4,max=64"`
+}
+
+type createAPIKeyInput struct {
+	Username string   `param:"username" validate:"required,min=4,max=64"`
+	Name     string   `json:"name" validate:"required,max=64"`
+	Scopes   []string `json:"scopes" validate:"required,min=1"`
+}
+
+type revokeAPIKeyInput struct {
+	Id int `param:"id" validate:"required,min=1"`
+}
+
+type apiKeyResponse struct {
+	Id         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+}
+
+func newAPIKeyRoutes(g *echo.Group, apiKeyService service.APIKey) {
+	r := &apiKeyRoutes{apiKeyService}
+
+	g.POST("/service-accounts", r.createServiceAccount)
+	g.POST("/service-accounts/:username/api-keys", r.createAPIKey)
+	g.GET("/service-accounts/:username/api-keys", r.listAPIKeys)
+	g.DELETE("/api-keys/:id", r.revokeAPIKey)
+}
+
+func (r *apiKeyRoutes) createServiceAccount(c echo.Context) error {
+	var input createServiceAccountInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	id, err := r.apiKeyService.CreateServiceAccount(c.Request().Context(), input.Username)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserAlreadyExists):
+			newErrorResponse(c, http.StatusConflict, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	type response struct {
+		Id       int    `json:"id"`
+		Username string `json:"username"`
+	}
+
+	return c.JSON(http.StatusCreated, response{id, input.Username})
+}
+
+func (r *apiKeyRoutes) createAPIKey(c echo.Context) error {
+	var input createAPIKeyInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	key, err := r.apiKeyService.Create(c.Request().Context(), service.APIKeyCreateInput{
+		UserName: input.Username,
+		Name:     input.Name,
+		Scopes:   input.Scopes,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidScope), errors.Is(err, service.ErrNotServiceAccount):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrUserNotFound):
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	type response struct {
+		Id     int      `json:"id"`
+		Key    string   `json:"key"`
+		Prefix string   `json:"prefix"`
+		Scopes []string `json:"scopes"`
+	}
+
+	return c.JSON(http.StatusCreated, response{key.Id, key.Key, key.Prefix, key.Scopes})
+}
+
+func (r *apiKeyRoutes) listAPIKeys(c echo.Context) error {
+	username := c.Param("username")
+
+	keys, err := r.apiKeyService.List(c.Request().Context(), username)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotServiceAccount):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrUserNotFound):
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	type response struct {
+		Keys []apiKeyResponse `json:"keys"`
+	}
+
+	resp := response{Keys: make([]apiKeyResponse, 0, len(keys))}
+	for _, key := range keys {
+		resp.Keys = append(resp.Keys, apiKeyResponse{
+			Id:         key.Id,
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			Scopes:     key.Scopes,
+			CreatedAt:  key.CreatedAt,
+			LastUsedAt: key.LastUsedAt,
+			RevokedAt:  key.RevokedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (r *apiKeyRoutes) revokeAPIKey(c echo.Context) error {
+	var input revokeAPIKeyInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid api key id")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	err := r.apiKeyService.Revoke(c.Request().Context(), input.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAPIKeyNotFound):
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -4,22 +4,62 @@ import (
 	"errors"
 	"github.com/labstack/echo/v4"
 	"github.com/spanwalla/merch-store/internal/service"
+	"github.com/spanwalla/merch-store/pkg/validator"
+	"math"
 	"net/http"
+	"strconv"
 )
 
 type authRoutes struct {
-	authService service.Auth
+	authService   service.Auth
+	passwordRules validator.PasswordRules
 }
 
 type getTokenInput struct {
 	Username string `json:"username" validate:"required,min=4,max=64"`
-	Password string `json:"password" validate:"required,password"`
+	// The policy is not checked on login, it may have changed since the password was set.
+	Password string `json:"password" validate:"required,max=256"`
 }
 
-func newAuthRoutes(g *echo.Group, authService service.Auth) {
-	r := &authRoutes{authService}
+type registerInput struct {
+	Username   string `json:"username" validate:"required,min=4,max=64"`
+	Password   string `json:"password" validate:"required,password"`
+	InviteCode string `json:"inviteCode" validate:"max=64"`
+}
+
+type refreshTokenInput struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+type changePasswordInput struct {
+	OldPassword string `json:"oldPassword" validate:"required,max=256"`
+	NewPassword string `json:"newPassword" validate:"required,password"`
+}
+
+type resetPasswordInput struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required,password"`
+}
+
+type logoutInput struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type tokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+func newAuthRoutes(g *echo.Group, authService service.Auth, userIdentity echo.MiddlewareFunc, passwordRules validator.PasswordRules) {
+	r := &authRoutes{authService, passwordRules}
 
 	g.POST("", r.getToken)
+	g.POST("/register", r.register)
+	g.POST("/refresh", r.refreshToken)
+	g.POST("/logout", r.logout)
+	g.POST("/password", r.changePassword, userIdentity)
+	g.POST("/password/reset", r.resetPassword)
+	g.GET("/password-policy", r.getPasswordPolicy)
 }
 
 func (r *authRoutes) getToken(c echo.Context) error {
@@ -31,26 +71,198 @@ func (r *authRoutes) getToken(c echo.Context) error {
 	}
 
 	if err := c.Validate(input); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		newValidationErrorResponse(c, err)
 		return err
 	}
 
-	token, err := r.authService.GenerateToken(c.Request().Context(), service.AuthGenerateTokenInput{
+	tokens, err := r.authService.GenerateToken(c.Request().Context(), service.AuthGenerateTokenInput{
 		Name:     input.Username,
 		Password: input.Password,
+		IP:       c.RealIP(),
 	})
 	if err != nil {
-		if errors.Is(err, service.ErrWrongPassword) {
+		var (
+			lockedErr     *service.LockedError
+			validationErr *validator.ValidationError
+		)
+		switch {
+		case errors.As(err, &lockedErr):
+			retryAfter := int(math.Ceil(lockedErr.RetryAfter.Seconds()))
+			c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(retryAfter))
+			newErrorResponse(c, http.StatusTooManyRequests, err.Error())
+		case errors.Is(err, service.ErrInvalidCredentials):
 			newErrorResponse(c, http.StatusBadRequest, err.Error())
-			return err
+		case errors.Is(err, service.ErrUserAlreadyExists):
+			newErrorResponse(c, http.StatusConflict, err.Error())
+		case errors.As(err, &validationErr):
+			// The password of an account created on login breaks the policy.
+			newValidationErrorResponse(c, err)
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
 		}
-		newErrorResponse(c, http.StatusInternalServerError, "internal server error")
 		return err
 	}
 
-	type response struct {
-		Token string `json:"token"`
+	return c.JSON(http.StatusOK, tokenResponse{tokens.AccessToken, tokens.RefreshToken})
+}
+
+func (r *authRoutes) register(c echo.Context) error {
+	var input registerInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	tokens, err := r.authService.Register(c.Request().Context(), service.AuthRegisterInput{
+		Name:       input.Username,
+		Password:   input.Password,
+		InviteCode: input.InviteCode,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserAlreadyExists):
+			newErrorResponse(c, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrInviteCodeRequired):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrInvalidInviteCode):
+			newErrorResponse(c, http.StatusForbidden, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, tokenResponse{tokens.AccessToken, tokens.RefreshToken})
+}
+
+func (r *authRoutes) refreshToken(c echo.Context) error {
+	var input refreshTokenInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	tokens, err := r.authService.RefreshToken(c.Request().Context(), input.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidRefreshToken):
+			newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		case errors.Is(err, service.ErrRefreshTokenReused):
+			newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	return c.JSON(http.StatusOK, tokenResponse{tokens.AccessToken, tokens.RefreshToken})
+}
+
+func (r *authRoutes) changePassword(c echo.Context) error {
+	var input changePasswordInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	err := r.authService.ChangePassword(c.Request().Context(), service.AuthChangePasswordInput{
+		UserId:      c.Get(userIdCtx).(int),
+		OldPassword: input.OldPassword,
+		NewPassword: input.NewPassword,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrWrongPassword):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (r *authRoutes) resetPassword(c echo.Context) error {
+	var input resetPasswordInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	err := r.authService.ResetPassword(c.Request().Context(), service.AuthResetPasswordInput{
+		Token:       input.Token,
+		NewPassword: input.NewPassword,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPasswordResetToken):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrPasswordNotAllowed):
+			newErrorResponse(c, http.StatusUnprocessableEntity, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (r *authRoutes) getPasswordPolicy(c echo.Context) error {
+	return c.JSON(http.StatusOK, r.passwordRules)
+}
+
+func (r *authRoutes) logout(c echo.Context) error {
+	token, ok := bearerToken(c.Request())
+	if !ok {
+		newErrorResponse(c, http.StatusUnauthorized, ErrInvalidAuthHeader.Error())
+		return ErrInvalidAuthHeader
+	}
+
+	var input logoutInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return err
+	}
+
+	err := r.authService.Logout(c.Request().Context(), service.AuthLogoutInput{
+		AccessToken:  token,
+		RefreshToken: input.RefreshToken,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrCannotParseToken) {
+			newErrorResponse(c, http.StatusUnauthorized, err.Error())
+			return err
+		}
+		newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		return err
 	}
 
-	return c.JSON(http.StatusOK, response{token})
+	return c.NoContent(http.StatusNoContent)
 }
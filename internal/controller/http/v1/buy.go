@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"context"
 	"errors"
 	"github.com/labstack/echo/v4"
 	"github.com/spanwalla/merch-store/internal/service"
@@ -8,15 +9,16 @@ import (
 )
 
 type buyRoutes struct {
-	paymentService service.Payment
+	paymentService     service.Payment
+	idempotencyService service.Idempotency
 }
 
 type buyItemInput struct {
 	Item string `param:"item" validate:"required,max=16"`
 }
 
-func newBuyRoutes(g *echo.Group, paymentService service.Payment) {
-	r := &buyRoutes{paymentService}
+func newBuyRoutes(g *echo.Group, paymentService service.Payment, idempotencyService service.Idempotency) {
+	r := &buyRoutes{paymentService, idempotencyService}
 
 	g.GET("/:item", r.buyItem)
 }
@@ -30,13 +32,20 @@ func (r *buyRoutes) buyItem(c echo.Context) error {
 	}
 
 	if err := c.Validate(input); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		newValidationErrorResponse(c, err)
 		return err
 	}
 
-	err := r.paymentService.BuyItem(c.Request().Context(), service.PaymentBuyItemInput{
-		UserId:   c.Get(userIdCtx).(int),
-		ItemName: input.Item,
+	response, err := r.idempotencyService.Do(c.Request().Context(), newIdempotencyInput(c, input), func(ctx context.Context) (service.IdempotentResponse, error) {
+		_, err := r.paymentService.BuyItem(ctx, service.PaymentBuyItemInput{
+			UserId:   c.Get(userIdCtx).(int),
+			ItemName: input.Item,
+			Quantity: 1,
+		})
+		if err != nil {
+			return service.IdempotentResponse{}, err
+		}
+		return service.IdempotentResponse{StatusCode: http.StatusOK}, nil
 	})
 	if err != nil {
 		switch {
@@ -44,11 +53,17 @@ func (r *buyRoutes) buyItem(c echo.Context) error {
 			newErrorResponse(c, http.StatusBadRequest, err.Error())
 		case errors.Is(err, service.ErrNotEnoughBalance):
 			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrItemOutOfStock):
+			newErrorResponse(c, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrInvalidIdempotencyKey):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrIdempotencyKeyReused):
+			newErrorResponse(c, http.StatusUnprocessableEntity, err.Error())
 		default:
 			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
 		}
 		return err
 	}
 
-	return c.NoContent(http.StatusOK)
+	return newIdempotentResponse(c, response)
 }
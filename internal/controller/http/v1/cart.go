@@ -0,0 +1,188 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/spanwalla/merch-store/internal/service"
+	"net/http"
+	"time"
+)
+
+type cartRoutes struct {
+	cartService        service.Cart
+	idempotencyService service.Idempotency
+}
+
+type addCartItemInput struct {
+	Item     string `json:"item" validate:"required,max=16"`
+	Quantity int    `json:"quantity" validate:"required,min=1,max=100"`
+}
+
+type removeCartItemInput struct {
+	Item string `param:"item" validate:"required,max=16"`
+}
+
+type cartLine struct {
+	Item     string `json:"item"`
+	Quantity int    `json:"quantity"`
+	Price    int    `json:"price"`
+	Total    int    `json:"total"`
+}
+
+type checkoutErrorResponse struct {
+	Errors   string `json:"errors"`
+	Item     string `json:"item"`
+	Quantity int    `json:"quantity"`
+}
+
+func newCartRoutes(g *echo.Group, cartService service.Cart, idempotencyService service.Idempotency) {
+	r := &cartRoutes{cartService, idempotencyService}
+
+	g.GET("", r.getCart)
+	g.POST("/items", r.addItem)
+	g.DELETE("/items/:item", r.removeItem)
+	g.POST("/checkout", r.checkout)
+}
+
+func (r *cartRoutes) getCart(c echo.Context) error {
+	contents, err := r.cartService.Get(c.Request().Context(), c.Get(userIdCtx).(int))
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		return err
+	}
+
+	type response struct {
+		Items []cartLine `json:"items"`
+		Total int        `json:"total"`
+	}
+
+	return c.JSON(http.StatusOK, response{newCartLines(contents.Lines), contents.Total})
+}
+
+func (r *cartRoutes) addItem(c echo.Context) error {
+	var input addCartItemInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	err := r.cartService.AddItem(c.Request().Context(), service.CartAddItemInput{
+		UserId:   c.Get(userIdCtx).(int),
+		ItemName: input.Item,
+		Quantity: input.Quantity,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrItemNotFound):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (r *cartRoutes) removeItem(c echo.Context) error {
+	var input removeCartItemInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid params")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	err := r.cartService.RemoveItem(c.Request().Context(), service.CartRemoveItemInput{
+		UserId:   c.Get(userIdCtx).(int),
+		ItemName: input.Item,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrItemNotFound), errors.Is(err, service.ErrCartItemNotFound):
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (r *cartRoutes) checkout(c echo.Context) error {
+	response, err := r.idempotencyService.Do(c.Request().Context(), newIdempotencyInput(c, nil), func(ctx context.Context) (service.IdempotentResponse, error) {
+		receipt, err := r.cartService.Checkout(ctx, c.Get(userIdCtx).(int))
+		if err != nil {
+			return service.IdempotentResponse{}, err
+		}
+
+		type response struct {
+			Id          int        `json:"id"`
+			Items       []cartLine `json:"items"`
+			Total       int        `json:"total"`
+			PurchasedAt time.Time  `json:"purchasedAt"`
+		}
+
+		body, err := json.Marshal(response{receipt.Id, newCartLines(receipt.Lines), receipt.Total, receipt.PurchasedAt})
+		if err != nil {
+			return service.IdempotentResponse{}, err
+		}
+		return service.IdempotentResponse{StatusCode: http.StatusCreated, Body: body}, nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCartEmpty):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrNotEnoughBalance):
+			newCheckoutErrorResponse(c, http.StatusBadRequest, err)
+		case errors.Is(err, service.ErrItemOutOfStock):
+			newCheckoutErrorResponse(c, http.StatusConflict, err)
+		case errors.Is(err, service.ErrInvalidIdempotencyKey):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrIdempotencyKeyReused):
+			newErrorResponse(c, http.StatusUnprocessableEntity, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	return newIdempotentResponse(c, response)
+}
+
+// newCheckoutErrorResponse names the cart line the checkout failed on, if it is known.
+func newCheckoutErrorResponse(c echo.Context, code int, err error) {
+	var checkoutErr *service.CheckoutError
+	if !errors.As(err, &checkoutErr) {
+		newErrorResponse(c, code, err.Error())
+		return
+	}
+
+	_ = c.JSON(code, checkoutErrorResponse{Errors: err.Error(), Item: checkoutErr.ItemName, Quantity: checkoutErr.Quantity})
+}
+
+func newCartLines(lines []service.CartLine) []cartLine {
+	result := make([]cartLine, 0, len(lines))
+	for _, line := range lines {
+		result = append(result, cartLine{
+			Item:     line.ItemName,
+			Quantity: line.Quantity,
+			Price:    line.Price,
+			Total:    line.Total,
+		})
+	}
+	return result
+}
@@ -3,13 +3,33 @@ package v1
 import (
 	"errors"
 	"github.com/labstack/echo/v4"
+	"github.com/spanwalla/merch-store/pkg/validator"
+	"net/http"
 )
 
 var (
 	ErrInvalidAuthHeader = errors.New("invalid auth header")
 	ErrCannotParseToken  = errors.New("cannot parse token")
+	ErrForbidden         = errors.New("forbidden")
 )
 
+type errorResponse struct {
+	Errors string `json:"errors"`
+	// Fields lists every failed field if the request did not pass validation.
+	Fields []validator.FieldError `json:"fields,omitempty"`
+}
+
 func newErrorResponse(c echo.Context, code int, message string) {
-	_ = c.JSON(code, map[string]string{"errors": message})
+	_ = c.JSON(code, errorResponse{Errors: message})
+}
+
+// newValidationErrorResponse responds with 400 and the failed fields, so clients can highlight each of them.
+func newValidationErrorResponse(c echo.Context, err error) {
+	var validationErr *validator.ValidationError
+	if !errors.As(err, &validationErr) {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	_ = c.JSON(http.StatusBadRequest, errorResponse{Errors: validationErr.Error(), Fields: validationErr.Fields})
 }
@@ -0,0 +1,34 @@
+package v1
+
+import (
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/spanwalla/merch-store/internal/service"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// newIdempotencyInput ties the Idempotency-Key header to the user, the route and the validated input.
+func newIdempotencyInput(c echo.Context, input any) service.IdempotencyInput {
+	body, _ := json.Marshal(input)
+
+	return service.IdempotencyInput{
+		UserId:  c.Get(userIdCtx).(int),
+		Key:     c.Request().Header.Get(idempotencyKeyHeader),
+		Request: append([]byte(c.Request().Method+" "+c.Path()+" "), body...),
+	}
+}
+
+func newIdempotentResponse(c echo.Context, response service.IdempotentResponse) error {
+	if response.Replayed {
+		c.Response().Header().Set(idempotentReplayedHeader, "true")
+	}
+
+	if len(response.Body) == 0 {
+		return c.NoContent(response.StatusCode)
+	}
+	return c.JSONBlob(response.StatusCode, response.Body)
+}
@@ -0,0 +1,53 @@
+package v1
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/spanwalla/merch-store/internal/service"
+	"net/http"
+	"time"
+)
+
+type inviteRoutes struct {
+	authService service.Auth
+}
+
+type createInviteInput struct {
+	MaxUses int `json:"maxUses" validate:"omitempty,min=1,max=100"`
+}
+
+func newInviteRoutes(g *echo.Group, authService service.Auth) {
+	r := &inviteRoutes{authService}
+
+	g.POST("", r.createInvite)
+}
+
+func (r *inviteRoutes) createInvite(c echo.Context) error {
+	var input createInviteInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	invite, err := r.authService.CreateInvite(c.Request().Context(), service.AuthCreateInviteInput{
+		UserId:  c.Get(userIdCtx).(int),
+		MaxUses: input.MaxUses,
+	})
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		return err
+	}
+
+	type response struct {
+		Code      string    `json:"code"`
+		MaxUses   int       `json:"maxUses"`
+		ExpiresAt time.Time `json:"expiresAt"`
+	}
+
+	return c.JSON(http.StatusCreated, response{invite.Code, invite.MaxUses, invite.ExpiresAt})
+}
@@ -0,0 +1,175 @@
+package v1
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/internal/service"
+	"net/http"
+)
+
+const defaultLowStockThreshold = 5
+
+type itemRoutes struct {
+	itemService service.Item
+}
+
+type restockInput struct {
+	Item     string `param:"name" validate:"required,max=16"`
+	Quantity int    `json:"quantity" validate:"required,min=1,max=100000"`
+}
+
+type setStockInput struct {
+	Item string `param:"name" validate:"required,max=16"`
+	// Stock is a pointer so that a missing stock is not taken for 0.
+	Stock *int `json:"stock" validate:"required,min=0,max=100000"`
+}
+
+type itemNameInput struct {
+	Item string `param:"name" validate:"required,max=16"`
+}
+
+type lowStockInput struct {
+	Threshold int `query:"threshold" validate:"min=0,max=100000"`
+}
+
+type itemResponse struct {
+	Name  string `json:"name"`
+	Price int    `json:"price"`
+	Stock *int   `json:"stock"`
+}
+
+// newItemRoutes registers the routes to manage the stock of the items.
+func newItemRoutes(g *echo.Group, itemService service.Item) {
+	r := &itemRoutes{itemService}
+
+	g.GET("/items/low-stock", r.lowStock)
+	g.POST("/items/:name/restock", r.restock)
+	g.PUT("/items/:name/stock", r.setStock)
+	g.DELETE("/items/:name/stock", r.unlimitStock)
+}
+
+func (r *itemRoutes) restock(c echo.Context) error {
+	var input restockInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	item, err := r.itemService.Restock(c.Request().Context(), service.ItemRestockInput{
+		ItemName: input.Item,
+		Quantity: input.Quantity,
+	})
+	if err != nil {
+		newItemErrorResponse(c, err)
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newItemResponse(item))
+}
+
+func (r *itemRoutes) setStock(c echo.Context) error {
+	var input setStockInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	item, err := r.itemService.SetStock(c.Request().Context(), service.ItemSetStockInput{
+		ItemName: input.Item,
+		Stock:    input.Stock,
+	})
+	if err != nil {
+		newItemErrorResponse(c, err)
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newItemResponse(item))
+}
+
+// unlimitStock makes the stock of the item unlimited.
+func (r *itemRoutes) unlimitStock(c echo.Context) error {
+	var input itemNameInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid params")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	item, err := r.itemService.SetStock(c.Request().Context(), service.ItemSetStockInput{ItemName: input.Item})
+	if err != nil {
+		newItemErrorResponse(c, err)
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newItemResponse(item))
+}
+
+func (r *itemRoutes) lowStock(c echo.Context) error {
+	input := lowStockInput{Threshold: defaultLowStockThreshold}
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	items, err := r.itemService.LowStock(c.Request().Context(), input.Threshold)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		return err
+	}
+
+	type response struct {
+		Items []itemResponse `json:"items"`
+	}
+
+	resp := response{Items: make([]itemResponse, 0, len(items))}
+	for _, item := range items {
+		resp.Items = append(resp.Items, newItemResponse(item))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func newItemErrorResponse(c echo.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrItemNotFound):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidQuantity), errors.Is(err, service.ErrInvalidStock):
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrStockLimitExceeded):
+		newErrorResponse(c, http.StatusUnprocessableEntity, err.Error())
+	default:
+		newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+func newItemResponse(item entity.Item) itemResponse {
+	return itemResponse{
+		Name:  item.Name,
+		Price: item.Price,
+		Stock: item.Stock,
+	}
+}
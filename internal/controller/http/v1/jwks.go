@@ -0,0 +1,23 @@
+package v1
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/spanwalla/merch-store/internal/service"
+	"net/http"
+)
+
+type jwksRoutes struct {
+	authService service.Auth
+}
+
+func newJWKSRoutes(g *echo.Group, authService service.Auth) {
+	r := &jwksRoutes{authService}
+
+	g.GET("/jwks.json", r.getJWKS)
+}
+
+func (r *jwksRoutes) getJWKS(c echo.Context) error {
+	// Verifiers refetch the set when they meet an unknown kid, so a short cache is enough.
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, r.authService.JWKS())
+}
@@ -1,21 +1,42 @@
 package v1
 
 import (
+	"errors"
 	"github.com/labstack/echo/v4"
 	log "github.com/sirupsen/logrus"
 	"github.com/spanwalla/merch-store/internal/service"
 	"net/http"
+	"slices"
 	"strings"
 )
 
-const userIdCtx = "userId"
+const (
+	userIdCtx = "userId"
+	roleCtx   = "role"
+	scopesCtx = "scopes"
+
+	headerAPIKey = "X-API-Key"
+)
 
 type AuthMiddleware struct {
-	authService service.Auth
+	authService   service.Auth
+	apiKeyService service.APIKey
+	// proxy is nil unless authentication by a trusted proxy is enabled.
+	proxy *TrustedProxy
 }
 
 func (h *AuthMiddleware) UserIdentity(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
+		if h.proxy != nil {
+			if name, ok := h.proxy.User(c.Request()); ok {
+				return h.proxyIdentity(c, next, name)
+			}
+		}
+
+		if key := c.Request().Header.Get(headerAPIKey); len(key) > 0 {
+			return h.apiKeyIdentity(c, next, key)
+		}
+
 		token, ok := bearerToken(c.Request())
 		if !ok {
 			log.Errorf("AuthMiddleware.UserIdentity - bearerToken: %v", ErrInvalidAuthHeader)
@@ -23,19 +44,95 @@ func (h *AuthMiddleware) UserIdentity(next echo.HandlerFunc) echo.HandlerFunc {
 			return nil
 		}
 
-		userId, err := h.authService.VerifyToken(token)
+		identity, err := h.authService.VerifyToken(token)
 		if err != nil {
 			log.Errorf("AuthMiddleware.UserIdentity - ParseToken: %v", err)
+			if errors.Is(err, service.ErrTokenRevoked) {
+				newErrorResponse(c, http.StatusUnauthorized, err.Error())
+				return err
+			}
 			newErrorResponse(c, http.StatusUnauthorized, ErrCannotParseToken.Error())
 			return err
 		}
 
-		c.Set(userIdCtx, userId)
+		c.Set(userIdCtx, identity.UserId)
+		c.Set(roleCtx, identity.Role)
 
 		return next(c)
 	}
 }
 
+func (h *AuthMiddleware) apiKeyIdentity(c echo.Context, next echo.HandlerFunc, key string) error {
+	identity, err := h.apiKeyService.Verify(c.Request().Context(), key)
+	if err != nil {
+		log.Errorf("AuthMiddleware.apiKeyIdentity - Verify: %v", err)
+		if errors.Is(err, service.ErrInvalidAPIKey) {
+			newErrorResponse(c, http.StatusUnauthorized, err.Error())
+			return err
+		}
+		newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		return err
+	}
+
+	c.Set(userIdCtx, identity.UserId)
+	c.Set(roleCtx, identity.Role)
+	c.Set(scopesCtx, identity.Scopes)
+
+	return next(c)
+}
+
+func (h *AuthMiddleware) proxyIdentity(c echo.Context, next echo.HandlerFunc, name string) error {
+	identity, err := h.authService.ProxyIdentity(c.Request().Context(), name)
+	if err != nil {
+		log.Errorf("AuthMiddleware.proxyIdentity - ProxyIdentity: %v", err)
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		case errors.Is(err, service.ErrServiceAccountLogin):
+			newErrorResponse(c, http.StatusForbidden, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	c.Set(userIdCtx, identity.UserId)
+	c.Set(roleCtx, identity.Role)
+
+	return next(c)
+}
+
+// RequireScope rejects API key requests if the key is not granted the scope. Requests
+// authenticated with an access token are not restricted.
+func RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			scopes, ok := c.Get(scopesCtx).([]string)
+			if ok && !slices.Contains(scopes, scope) {
+				newErrorResponse(c, http.StatusForbidden, ErrForbidden.Error())
+				return nil
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RequireRole allows the request only if UserIdentity has put one of the roles into the context.
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			role, _ := c.Get(roleCtx).(string)
+			if !slices.Contains(roles, role) {
+				newErrorResponse(c, http.StatusForbidden, ErrForbidden.Error())
+				return nil
+			}
+
+			return next(c)
+		}
+	}
+}
+
 func bearerToken(req *http.Request) (string, bool) {
 	const prefix = "Bearer "
 
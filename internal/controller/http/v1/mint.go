@@ -0,0 +1,87 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/spanwalla/merch-store/internal/service"
+	"net/http"
+)
+
+type mintRoutes struct {
+	paymentService     service.Payment
+	idempotencyService service.Idempotency
+}
+
+type mintInput struct {
+	Users  []string `json:"users" validate:"required,min=1,max=1000,dive,required,max=64"`
+	Amount int      `json:"amount" validate:"required,gt=0,max=100000"`
+	Reason string   `json:"reason" validate:"required,max=255"`
+}
+
+type unknownUsersErrorResponse struct {
+	Errors string   `json:"errors"`
+	Users  []string `json:"users"`
+}
+
+func newMintRoutes(g *echo.Group, paymentService service.Payment, idempotencyService service.Idempotency) {
+	r := &mintRoutes{paymentService, idempotencyService}
+
+	g.POST("/mint", r.mint)
+}
+
+func (r *mintRoutes) mint(c echo.Context) error {
+	var input mintInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	response, err := r.idempotencyService.Do(c.Request().Context(), newIdempotencyInput(c, input), func(ctx context.Context) (service.IdempotentResponse, error) {
+		output, err := r.paymentService.Mint(ctx, service.PaymentMintInput{
+			AdminId:   c.Get(userIdCtx).(int),
+			UserNames: input.Users,
+			Amount:    input.Amount,
+			Reason:    input.Reason,
+		})
+		if err != nil {
+			return service.IdempotentResponse{}, err
+		}
+
+		type response struct {
+			Users int `json:"users"`
+			Total int `json:"total"`
+		}
+
+		body, err := json.Marshal(response{output.Users, output.Total})
+		if err != nil {
+			return service.IdempotentResponse{}, err
+		}
+		return service.IdempotentResponse{StatusCode: http.StatusOK, Body: body}, nil
+	})
+	if err != nil {
+		var unknownErr *service.UnknownUsersError
+		switch {
+		case errors.As(err, &unknownErr):
+			_ = c.JSON(http.StatusBadRequest, unknownUsersErrorResponse{Errors: service.ErrUserNotFound.Error(), Users: unknownErr.UserNames})
+		case errors.Is(err, service.ErrDuplicateRecipient), errors.Is(err, service.ErrMintReasonRequired), errors.Is(err, service.ErrInvalidAmount):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrInvalidIdempotencyKey):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrIdempotencyKeyReused):
+			newErrorResponse(c, http.StatusUnprocessableEntity, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	return newIdempotentResponse(c, response)
+}
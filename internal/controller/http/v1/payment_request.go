@@ -0,0 +1,172 @@
+package v1
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/internal/service"
+	"net/http"
+	"time"
+)
+
+type paymentRequestRoutes struct {
+	paymentService service.Payment
+}
+
+type createPaymentRequestInput struct {
+	Users  []string `json:"users" validate:"required,min=1,max=100,dive,required,max=64"`
+	Amount int      `json:"amount" validate:"required,gt=0"`
+	Note   string   `json:"note" validate:"max=255"`
+}
+
+type payPaymentRequestInput struct {
+	Id int `param:"id" validate:"required,min=1"`
+}
+
+type paymentRequestResponse struct {
+	Id        int        `json:"id"`
+	Requester string     `json:"requester"`
+	Payer     string     `json:"payer"`
+	Amount    int        `json:"amount"`
+	Note      string     `json:"note"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"createdAt"`
+	PaidAt    *time.Time `json:"paidAt"`
+}
+
+func newPaymentRequestRoutes(g *echo.Group, paymentService service.Payment) {
+	r := &paymentRequestRoutes{paymentService}
+
+	g.POST("", r.createRequest)
+	g.GET("/incoming", r.listIncoming)
+	g.GET("/outgoing", r.listOutgoing)
+	g.POST("/:id/pay", r.payRequest)
+}
+
+func (r *paymentRequestRoutes) createRequest(c echo.Context) error {
+	var input createPaymentRequestInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	requests, err := r.paymentService.RequestPayment(c.Request().Context(), service.PaymentRequestInput{
+		RequesterId: c.Get(userIdCtx).(int),
+		PayerNames:  input.Users,
+		Amount:      input.Amount,
+		Note:        input.Note,
+	})
+	if err != nil {
+		var unknownErr *service.UnknownUsersError
+		switch {
+		case errors.As(err, &unknownErr):
+			_ = c.JSON(http.StatusBadRequest, unknownUsersErrorResponse{Errors: service.ErrUserNotFound.Error(), Users: unknownErr.UserNames})
+		case errors.Is(err, service.ErrSelfTransfer), errors.Is(err, service.ErrDuplicateRecipient):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrLimitExceeded):
+			newLimitErrorResponse(c, err)
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	type request struct {
+		Id     int    `json:"id"`
+		Payer  string `json:"payer"`
+		Status string `json:"status"`
+	}
+
+	type response struct {
+		Requests []request `json:"requests"`
+	}
+
+	resp := response{Requests: make([]request, 0, len(requests))}
+	for _, req := range requests {
+		resp.Requests = append(resp.Requests, request{req.Id, req.PayerName, req.Status})
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (r *paymentRequestRoutes) listIncoming(c echo.Context) error {
+	requests, err := r.paymentService.IncomingPaymentRequests(c.Request().Context(), c.Get(userIdCtx).(int))
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		return err
+	}
+
+	return newPaymentRequestsResponse(c, requests)
+}
+
+func (r *paymentRequestRoutes) listOutgoing(c echo.Context) error {
+	requests, err := r.paymentService.OutgoingPaymentRequests(c.Request().Context(), c.Get(userIdCtx).(int))
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		return err
+	}
+
+	return newPaymentRequestsResponse(c, requests)
+}
+
+func (r *paymentRequestRoutes) payRequest(c echo.Context) error {
+	var input payPaymentRequestInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	err := r.paymentService.PayRequest(c.Request().Context(), service.PaymentPayRequestInput{
+		UserId:    c.Get(userIdCtx).(int),
+		RequestId: input.Id,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPaymentRequestNotFound):
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+		case errors.Is(err, service.ErrNotEnoughBalance), errors.Is(err, service.ErrUserNotFound):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrLimitExceeded):
+			newLimitErrorResponse(c, err)
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func newPaymentRequestsResponse(c echo.Context, requests []entity.PaymentRequest) error {
+	type response struct {
+		Requests []paymentRequestResponse `json:"requests"`
+	}
+
+	resp := response{Requests: make([]paymentRequestResponse, 0, len(requests))}
+	for _, req := range requests {
+		resp.Requests = append(resp.Requests, paymentRequestResponse{
+			Id:        req.Id,
+			Requester: req.RequesterName,
+			Payer:     req.PayerName,
+			Amount:    req.Amount,
+			Note:      req.Note,
+			Status:    req.Status,
+			CreatedAt: req.CreatedAt,
+			PaidAt:    req.PaidAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,90 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// maxProxyUserLength matches the length of users.name.
+const maxProxyUserLength = 64
+
+// TrustedProxy is a reverse proxy, usually an SSO gateway, that authenticates users
+// itself and passes the username in a header.
+type TrustedProxy struct {
+	header   string
+	networks []*net.IPNet
+}
+
+func NewTrustedProxy(header string, cidrs []string) (*TrustedProxy, error) {
+	if len(header) == 0 {
+		return nil, errors.New("user header is empty")
+	}
+	if len(cidrs) == 0 {
+		return nil, errors.New("no trusted CIDRs")
+	}
+
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("NewTrustedProxy - net.ParseCIDR: %w", err)
+		}
+		networks = append(networks, network)
+	}
+
+	return &TrustedProxy{header: http.CanonicalHeaderKey(header), networks: networks}, nil
+}
+
+// User returns the username set by the proxy. The header is ignored unless the request
+// comes directly from a trusted network, otherwise any client could set it.
+func (p *TrustedProxy) User(req *http.Request) (string, bool) {
+	name := strings.TrimSpace(req.Header.Get(p.header))
+	if len(name) == 0 || len(name) > maxProxyUserLength {
+		return "", false
+	}
+
+	if !p.trusted(req.RemoteAddr) {
+		return "", false
+	}
+
+	return name, true
+}
+
+// IPExtractor takes the client IP from X-Forwarded-For set by the proxy, so login
+// throttling counts clients rather than the proxy.
+func (p *TrustedProxy) IPExtractor() echo.IPExtractor {
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, network := range p.networks {
+		options = append(options, echo.TrustIPRange(network))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+func (p *TrustedProxy) trusted(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
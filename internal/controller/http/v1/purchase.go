@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/spanwalla/merch-store/internal/service"
+	"net/http"
+	"time"
+)
+
+type purchaseRoutes struct {
+	paymentService     service.Payment
+	idempotencyService service.Idempotency
+}
+
+type createPurchaseInput struct {
+	Item     string `json:"item" validate:"required,max=16"`
+	Quantity int    `json:"quantity" validate:"required,min=1,max=100"`
+}
+
+type purchaseReceipt struct {
+	Id          int       `json:"id"`
+	Item        string    `json:"item"`
+	Quantity    int       `json:"quantity"`
+	Price       int       `json:"price"`
+	Total       int       `json:"total"`
+	PurchasedAt time.Time `json:"purchasedAt"`
+}
+
+func newPurchaseRoutes(g *echo.Group, paymentService service.Payment, idempotencyService service.Idempotency) {
+	r := &purchaseRoutes{paymentService, idempotencyService}
+
+	g.POST("", r.createPurchase)
+}
+
+func (r *purchaseRoutes) createPurchase(c echo.Context) error {
+	var input createPurchaseInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	response, err := r.idempotencyService.Do(c.Request().Context(), newIdempotencyInput(c, input), func(ctx context.Context) (service.IdempotentResponse, error) {
+		receipt, err := r.paymentService.BuyItem(ctx, service.PaymentBuyItemInput{
+			UserId:   c.Get(userIdCtx).(int),
+			ItemName: input.Item,
+			Quantity: input.Quantity,
+		})
+		if err != nil {
+			return service.IdempotentResponse{}, err
+		}
+
+		body, err := json.Marshal(purchaseReceipt{
+			Id:          receipt.Id,
+			Item:        receipt.ItemName,
+			Quantity:    receipt.Quantity,
+			Price:       receipt.Price,
+			Total:       receipt.Total,
+			PurchasedAt: receipt.PurchasedAt,
+		})
+		if err != nil {
+			return service.IdempotentResponse{}, err
+		}
+		return service.IdempotentResponse{StatusCode: http.StatusCreated, Body: body}, nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrItemNotFound):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrNotEnoughBalance):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrItemOutOfStock):
+			newErrorResponse(c, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrInvalidIdempotencyKey):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrIdempotencyKeyReused):
+			newErrorResponse(c, http.StatusUnprocessableEntity, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	return newIdempotentResponse(c, response)
+}
@@ -0,0 +1,194 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/internal/service"
+	"net/http"
+	"time"
+)
+
+type returnRoutes struct {
+	returnService service.Return
+}
+
+type requestReturnInput struct {
+	PurchaseId int    `json:"purchaseId" validate:"required,min=1"`
+	Item       string `json:"item" validate:"required,max=16"`
+	Quantity   int    `json:"quantity" validate:"required,min=1,max=100"`
+	Reason     string `json:"reason" validate:"max=255"`
+}
+
+type listReturnsInput struct {
+	Status string `query:"status" validate:"oneof=pending approved rejected"`
+}
+
+type decideReturnInput struct {
+	Id int `param:"id" validate:"required,min=1"`
+}
+
+type returnResponse struct {
+	Id         int        `json:"id"`
+	PurchaseId int        `json:"purchaseId"`
+	UserId     int        `json:"userId"`
+	Item       string     `json:"item"`
+	Quantity   int        `json:"quantity"`
+	Amount     int        `json:"amount"`
+	Reason     string     `json:"reason"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	DecidedAt  *time.Time `json:"decidedAt"`
+}
+
+func newReturnRoutes(g *echo.Group, returnService service.Return) {
+	r := &returnRoutes{returnService}
+
+	g.POST("", r.requestReturn)
+	g.GET("", r.listReturns)
+}
+
+// newAdminReturnRoutes registers the routes to review the returns of every user.
+func newAdminReturnRoutes(g *echo.Group, returnService service.Return) {
+	r := &returnRoutes{returnService}
+
+	g.GET("/returns", r.listReturnsByStatus)
+	g.POST("/returns/:id/approve", r.approveReturn)
+	g.POST("/returns/:id/reject", r.rejectReturn)
+}
+
+func (r *returnRoutes) requestReturn(c echo.Context) error {
+	var input requestReturnInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	ret, err := r.returnService.Request(c.Request().Context(), service.ReturnRequestInput{
+		UserId:     c.Get(userIdCtx).(int),
+		PurchaseId: input.PurchaseId,
+		ItemName:   input.Item,
+		Quantity:   input.Quantity,
+		Reason:     input.Reason,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPurchaseNotFound):
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+		case errors.Is(err, service.ErrReturnWindowExpired), errors.Is(err, service.ErrReturnQuantityExceeded):
+			newErrorResponse(c, http.StatusUnprocessableEntity, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	type response struct {
+		Id     int    `json:"id"`
+		Status string `json:"status"`
+		Amount int    `json:"amount"`
+	}
+
+	return c.JSON(http.StatusCreated, response{ret.Id, ret.Status, ret.Amount})
+}
+
+func (r *returnRoutes) listReturns(c echo.Context) error {
+	returns, err := r.returnService.List(c.Request().Context(), c.Get(userIdCtx).(int))
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		return err
+	}
+
+	return newReturnsResponse(c, returns)
+}
+
+func (r *returnRoutes) listReturnsByStatus(c echo.Context) error {
+	input := listReturnsInput{Status: entity.ReturnStatusPending}
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	returns, err := r.returnService.ListByStatus(c.Request().Context(), input.Status)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		return err
+	}
+
+	return newReturnsResponse(c, returns)
+}
+
+func (r *returnRoutes) approveReturn(c echo.Context) error {
+	return r.decideReturn(c, r.returnService.Approve)
+}
+
+func (r *returnRoutes) rejectReturn(c echo.Context) error {
+	return r.decideReturn(c, r.returnService.Reject)
+}
+
+func (r *returnRoutes) decideReturn(c echo.Context, decide func(ctx context.Context, input service.ReturnDecideInput) error) error {
+	var input decideReturnInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	err := decide(c.Request().Context(), service.ReturnDecideInput{
+		Id:      input.Id,
+		AdminId: c.Get(userIdCtx).(int),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrReturnNotFound):
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func newReturnsResponse(c echo.Context, returns []entity.Return) error {
+	type response struct {
+		Returns []returnResponse `json:"returns"`
+	}
+
+	resp := response{Returns: make([]returnResponse, 0, len(returns))}
+	for _, ret := range returns {
+		resp.Returns = append(resp.Returns, returnResponse{
+			Id:         ret.Id,
+			PurchaseId: ret.PurchaseId,
+			UserId:     ret.UserId,
+			Item:       ret.ItemName,
+			Quantity:   ret.Quantity,
+			Amount:     ret.Amount,
+			Reason:     ret.Reason,
+			Status:     ret.Status,
+			CreatedAt:  ret.CreatedAt,
+			DecidedAt:  ret.DecidedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
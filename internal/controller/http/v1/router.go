@@ -4,12 +4,16 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	log "github.com/sirupsen/logrus"
+	"github.com/spanwalla/merch-store/internal/entity"
 	"github.com/spanwalla/merch-store/internal/service"
+	"github.com/spanwalla/merch-store/pkg/validator"
 	"net/http"
 	"os"
 )
 
-func ConfigureRouter(handler *echo.Echo, services *service.Services) {
+// ConfigureRouter registers the routes. A nil proxy disables authentication by a trusted proxy.
+// passwordRules are published for clients and must match the rules of handler.Validator.
+func ConfigureRouter(handler *echo.Echo, services *service.Services, proxy *TrustedProxy, passwordRules validator.PasswordRules) {
 	handler.Use(middleware.CORS())
 	handler.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
 		Format: `{"time":"${time_rfc3339_nano}", "method":"${method}","uri":"${uri}", "status":${status},"error":"${error}"}` + "\n",
@@ -19,17 +23,35 @@ func ConfigureRouter(handler *echo.Echo, services *service.Services) {
 
 	handler.GET("/health", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
 
+	newJWKSRoutes(handler.Group("/.well-known"), services.Auth)
+
+	authMiddleware := &AuthMiddleware{services.Auth, services.APIKey, proxy}
+
 	authGroup := handler.Group("/api/auth")
 	{
-		newAuthRoutes(authGroup, services.Auth)
+		newAuthRoutes(authGroup, services.Auth, authMiddleware.UserIdentity, passwordRules)
 	}
 
-	authMiddleware := &AuthMiddleware{services.Auth}
 	protectedGroup := handler.Group("/api", authMiddleware.UserIdentity)
 	{
-		newInfoRoutes(protectedGroup.Group("/info"), services.UserReport)
-		newBuyRoutes(protectedGroup.Group("/buy"), services.Payment)
-		newSendRoutes(protectedGroup.Group("/sendCoin"), services.Payment)
+		newInfoRoutes(protectedGroup.Group("/info", RequireScope(entity.ScopeReportRead)), services.UserReport)
+		newBuyRoutes(protectedGroup.Group("/buy", RequireScope(entity.ScopeItemBuy)), services.Payment, services.Idempotency)
+		newSendRoutes(protectedGroup.Group("/sendCoin", RequireScope(entity.ScopeTransferSend)), services.Payment, services.Idempotency)
+		newTransferRoutes(protectedGroup.Group("/v1/transfers", RequireScope(entity.ScopeTransferSend)), services.Payment)
+		newPaymentRequestRoutes(protectedGroup.Group("/payment-requests", RequireScope(entity.ScopeTransferSend)), services.Payment)
+		newInviteRoutes(protectedGroup.Group("/invites", RequireScope(entity.ScopeInviteCreate)), services.Auth)
+		newPurchaseRoutes(protectedGroup.Group("/v1/purchases", RequireScope(entity.ScopeItemBuy)), services.Payment, services.Idempotency)
+		newCartRoutes(protectedGroup.Group("/v1/cart", RequireScope(entity.ScopeItemBuy)), services.Cart, services.Idempotency)
+		newReturnRoutes(protectedGroup.Group("/v1/returns", RequireScope(entity.ScopeItemBuy)), services.Return)
+	}
+
+	adminGroup := protectedGroup.Group("/admin", RequireRole(entity.RoleAdmin))
+	{
+		newAdminRoutes(adminGroup, services.Auth)
+		newAPIKeyRoutes(adminGroup, services.APIKey)
+		newAdminReturnRoutes(adminGroup, services.Return)
+		newMintRoutes(adminGroup, services.Payment, services.Idempotency)
+		newItemRoutes(adminGroup, services.Item)
 	}
 }
 
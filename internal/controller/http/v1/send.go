@@ -1,14 +1,18 @@
 package v1
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"github.com/labstack/echo/v4"
 	"github.com/spanwalla/merch-store/internal/service"
 	"net/http"
+	"time"
 )
 
 type sendRoutes struct {
-	paymentService service.Payment
+	paymentService     service.Payment
+	idempotencyService service.Idempotency
 }
 
 type sendCoinInput struct {
@@ -16,14 +20,48 @@ type sendCoinInput struct {
 	Amount int    `json:"amount" validate:"required,gt=0"`
 }
 
-func newSendRoutes(g *echo.Group, paymentService service.Payment) {
-	r := &sendRoutes{paymentService}
+type sendSingleCoinInput struct {
+	sendCoinInput
+	// Pending holds the coins in escrow until the recipient accepts the transfer.
+	Pending bool `json:"pending"`
+}
+
+type sendCoinBatchInput struct {
+	Transfers []sendCoinInput `json:"transfers" validate:"required,min=1,max=100,dive"`
+	// AllOrNothing rejects the whole batch if any transfer is invalid.
+	AllOrNothing bool `json:"allOrNothing"`
+}
+
+type transferResult struct {
+	ToUser string `json:"toUser"`
+	Amount int    `json:"amount"`
+	// Status is sent, failed or skipped. Valid transfers are skipped when an all-or-nothing batch is rejected.
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type limitErrorResponse struct {
+	Errors string `json:"errors"`
+	Limit  string `json:"limit"`
+	Max    int    `json:"max"`
+	Used   int    `json:"used"`
+	ToUser string `json:"toUser,omitempty"`
+}
+
+type batchTransferErrorResponse struct {
+	Errors  string           `json:"errors"`
+	Results []transferResult `json:"results"`
+}
+
+func newSendRoutes(g *echo.Group, paymentService service.Payment, idempotencyService service.Idempotency) {
+	r := &sendRoutes{paymentService, idempotencyService}
 
 	g.POST("", r.sendCoin)
+	g.POST("/batch", r.sendCoinBatch)
 }
 
 func (r *sendRoutes) sendCoin(c echo.Context) error {
-	var input sendCoinInput
+	var input sendSingleCoinInput
 
 	if err := c.Bind(&input); err != nil {
 		newErrorResponse(c, http.StatusBadRequest, "invalid request body")
@@ -31,14 +69,41 @@ func (r *sendRoutes) sendCoin(c echo.Context) error {
 	}
 
 	if err := c.Validate(input); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		newValidationErrorResponse(c, err)
 		return err
 	}
 
-	err := r.paymentService.Transfer(c.Request().Context(), service.PaymentTransferInput{
-		FromUserId: c.Get(userIdCtx).(int),
-		ToUserName: input.ToUser,
-		Amount:     input.Amount,
+	response, err := r.idempotencyService.Do(c.Request().Context(), newIdempotencyInput(c, input), func(ctx context.Context) (service.IdempotentResponse, error) {
+		transferInput := service.PaymentTransferInput{
+			FromUserId: c.Get(userIdCtx).(int),
+			ToUserName: input.ToUser,
+			Amount:     input.Amount,
+		}
+
+		if !input.Pending {
+			err := r.paymentService.Transfer(ctx, transferInput)
+			if err != nil {
+				return service.IdempotentResponse{}, err
+			}
+			return service.IdempotentResponse{StatusCode: http.StatusOK}, nil
+		}
+
+		transfer, err := r.paymentService.TransferPending(ctx, transferInput)
+		if err != nil {
+			return service.IdempotentResponse{}, err
+		}
+
+		type response struct {
+			Id        int       `json:"id"`
+			Status    string    `json:"status"`
+			ExpiresAt time.Time `json:"expiresAt"`
+		}
+
+		body, err := json.Marshal(response{transfer.Id, transfer.Status, transfer.ExpiresAt})
+		if err != nil {
+			return service.IdempotentResponse{}, err
+		}
+		return service.IdempotentResponse{StatusCode: http.StatusAccepted, Body: body}, nil
 	})
 	if err != nil {
 		switch {
@@ -46,13 +111,112 @@ func (r *sendRoutes) sendCoin(c echo.Context) error {
 			newErrorResponse(c, http.StatusBadRequest, err.Error())
 		case errors.Is(err, service.ErrNotEnoughBalance):
 			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrLimitExceeded):
+			newLimitErrorResponse(c, err)
 		case errors.Is(err, service.ErrSelfTransfer):
 			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrInvalidIdempotencyKey):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrIdempotencyKeyReused):
+			newErrorResponse(c, http.StatusUnprocessableEntity, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	return newIdempotentResponse(c, response)
+}
+
+func (r *sendRoutes) sendCoinBatch(c echo.Context) error {
+	var input sendCoinBatchInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	transfers := make([]service.PaymentBatchTransfer, 0, len(input.Transfers))
+	for _, transfer := range input.Transfers {
+		transfers = append(transfers, service.PaymentBatchTransfer{ToUserName: transfer.ToUser, Amount: transfer.Amount})
+	}
+
+	response, err := r.idempotencyService.Do(c.Request().Context(), newIdempotencyInput(c, input), func(ctx context.Context) (service.IdempotentResponse, error) {
+		output, err := r.paymentService.BatchTransfer(ctx, service.PaymentBatchTransferInput{
+			FromUserId:   c.Get(userIdCtx).(int),
+			Transfers:    transfers,
+			AllOrNothing: input.AllOrNothing,
+		})
+		if err != nil {
+			return service.IdempotentResponse{}, err
+		}
+
+		type response struct {
+			Total   int              `json:"total"`
+			Results []transferResult `json:"results"`
+		}
+
+		body, err := json.Marshal(response{output.Total, newTransferResults(output.Results, "sent")})
+		if err != nil {
+			return service.IdempotentResponse{}, err
+		}
+		return service.IdempotentResponse{StatusCode: http.StatusOK, Body: body}, nil
+	})
+	if err != nil {
+		var batchErr *service.BatchTransferError
+		switch {
+		case errors.As(err, &batchErr):
+			_ = c.JSON(http.StatusBadRequest, batchTransferErrorResponse{Errors: err.Error(), Results: newTransferResults(batchErr.Results, "skipped")})
+		case errors.Is(err, service.ErrUserNotFound):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrNotEnoughBalance):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrLimitExceeded):
+			newLimitErrorResponse(c, err)
+		case errors.Is(err, service.ErrInvalidIdempotencyKey):
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrIdempotencyKeyReused):
+			newErrorResponse(c, http.StatusUnprocessableEntity, err.Error())
 		default:
 			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
 		}
 		return err
 	}
 
-	return c.NoContent(http.StatusOK)
+	return newIdempotentResponse(c, response)
+}
+
+// newTransferResults reports the transfers without an error with okStatus.
+func newTransferResults(results []service.PaymentTransferResult, okStatus string) []transferResult {
+	response := make([]transferResult, 0, len(results))
+	for _, result := range results {
+		item := transferResult{ToUser: result.ToUserName, Amount: result.Amount, Status: okStatus}
+		if result.Err != nil {
+			item.Status, item.Error = "failed", result.Err.Error()
+		}
+		response = append(response, item)
+	}
+	return response
+}
+
+// newLimitErrorResponse responds with 422 and the limit that was hit.
+func newLimitErrorResponse(c echo.Context, err error) {
+	var limitErr *service.LimitError
+	if !errors.As(err, &limitErr) {
+		newErrorResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	_ = c.JSON(http.StatusUnprocessableEntity, limitErrorResponse{
+		Errors: err.Error(),
+		Limit:  limitErr.Limit,
+		Max:    limitErr.Max,
+		Used:   limitErr.Used,
+		ToUser: limitErr.UserName,
+	})
 }
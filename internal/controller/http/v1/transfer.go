@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/spanwalla/merch-store/internal/service"
+	"net/http"
+)
+
+type transferRoutes struct {
+	paymentService service.Payment
+}
+
+type resolveTransferInput struct {
+	Id int `param:"id" validate:"required,min=1"`
+}
+
+// newTransferRoutes registers the routes for the receiver of a pending transfer.
+func newTransferRoutes(g *echo.Group, paymentService service.Payment) {
+	r := &transferRoutes{paymentService}
+
+	g.POST("/:id/accept", r.acceptTransfer)
+	g.POST("/:id/decline", r.declineTransfer)
+}
+
+func (r *transferRoutes) acceptTransfer(c echo.Context) error {
+	return r.resolveTransfer(c, r.paymentService.AcceptTransfer)
+}
+
+func (r *transferRoutes) declineTransfer(c echo.Context) error {
+	return r.resolveTransfer(c, r.paymentService.DeclineTransfer)
+}
+
+func (r *transferRoutes) resolveTransfer(c echo.Context, resolve func(ctx context.Context, input service.PaymentResolveTransferInput) error) error {
+	var input resolveTransferInput
+
+	if err := c.Bind(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid request")
+		return err
+	}
+
+	if err := c.Validate(input); err != nil {
+		newValidationErrorResponse(c, err)
+		return err
+	}
+
+	err := resolve(c.Request().Context(), service.PaymentResolveTransferInput{
+		UserId:     c.Get(userIdCtx).(int),
+		TransferId: input.Id,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPendingTransferNotFound):
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+		case errors.Is(err, service.ErrLimitExceeded):
+			newLimitErrorResponse(c, err)
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		}
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
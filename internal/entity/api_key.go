@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+const (
+	ScopeReportRead   = "report:read"
+	ScopeItemBuy      = "item:buy"
+	ScopeTransferSend = "transfer:send"
+	ScopeInviteCreate = "invite:create"
+)
+
+type APIKey struct {
+	Id         int        `db:"id"`
+	UserId     int        `db:"user_id"`
+	Name       string     `db:"name"`
+	Prefix     string     `db:"prefix"`
+	KeyHash    string     `db:"key_hash"`
+	Scopes     []string   `db:"scopes"`
+	CreatedAt  time.Time  `db:"created_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+}
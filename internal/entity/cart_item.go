@@ -0,0 +1,9 @@
+package entity
+
+// CartItem is a line of the user's cart with the current name and price of the item.
+type CartItem struct {
+	ItemId   int    `db:"item_id"`
+	ItemName string `db:"name"`
+	Price    int    `db:"price"`
+	Quantity int    `db:"quantity"`
+}
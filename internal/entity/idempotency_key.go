@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// IdempotencyKey remembers the result of a request sent with the Idempotency-Key header.
+// StatusCode is zero until the request completes.
+type IdempotencyKey struct {
+	Id          int       `db:"id"`
+	UserId      int       `db:"user_id"`
+	Key         string    `db:"key"`
+	Fingerprint string    `db:"fingerprint"`
+	StatusCode  int       `db:"status_code"`
+	Response    []byte    `db:"response"`
+	CreatedAt   time.Time `db:"created_at"`
+	// ExpiresAt is when the key may be used for another request and the row deleted.
+	ExpiresAt time.Time `db:"expires_at"`
+}
@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+type Invite struct {
+	Id        int       `db:"id"`
+	CodeHash  string    `db:"code_hash"`
+	CreatedBy int       `db:"created_by"`
+	MaxUses   int       `db:"max_uses"`
+	Uses      int       `db:"uses"`
+	ExpiresAt time.Time `db:"expires_at"`
+	CreatedAt time.Time `db:"created_at"`
+}
@@ -1,7 +1,14 @@
 package entity
 
+import "math"
+
+// MaxItemStock is the largest stock the INT column holds.
+const MaxItemStock = math.MaxInt32
+
 type Item struct {
 	Id    int    `db:"id"`
 	Name  string `db:"name"`
 	Price int    `db:"price"`
+	// Stock is the number of items left, nil means the stock is unlimited.
+	Stock *int `db:"stock"`
 }
@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+const (
+	JobRunStatusRunning   = "running"
+	JobRunStatusSucceeded = "succeeded"
+	JobRunStatusFailed    = "failed"
+)
+
+// JobRun records a run of a scheduled job. ScheduledAt is the time from the schedule,
+// so replicas running the same job agree on it.
+type JobRun struct {
+	Id          int        `db:"id"`
+	Job         string     `db:"job"`
+	ScheduledAt time.Time  `db:"scheduled_at"`
+	StartedAt   time.Time  `db:"started_at"`
+	FinishedAt  *time.Time `db:"finished_at"`
+	Status      string     `db:"status"`
+	Details     string     `db:"details"`
+}
@@ -0,0 +1,117 @@
+package entity
+
+import "time"
+
+const (
+	LedgerKindTransfer = "transfer"
+	LedgerKindPurchase = "purchase"
+	LedgerKindRefund   = "refund"
+	// LedgerKindMint entries are sent by the system account and create new coins.
+	LedgerKindMint = "mint"
+	// LedgerKindAllowance entries are sent by the system account on schedule.
+	LedgerKindAllowance = "allowance"
+	// LedgerKindExpiration entries have no receiver, the coins are taken out of circulation.
+	LedgerKindExpiration = "expiration"
+	// Coins of a pending transfer are held without a receiver, then released to the receiver or
+	// refunded to the sender without a sender. The entries of a transfer share its PendingTransferId.
+	LedgerKindEscrowHold    = "escrow_hold"
+	LedgerKindEscrowRelease = "escrow_release"
+	LedgerKindEscrowRefund  = "escrow_refund"
+)
+
+// TransferStats sums up the transfers of a user over a period.
+type TransferStats struct {
+	Count  int
+	Amount int
+}
+
+// Expiration holds the ledger entries of a user that may have coins old enough to expire.
+type Expiration struct {
+	UserId  int
+	Balance int
+	// Before is the expiration time, coins minted or given as allowance earlier expire.
+	Before time.Time
+	// Entries are the entries sent or received by the user, oldest first.
+	Entries []LedgerEntry
+}
+
+// expirationLot is a number of coins the user received at once and has not spent yet.
+type expirationLot struct {
+	amount   int
+	granted  bool
+	expiring bool
+}
+
+// Amount is how many coins the user is going to lose. Coins are spent first in, first out: every
+// payment takes the oldest coins left, and an expiration takes the oldest granted coins left. Coins
+// the ledger does not account for, such as the initial balance, are older than any entry.
+func (e Expiration) Amount() int {
+	var received, sent int
+	for _, entry := range e.Entries {
+		if entry.ReceiverId != nil && *entry.ReceiverId == e.UserId {
+			received += entry.Amount
+		} else {
+			sent += entry.Amount
+		}
+	}
+
+	lots := make([]expirationLot, 0, len(e.Entries)+1)
+	if initial := e.Balance - received + sent; initial > 0 {
+		lots = append(lots, expirationLot{amount: initial})
+	}
+
+	for _, entry := range e.Entries {
+		switch {
+		case entry.ReceiverId != nil && *entry.ReceiverId == e.UserId:
+			granted := entry.Kind == LedgerKindMint || entry.Kind == LedgerKindAllowance
+			lots = append(lots, expirationLot{
+				amount:   entry.Amount,
+				granted:  granted,
+				expiring: granted && entry.CreatedAt.Before(e.Before),
+			})
+		case entry.Kind == LedgerKindExpiration:
+			spendLots(lots, entry.Amount, true)
+		default:
+			spendLots(lots, entry.Amount, false)
+		}
+	}
+
+	var amount int
+	for _, lot := range lots {
+		if lot.expiring {
+			amount += lot.amount
+		}
+	}
+	return min(amount, max(0, e.Balance))
+}
+
+// spendLots takes amount coins out of the oldest lots, only out of granted ones if grantedOnly is set.
+func spendLots(lots []expirationLot, amount int, grantedOnly bool) {
+	for i := range lots {
+		if amount == 0 {
+			return
+		}
+		if grantedOnly && !lots[i].granted {
+			continue
+		}
+		spent := min(lots[i].amount, amount)
+		lots[i].amount -= spent
+		amount -= spent
+	}
+}
+
+// LedgerEntry records a single movement of coins and is never changed. SenderId or
+// ReceiverId is nil when coins leave or enter circulation, e.g. on a purchase.
+type LedgerEntry struct {
+	Id         int       `db:"id"`
+	CreatedAt  time.Time `db:"created_at"`
+	SenderId   *int      `db:"sender_id"`
+	ReceiverId *int      `db:"receiver_id"`
+	Amount     int       `db:"amount"`
+	Kind       string    `db:"kind"`
+	Reason     string    `db:"reason"`
+	// CreatedBy is the admin who minted the coins.
+	CreatedBy *int `db:"created_by"`
+	// PendingTransferId pairs the escrow entries of a pending transfer: the hold with the release or the refund.
+	PendingTransferId *int `db:"pending_transfer_id"`
+}
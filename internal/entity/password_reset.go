@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+type PasswordReset struct {
+	Id        int        `db:"id"`
+	UserId    int        `db:"user_id"`
+	TokenHash string     `db:"token_hash"`
+	CreatedBy int        `db:"created_by"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	CreatedAt time.Time  `db:"created_at"`
+	UsedAt    *time.Time `db:"used_at"`
+}
@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+const (
+	PaymentRequestStatusPending = "pending"
+	PaymentRequestStatusPaid    = "paid"
+)
+
+// PaymentRequest asks the payer to transfer Amount coins to the requester.
+type PaymentRequest struct {
+	Id            int        `db:"id"`
+	RequesterId   int        `db:"requester_id"`
+	RequesterName string     `db:"requester_name"`
+	PayerId       int        `db:"payer_id"`
+	PayerName     string     `db:"payer_name"`
+	Amount        int        `db:"amount"`
+	Note          string     `db:"note"`
+	Status        string     `db:"status"`
+	CreatedAt     time.Time  `db:"created_at"`
+	PaidAt        *time.Time `db:"paid_at"`
+}
@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+const (
+	PendingTransferStatusPending  = "pending"
+	PendingTransferStatusAccepted = "accepted"
+	PendingTransferStatusDeclined = "declined"
+	PendingTransferStatusExpired  = "expired"
+)
+
+// PendingTransfer holds the coins of a transfer in escrow until the receiver accepts it.
+// Declined and expired transfers are returned to the sender.
+type PendingTransfer struct {
+	Id         int        `db:"id"`
+	SenderId   int        `db:"sender_id"`
+	ReceiverId int        `db:"receiver_id"`
+	Amount     int        `db:"amount"`
+	Status     string     `db:"status"`
+	CreatedAt  time.Time  `db:"created_at"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	ResolvedAt *time.Time `db:"resolved_at"`
+}
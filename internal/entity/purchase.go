@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// Purchase is one item line of a purchase. LedgerEntryId is the id shown on the receipt.
+type Purchase struct {
+	Id            int       `db:"id"`
+	LedgerEntryId int       `db:"ledger_entry_id"`
+	UserId        int       `db:"user_id"`
+	ItemId        int       `db:"item_id"`
+	ItemName      string    `db:"name"`
+	Quantity      int       `db:"quantity"`
+	Price         int       `db:"price"`
+	CreatedAt     time.Time `db:"created_at"`
+}
@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+type RefreshToken struct {
+	Id        int        `db:"id"`
+	UserId    int        `db:"user_id"`
+	FamilyId  string     `db:"family_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	CreatedAt time.Time  `db:"created_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+}
@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+const (
+	ReturnStatusPending  = "pending"
+	ReturnStatusApproved = "approved"
+	ReturnStatusRejected = "rejected"
+)
+
+type Return struct {
+	Id         int        `db:"id"`
+	PurchaseId int        `db:"purchase_id"`
+	UserId     int        `db:"user_id"`
+	ItemId     int        `db:"item_id"`
+	ItemName   string     `db:"name"`
+	Quantity   int        `db:"quantity"`
+	Amount     int        `db:"amount"`
+	Reason     string     `db:"reason"`
+	Status     string     `db:"status"`
+	CreatedAt  time.Time  `db:"created_at"`
+	DecidedAt  *time.Time `db:"decided_at"`
+	DecidedBy  *int       `db:"decided_by"`
+}
@@ -0,0 +1,8 @@
+package entity
+
+import "time"
+
+type RevokedToken struct {
+	Jti       string    `db:"jti"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
@@ -0,0 +1,10 @@
+package entity
+
+import "time"
+
+// RevokedUser invalidates all access tokens of the user issued before RevokedAt.
+type RevokedUser struct {
+	UserId    int       `db:"user_id"`
+	RevokedAt time.Time `db:"revoked_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
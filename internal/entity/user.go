@@ -1,8 +1,37 @@
 package entity
 
+import "strings"
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+	// RoleService is a bot account. It cannot log in with a password and authenticates with API keys only.
+	RoleService = "service"
+	// RoleSystem is held only by the system account, which sends minted coins. It cannot log in.
+	RoleSystem = "system"
+
+	SystemUserName = "system"
+	// SystemUserFallbackName is given to the system account if a user already had SystemUserName
+	// when the account was created. The account is found by its role, not by the name.
+	SystemUserFallbackName = "~system"
+)
+
+// IsReservedUserName reports whether the name cannot be taken by a new account. Case is ignored,
+// so that nobody can pass for the system account.
+func IsReservedUserName(name string) bool {
+	return strings.EqualFold(name, SystemUserName) || strings.EqualFold(name, SystemUserFallbackName)
+}
+
+// CanLogInWithPassword reports whether accounts with the role may have a password. Service accounts
+// authenticate with API keys only, and nobody may act as the system account.
+func CanLogInWithPassword(role string) bool {
+	return role != RoleService && role != RoleSystem
+}
+
 type User struct {
 	Id       int    `db:"id"`
 	Name     string `db:"name"`
 	Password string `db:"password"`
 	Balance  int    `db:"balance"`
+	Role     string `db:"role"`
 }
@@ -1,5 +1,7 @@
 package entity
 
+import "time"
+
 type ReceivedTransaction struct {
 	FromUser string `json:"fromUser"`
 	Amount   int    `json:"amount"`
@@ -10,9 +12,36 @@ type SentTransaction struct {
 	Amount int    `json:"amount"`
 }
 
+type RefundTransaction struct {
+	Item     string `json:"item"`
+	Quantity int    `json:"quantity"`
+	Amount   int    `json:"amount"`
+}
+
 type CoinHistory struct {
 	Received []ReceivedTransaction `json:"received"`
 	Sent     []SentTransaction     `json:"sent"`
+	Refunds  []RefundTransaction   `json:"refunds"`
+}
+
+type IncomingTransfer struct {
+	Id        int       `json:"id"`
+	FromUser  string    `json:"fromUser"`
+	Amount    int       `json:"amount"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type OutgoingTransfer struct {
+	Id        int       `json:"id"`
+	ToUser    string    `json:"toUser"`
+	Amount    int       `json:"amount"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// PendingTransfers are waiting for the receiver to accept them.
+type PendingTransfers struct {
+	Incoming []IncomingTransfer `json:"incoming"`
+	Outgoing []OutgoingTransfer `json:"outgoing"`
 }
 
 type Inventory struct {
@@ -21,7 +50,8 @@ type Inventory struct {
 }
 
 type UserReport struct {
-	Coins       int         `db:"coins" json:"coins"`
-	Inventory   []Inventory `db:"inventory" json:"inventory"`
-	CoinHistory CoinHistory `db:"coin_history" json:"coinHistory"`
+	Coins            int              `db:"coins" json:"coins"`
+	Inventory        []Inventory      `db:"inventory" json:"inventory"`
+	CoinHistory      CoinHistory      `db:"coin_history" json:"coinHistory"`
+	PendingTransfers PendingTransfers `db:"pending_transfers" json:"pendingTransfers"`
 }
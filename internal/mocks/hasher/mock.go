@@ -40,11 +40,12 @@ func (m *MockPasswordHasher) EXPECT() *MockPasswordHasherMockRecorder {
 }
 
 // Hash mocks base method.
-func (m *MockPasswordHasher) Hash(password string) string {
+func (m *MockPasswordHasher) Hash(password string) (string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Hash", password)
 	ret0, _ := ret[0].(string)
-	return ret0
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Hash indicates an expected call of Hash.
@@ -52,3 +53,114 @@ func (mr *MockPasswordHasherMockRecorder) Hash(password any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Hash", reflect.TypeOf((*MockPasswordHasher)(nil).Hash), password)
 }
+
+// NeedsRehash mocks base method.
+func (m *MockPasswordHasher) NeedsRehash(encodedHash string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NeedsRehash", encodedHash)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// NeedsRehash indicates an expected call of NeedsRehash.
+func (mr *MockPasswordHasherMockRecorder) NeedsRehash(encodedHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NeedsRehash", reflect.TypeOf((*MockPasswordHasher)(nil).NeedsRehash), encodedHash)
+}
+
+// Verify mocks base method.
+func (m *MockPasswordHasher) Verify(password, encodedHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", password, encodedHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockPasswordHasherMockRecorder) Verify(password, encodedHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockPasswordHasher)(nil).Verify), password, encodedHash)
+}
+
+// MockAlgorithm is a mock of Algorithm interface.
+type MockAlgorithm struct {
+	ctrl     *gomock.Controller
+	recorder *MockAlgorithmMockRecorder
+	isgomock struct{}
+}
+
+// MockAlgorithmMockRecorder is the mock recorder for MockAlgorithm.
+type MockAlgorithmMockRecorder struct {
+	mock *MockAlgorithm
+}
+
+// NewMockAlgorithm creates a new mock instance.
+func NewMockAlgorithm(ctrl *gomock.Controller) *MockAlgorithm {
+	mock := &MockAlgorithm{ctrl: ctrl}
+	mock.recorder = &MockAlgorithmMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAlgorithm) EXPECT() *MockAlgorithmMockRecorder {
+	return m.recorder
+}
+
+// Hash mocks base method.
+func (m *MockAlgorithm) Hash(password string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Hash", password)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Hash indicates an expected call of Hash.
+func (mr *MockAlgorithmMockRecorder) Hash(password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Hash", reflect.TypeOf((*MockAlgorithm)(nil).Hash), password)
+}
+
+// Identify mocks base method.
+func (m *MockAlgorithm) Identify(encodedHash string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Identify", encodedHash)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Identify indicates an expected call of Identify.
+func (mr *MockAlgorithmMockRecorder) Identify(encodedHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Identify", reflect.TypeOf((*MockAlgorithm)(nil).Identify), encodedHash)
+}
+
+// NeedsRehash mocks base method.
+func (m *MockAlgorithm) NeedsRehash(encodedHash string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NeedsRehash", encodedHash)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// NeedsRehash indicates an expected call of NeedsRehash.
+func (mr *MockAlgorithmMockRecorder) NeedsRehash(encodedHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NeedsRehash", reflect.TypeOf((*MockAlgorithm)(nil).NeedsRehash), encodedHash)
+}
+
+// Verify mocks base method.
+func (m *MockAlgorithm) Verify(password, encodedHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", password, encodedHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockAlgorithmMockRecorder) Verify(password, encodedHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockAlgorithm)(nil).Verify), password, encodedHash)
+}
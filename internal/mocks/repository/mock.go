@@ -12,6 +12,7 @@ package repomocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	entity "github.com/spanwalla/merch-store/internal/entity"
 	gomock "go.uber.org/mock/gomock"
@@ -55,42 +56,102 @@ func (mr *MockTransactorMockRecorder) WithinTransaction(ctx, fn any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTransaction", reflect.TypeOf((*MockTransactor)(nil).WithinTransaction), ctx, fn)
 }
 
-// MockOperation is a mock of Operation interface.
-type MockOperation struct {
+// MockLedger is a mock of Ledger interface.
+type MockLedger struct {
 	ctrl     *gomock.Controller
-	recorder *MockOperationMockRecorder
+	recorder *MockLedgerMockRecorder
 	isgomock struct{}
 }
 
-// MockOperationMockRecorder is the mock recorder for MockOperation.
-type MockOperationMockRecorder struct {
-	mock *MockOperation
+// MockLedgerMockRecorder is the mock recorder for MockLedger.
+type MockLedgerMockRecorder struct {
+	mock *MockLedger
 }
 
-// NewMockOperation creates a new mock instance.
-func NewMockOperation(ctrl *gomock.Controller) *MockOperation {
-	mock := &MockOperation{ctrl: ctrl}
-	mock.recorder = &MockOperationMockRecorder{mock}
+// NewMockLedger creates a new mock instance.
+func NewMockLedger(ctrl *gomock.Controller) *MockLedger {
+	mock := &MockLedger{ctrl: ctrl}
+	mock.recorder = &MockLedgerMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockOperation) EXPECT() *MockOperationMockRecorder {
+func (m *MockLedger) EXPECT() *MockLedgerMockRecorder {
 	return m.recorder
 }
 
-// Upsert mocks base method.
-func (m *MockOperation) Upsert(ctx context.Context, operation entity.Operation) error {
+// Append mocks base method.
+func (m *MockLedger) Append(ctx context.Context, entry entity.LedgerEntry) (entity.LedgerEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", ctx, entry)
+	ret0, _ := ret[0].(entity.LedgerEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockLedgerMockRecorder) Append(ctx, entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockLedger)(nil).Append), ctx, entry)
+}
+
+// AppendBatch mocks base method.
+func (m *MockLedger) AppendBatch(ctx context.Context, entries []entity.LedgerEntry) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Upsert", ctx, operation)
+	ret := m.ctrl.Call(m, "AppendBatch", ctx, entries)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Upsert indicates an expected call of Upsert.
-func (mr *MockOperationMockRecorder) Upsert(ctx, operation any) *gomock.Call {
+// AppendBatch indicates an expected call of AppendBatch.
+func (mr *MockLedgerMockRecorder) AppendBatch(ctx, entries any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendBatch", reflect.TypeOf((*MockLedger)(nil).AppendBatch), ctx, entries)
+}
+
+// GetExpirable mocks base method.
+func (m *MockLedger) GetExpirable(ctx context.Context, before time.Time) ([]entity.Expiration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpirable", ctx, before)
+	ret0, _ := ret[0].([]entity.Expiration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpirable indicates an expected call of GetExpirable.
+func (mr *MockLedgerMockRecorder) GetExpirable(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpirable", reflect.TypeOf((*MockLedger)(nil).GetExpirable), ctx, before)
+}
+
+// GetReceivedSince mocks base method.
+func (m *MockLedger) GetReceivedSince(ctx context.Context, receiverId int, since time.Time) (entity.TransferStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReceivedSince", ctx, receiverId, since)
+	ret0, _ := ret[0].(entity.TransferStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReceivedSince indicates an expected call of GetReceivedSince.
+func (mr *MockLedgerMockRecorder) GetReceivedSince(ctx, receiverId, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReceivedSince", reflect.TypeOf((*MockLedger)(nil).GetReceivedSince), ctx, receiverId, since)
+}
+
+// GetSentSince mocks base method.
+func (m *MockLedger) GetSentSince(ctx context.Context, senderId int, since time.Time) (entity.TransferStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSentSince", ctx, senderId, since)
+	ret0, _ := ret[0].(entity.TransferStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSentSince indicates an expected call of GetSentSince.
+func (mr *MockLedgerMockRecorder) GetSentSince(ctx, senderId, since any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockOperation)(nil).Upsert), ctx, operation)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSentSince", reflect.TypeOf((*MockLedger)(nil).GetSentSince), ctx, senderId, since)
 }
 
 // MockItem is a mock of Item interface.
@@ -117,6 +178,21 @@ func (m *MockItem) EXPECT() *MockItemMockRecorder {
 	return m.recorder
 }
 
+// AddStock mocks base method.
+func (m *MockItem) AddStock(ctx context.Context, id, quantity int) (entity.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddStock", ctx, id, quantity)
+	ret0, _ := ret[0].(entity.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddStock indicates an expected call of AddStock.
+func (mr *MockItemMockRecorder) AddStock(ctx, id, quantity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddStock", reflect.TypeOf((*MockItem)(nil).AddStock), ctx, id, quantity)
+}
+
 // GetItemByName mocks base method.
 func (m *MockItem) GetItemByName(ctx context.Context, name string) (entity.Item, error) {
 	m.ctrl.T.Helper()
@@ -132,6 +208,50 @@ func (mr *MockItemMockRecorder) GetItemByName(ctx, name any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItemByName", reflect.TypeOf((*MockItem)(nil).GetItemByName), ctx, name)
 }
 
+// GetLowStock mocks base method.
+func (m *MockItem) GetLowStock(ctx context.Context, threshold int) ([]entity.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLowStock", ctx, threshold)
+	ret0, _ := ret[0].([]entity.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLowStock indicates an expected call of GetLowStock.
+func (mr *MockItemMockRecorder) GetLowStock(ctx, threshold any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLowStock", reflect.TypeOf((*MockItem)(nil).GetLowStock), ctx, threshold)
+}
+
+// SetStock mocks base method.
+func (m *MockItem) SetStock(ctx context.Context, id int, stock *int) (entity.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStock", ctx, id, stock)
+	ret0, _ := ret[0].(entity.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetStock indicates an expected call of SetStock.
+func (mr *MockItemMockRecorder) SetStock(ctx, id, stock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStock", reflect.TypeOf((*MockItem)(nil).SetStock), ctx, id, stock)
+}
+
+// TakeStock mocks base method.
+func (m *MockItem) TakeStock(ctx context.Context, id, quantity int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeStock", ctx, id, quantity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TakeStock indicates an expected call of TakeStock.
+func (mr *MockItemMockRecorder) TakeStock(ctx, id, quantity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeStock", reflect.TypeOf((*MockItem)(nil).TakeStock), ctx, id, quantity)
+}
+
 // MockSale is a mock of Sale interface.
 type MockSale struct {
 	ctrl     *gomock.Controller
@@ -156,6 +276,20 @@ func (m *MockSale) EXPECT() *MockSaleMockRecorder {
 	return m.recorder
 }
 
+// Decrement mocks base method.
+func (m *MockSale) Decrement(ctx context.Context, sale entity.Sale) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decrement", ctx, sale)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Decrement indicates an expected call of Decrement.
+func (mr *MockSaleMockRecorder) Decrement(ctx, sale any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decrement", reflect.TypeOf((*MockSale)(nil).Decrement), ctx, sale)
+}
+
 // Upsert mocks base method.
 func (m *MockSale) Upsert(ctx context.Context, sale entity.Sale) error {
 	m.ctrl.T.Helper()
@@ -170,6 +304,159 @@ func (mr *MockSaleMockRecorder) Upsert(ctx, sale any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockSale)(nil).Upsert), ctx, sale)
 }
 
+// MockPurchase is a mock of Purchase interface.
+type MockPurchase struct {
+	ctrl     *gomock.Controller
+	recorder *MockPurchaseMockRecorder
+	isgomock struct{}
+}
+
+// MockPurchaseMockRecorder is the mock recorder for MockPurchase.
+type MockPurchaseMockRecorder struct {
+	mock *MockPurchase
+}
+
+// NewMockPurchase creates a new mock instance.
+func NewMockPurchase(ctrl *gomock.Controller) *MockPurchase {
+	mock := &MockPurchase{ctrl: ctrl}
+	mock.recorder = &MockPurchaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPurchase) EXPECT() *MockPurchaseMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockPurchase) Create(ctx context.Context, purchase entity.Purchase) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, purchase)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPurchaseMockRecorder) Create(ctx, purchase any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPurchase)(nil).Create), ctx, purchase)
+}
+
+// GetForUpdate mocks base method.
+func (m *MockPurchase) GetForUpdate(ctx context.Context, userId, ledgerEntryId int, itemName string) (entity.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForUpdate", ctx, userId, ledgerEntryId, itemName)
+	ret0, _ := ret[0].(entity.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForUpdate indicates an expected call of GetForUpdate.
+func (mr *MockPurchaseMockRecorder) GetForUpdate(ctx, userId, ledgerEntryId, itemName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForUpdate", reflect.TypeOf((*MockPurchase)(nil).GetForUpdate), ctx, userId, ledgerEntryId, itemName)
+}
+
+// MockReturn is a mock of Return interface.
+type MockReturn struct {
+	ctrl     *gomock.Controller
+	recorder *MockReturnMockRecorder
+	isgomock struct{}
+}
+
+// MockReturnMockRecorder is the mock recorder for MockReturn.
+type MockReturnMockRecorder struct {
+	mock *MockReturn
+}
+
+// NewMockReturn creates a new mock instance.
+func NewMockReturn(ctrl *gomock.Controller) *MockReturn {
+	mock := &MockReturn{ctrl: ctrl}
+	mock.recorder = &MockReturnMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReturn) EXPECT() *MockReturnMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockReturn) Create(ctx context.Context, ret entity.Return) (int, error) {
+	m.ctrl.T.Helper()
+	ret_2 := m.ctrl.Call(m, "Create", ctx, ret)
+	ret0, _ := ret_2[0].(int)
+	ret1, _ := ret_2[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockReturnMockRecorder) Create(ctx, ret any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockReturn)(nil).Create), ctx, ret)
+}
+
+// Decide mocks base method.
+func (m *MockReturn) Decide(ctx context.Context, id int, status string, decidedBy int) (entity.Return, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decide", ctx, id, status, decidedBy)
+	ret0, _ := ret[0].(entity.Return)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Decide indicates an expected call of Decide.
+func (mr *MockReturnMockRecorder) Decide(ctx, id, status, decidedBy any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decide", reflect.TypeOf((*MockReturn)(nil).Decide), ctx, id, status, decidedBy)
+}
+
+// GetByStatus mocks base method.
+func (m *MockReturn) GetByStatus(ctx context.Context, status string) ([]entity.Return, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByStatus", ctx, status)
+	ret0, _ := ret[0].([]entity.Return)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByStatus indicates an expected call of GetByStatus.
+func (mr *MockReturnMockRecorder) GetByStatus(ctx, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByStatus", reflect.TypeOf((*MockReturn)(nil).GetByStatus), ctx, status)
+}
+
+// GetByUserId mocks base method.
+func (m *MockReturn) GetByUserId(ctx context.Context, userId int) ([]entity.Return, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUserId", ctx, userId)
+	ret0, _ := ret[0].([]entity.Return)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUserId indicates an expected call of GetByUserId.
+func (mr *MockReturnMockRecorder) GetByUserId(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserId", reflect.TypeOf((*MockReturn)(nil).GetByUserId), ctx, userId)
+}
+
+// GetReturnedQuantity mocks base method.
+func (m *MockReturn) GetReturnedQuantity(ctx context.Context, purchaseId int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReturnedQuantity", ctx, purchaseId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReturnedQuantity indicates an expected call of GetReturnedQuantity.
+func (mr *MockReturnMockRecorder) GetReturnedQuantity(ctx, purchaseId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReturnedQuantity", reflect.TypeOf((*MockReturn)(nil).GetReturnedQuantity), ctx, purchaseId)
+}
+
 // MockUser is a mock of User interface.
 type MockUser struct {
 	ctrl     *gomock.Controller
@@ -223,6 +510,51 @@ func (mr *MockUserMockRecorder) Deposit(ctx, id, amount any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deposit", reflect.TypeOf((*MockUser)(nil).Deposit), ctx, id, amount)
 }
 
+// DepositAll mocks base method.
+func (m *MockUser) DepositAll(ctx context.Context, amount int, roles []string) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DepositAll", ctx, amount, roles)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DepositAll indicates an expected call of DepositAll.
+func (mr *MockUserMockRecorder) DepositAll(ctx, amount, roles any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DepositAll", reflect.TypeOf((*MockUser)(nil).DepositAll), ctx, amount, roles)
+}
+
+// GetSystemUserId mocks base method.
+func (m *MockUser) GetSystemUserId(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSystemUserId", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSystemUserId indicates an expected call of GetSystemUserId.
+func (mr *MockUserMockRecorder) GetSystemUserId(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSystemUserId", reflect.TypeOf((*MockUser)(nil).GetSystemUserId), ctx)
+}
+
+// GetUserById mocks base method.
+func (m *MockUser) GetUserById(ctx context.Context, id int) (entity.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserById", ctx, id)
+	ret0, _ := ret[0].(entity.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserById indicates an expected call of GetUserById.
+func (mr *MockUserMockRecorder) GetUserById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockUser)(nil).GetUserById), ctx, id)
+}
+
 // GetUserByName mocks base method.
 func (m *MockUser) GetUserByName(ctx context.Context, username string) (entity.User, error) {
 	m.ctrl.T.Helper()
@@ -253,6 +585,63 @@ func (mr *MockUserMockRecorder) GetUserIdByName(ctx, username any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIdByName", reflect.TypeOf((*MockUser)(nil).GetUserIdByName), ctx, username)
 }
 
+// GetUserIdsByNames mocks base method.
+func (m *MockUser) GetUserIdsByNames(ctx context.Context, usernames []string) (map[string]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserIdsByNames", ctx, usernames)
+	ret0, _ := ret[0].(map[string]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserIdsByNames indicates an expected call of GetUserIdsByNames.
+func (mr *MockUserMockRecorder) GetUserIdsByNames(ctx, usernames any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIdsByNames", reflect.TypeOf((*MockUser)(nil).GetUserIdsByNames), ctx, usernames)
+}
+
+// LockUsers mocks base method.
+func (m *MockUser) LockUsers(ctx context.Context, ids []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockUsers", ctx, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockUsers indicates an expected call of LockUsers.
+func (mr *MockUserMockRecorder) LockUsers(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockUsers", reflect.TypeOf((*MockUser)(nil).LockUsers), ctx, ids)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUser) UpdatePassword(ctx context.Context, id int, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, id, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserMockRecorder) UpdatePassword(ctx, id, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUser)(nil).UpdatePassword), ctx, id, password)
+}
+
+// UpdateRole mocks base method.
+func (m *MockUser) UpdateRole(ctx context.Context, id int, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRole", ctx, id, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRole indicates an expected call of UpdateRole.
+func (mr *MockUserMockRecorder) UpdateRole(ctx, id, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRole", reflect.TypeOf((*MockUser)(nil).UpdateRole), ctx, id, role)
+}
+
 // Withdraw mocks base method.
 func (m *MockUser) Withdraw(ctx context.Context, id, amount int) error {
 	m.ctrl.T.Helper()
@@ -267,6 +656,923 @@ func (mr *MockUserMockRecorder) Withdraw(ctx, id, amount any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Withdraw", reflect.TypeOf((*MockUser)(nil).Withdraw), ctx, id, amount)
 }
 
+// MockRefreshToken is a mock of RefreshToken interface.
+type MockRefreshToken struct {
+	ctrl     *gomock.Controller
+	recorder *MockRefreshTokenMockRecorder
+	isgomock struct{}
+}
+
+// MockRefreshTokenMockRecorder is the mock recorder for MockRefreshToken.
+type MockRefreshTokenMockRecorder struct {
+	mock *MockRefreshToken
+}
+
+// NewMockRefreshToken creates a new mock instance.
+func NewMockRefreshToken(ctrl *gomock.Controller) *MockRefreshToken {
+	mock := &MockRefreshToken{ctrl: ctrl}
+	mock.recorder = &MockRefreshTokenMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRefreshToken) EXPECT() *MockRefreshTokenMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockRefreshToken) Create(ctx context.Context, token entity.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockRefreshTokenMockRecorder) Create(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRefreshToken)(nil).Create), ctx, token)
+}
+
+// GetByHashForUpdate mocks base method.
+func (m *MockRefreshToken) GetByHashForUpdate(ctx context.Context, tokenHash string) (entity.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByHashForUpdate", ctx, tokenHash)
+	ret0, _ := ret[0].(entity.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByHashForUpdate indicates an expected call of GetByHashForUpdate.
+func (mr *MockRefreshTokenMockRecorder) GetByHashForUpdate(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByHashForUpdate", reflect.TypeOf((*MockRefreshToken)(nil).GetByHashForUpdate), ctx, tokenHash)
+}
+
+// Revoke mocks base method.
+func (m *MockRefreshToken) Revoke(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockRefreshTokenMockRecorder) Revoke(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockRefreshToken)(nil).Revoke), ctx, id)
+}
+
+// RevokeByUserId mocks base method.
+func (m *MockRefreshToken) RevokeByUserId(ctx context.Context, userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeByUserId", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeByUserId indicates an expected call of RevokeByUserId.
+func (mr *MockRefreshTokenMockRecorder) RevokeByUserId(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeByUserId", reflect.TypeOf((*MockRefreshToken)(nil).RevokeByUserId), ctx, userId)
+}
+
+// RevokeFamily mocks base method.
+func (m *MockRefreshToken) RevokeFamily(ctx context.Context, familyId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeFamily", ctx, familyId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeFamily indicates an expected call of RevokeFamily.
+func (mr *MockRefreshTokenMockRecorder) RevokeFamily(ctx, familyId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeFamily", reflect.TypeOf((*MockRefreshToken)(nil).RevokeFamily), ctx, familyId)
+}
+
+// MockPasswordReset is a mock of PasswordReset interface.
+type MockPasswordReset struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordResetMockRecorder
+	isgomock struct{}
+}
+
+// MockPasswordResetMockRecorder is the mock recorder for MockPasswordReset.
+type MockPasswordResetMockRecorder struct {
+	mock *MockPasswordReset
+}
+
+// NewMockPasswordReset creates a new mock instance.
+func NewMockPasswordReset(ctrl *gomock.Controller) *MockPasswordReset {
+	mock := &MockPasswordReset{ctrl: ctrl}
+	mock.recorder = &MockPasswordResetMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordReset) EXPECT() *MockPasswordResetMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockPasswordReset) Create(ctx context.Context, reset entity.PasswordReset) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, reset)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPasswordResetMockRecorder) Create(ctx, reset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPasswordReset)(nil).Create), ctx, reset)
+}
+
+// InvalidateByUserId mocks base method.
+func (m *MockPasswordReset) InvalidateByUserId(ctx context.Context, userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InvalidateByUserId", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InvalidateByUserId indicates an expected call of InvalidateByUserId.
+func (mr *MockPasswordResetMockRecorder) InvalidateByUserId(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateByUserId", reflect.TypeOf((*MockPasswordReset)(nil).InvalidateByUserId), ctx, userId)
+}
+
+// Use mocks base method.
+func (m *MockPasswordReset) Use(ctx context.Context, tokenHash string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Use", ctx, tokenHash)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Use indicates an expected call of Use.
+func (mr *MockPasswordResetMockRecorder) Use(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Use", reflect.TypeOf((*MockPasswordReset)(nil).Use), ctx, tokenHash)
+}
+
+// MockRevokedToken is a mock of RevokedToken interface.
+type MockRevokedToken struct {
+	ctrl     *gomock.Controller
+	recorder *MockRevokedTokenMockRecorder
+	isgomock struct{}
+}
+
+// MockRevokedTokenMockRecorder is the mock recorder for MockRevokedToken.
+type MockRevokedTokenMockRecorder struct {
+	mock *MockRevokedToken
+}
+
+// NewMockRevokedToken creates a new mock instance.
+func NewMockRevokedToken(ctrl *gomock.Controller) *MockRevokedToken {
+	mock := &MockRevokedToken{ctrl: ctrl}
+	mock.recorder = &MockRevokedTokenMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRevokedToken) EXPECT() *MockRevokedTokenMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockRevokedToken) Add(ctx context.Context, token entity.RevokedToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockRevokedTokenMockRecorder) Add(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockRevokedToken)(nil).Add), ctx, token)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockRevokedToken) DeleteExpired(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockRevokedTokenMockRecorder) DeleteExpired(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockRevokedToken)(nil).DeleteExpired), ctx)
+}
+
+// GetActive mocks base method.
+func (m *MockRevokedToken) GetActive(ctx context.Context) ([]entity.RevokedToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActive", ctx)
+	ret0, _ := ret[0].([]entity.RevokedToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActive indicates an expected call of GetActive.
+func (mr *MockRevokedTokenMockRecorder) GetActive(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActive", reflect.TypeOf((*MockRevokedToken)(nil).GetActive), ctx)
+}
+
+// MockRevokedUser is a mock of RevokedUser interface.
+type MockRevokedUser struct {
+	ctrl     *gomock.Controller
+	recorder *MockRevokedUserMockRecorder
+	isgomock struct{}
+}
+
+// MockRevokedUserMockRecorder is the mock recorder for MockRevokedUser.
+type MockRevokedUserMockRecorder struct {
+	mock *MockRevokedUser
+}
+
+// NewMockRevokedUser creates a new mock instance.
+func NewMockRevokedUser(ctrl *gomock.Controller) *MockRevokedUser {
+	mock := &MockRevokedUser{ctrl: ctrl}
+	mock.recorder = &MockRevokedUserMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRevokedUser) EXPECT() *MockRevokedUserMockRecorder {
+	return m.recorder
+}
+
+// DeleteExpired mocks base method.
+func (m *MockRevokedUser) DeleteExpired(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockRevokedUserMockRecorder) DeleteExpired(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockRevokedUser)(nil).DeleteExpired), ctx)
+}
+
+// GetActive mocks base method.
+func (m *MockRevokedUser) GetActive(ctx context.Context) ([]entity.RevokedUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActive", ctx)
+	ret0, _ := ret[0].([]entity.RevokedUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActive indicates an expected call of GetActive.
+func (mr *MockRevokedUserMockRecorder) GetActive(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActive", reflect.TypeOf((*MockRevokedUser)(nil).GetActive), ctx)
+}
+
+// Upsert mocks base method.
+func (m *MockRevokedUser) Upsert(ctx context.Context, user entity.RevokedUser) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upsert", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Upsert indicates an expected call of Upsert.
+func (mr *MockRevokedUserMockRecorder) Upsert(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockRevokedUser)(nil).Upsert), ctx, user)
+}
+
+// MockLoginAttempt is a mock of LoginAttempt interface.
+type MockLoginAttempt struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginAttemptMockRecorder
+	isgomock struct{}
+}
+
+// MockLoginAttemptMockRecorder is the mock recorder for MockLoginAttempt.
+type MockLoginAttemptMockRecorder struct {
+	mock *MockLoginAttempt
+}
+
+// NewMockLoginAttempt creates a new mock instance.
+func NewMockLoginAttempt(ctrl *gomock.Controller) *MockLoginAttempt {
+	mock := &MockLoginAttempt{ctrl: ctrl}
+	mock.recorder = &MockLoginAttemptMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginAttempt) EXPECT() *MockLoginAttemptMockRecorder {
+	return m.recorder
+}
+
+// GetLockedUntil mocks base method.
+func (m *MockLoginAttempt) GetLockedUntil(ctx context.Context, subjects []string) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLockedUntil", ctx, subjects)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLockedUntil indicates an expected call of GetLockedUntil.
+func (mr *MockLoginAttemptMockRecorder) GetLockedUntil(ctx, subjects any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLockedUntil", reflect.TypeOf((*MockLoginAttempt)(nil).GetLockedUntil), ctx, subjects)
+}
+
+// Lock mocks base method.
+func (m *MockLoginAttempt) Lock(ctx context.Context, subject string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, subject, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockLoginAttemptMockRecorder) Lock(ctx, subject, until any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockLoginAttempt)(nil).Lock), ctx, subject, until)
+}
+
+// RegisterFailure mocks base method.
+func (m *MockLoginAttempt) RegisterFailure(ctx context.Context, subject string, resetBefore time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterFailure", ctx, subject, resetBefore)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterFailure indicates an expected call of RegisterFailure.
+func (mr *MockLoginAttemptMockRecorder) RegisterFailure(ctx, subject, resetBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterFailure", reflect.TypeOf((*MockLoginAttempt)(nil).RegisterFailure), ctx, subject, resetBefore)
+}
+
+// Reset mocks base method.
+func (m *MockLoginAttempt) Reset(ctx context.Context, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockLoginAttemptMockRecorder) Reset(ctx, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockLoginAttempt)(nil).Reset), ctx, subject)
+}
+
+// MockAPIKey is a mock of APIKey interface.
+type MockAPIKey struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyMockRecorder
+	isgomock struct{}
+}
+
+// MockAPIKeyMockRecorder is the mock recorder for MockAPIKey.
+type MockAPIKeyMockRecorder struct {
+	mock *MockAPIKey
+}
+
+// NewMockAPIKey creates a new mock instance.
+func NewMockAPIKey(ctrl *gomock.Controller) *MockAPIKey {
+	mock := &MockAPIKey{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKey) EXPECT() *MockAPIKeyMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockAPIKey) Create(ctx context.Context, key entity.APIKey) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, key)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAPIKeyMockRecorder) Create(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAPIKey)(nil).Create), ctx, key)
+}
+
+// GetActiveByHash mocks base method.
+func (m *MockAPIKey) GetActiveByHash(ctx context.Context, keyHash string) (entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveByHash", ctx, keyHash)
+	ret0, _ := ret[0].(entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveByHash indicates an expected call of GetActiveByHash.
+func (mr *MockAPIKeyMockRecorder) GetActiveByHash(ctx, keyHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveByHash", reflect.TypeOf((*MockAPIKey)(nil).GetActiveByHash), ctx, keyHash)
+}
+
+// GetByUserId mocks base method.
+func (m *MockAPIKey) GetByUserId(ctx context.Context, userId int) ([]entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUserId", ctx, userId)
+	ret0, _ := ret[0].([]entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUserId indicates an expected call of GetByUserId.
+func (mr *MockAPIKeyMockRecorder) GetByUserId(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserId", reflect.TypeOf((*MockAPIKey)(nil).GetByUserId), ctx, userId)
+}
+
+// Revoke mocks base method.
+func (m *MockAPIKey) Revoke(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockAPIKeyMockRecorder) Revoke(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockAPIKey)(nil).Revoke), ctx, id)
+}
+
+// Touch mocks base method.
+func (m *MockAPIKey) Touch(ctx context.Context, id int, notBefore time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Touch", ctx, id, notBefore)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Touch indicates an expected call of Touch.
+func (mr *MockAPIKeyMockRecorder) Touch(ctx, id, notBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Touch", reflect.TypeOf((*MockAPIKey)(nil).Touch), ctx, id, notBefore)
+}
+
+// MockInvite is a mock of Invite interface.
+type MockInvite struct {
+	ctrl     *gomock.Controller
+	recorder *MockInviteMockRecorder
+	isgomock struct{}
+}
+
+// MockInviteMockRecorder is the mock recorder for MockInvite.
+type MockInviteMockRecorder struct {
+	mock *MockInvite
+}
+
+// NewMockInvite creates a new mock instance.
+func NewMockInvite(ctrl *gomock.Controller) *MockInvite {
+	mock := &MockInvite{ctrl: ctrl}
+	mock.recorder = &MockInviteMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInvite) EXPECT() *MockInviteMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockInvite) Create(ctx context.Context, invite entity.Invite) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, invite)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockInviteMockRecorder) Create(ctx, invite any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockInvite)(nil).Create), ctx, invite)
+}
+
+// Use mocks base method.
+func (m *MockInvite) Use(ctx context.Context, codeHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Use", ctx, codeHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Use indicates an expected call of Use.
+func (mr *MockInviteMockRecorder) Use(ctx, codeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Use", reflect.TypeOf((*MockInvite)(nil).Use), ctx, codeHash)
+}
+
+// MockCart is a mock of Cart interface.
+type MockCart struct {
+	ctrl     *gomock.Controller
+	recorder *MockCartMockRecorder
+	isgomock struct{}
+}
+
+// MockCartMockRecorder is the mock recorder for MockCart.
+type MockCartMockRecorder struct {
+	mock *MockCart
+}
+
+// NewMockCart creates a new mock instance.
+func NewMockCart(ctrl *gomock.Controller) *MockCart {
+	mock := &MockCart{ctrl: ctrl}
+	mock.recorder = &MockCartMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCart) EXPECT() *MockCartMockRecorder {
+	return m.recorder
+}
+
+// AddItem mocks base method.
+func (m *MockCart) AddItem(ctx context.Context, userId, itemId, quantity int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddItem", ctx, userId, itemId, quantity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddItem indicates an expected call of AddItem.
+func (mr *MockCartMockRecorder) AddItem(ctx, userId, itemId, quantity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddItem", reflect.TypeOf((*MockCart)(nil).AddItem), ctx, userId, itemId, quantity)
+}
+
+// GetItems mocks base method.
+func (m *MockCart) GetItems(ctx context.Context, userId int) ([]entity.CartItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetItems", ctx, userId)
+	ret0, _ := ret[0].([]entity.CartItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetItems indicates an expected call of GetItems.
+func (mr *MockCartMockRecorder) GetItems(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItems", reflect.TypeOf((*MockCart)(nil).GetItems), ctx, userId)
+}
+
+// RemoveItem mocks base method.
+func (m *MockCart) RemoveItem(ctx context.Context, userId, itemId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveItem", ctx, userId, itemId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveItem indicates an expected call of RemoveItem.
+func (mr *MockCartMockRecorder) RemoveItem(ctx, userId, itemId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveItem", reflect.TypeOf((*MockCart)(nil).RemoveItem), ctx, userId, itemId)
+}
+
+// TakeItems mocks base method.
+func (m *MockCart) TakeItems(ctx context.Context, userId int) ([]entity.CartItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeItems", ctx, userId)
+	ret0, _ := ret[0].([]entity.CartItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeItems indicates an expected call of TakeItems.
+func (mr *MockCartMockRecorder) TakeItems(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeItems", reflect.TypeOf((*MockCart)(nil).TakeItems), ctx, userId)
+}
+
+// MockIdempotencyKey is a mock of IdempotencyKey interface.
+type MockIdempotencyKey struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyKeyMockRecorder
+	isgomock struct{}
+}
+
+// MockIdempotencyKeyMockRecorder is the mock recorder for MockIdempotencyKey.
+type MockIdempotencyKeyMockRecorder struct {
+	mock *MockIdempotencyKey
+}
+
+// NewMockIdempotencyKey creates a new mock instance.
+func NewMockIdempotencyKey(ctrl *gomock.Controller) *MockIdempotencyKey {
+	mock := &MockIdempotencyKey{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyKeyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyKey) EXPECT() *MockIdempotencyKeyMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockIdempotencyKey) Create(ctx context.Context, key entity.IdempotencyKey) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, key)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIdempotencyKeyMockRecorder) Create(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIdempotencyKey)(nil).Create), ctx, key)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockIdempotencyKey) DeleteExpired(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockIdempotencyKeyMockRecorder) DeleteExpired(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockIdempotencyKey)(nil).DeleteExpired), ctx)
+}
+
+// Get mocks base method.
+func (m *MockIdempotencyKey) Get(ctx context.Context, userId int, key string) (entity.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, userId, key)
+	ret0, _ := ret[0].(entity.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockIdempotencyKeyMockRecorder) Get(ctx, userId, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockIdempotencyKey)(nil).Get), ctx, userId, key)
+}
+
+// SaveResponse mocks base method.
+func (m *MockIdempotencyKey) SaveResponse(ctx context.Context, id, statusCode int, response []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveResponse", ctx, id, statusCode, response)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveResponse indicates an expected call of SaveResponse.
+func (mr *MockIdempotencyKeyMockRecorder) SaveResponse(ctx, id, statusCode, response any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveResponse", reflect.TypeOf((*MockIdempotencyKey)(nil).SaveResponse), ctx, id, statusCode, response)
+}
+
+// MockPendingTransfer is a mock of PendingTransfer interface.
+type MockPendingTransfer struct {
+	ctrl     *gomock.Controller
+	recorder *MockPendingTransferMockRecorder
+	isgomock struct{}
+}
+
+// MockPendingTransferMockRecorder is the mock recorder for MockPendingTransfer.
+type MockPendingTransferMockRecorder struct {
+	mock *MockPendingTransfer
+}
+
+// NewMockPendingTransfer creates a new mock instance.
+func NewMockPendingTransfer(ctrl *gomock.Controller) *MockPendingTransfer {
+	mock := &MockPendingTransfer{ctrl: ctrl}
+	mock.recorder = &MockPendingTransferMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPendingTransfer) EXPECT() *MockPendingTransferMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockPendingTransfer) Create(ctx context.Context, transfer entity.PendingTransfer) (entity.PendingTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, transfer)
+	ret0, _ := ret[0].(entity.PendingTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPendingTransferMockRecorder) Create(ctx, transfer any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPendingTransfer)(nil).Create), ctx, transfer)
+}
+
+// ExpireDue mocks base method.
+func (m *MockPendingTransfer) ExpireDue(ctx context.Context, now time.Time) ([]entity.PendingTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireDue", ctx, now)
+	ret0, _ := ret[0].([]entity.PendingTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireDue indicates an expected call of ExpireDue.
+func (mr *MockPendingTransferMockRecorder) ExpireDue(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireDue", reflect.TypeOf((*MockPendingTransfer)(nil).ExpireDue), ctx, now)
+}
+
+// Resolve mocks base method.
+func (m *MockPendingTransfer) Resolve(ctx context.Context, id, receiverId int, status string) (entity.PendingTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resolve", ctx, id, receiverId, status)
+	ret0, _ := ret[0].(entity.PendingTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resolve indicates an expected call of Resolve.
+func (mr *MockPendingTransferMockRecorder) Resolve(ctx, id, receiverId, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resolve", reflect.TypeOf((*MockPendingTransfer)(nil).Resolve), ctx, id, receiverId, status)
+}
+
+// MockPaymentRequest is a mock of PaymentRequest interface.
+type MockPaymentRequest struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentRequestMockRecorder
+	isgomock struct{}
+}
+
+// MockPaymentRequestMockRecorder is the mock recorder for MockPaymentRequest.
+type MockPaymentRequestMockRecorder struct {
+	mock *MockPaymentRequest
+}
+
+// NewMockPaymentRequest creates a new mock instance.
+func NewMockPaymentRequest(ctrl *gomock.Controller) *MockPaymentRequest {
+	mock := &MockPaymentRequest{ctrl: ctrl}
+	mock.recorder = &MockPaymentRequestMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentRequest) EXPECT() *MockPaymentRequestMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockPaymentRequest) Create(ctx context.Context, request entity.PaymentRequest) (entity.PaymentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, request)
+	ret0, _ := ret[0].(entity.PaymentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPaymentRequestMockRecorder) Create(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPaymentRequest)(nil).Create), ctx, request)
+}
+
+// GetByPayerId mocks base method.
+func (m *MockPaymentRequest) GetByPayerId(ctx context.Context, payerId int) ([]entity.PaymentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByPayerId", ctx, payerId)
+	ret0, _ := ret[0].([]entity.PaymentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByPayerId indicates an expected call of GetByPayerId.
+func (mr *MockPaymentRequestMockRecorder) GetByPayerId(ctx, payerId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByPayerId", reflect.TypeOf((*MockPaymentRequest)(nil).GetByPayerId), ctx, payerId)
+}
+
+// GetByRequesterId mocks base method.
+func (m *MockPaymentRequest) GetByRequesterId(ctx context.Context, requesterId int) ([]entity.PaymentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByRequesterId", ctx, requesterId)
+	ret0, _ := ret[0].([]entity.PaymentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByRequesterId indicates an expected call of GetByRequesterId.
+func (mr *MockPaymentRequestMockRecorder) GetByRequesterId(ctx, requesterId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByRequesterId", reflect.TypeOf((*MockPaymentRequest)(nil).GetByRequesterId), ctx, requesterId)
+}
+
+// MarkPaid mocks base method.
+func (m *MockPaymentRequest) MarkPaid(ctx context.Context, id, payerId int) (entity.PaymentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkPaid", ctx, id, payerId)
+	ret0, _ := ret[0].(entity.PaymentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkPaid indicates an expected call of MarkPaid.
+func (mr *MockPaymentRequestMockRecorder) MarkPaid(ctx, id, payerId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPaid", reflect.TypeOf((*MockPaymentRequest)(nil).MarkPaid), ctx, id, payerId)
+}
+
+// MockJob is a mock of Job interface.
+type MockJob struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobMockRecorder
+	isgomock struct{}
+}
+
+// MockJobMockRecorder is the mock recorder for MockJob.
+type MockJobMockRecorder struct {
+	mock *MockJob
+}
+
+// NewMockJob creates a new mock instance.
+func NewMockJob(ctrl *gomock.Controller) *MockJob {
+	mock := &MockJob{ctrl: ctrl}
+	mock.recorder = &MockJobMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJob) EXPECT() *MockJobMockRecorder {
+	return m.recorder
+}
+
+// CreateRun mocks base method.
+func (m *MockJob) CreateRun(ctx context.Context, run entity.JobRun) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRun", ctx, run)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRun indicates an expected call of CreateRun.
+func (mr *MockJobMockRecorder) CreateRun(ctx, run any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRun", reflect.TypeOf((*MockJob)(nil).CreateRun), ctx, run)
+}
+
+// FinishRun mocks base method.
+func (m *MockJob) FinishRun(ctx context.Context, id int, status, details string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishRun", ctx, id, status, details)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FinishRun indicates an expected call of FinishRun.
+func (mr *MockJobMockRecorder) FinishRun(ctx, id, status, details any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishRun", reflect.TypeOf((*MockJob)(nil).FinishRun), ctx, id, status, details)
+}
+
+// TryLock mocks base method.
+func (m *MockJob) TryLock(ctx context.Context, job string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TryLock", ctx, job)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TryLock indicates an expected call of TryLock.
+func (mr *MockJobMockRecorder) TryLock(ctx, job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TryLock", reflect.TypeOf((*MockJob)(nil).TryLock), ctx, job)
+}
+
 // MockUserReport is a mock of UserReport interface.
 type MockUserReport struct {
 	ctrl     *gomock.Controller
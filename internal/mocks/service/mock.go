@@ -12,9 +12,11 @@ package servicemocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	entity "github.com/spanwalla/merch-store/internal/entity"
 	service "github.com/spanwalla/merch-store/internal/service"
+	jwks "github.com/spanwalla/merch-store/pkg/jwks"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -42,11 +44,55 @@ func (m *MockAuth) EXPECT() *MockAuthMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockAuth) ChangePassword(ctx context.Context, input service.AuthChangePasswordInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockAuthMockRecorder) ChangePassword(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockAuth)(nil).ChangePassword), ctx, input)
+}
+
+// CreateInvite mocks base method.
+func (m *MockAuth) CreateInvite(ctx context.Context, input service.AuthCreateInviteInput) (service.AuthInvite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInvite", ctx, input)
+	ret0, _ := ret[0].(service.AuthInvite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInvite indicates an expected call of CreateInvite.
+func (mr *MockAuthMockRecorder) CreateInvite(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvite", reflect.TypeOf((*MockAuth)(nil).CreateInvite), ctx, input)
+}
+
+// CreatePasswordReset mocks base method.
+func (m *MockAuth) CreatePasswordReset(ctx context.Context, input service.AuthCreatePasswordResetInput) (service.AuthPasswordReset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordReset", ctx, input)
+	ret0, _ := ret[0].(service.AuthPasswordReset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePasswordReset indicates an expected call of CreatePasswordReset.
+func (mr *MockAuthMockRecorder) CreatePasswordReset(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordReset", reflect.TypeOf((*MockAuth)(nil).CreatePasswordReset), ctx, input)
+}
+
 // GenerateToken mocks base method.
-func (m *MockAuth) GenerateToken(ctx context.Context, input service.AuthGenerateTokenInput) (string, error) {
+func (m *MockAuth) GenerateToken(ctx context.Context, input service.AuthGenerateTokenInput) (service.AuthTokens, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GenerateToken", ctx, input)
-	ret0, _ := ret[0].(string)
+	ret0, _ := ret[0].(service.AuthTokens)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -57,11 +103,126 @@ func (mr *MockAuthMockRecorder) GenerateToken(ctx, input any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockAuth)(nil).GenerateToken), ctx, input)
 }
 
+// JWKS mocks base method.
+func (m *MockAuth) JWKS() jwks.JWKS {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(jwks.JWKS)
+	return ret0
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockAuthMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockAuth)(nil).JWKS))
+}
+
+// Logout mocks base method.
+func (m *MockAuth) Logout(ctx context.Context, input service.AuthLogoutInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockAuthMockRecorder) Logout(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockAuth)(nil).Logout), ctx, input)
+}
+
+// ProxyIdentity mocks base method.
+func (m *MockAuth) ProxyIdentity(ctx context.Context, userName string) (service.AuthIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProxyIdentity", ctx, userName)
+	ret0, _ := ret[0].(service.AuthIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProxyIdentity indicates an expected call of ProxyIdentity.
+func (mr *MockAuthMockRecorder) ProxyIdentity(ctx, userName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProxyIdentity", reflect.TypeOf((*MockAuth)(nil).ProxyIdentity), ctx, userName)
+}
+
+// RefreshToken mocks base method.
+func (m *MockAuth) RefreshToken(ctx context.Context, refreshToken string) (service.AuthTokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshToken", ctx, refreshToken)
+	ret0, _ := ret[0].(service.AuthTokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshToken indicates an expected call of RefreshToken.
+func (mr *MockAuthMockRecorder) RefreshToken(ctx, refreshToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockAuth)(nil).RefreshToken), ctx, refreshToken)
+}
+
+// Register mocks base method.
+func (m *MockAuth) Register(ctx context.Context, input service.AuthRegisterInput) (service.AuthTokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Register", ctx, input)
+	ret0, _ := ret[0].(service.AuthTokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockAuthMockRecorder) Register(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockAuth)(nil).Register), ctx, input)
+}
+
+// ResetPassword mocks base method.
+func (m *MockAuth) ResetPassword(ctx context.Context, input service.AuthResetPasswordInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockAuthMockRecorder) ResetPassword(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockAuth)(nil).ResetPassword), ctx, input)
+}
+
+// SetRole mocks base method.
+func (m *MockAuth) SetRole(ctx context.Context, input service.AuthSetRoleInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRole", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRole indicates an expected call of SetRole.
+func (mr *MockAuthMockRecorder) SetRole(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRole", reflect.TypeOf((*MockAuth)(nil).SetRole), ctx, input)
+}
+
+// Unlock mocks base method.
+func (m *MockAuth) Unlock(ctx context.Context, userName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlock", ctx, userName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlock indicates an expected call of Unlock.
+func (mr *MockAuthMockRecorder) Unlock(ctx, userName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockAuth)(nil).Unlock), ctx, userName)
+}
+
 // VerifyToken mocks base method.
-func (m *MockAuth) VerifyToken(tokenString string) (int, error) {
+func (m *MockAuth) VerifyToken(tokenString string) (service.AuthIdentity, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "VerifyToken", tokenString)
-	ret0, _ := ret[0].(int)
+	ret0, _ := ret[0].(service.AuthIdentity)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -72,6 +233,104 @@ func (mr *MockAuthMockRecorder) VerifyToken(tokenString any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyToken", reflect.TypeOf((*MockAuth)(nil).VerifyToken), tokenString)
 }
 
+// MockAPIKey is a mock of APIKey interface.
+type MockAPIKey struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyMockRecorder
+	isgomock struct{}
+}
+
+// MockAPIKeyMockRecorder is the mock recorder for MockAPIKey.
+type MockAPIKeyMockRecorder struct {
+	mock *MockAPIKey
+}
+
+// NewMockAPIKey creates a new mock instance.
+func NewMockAPIKey(ctrl *gomock.Controller) *MockAPIKey {
+	mock := &MockAPIKey{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKey) EXPECT() *MockAPIKeyMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockAPIKey) Create(ctx context.Context, input service.APIKeyCreateInput) (service.APIKeyCreateOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, input)
+	ret0, _ := ret[0].(service.APIKeyCreateOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAPIKeyMockRecorder) Create(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAPIKey)(nil).Create), ctx, input)
+}
+
+// CreateServiceAccount mocks base method.
+func (m *MockAPIKey) CreateServiceAccount(ctx context.Context, name string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateServiceAccount", ctx, name)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateServiceAccount indicates an expected call of CreateServiceAccount.
+func (mr *MockAPIKeyMockRecorder) CreateServiceAccount(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateServiceAccount", reflect.TypeOf((*MockAPIKey)(nil).CreateServiceAccount), ctx, name)
+}
+
+// List mocks base method.
+func (m *MockAPIKey) List(ctx context.Context, userName string) ([]entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, userName)
+	ret0, _ := ret[0].([]entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAPIKeyMockRecorder) List(ctx, userName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAPIKey)(nil).List), ctx, userName)
+}
+
+// Revoke mocks base method.
+func (m *MockAPIKey) Revoke(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockAPIKeyMockRecorder) Revoke(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockAPIKey)(nil).Revoke), ctx, id)
+}
+
+// Verify mocks base method.
+func (m *MockAPIKey) Verify(ctx context.Context, key string) (service.AuthIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", ctx, key)
+	ret0, _ := ret[0].(service.AuthIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockAPIKeyMockRecorder) Verify(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockAPIKey)(nil).Verify), ctx, key)
+}
+
 // MockPayment is a mock of Payment interface.
 type MockPayment struct {
 	ctrl     *gomock.Controller
@@ -96,20 +355,138 @@ func (m *MockPayment) EXPECT() *MockPaymentMockRecorder {
 	return m.recorder
 }
 
-// BuyItem mocks base method.
-func (m *MockPayment) BuyItem(ctx context.Context, input service.PaymentBuyItemInput) error {
+// AcceptTransfer mocks base method.
+func (m *MockPayment) AcceptTransfer(ctx context.Context, input service.PaymentResolveTransferInput) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BuyItem", ctx, input)
+	ret := m.ctrl.Call(m, "AcceptTransfer", ctx, input)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// AcceptTransfer indicates an expected call of AcceptTransfer.
+func (mr *MockPaymentMockRecorder) AcceptTransfer(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptTransfer", reflect.TypeOf((*MockPayment)(nil).AcceptTransfer), ctx, input)
+}
+
+// BatchTransfer mocks base method.
+func (m *MockPayment) BatchTransfer(ctx context.Context, input service.PaymentBatchTransferInput) (service.PaymentBatchTransferOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchTransfer", ctx, input)
+	ret0, _ := ret[0].(service.PaymentBatchTransferOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchTransfer indicates an expected call of BatchTransfer.
+func (mr *MockPaymentMockRecorder) BatchTransfer(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchTransfer", reflect.TypeOf((*MockPayment)(nil).BatchTransfer), ctx, input)
+}
+
+// BuyItem mocks base method.
+func (m *MockPayment) BuyItem(ctx context.Context, input service.PaymentBuyItemInput) (service.PaymentReceipt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuyItem", ctx, input)
+	ret0, _ := ret[0].(service.PaymentReceipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // BuyItem indicates an expected call of BuyItem.
 func (mr *MockPaymentMockRecorder) BuyItem(ctx, input any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyItem", reflect.TypeOf((*MockPayment)(nil).BuyItem), ctx, input)
 }
 
+// DeclineTransfer mocks base method.
+func (m *MockPayment) DeclineTransfer(ctx context.Context, input service.PaymentResolveTransferInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeclineTransfer", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeclineTransfer indicates an expected call of DeclineTransfer.
+func (mr *MockPaymentMockRecorder) DeclineTransfer(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeclineTransfer", reflect.TypeOf((*MockPayment)(nil).DeclineTransfer), ctx, input)
+}
+
+// IncomingPaymentRequests mocks base method.
+func (m *MockPayment) IncomingPaymentRequests(ctx context.Context, userId int) ([]entity.PaymentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncomingPaymentRequests", ctx, userId)
+	ret0, _ := ret[0].([]entity.PaymentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncomingPaymentRequests indicates an expected call of IncomingPaymentRequests.
+func (mr *MockPaymentMockRecorder) IncomingPaymentRequests(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncomingPaymentRequests", reflect.TypeOf((*MockPayment)(nil).IncomingPaymentRequests), ctx, userId)
+}
+
+// Mint mocks base method.
+func (m *MockPayment) Mint(ctx context.Context, input service.PaymentMintInput) (service.PaymentMintOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Mint", ctx, input)
+	ret0, _ := ret[0].(service.PaymentMintOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Mint indicates an expected call of Mint.
+func (mr *MockPaymentMockRecorder) Mint(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Mint", reflect.TypeOf((*MockPayment)(nil).Mint), ctx, input)
+}
+
+// OutgoingPaymentRequests mocks base method.
+func (m *MockPayment) OutgoingPaymentRequests(ctx context.Context, userId int) ([]entity.PaymentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OutgoingPaymentRequests", ctx, userId)
+	ret0, _ := ret[0].([]entity.PaymentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OutgoingPaymentRequests indicates an expected call of OutgoingPaymentRequests.
+func (mr *MockPaymentMockRecorder) OutgoingPaymentRequests(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OutgoingPaymentRequests", reflect.TypeOf((*MockPayment)(nil).OutgoingPaymentRequests), ctx, userId)
+}
+
+// PayRequest mocks base method.
+func (m *MockPayment) PayRequest(ctx context.Context, input service.PaymentPayRequestInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PayRequest", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PayRequest indicates an expected call of PayRequest.
+func (mr *MockPaymentMockRecorder) PayRequest(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PayRequest", reflect.TypeOf((*MockPayment)(nil).PayRequest), ctx, input)
+}
+
+// RequestPayment mocks base method.
+func (m *MockPayment) RequestPayment(ctx context.Context, input service.PaymentRequestInput) ([]entity.PaymentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestPayment", ctx, input)
+	ret0, _ := ret[0].([]entity.PaymentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestPayment indicates an expected call of RequestPayment.
+func (mr *MockPaymentMockRecorder) RequestPayment(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPayment", reflect.TypeOf((*MockPayment)(nil).RequestPayment), ctx, input)
+}
+
 // Transfer mocks base method.
 func (m *MockPayment) Transfer(ctx context.Context, input service.PaymentTransferInput) error {
 	m.ctrl.T.Helper()
@@ -124,6 +501,323 @@ func (mr *MockPaymentMockRecorder) Transfer(ctx, input any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockPayment)(nil).Transfer), ctx, input)
 }
 
+// TransferPending mocks base method.
+func (m *MockPayment) TransferPending(ctx context.Context, input service.PaymentTransferInput) (entity.PendingTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferPending", ctx, input)
+	ret0, _ := ret[0].(entity.PendingTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransferPending indicates an expected call of TransferPending.
+func (mr *MockPaymentMockRecorder) TransferPending(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferPending", reflect.TypeOf((*MockPayment)(nil).TransferPending), ctx, input)
+}
+
+// MockCart is a mock of Cart interface.
+type MockCart struct {
+	ctrl     *gomock.Controller
+	recorder *MockCartMockRecorder
+	isgomock struct{}
+}
+
+// MockCartMockRecorder is the mock recorder for MockCart.
+type MockCartMockRecorder struct {
+	mock *MockCart
+}
+
+// NewMockCart creates a new mock instance.
+func NewMockCart(ctrl *gomock.Controller) *MockCart {
+	mock := &MockCart{ctrl: ctrl}
+	mock.recorder = &MockCartMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCart) EXPECT() *MockCartMockRecorder {
+	return m.recorder
+}
+
+// AddItem mocks base method.
+func (m *MockCart) AddItem(ctx context.Context, input service.CartAddItemInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddItem", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddItem indicates an expected call of AddItem.
+func (mr *MockCartMockRecorder) AddItem(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddItem", reflect.TypeOf((*MockCart)(nil).AddItem), ctx, input)
+}
+
+// Checkout mocks base method.
+func (m *MockCart) Checkout(ctx context.Context, userId int) (service.CartReceipt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Checkout", ctx, userId)
+	ret0, _ := ret[0].(service.CartReceipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Checkout indicates an expected call of Checkout.
+func (mr *MockCartMockRecorder) Checkout(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Checkout", reflect.TypeOf((*MockCart)(nil).Checkout), ctx, userId)
+}
+
+// Get mocks base method.
+func (m *MockCart) Get(ctx context.Context, userId int) (service.CartContents, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, userId)
+	ret0, _ := ret[0].(service.CartContents)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockCartMockRecorder) Get(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCart)(nil).Get), ctx, userId)
+}
+
+// RemoveItem mocks base method.
+func (m *MockCart) RemoveItem(ctx context.Context, input service.CartRemoveItemInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveItem", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveItem indicates an expected call of RemoveItem.
+func (mr *MockCartMockRecorder) RemoveItem(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveItem", reflect.TypeOf((*MockCart)(nil).RemoveItem), ctx, input)
+}
+
+// MockReturn is a mock of Return interface.
+type MockReturn struct {
+	ctrl     *gomock.Controller
+	recorder *MockReturnMockRecorder
+	isgomock struct{}
+}
+
+// MockReturnMockRecorder is the mock recorder for MockReturn.
+type MockReturnMockRecorder struct {
+	mock *MockReturn
+}
+
+// NewMockReturn creates a new mock instance.
+func NewMockReturn(ctrl *gomock.Controller) *MockReturn {
+	mock := &MockReturn{ctrl: ctrl}
+	mock.recorder = &MockReturnMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReturn) EXPECT() *MockReturnMockRecorder {
+	return m.recorder
+}
+
+// Approve mocks base method.
+func (m *MockReturn) Approve(ctx context.Context, input service.ReturnDecideInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Approve", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Approve indicates an expected call of Approve.
+func (mr *MockReturnMockRecorder) Approve(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Approve", reflect.TypeOf((*MockReturn)(nil).Approve), ctx, input)
+}
+
+// List mocks base method.
+func (m *MockReturn) List(ctx context.Context, userId int) ([]entity.Return, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, userId)
+	ret0, _ := ret[0].([]entity.Return)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockReturnMockRecorder) List(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockReturn)(nil).List), ctx, userId)
+}
+
+// ListByStatus mocks base method.
+func (m *MockReturn) ListByStatus(ctx context.Context, status string) ([]entity.Return, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByStatus", ctx, status)
+	ret0, _ := ret[0].([]entity.Return)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByStatus indicates an expected call of ListByStatus.
+func (mr *MockReturnMockRecorder) ListByStatus(ctx, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByStatus", reflect.TypeOf((*MockReturn)(nil).ListByStatus), ctx, status)
+}
+
+// Reject mocks base method.
+func (m *MockReturn) Reject(ctx context.Context, input service.ReturnDecideInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reject", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reject indicates an expected call of Reject.
+func (mr *MockReturnMockRecorder) Reject(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reject", reflect.TypeOf((*MockReturn)(nil).Reject), ctx, input)
+}
+
+// Request mocks base method.
+func (m *MockReturn) Request(ctx context.Context, input service.ReturnRequestInput) (entity.Return, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Request", ctx, input)
+	ret0, _ := ret[0].(entity.Return)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Request indicates an expected call of Request.
+func (mr *MockReturnMockRecorder) Request(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Request", reflect.TypeOf((*MockReturn)(nil).Request), ctx, input)
+}
+
+// MockItem is a mock of Item interface.
+type MockItem struct {
+	ctrl     *gomock.Controller
+	recorder *MockItemMockRecorder
+	isgomock struct{}
+}
+
+// MockItemMockRecorder is the mock recorder for MockItem.
+type MockItemMockRecorder struct {
+	mock *MockItem
+}
+
+// NewMockItem creates a new mock instance.
+func NewMockItem(ctrl *gomock.Controller) *MockItem {
+	mock := &MockItem{ctrl: ctrl}
+	mock.recorder = &MockItemMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockItem) EXPECT() *MockItemMockRecorder {
+	return m.recorder
+}
+
+// LowStock mocks base method.
+func (m *MockItem) LowStock(ctx context.Context, threshold int) ([]entity.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LowStock", ctx, threshold)
+	ret0, _ := ret[0].([]entity.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LowStock indicates an expected call of LowStock.
+func (mr *MockItemMockRecorder) LowStock(ctx, threshold any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LowStock", reflect.TypeOf((*MockItem)(nil).LowStock), ctx, threshold)
+}
+
+// Restock mocks base method.
+func (m *MockItem) Restock(ctx context.Context, input service.ItemRestockInput) (entity.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restock", ctx, input)
+	ret0, _ := ret[0].(entity.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restock indicates an expected call of Restock.
+func (mr *MockItemMockRecorder) Restock(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restock", reflect.TypeOf((*MockItem)(nil).Restock), ctx, input)
+}
+
+// SetStock mocks base method.
+func (m *MockItem) SetStock(ctx context.Context, input service.ItemSetStockInput) (entity.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStock", ctx, input)
+	ret0, _ := ret[0].(entity.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetStock indicates an expected call of SetStock.
+func (mr *MockItemMockRecorder) SetStock(ctx, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStock", reflect.TypeOf((*MockItem)(nil).SetStock), ctx, input)
+}
+
+// MockIdempotency is a mock of Idempotency interface.
+type MockIdempotency struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyMockRecorder
+	isgomock struct{}
+}
+
+// MockIdempotencyMockRecorder is the mock recorder for MockIdempotency.
+type MockIdempotencyMockRecorder struct {
+	mock *MockIdempotency
+}
+
+// NewMockIdempotency creates a new mock instance.
+func NewMockIdempotency(ctrl *gomock.Controller) *MockIdempotency {
+	mock := &MockIdempotency{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotency) EXPECT() *MockIdempotencyMockRecorder {
+	return m.recorder
+}
+
+// DeleteExpiredKeys mocks base method.
+func (m *MockIdempotency) DeleteExpiredKeys(ctx context.Context, scheduledAt time.Time) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredKeys", ctx, scheduledAt)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredKeys indicates an expected call of DeleteExpiredKeys.
+func (mr *MockIdempotencyMockRecorder) DeleteExpiredKeys(ctx, scheduledAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredKeys", reflect.TypeOf((*MockIdempotency)(nil).DeleteExpiredKeys), ctx, scheduledAt)
+}
+
+// Do mocks base method.
+func (m *MockIdempotency) Do(ctx context.Context, input service.IdempotencyInput, fn func(context.Context) (service.IdempotentResponse, error)) (service.IdempotentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Do", ctx, input, fn)
+	ret0, _ := ret[0].(service.IdempotentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Do indicates an expected call of Do.
+func (mr *MockIdempotencyMockRecorder) Do(ctx, input, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockIdempotency)(nil).Do), ctx, input, fn)
+}
+
 // MockUserReport is a mock of UserReport interface.
 type MockUserReport struct {
 	ctrl     *gomock.Controller
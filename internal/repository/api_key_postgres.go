@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"time"
+)
+
+type APIKeyRepo struct {
+	*postgres.Postgres
+}
+
+func NewAPIKeyRepo(pg *postgres.Postgres) *APIKeyRepo {
+	return &APIKeyRepo{pg}
+}
+
+func (r *APIKeyRepo) Create(ctx context.Context, key entity.APIKey) (int, error) {
+	sql, args, _ := r.Builder.
+		Insert("api_keys").
+		Columns("user_id, name, prefix, key_hash, scopes").
+		Values(key.UserId, key.Name, key.Prefix, key.KeyHash, key.Scopes).
+		Suffix("RETURNING id").
+		ToSql()
+
+	var id int
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("APIKeyRepo.Create - QueryRow: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetActiveByHash returns a key which is not revoked.
+func (r *APIKeyRepo) GetActiveByHash(ctx context.Context, keyHash string) (entity.APIKey, error) {
+	sql, args, _ := r.Builder.
+		Select("id, user_id, name, prefix, key_hash, scopes, created_at, last_used_at, revoked_at").
+		From("api_keys").
+		Where(squirrel.Eq{"key_hash": keyHash, "revoked_at": nil}).
+		ToSql()
+
+	var key entity.APIKey
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(
+		&key.Id,
+		&key.UserId,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&key.Scopes,
+		&key.CreatedAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.APIKey{}, ErrNotFound
+		}
+		return entity.APIKey{}, fmt.Errorf("APIKeyRepo.GetActiveByHash - QueryRow: %w", err)
+	}
+
+	return key, nil
+}
+
+func (r *APIKeyRepo) GetByUserId(ctx context.Context, userId int) ([]entity.APIKey, error) {
+	sql, args, _ := r.Builder.
+		Select("id, user_id, name, prefix, key_hash, scopes, created_at, last_used_at, revoked_at").
+		From("api_keys").
+		Where(squirrel.Eq{"user_id": userId}).
+		OrderBy("id").
+		ToSql()
+
+	rows, err := r.GetQueryRunner(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("APIKeyRepo.GetByUserId - Query: %w", err)
+	}
+
+	keys, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.APIKey, error) {
+		var key entity.APIKey
+		err := row.Scan(
+			&key.Id,
+			&key.UserId,
+			&key.Name,
+			&key.Prefix,
+			&key.KeyHash,
+			&key.Scopes,
+			&key.CreatedAt,
+			&key.LastUsedAt,
+			&key.RevokedAt,
+		)
+		return key, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("APIKeyRepo.GetByUserId - CollectRows: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepo) Revoke(ctx context.Context, id int) error {
+	sql, args, _ := r.Builder.
+		Update("api_keys").
+		Set("revoked_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": id, "revoked_at": nil}).
+		ToSql()
+
+	cmdTag, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("APIKeyRepo.Revoke - Exec: %w", err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Touch updates last_used_at unless it was updated after notBefore, so that
+// a busy key does not cause a write on every request.
+func (r *APIKeyRepo) Touch(ctx context.Context, id int, notBefore time.Time) error {
+	sql, args, _ := r.Builder.
+		Update("api_keys").
+		Set("last_used_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.Or{
+			squirrel.Eq{"last_used_at": nil},
+			squirrel.Lt{"last_used_at": notBefore},
+		}).
+		ToSql()
+
+	_, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("APIKeyRepo.Touch - Exec: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,383 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var apiKeyColumns = []string{"id", "user_id", "name", "prefix", "key_hash", "scopes", "created_at", "last_used_at", "revoked_at"}
+
+func TestAPIKeyRepo_Create(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		key entity.APIKey
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	key := entity.APIKey{
+		UserId:  1,
+		Name:    "reward-bot",
+		Prefix:  "ms_abcd",
+		KeyHash: "hash",
+		Scopes:  []string{entity.ScopeTransferSend},
+	}
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         int
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx: context.Background(),
+				key: key,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id"}).
+					AddRow(1)
+
+				m.ExpectQuery(`INSERT INTO api_keys`).
+					WithArgs(args.key.UserId, args.key.Name, args.key.Prefix, args.key.KeyHash, args.key.Scopes).
+					WillReturnRows(rows)
+			},
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx: context.Background(),
+				key: key,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`INSERT INTO api_keys`).
+					WithArgs(args.key.UserId, args.key.Name, args.key.Prefix, args.key.KeyHash, args.key.Scopes).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			apiKeyRepoMock := NewAPIKeyRepo(postgresMock)
+
+			got, err := apiKeyRepoMock.Create(tc.args.ctx, tc.args.key)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAPIKeyRepo_GetActiveByHash(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		keyHash string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	createdAt := time.Now()
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         entity.APIKey
+		wantErr      error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:     context.Background(),
+				keyHash: "hash",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows(apiKeyColumns).
+					AddRow(1, 2, "reward-bot", "ms_abcd", "hash", []string{"transfer:send"}, createdAt, nil, nil)
+
+				m.ExpectQuery(`SELECT (.+) FROM api_keys WHERE key_hash = \$1 AND revoked_at IS NULL`).
+					WithArgs(args.keyHash).
+					WillReturnRows(rows)
+			},
+			want: entity.APIKey{
+				Id:        1,
+				UserId:    2,
+				Name:      "reward-bot",
+				Prefix:    "ms_abcd",
+				KeyHash:   "hash",
+				Scopes:    []string{"transfer:send"},
+				CreatedAt: createdAt,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			args: args{
+				ctx:     context.Background(),
+				keyHash: "hash",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`SELECT (.+) FROM api_keys`).
+					WithArgs(args.keyHash).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:     context.Background(),
+				keyHash: "hash",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`SELECT (.+) FROM api_keys`).
+					WithArgs(args.keyHash).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: errors.New("some query error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			apiKeyRepoMock := NewAPIKeyRepo(postgresMock)
+
+			got, err := apiKeyRepoMock.GetActiveByHash(tc.args.ctx, tc.args.keyHash)
+			if tc.wantErr != nil {
+				assert.Error(t, err)
+				if errors.Is(tc.wantErr, ErrNotFound) {
+					assert.ErrorIs(t, err, ErrNotFound)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAPIKeyRepo_GetByUserId(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	createdAt := time.Now()
+	revokedAt := createdAt.Add(time.Hour)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		want         []entity.APIKey
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows(apiKeyColumns).
+					AddRow(1, 2, "first", "ms_abcd", "hash1", []string{"report:read"}, createdAt, nil, &revokedAt).
+					AddRow(3, 2, "second", "ms_efgh", "hash2", []string{"transfer:send"}, createdAt, nil, nil)
+
+				m.ExpectQuery(`SELECT (.+) FROM api_keys WHERE user_id = \$1 ORDER BY id`).
+					WithArgs(2).
+					WillReturnRows(rows)
+			},
+			want: []entity.APIKey{
+				{Id: 1, UserId: 2, Name: "first", Prefix: "ms_abcd", KeyHash: "hash1", Scopes: []string{"report:read"}, CreatedAt: createdAt, RevokedAt: &revokedAt},
+				{Id: 3, UserId: 2, Name: "second", Prefix: "ms_efgh", KeyHash: "hash2", Scopes: []string{"transfer:send"}, CreatedAt: createdAt},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery(`SELECT (.+) FROM api_keys`).
+					WithArgs(2).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			apiKeyRepoMock := NewAPIKeyRepo(postgresMock)
+
+			got, err := apiKeyRepoMock.GetByUserId(context.Background(), 2)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAPIKeyRepo_Revoke(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec(`UPDATE api_keys SET revoked_at = now\(\) WHERE id = \$1 AND revoked_at IS NULL`).
+					WithArgs(1).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantErr: false,
+		},
+		{
+			name: "not found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec(`UPDATE api_keys SET revoked_at`).
+					WithArgs(1).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec(`UPDATE api_keys SET revoked_at`).
+					WithArgs(1).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			apiKeyRepoMock := NewAPIKeyRepo(postgresMock)
+
+			err := apiKeyRepoMock.Revoke(context.Background(), 1)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAPIKeyRepo_Touch(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface, notBefore time.Time)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			mockBehavior: func(m pgxmock.PgxPoolIface, notBefore time.Time) {
+				m.ExpectExec(`UPDATE api_keys SET last_used_at = now\(\) WHERE id = \$1 AND \(last_used_at IS NULL OR last_used_at < \$2\)`).
+					WithArgs(1, notBefore).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			mockBehavior: func(m pgxmock.PgxPoolIface, notBefore time.Time) {
+				m.ExpectExec(`UPDATE api_keys SET last_used_at`).
+					WithArgs(1, notBefore).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			notBefore := time.Now().Add(-time.Minute)
+			tc.mockBehavior(poolMock, notBefore)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			apiKeyRepoMock := NewAPIKeyRepo(postgresMock)
+
+			err := apiKeyRepoMock.Touch(context.Background(), 1, notBefore)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
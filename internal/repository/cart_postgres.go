@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+)
+
+type CartRepo struct {
+	*postgres.Postgres
+}
+
+func NewCartRepo(pg *postgres.Postgres) *CartRepo {
+	return &CartRepo{pg}
+}
+
+// AddItem puts the item into the cart or increases the quantity already there.
+func (r *CartRepo) AddItem(ctx context.Context, userId, itemId, quantity int) error {
+	sql, args, _ := r.Builder.
+		Insert("cart_items").
+		Columns("user_id, item_id, quantity").
+		Values(userId, itemId, quantity).
+		Suffix("ON CONFLICT (user_id, item_id) DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity").
+		ToSql()
+
+	_, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("CartRepo.AddItem - Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CartRepo) RemoveItem(ctx context.Context, userId, itemId int) error {
+	sql, args, _ := r.Builder.
+		Delete("cart_items").
+		Where(squirrel.Eq{"user_id": userId, "item_id": itemId}).
+		ToSql()
+
+	cmdTag, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("CartRepo.RemoveItem - Exec: %w", err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *CartRepo) GetItems(ctx context.Context, userId int) ([]entity.CartItem, error) {
+	sql, args, _ := r.Builder.
+		Select("c.item_id, i.name, i.price, c.quantity").
+		From("cart_items c").
+		Join("items i ON c.item_id = i.id").
+		Where(squirrel.Eq{"c.user_id": userId}).
+		OrderBy("i.name").
+		ToSql()
+
+	rows, err := r.GetQueryRunner(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("CartRepo.GetItems - Query: %w", err)
+	}
+
+	items, err := pgx.CollectRows(rows, scanCartItem)
+	if err != nil {
+		return nil, fmt.Errorf("CartRepo.GetItems - CollectRows: %w", err)
+	}
+
+	return items, nil
+}
+
+// TakeItems empties the cart and returns what it held. Concurrent calls for one user wait for each
+// other, so a cart is checked out only once; rolling back the transaction restores the cart.
+func (r *CartRepo) TakeItems(ctx context.Context, userId int) ([]entity.CartItem, error) {
+	sql, args, _ := r.Builder.
+		Delete("cart_items c USING items i").
+		Where(squirrel.And{
+			squirrel.Expr("c.item_id = i.id"),
+			squirrel.Eq{"c.user_id": userId},
+		}).
+		Suffix("RETURNING c.item_id, i.name, i.price, c.quantity").
+		ToSql()
+
+	rows, err := r.GetQueryRunner(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("CartRepo.TakeItems - Query: %w", err)
+	}
+
+	items, err := pgx.CollectRows(rows, scanCartItem)
+	if err != nil {
+		return nil, fmt.Errorf("CartRepo.TakeItems - CollectRows: %w", err)
+	}
+
+	return items, nil
+}
+
+func scanCartItem(row pgx.CollectableRow) (entity.CartItem, error) {
+	var item entity.CartItem
+	err := row.Scan(&item.ItemId, &item.ItemName, &item.Price, &item.Quantity)
+	return item, err
+}
@@ -0,0 +1,313 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var cartItemColumns = []string{"item_id", "name", "price", "quantity"}
+
+func TestCartRepo_AddItem(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		userId   int
+		itemId   int
+		quantity int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:      context.Background(),
+				userId:   1,
+				itemId:   4,
+				quantity: 2,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`INSERT INTO cart_items \(user_id, item_id, quantity\) VALUES \(\$1,\$2,\$3\) ON CONFLICT \(user_id, item_id\) DO UPDATE SET quantity = cart_items.quantity \+ EXCLUDED.quantity`).
+					WithArgs(args.userId, args.itemId, args.quantity).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:      context.Background(),
+				userId:   1,
+				itemId:   4,
+				quantity: 2,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`INSERT INTO cart_items`).
+					WithArgs(args.userId, args.itemId, args.quantity).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			cartRepoMock := NewCartRepo(postgresMock)
+
+			err := cartRepoMock.AddItem(tc.args.ctx, tc.args.userId, tc.args.itemId, tc.args.quantity)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCartRepo_RemoveItem(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		userId int
+		itemId int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:    context.Background(),
+				userId: 1,
+				itemId: 4,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`DELETE FROM cart_items WHERE item_id = \$1 AND user_id = \$2`).
+					WithArgs(args.itemId, args.userId).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not in cart",
+			args: args{
+				ctx:    context.Background(),
+				userId: 1,
+				itemId: 4,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`DELETE FROM cart_items`).
+					WithArgs(args.itemId, args.userId).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			cartRepoMock := NewCartRepo(postgresMock)
+
+			err := cartRepoMock.RemoveItem(tc.args.ctx, tc.args.userId, tc.args.itemId)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCartRepo_GetItems(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		userId int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         []entity.CartItem
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:    context.Background(),
+				userId: 1,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows(cartItemColumns).
+					AddRow(3, "cup", 20, 1).
+					AddRow(4, "socks", 10, 2)
+
+				m.ExpectQuery(`SELECT c.item_id, i.name, i.price, c.quantity FROM cart_items c JOIN items i ON c.item_id = i.id WHERE c.user_id = \$1 ORDER BY i.name`).
+					WithArgs(args.userId).
+					WillReturnRows(rows)
+			},
+			want: []entity.CartItem{
+				{ItemId: 3, ItemName: "cup", Price: 20, Quantity: 1},
+				{ItemId: 4, ItemName: "socks", Price: 10, Quantity: 2},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:    context.Background(),
+				userId: 1,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`SELECT (.+) FROM cart_items`).
+					WithArgs(args.userId).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			cartRepoMock := NewCartRepo(postgresMock)
+
+			got, err := cartRepoMock.GetItems(tc.args.ctx, tc.args.userId)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCartRepo_TakeItems(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		userId int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         []entity.CartItem
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:    context.Background(),
+				userId: 1,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows(cartItemColumns).
+					AddRow(4, "socks", 10, 2)
+
+				m.ExpectQuery(`DELETE FROM cart_items c USING items i WHERE \(c.item_id = i.id AND c.user_id = \$1\) RETURNING c.item_id, i.name, i.price, c.quantity`).
+					WithArgs(args.userId).
+					WillReturnRows(rows)
+			},
+			want: []entity.CartItem{
+				{ItemId: 4, ItemName: "socks", Price: 10, Quantity: 2},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:    context.Background(),
+				userId: 1,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`DELETE FROM cart_items`).
+					WithArgs(args.userId).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			cartRepoMock := NewCartRepo(postgresMock)
+
+			got, err := cartRepoMock.TakeItems(tc.args.ctx, tc.args.userId)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+)
+
+type IdempotencyKeyRepo struct {
+	*postgres.Postgres
+}
+
+func NewIdempotencyKeyRepo(pg *postgres.Postgres) *IdempotencyKeyRepo {
+	return &IdempotencyKeyRepo{pg}
+}
+
+// Create claims the key for the user, an expired key is claimed anew. ErrAlreadyExists means the key is taken;
+// if another transaction holds it, Create waits until that transaction ends.
+func (r *IdempotencyKeyRepo) Create(ctx context.Context, key entity.IdempotencyKey) (int, error) {
+	sql, args, _ := r.Builder.
+		Insert("idempotency_keys").
+		Columns("user_id, key, fingerprint, expires_at").
+		Values(key.UserId, key.Key, key.Fingerprint, key.ExpiresAt).
+		Suffix("ON CONFLICT (user_id, key) DO UPDATE SET " +
+			"fingerprint = EXCLUDED.fingerprint, status_code = NULL, response = NULL, created_at = now(), expires_at = EXCLUDED.expires_at " +
+			"WHERE idempotency_keys.expires_at <= now() RETURNING id").
+		ToSql()
+
+	var id int
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrAlreadyExists
+		}
+		return 0, fmt.Errorf("IdempotencyKeyRepo.Create - QueryRow: %w", err)
+	}
+
+	return id, nil
+}
+
+// Get returns the key of the user unless it has expired.
+func (r *IdempotencyKeyRepo) Get(ctx context.Context, userId int, key string) (entity.IdempotencyKey, error) {
+	sql, args, _ := r.Builder.
+		Select("id, user_id, key, fingerprint, COALESCE(status_code, 0), response, created_at, expires_at").
+		From("idempotency_keys").
+		Where(squirrel.Eq{"user_id": userId, "key": key}).
+		Where("expires_at > now()").
+		ToSql()
+
+	var idempotencyKey entity.IdempotencyKey
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(
+		&idempotencyKey.Id,
+		&idempotencyKey.UserId,
+		&idempotencyKey.Key,
+		&idempotencyKey.Fingerprint,
+		&idempotencyKey.StatusCode,
+		&idempotencyKey.Response,
+		&idempotencyKey.CreatedAt,
+		&idempotencyKey.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.IdempotencyKey{}, ErrNotFound
+		}
+		return entity.IdempotencyKey{}, fmt.Errorf("IdempotencyKeyRepo.Get - QueryRow: %w", err)
+	}
+
+	return idempotencyKey, nil
+}
+
+func (r *IdempotencyKeyRepo) SaveResponse(ctx context.Context, id, statusCode int, response []byte) error {
+	sql, args, _ := r.Builder.
+		Update("idempotency_keys").
+		Set("status_code", statusCode).
+		Set("response", response).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+
+	_, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("IdempotencyKeyRepo.SaveResponse - Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *IdempotencyKeyRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	sql, args, _ := r.Builder.
+		Delete("idempotency_keys").
+		Where("expires_at <= now()").
+		ToSql()
+
+	cmdTag, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("IdempotencyKeyRepo.DeleteExpired - Exec: %w", err)
+	}
+
+	return cmdTag.RowsAffected(), nil
+}
@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKeyRepo_Create(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		key entity.IdempotencyKey
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	expiresAt := time.Now().Add(24 * time.Hour)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         int
+		wantErr      error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx: context.Background(),
+				key: entity.IdempotencyKey{UserId: 1, Key: "key", Fingerprint: "fingerprint", ExpiresAt: expiresAt},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id"}).
+					AddRow(3)
+
+				m.ExpectQuery(`INSERT INTO idempotency_keys \(user_id, key, fingerprint, expires_at\) VALUES \(\$1,\$2,\$3,\$4\) `+
+					`ON CONFLICT \(user_id, key\) DO UPDATE SET (.+) WHERE idempotency_keys.expires_at <= now\(\) RETURNING id`).
+					WithArgs(args.key.UserId, args.key.Key, args.key.Fingerprint, args.key.ExpiresAt).
+					WillReturnRows(rows)
+			},
+			want:    3,
+			wantErr: nil,
+		},
+		{
+			name: "already exists",
+			args: args{
+				ctx: context.Background(),
+				key: entity.IdempotencyKey{UserId: 1, Key: "key", Fingerprint: "fingerprint", ExpiresAt: expiresAt},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`INSERT INTO idempotency_keys`).
+					WithArgs(args.key.UserId, args.key.Key, args.key.Fingerprint, args.key.ExpiresAt).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			want:    0,
+			wantErr: ErrAlreadyExists,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx: context.Background(),
+				key: entity.IdempotencyKey{UserId: 1, Key: "key", Fingerprint: "fingerprint", ExpiresAt: expiresAt},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`INSERT INTO idempotency_keys`).
+					WithArgs(args.key.UserId, args.key.Key, args.key.Fingerprint, args.key.ExpiresAt).
+					WillReturnError(errors.New("some query error"))
+			},
+			want:    0,
+			wantErr: errors.New("some query error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			idempotencyKeyRepoMock := NewIdempotencyKeyRepo(postgresMock)
+
+			got, err := idempotencyKeyRepoMock.Create(tc.args.ctx, tc.args.key)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestIdempotencyKeyRepo_Get(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		userId int
+		key    string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	createdAt := time.Now()
+	expiresAt := createdAt.Add(24 * time.Hour)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         entity.IdempotencyKey
+		wantErr      error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:    context.Background(),
+				userId: 1,
+				key:    "key",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "user_id", "key", "fingerprint", "status_code", "response", "created_at", "expires_at"}).
+					AddRow(3, args.userId, args.key, "fingerprint", 200, []byte(`{}`), createdAt, expiresAt)
+
+				m.ExpectQuery(`SELECT (.+) FROM idempotency_keys WHERE key = \$1 AND user_id = \$2 AND expires_at > now\(\)`).
+					WithArgs(args.key, args.userId).
+					WillReturnRows(rows)
+			},
+			want: entity.IdempotencyKey{
+				Id:          3,
+				UserId:      1,
+				Key:         "key",
+				Fingerprint: "fingerprint",
+				StatusCode:  200,
+				Response:    []byte(`{}`),
+				CreatedAt:   createdAt,
+				ExpiresAt:   expiresAt,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			args: args{
+				ctx:    context.Background(),
+				userId: 1,
+				key:    "key",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`SELECT (.+) FROM idempotency_keys`).
+					WithArgs(args.key, args.userId).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			want:    entity.IdempotencyKey{},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			idempotencyKeyRepoMock := NewIdempotencyKeyRepo(postgresMock)
+
+			got, err := idempotencyKeyRepoMock.Get(tc.args.ctx, tc.args.userId, tc.args.key)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestIdempotencyKeyRepo_SaveResponse(t *testing.T) {
+	type args struct {
+		ctx        context.Context
+		id         int
+		statusCode int
+		response   []byte
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:        context.Background(),
+				id:         3,
+				statusCode: 200,
+				response:   []byte(`{}`),
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE idempotency_keys SET status_code = \$1, response = \$2 WHERE id = \$3`).
+					WithArgs(args.statusCode, args.response, args.id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:        context.Background(),
+				id:         3,
+				statusCode: 200,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE idempotency_keys`).
+					WithArgs(args.statusCode, args.response, args.id).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			idempotencyKeyRepoMock := NewIdempotencyKeyRepo(postgresMock)
+
+			err := idempotencyKeyRepoMock.SaveResponse(tc.args.ctx, tc.args.id, tc.args.statusCode, tc.args.response)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestIdempotencyKeyRepo_DeleteExpired(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		want         int64
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec(`DELETE FROM idempotency_keys WHERE expires_at <= now\(\)`).
+					WillReturnResult(pgxmock.NewResult("DELETE", 3))
+			},
+			want:    3,
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec(`DELETE FROM idempotency_keys`).
+					WillReturnError(errors.New("some query error"))
+			},
+			want:    0,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			idempotencyKeyRepoMock := NewIdempotencyKeyRepo(postgresMock)
+
+			got, err := idempotencyKeyRepoMock.DeleteExpired(context.Background())
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+)
+
+type InviteRepo struct {
+	*postgres.Postgres
+}
+
+func NewInviteRepo(pg *postgres.Postgres) *InviteRepo {
+	return &InviteRepo{pg}
+}
+
+func (r *InviteRepo) Create(ctx context.Context, invite entity.Invite) (int, error) {
+	sql, args, _ := r.Builder.
+		Insert("invites").
+		Columns("code_hash, created_by, max_uses, expires_at").
+		Values(invite.CodeHash, invite.CreatedBy, invite.MaxUses, invite.ExpiresAt).
+		Suffix("RETURNING id").
+		ToSql()
+
+	var id int
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("InviteRepo.Create - QueryRow: %w", err)
+	}
+
+	return id, nil
+}
+
+// Use consumes one use of a valid invite. ErrNotFound means the code is unknown, expired or used up.
+func (r *InviteRepo) Use(ctx context.Context, codeHash string) error {
+	sql, args, _ := r.Builder.
+		Update("invites").
+		Set("uses", squirrel.Expr("uses + 1")).
+		Where(squirrel.And{
+			squirrel.Eq{"code_hash": codeHash},
+			squirrel.Expr("uses < max_uses"),
+			squirrel.Expr("expires_at > now()"),
+		}).
+		ToSql()
+
+	cmdTag, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("InviteRepo.Use - Exec: %w", err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
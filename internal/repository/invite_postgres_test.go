@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestInviteRepo_Create(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		invite entity.Invite
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         int
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:    context.Background(),
+				invite: entity.Invite{CodeHash: "hash", CreatedBy: 1, MaxUses: 3, ExpiresAt: expiresAt},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id"}).
+					AddRow(7)
+
+				m.ExpectQuery(`INSERT INTO invites`).
+					WithArgs(args.invite.CodeHash, args.invite.CreatedBy, args.invite.MaxUses, args.invite.ExpiresAt).
+					WillReturnRows(rows)
+			},
+			want:    7,
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:    context.Background(),
+				invite: entity.Invite{CodeHash: "hash", CreatedBy: 1, MaxUses: 3, ExpiresAt: expiresAt},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`INSERT INTO invites`).
+					WithArgs(args.invite.CodeHash, args.invite.CreatedBy, args.invite.MaxUses, args.invite.ExpiresAt).
+					WillReturnError(errors.New("some query error"))
+			},
+			want:    0,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			inviteRepoMock := NewInviteRepo(postgresMock)
+
+			got, err := inviteRepoMock.Create(tc.args.ctx, tc.args.invite)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestInviteRepo_Use(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		codeHash string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:      context.Background(),
+				codeHash: "hash",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE invites SET uses = uses \+ 1 WHERE \(code_hash = \$1 AND uses < max_uses AND expires_at > now\(\)\)`).
+					WithArgs(args.codeHash).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "unknown, expired or used up",
+			args: args{
+				ctx:      context.Background(),
+				codeHash: "hash",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE invites`).
+					WithArgs(args.codeHash).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:      context.Background(),
+				codeHash: "hash",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE invites`).
+					WithArgs(args.codeHash).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: errors.New("some query error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			inviteRepoMock := NewInviteRepo(postgresMock)
+
+			err := inviteRepoMock.Use(tc.args.ctx, tc.args.codeHash)
+			if tc.wantErr != nil {
+				assert.Error(t, err)
+				if errors.Is(tc.wantErr, ErrNotFound) {
+					assert.ErrorIs(t, err, ErrNotFound)
+				}
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
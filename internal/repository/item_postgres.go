@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
 	"github.com/spanwalla/merch-store/internal/entity"
 	"github.com/spanwalla/merch-store/pkg/postgres"
@@ -19,17 +20,12 @@ func NewItemRepo(pg *postgres.Postgres) *ItemRepo {
 
 func (r *ItemRepo) GetItemByName(ctx context.Context, name string) (entity.Item, error) {
 	sql, args, _ := r.Builder.
-		Select("id, name, price").
+		Select("id, name, price, stock").
 		From("items").
 		Where("name = ?", name).
 		ToSql()
 
-	var item entity.Item
-	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(
-		&item.Id,
-		&item.Name,
-		&item.Price,
-	)
+	item, err := scanItem(r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return entity.Item{}, ErrNotFound
@@ -39,3 +35,108 @@ func (r *ItemRepo) GetItemByName(ctx context.Context, name string) (entity.Item,
 
 	return item, nil
 }
+
+// GetLowStock returns the items with a limited stock of at most threshold, the fewest left first.
+func (r *ItemRepo) GetLowStock(ctx context.Context, threshold int) ([]entity.Item, error) {
+	sql, args, _ := r.Builder.
+		Select("id, name, price, stock").
+		From("items").
+		Where(squirrel.LtOrEq{"stock": threshold}).
+		OrderBy("stock, name").
+		ToSql()
+
+	rows, err := r.GetQueryRunner(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ItemRepo.GetLowStock - Query: %w", err)
+	}
+
+	items, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Item, error) {
+		return scanItem(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ItemRepo.GetLowStock - CollectRows: %w", err)
+	}
+
+	return items, nil
+}
+
+// TakeStock takes quantity items out of the stock. The stock is checked by the update itself, so concurrent
+// purchases cannot sell more items than are left. It returns ErrNotFound if there are not enough items.
+// An unlimited stock stays unlimited.
+func (r *ItemRepo) TakeStock(ctx context.Context, id, quantity int) error {
+	sql, args, _ := r.Builder.
+		Update("items").
+		Set("stock", squirrel.Expr("stock - ?", quantity)).
+		Where(squirrel.And{
+			squirrel.Eq{"id": id},
+			squirrel.Or{squirrel.Eq{"stock": nil}, squirrel.GtOrEq{"stock": quantity}},
+		}).
+		ToSql()
+
+	cmdTag, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("ItemRepo.TakeStock - Exec: %w", err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// AddStock puts quantity items into the stock and returns the item. The limit is checked by the update itself,
+// so concurrent restocks cannot exceed entity.MaxItemStock. It returns ErrNotFound if the item does not exist or
+// the stock would exceed the limit. An unlimited stock stays unlimited.
+func (r *ItemRepo) AddStock(ctx context.Context, id, quantity int) (entity.Item, error) {
+	sql, args, _ := r.Builder.
+		Update("items").
+		Set("stock", squirrel.Expr("stock + ?", quantity)).
+		Where(squirrel.And{
+			squirrel.Eq{"id": id},
+			squirrel.Or{squirrel.Eq{"stock": nil}, squirrel.LtOrEq{"stock": entity.MaxItemStock - quantity}},
+		}).
+		Suffix("RETURNING id, name, price, stock").
+		ToSql()
+
+	item, err := scanItem(r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Item{}, ErrNotFound
+		}
+		return entity.Item{}, fmt.Errorf("ItemRepo.AddStock - QueryRow: %w", err)
+	}
+
+	return item, nil
+}
+
+// SetStock replaces the stock of the item, nil makes it unlimited.
+func (r *ItemRepo) SetStock(ctx context.Context, id int, stock *int) (entity.Item, error) {
+	sql, args, _ := r.Builder.
+		Update("items").
+		Set("stock", stock).
+		Where(squirrel.Eq{"id": id}).
+		Suffix("RETURNING id, name, price, stock").
+		ToSql()
+
+	item, err := scanItem(r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Item{}, ErrNotFound
+		}
+		return entity.Item{}, fmt.Errorf("ItemRepo.SetStock - QueryRow: %w", err)
+	}
+
+	return item, nil
+}
+
+func scanItem(row pgx.Row) (entity.Item, error) {
+	var item entity.Item
+	err := row.Scan(
+		&item.Id,
+		&item.Name,
+		&item.Price,
+		&item.Stock,
+	)
+	return item, err
+}
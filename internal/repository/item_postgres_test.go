@@ -9,6 +9,7 @@ import (
 	"github.com/spanwalla/merch-store/internal/entity"
 	"github.com/spanwalla/merch-store/pkg/postgres"
 	"github.com/stretchr/testify/assert"
+	"math"
 	"testing"
 )
 
@@ -20,6 +21,8 @@ func TestItemRepo_GetItemByName(t *testing.T) {
 
 	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
 
+	stock := 12
+
 	testCases := []struct {
 		name         string
 		args         args
@@ -34,10 +37,10 @@ func TestItemRepo_GetItemByName(t *testing.T) {
 				name: "sweater",
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
-				rows := pgxmock.NewRows([]string{"id", "name", "price"}).
-					AddRow(1, args.name, 100)
+				rows := pgxmock.NewRows([]string{"id", "name", "price", "stock"}).
+					AddRow(1, args.name, 100, &stock)
 
-				m.ExpectQuery(`SELECT id, name, price`).
+				m.ExpectQuery(`SELECT id, name, price, stock FROM items WHERE name = \$1`).
 					WithArgs(args.name).
 					WillReturnRows(rows)
 			},
@@ -45,6 +48,7 @@ func TestItemRepo_GetItemByName(t *testing.T) {
 				Id:    1,
 				Name:  "sweater",
 				Price: 100,
+				Stock: &stock,
 			},
 			wantErr: false,
 		},
@@ -104,3 +108,358 @@ func TestItemRepo_GetItemByName(t *testing.T) {
 		})
 	}
 }
+
+func TestItemRepo_GetLowStock(t *testing.T) {
+	type args struct {
+		ctx       context.Context
+		threshold int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	hoodyStock, cupStock := 0, 3
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         []entity.Item
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:       context.Background(),
+				threshold: 5,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "name", "price", "stock"}).
+					AddRow(7, "pink-hoody", 500, &hoodyStock).
+					AddRow(2, "cup", 20, &cupStock)
+
+				m.ExpectQuery(`SELECT id, name, price, stock FROM items WHERE stock <= \$1 ORDER BY stock, name`).
+					WithArgs(args.threshold).
+					WillReturnRows(rows)
+			},
+			want: []entity.Item{
+				{Id: 7, Name: "pink-hoody", Price: 500, Stock: &hoodyStock},
+				{Id: 2, Name: "cup", Price: 20, Stock: &cupStock},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:       context.Background(),
+				threshold: 5,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`SELECT id, name, price, stock FROM items`).
+					WithArgs(args.threshold).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			itemRepoMock := NewItemRepo(postgresMock)
+
+			got, err := itemRepoMock.GetLowStock(tc.args.ctx, tc.args.threshold)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestItemRepo_TakeStock(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		id       int
+		quantity int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:      context.Background(),
+				id:       7,
+				quantity: 2,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE items SET stock = stock - \$1 WHERE \(id = \$2 AND \(stock IS NULL OR stock >= \$3\)\)`).
+					WithArgs(args.quantity, args.id, args.quantity).
+					WillReturnResult(pgxmock.NewResult(`UPDATE`, 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "out of stock",
+			args: args{
+				ctx:      context.Background(),
+				id:       7,
+				quantity: 2,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE items`).
+					WithArgs(args.quantity, args.id, args.quantity).
+					WillReturnResult(pgxmock.NewResult(`UPDATE`, 0))
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:      context.Background(),
+				id:       7,
+				quantity: 2,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE items`).
+					WithArgs(args.quantity, args.id, args.quantity).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: errors.New("some query error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			itemRepoMock := NewItemRepo(postgresMock)
+
+			err := itemRepoMock.TakeStock(tc.args.ctx, tc.args.id, tc.args.quantity)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestItemRepo_AddStock(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		id       int
+		quantity int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	stock := 12
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         entity.Item
+		wantErr      error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:      context.Background(),
+				id:       7,
+				quantity: 10,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "name", "price", "stock"}).
+					AddRow(7, "pink-hoody", 500, &stock)
+
+				m.ExpectQuery(`UPDATE items SET stock = stock \+ \$1 WHERE \(id = \$2 AND \(stock IS NULL OR stock <= \$3\)\) RETURNING id, name, price, stock`).
+					WithArgs(args.quantity, args.id, math.MaxInt32-args.quantity).
+					WillReturnRows(rows)
+			},
+			want:    entity.Item{Id: 7, Name: "pink-hoody", Price: 500, Stock: &stock},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			args: args{
+				ctx:      context.Background(),
+				id:       7,
+				quantity: 10,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`UPDATE items`).
+					WithArgs(args.quantity, args.id, math.MaxInt32-args.quantity).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "stock limit exceeded",
+			args: args{
+				ctx:      context.Background(),
+				id:       7,
+				quantity: math.MaxInt32,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`UPDATE items`).
+					WithArgs(args.quantity, args.id, 0).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			itemRepoMock := NewItemRepo(postgresMock)
+
+			got, err := itemRepoMock.AddStock(tc.args.ctx, tc.args.id, tc.args.quantity)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestItemRepo_SetStock(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		id    int
+		stock *int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	stock := 12
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         entity.Item
+		wantErr      bool
+	}{
+		{
+			name: "limited",
+			args: args{
+				ctx:   context.Background(),
+				id:    7,
+				stock: &stock,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "name", "price", "stock"}).
+					AddRow(7, "pink-hoody", 500, &stock)
+
+				m.ExpectQuery(`UPDATE items SET stock = \$1 WHERE id = \$2 RETURNING id, name, price, stock`).
+					WithArgs(args.stock, args.id).
+					WillReturnRows(rows)
+			},
+			want:    entity.Item{Id: 7, Name: "pink-hoody", Price: 500, Stock: &stock},
+			wantErr: false,
+		},
+		{
+			name: "unlimited",
+			args: args{
+				ctx:   context.Background(),
+				id:    7,
+				stock: nil,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "name", "price", "stock"}).
+					AddRow(7, "pink-hoody", 500, (*int)(nil))
+
+				m.ExpectQuery(`UPDATE items SET stock = \$1 WHERE id = \$2 RETURNING id, name, price, stock`).
+					WithArgs(args.stock, args.id).
+					WillReturnRows(rows)
+			},
+			want:    entity.Item{Id: 7, Name: "pink-hoody", Price: 500},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:   context.Background(),
+				id:    7,
+				stock: &stock,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`UPDATE items`).
+					WithArgs(args.stock, args.id).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			itemRepoMock := NewItemRepo(postgresMock)
+
+			got, err := itemRepoMock.SetStock(tc.args.ctx, tc.args.id, tc.args.stock)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+)
+
+type JobRepo struct {
+	*postgres.Postgres
+}
+
+func NewJobRepo(pg *postgres.Postgres) *JobRepo {
+	return &JobRepo{pg}
+}
+
+// TryLock takes the advisory lock of the job without waiting. The lock is released when the
+// transaction ends, so TryLock must be called within one.
+func (r *JobRepo) TryLock(ctx context.Context, job string) (bool, error) {
+	sql, args, _ := r.Builder.
+		Select().
+		Column("pg_try_advisory_xact_lock(hashtext(?))", job).
+		ToSql()
+
+	var locked bool
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(&locked)
+	if err != nil {
+		return false, fmt.Errorf("JobRepo.TryLock - QueryRow: %w", err)
+	}
+
+	return locked, nil
+}
+
+// CreateRun returns ErrAlreadyExists if the run scheduled at the same time has been recorded.
+func (r *JobRepo) CreateRun(ctx context.Context, run entity.JobRun) (int, error) {
+	sql, args, _ := r.Builder.
+		Insert("job_runs").
+		Columns("job, scheduled_at, finished_at, status, details").
+		Values(run.Job, run.ScheduledAt, run.FinishedAt, run.Status, run.Details).
+		Suffix("ON CONFLICT (job, scheduled_at) DO NOTHING RETURNING id").
+		ToSql()
+
+	var id int
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrAlreadyExists
+		}
+		return 0, fmt.Errorf("JobRepo.CreateRun - QueryRow: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *JobRepo) FinishRun(ctx context.Context, id int, status, details string) error {
+	sql, args, _ := r.Builder.
+		Update("job_runs").
+		Set("finished_at", squirrel.Expr("now()")).
+		Set("status", status).
+		Set("details", details).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+
+	cmdTag, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("JobRepo.FinishRun - Exec: %w", err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
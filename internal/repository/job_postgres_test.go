@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestJobRepo_TryLock(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		job string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         bool
+		wantErr      bool
+	}{
+		{
+			name: "locked",
+			args: args{
+				ctx: context.Background(),
+				job: "allowance",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"pg_try_advisory_xact_lock"}).
+					AddRow(true)
+
+				m.ExpectQuery(`SELECT pg_try_advisory_xact_lock\(hashtext\(\$1\)\)`).
+					WithArgs(args.job).
+					WillReturnRows(rows)
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "held by another session",
+			args: args{
+				ctx: context.Background(),
+				job: "allowance",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"pg_try_advisory_xact_lock"}).
+					AddRow(false)
+
+				m.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).
+					WithArgs(args.job).
+					WillReturnRows(rows)
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx: context.Background(),
+				job: "allowance",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).
+					WithArgs(args.job).
+					WillReturnError(errors.New("some query error"))
+			},
+			want:    false,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			jobRepoMock := NewJobRepo(postgresMock)
+
+			got, err := jobRepoMock.TryLock(tc.args.ctx, tc.args.job)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestJobRepo_CreateRun(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		run entity.JobRun
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	scheduledAt := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	finishedAt := scheduledAt.Add(time.Second)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         int
+		wantErr      error
+	}{
+		{
+			name: "running",
+			args: args{
+				ctx: context.Background(),
+				run: entity.JobRun{Job: "allowance", ScheduledAt: scheduledAt, Status: entity.JobRunStatusRunning},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id"}).
+					AddRow(5)
+
+				m.ExpectQuery(`INSERT INTO job_runs \(job, scheduled_at, finished_at, status, details\) VALUES \(\$1,\$2,\$3,\$4,\$5\) ON CONFLICT \(job, scheduled_at\) DO NOTHING RETURNING id`).
+					WithArgs(args.run.Job, args.run.ScheduledAt, (*time.Time)(nil), args.run.Status, "").
+					WillReturnRows(rows)
+			},
+			want:    5,
+			wantErr: nil,
+		},
+		{
+			name: "failed",
+			args: args{
+				ctx: context.Background(),
+				run: entity.JobRun{
+					Job:         "allowance",
+					ScheduledAt: scheduledAt,
+					FinishedAt:  &finishedAt,
+					Status:      entity.JobRunStatusFailed,
+					Details:     "some error",
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id"}).
+					AddRow(6)
+
+				m.ExpectQuery(`INSERT INTO job_runs`).
+					WithArgs(args.run.Job, args.run.ScheduledAt, args.run.FinishedAt, args.run.Status, args.run.Details).
+					WillReturnRows(rows)
+			},
+			want:    6,
+			wantErr: nil,
+		},
+		{
+			name: "already exists",
+			args: args{
+				ctx: context.Background(),
+				run: entity.JobRun{Job: "allowance", ScheduledAt: scheduledAt, Status: entity.JobRunStatusRunning},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`INSERT INTO job_runs`).
+					WithArgs(args.run.Job, args.run.ScheduledAt, (*time.Time)(nil), args.run.Status, "").
+					WillReturnError(pgx.ErrNoRows)
+			},
+			want:    0,
+			wantErr: ErrAlreadyExists,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx: context.Background(),
+				run: entity.JobRun{Job: "allowance", ScheduledAt: scheduledAt, Status: entity.JobRunStatusRunning},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`INSERT INTO job_runs`).
+					WithArgs(args.run.Job, args.run.ScheduledAt, (*time.Time)(nil), args.run.Status, "").
+					WillReturnError(errors.New("some query error"))
+			},
+			want:    0,
+			wantErr: errors.New("some query error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			jobRepoMock := NewJobRepo(postgresMock)
+
+			got, err := jobRepoMock.CreateRun(tc.args.ctx, tc.args.run)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestJobRepo_FinishRun(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		id      int
+		status  string
+		details string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:     context.Background(),
+				id:      5,
+				status:  entity.JobRunStatusSucceeded,
+				details: "credited 1000 coins to 3 users",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE job_runs SET finished_at = now\(\), status = \$1, details = \$2 WHERE id = \$3`).
+					WithArgs(args.status, args.details, args.id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			args: args{
+				ctx:     context.Background(),
+				id:      5,
+				status:  entity.JobRunStatusSucceeded,
+				details: "",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE job_runs`).
+					WithArgs(args.status, args.details, args.id).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:     context.Background(),
+				id:      5,
+				status:  entity.JobRunStatusSucceeded,
+				details: "",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE job_runs`).
+					WithArgs(args.status, args.details, args.id).
+					WillReturnError(errors.New("some exec error"))
+			},
+			wantErr: errors.New("some exec error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			jobRepoMock := NewJobRepo(postgresMock)
+
+			err := jobRepoMock.FinishRun(tc.args.ctx, tc.args.id, tc.args.status, tc.args.details)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"slices"
+	"time"
+)
+
+// appendBatchSize keeps the number of arguments of a statement well below the limit of 65535.
+const appendBatchSize = 1000
+
+type LedgerRepo struct {
+	*postgres.Postgres
+}
+
+func NewLedgerRepo(pg *postgres.Postgres) *LedgerRepo {
+	return &LedgerRepo{pg}
+}
+
+// Append stores the entry and returns it with the id and the time it was recorded at.
+func (r *LedgerRepo) Append(ctx context.Context, entry entity.LedgerEntry) (entity.LedgerEntry, error) {
+	sql, args, _ := r.Builder.
+		Insert("ledger_entries").
+		Columns("sender_id, receiver_id, amount, kind, reason, created_by, pending_transfer_id").
+		Values(entry.SenderId, entry.ReceiverId, entry.Amount, entry.Kind, entry.Reason, entry.CreatedBy, entry.PendingTransferId).
+		Suffix("RETURNING id, created_at").
+		ToSql()
+
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(&entry.Id, &entry.CreatedAt)
+	if err != nil {
+		return entity.LedgerEntry{}, fmt.Errorf("LedgerRepo.Append - QueryRow: %w", err)
+	}
+
+	return entry, nil
+}
+
+// AppendBatch stores the entries with as few statements as possible.
+func (r *LedgerRepo) AppendBatch(ctx context.Context, entries []entity.LedgerEntry) error {
+	for chunk := range slices.Chunk(entries, appendBatchSize) {
+		query := r.Builder.
+			Insert("ledger_entries").
+			Columns("sender_id, receiver_id, amount, kind, reason, created_by, pending_transfer_id")
+		for _, entry := range chunk {
+			query = query.Values(entry.SenderId, entry.ReceiverId, entry.Amount, entry.Kind, entry.Reason, entry.CreatedBy, entry.PendingTransferId)
+		}
+		sql, args, _ := query.ToSql()
+
+		_, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+		if err != nil {
+			return fmt.Errorf("LedgerRepo.AppendBatch - Exec: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetExpirable returns the users holding minted or allowance coins received before the given time
+// that may not have expired yet, with the entries entity.Expiration.Amount needs.
+func (r *LedgerRepo) GetExpirable(ctx context.Context, before time.Time) ([]entity.Expiration, error) {
+	sums := r.Builder.
+		Select("COALESCE(receiver_id, sender_id) AS user_id").
+		Column("COALESCE(SUM(amount) FILTER (WHERE kind IN (?, ?) AND created_at < ?), 0) AS granted", entity.LedgerKindMint, entity.LedgerKindAllowance, before).
+		Column("COALESCE(SUM(amount) FILTER (WHERE kind = ?), 0) AS expired", entity.LedgerKindExpiration).
+		From("ledger_entries").
+		Where(squirrel.Or{squirrel.NotEq{"receiver_id": nil}, squirrel.Eq{"kind": entity.LedgerKindExpiration}}).
+		GroupBy("user_id")
+
+	sql, args, _ := r.Builder.
+		Select("u.id", "u.balance").
+		FromSelect(sums, "l").
+		Join("users u ON l.user_id = u.id").
+		Where(squirrel.NotEq{"u.role": entity.RoleSystem}).
+		Where("l.granted > l.expired").
+		OrderBy("u.id").
+		ToSql()
+
+	rows, err := r.GetQueryRunner(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("LedgerRepo.GetExpirable - Query: %w", err)
+	}
+
+	expirations, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Expiration, error) {
+		expiration := entity.Expiration{Before: before}
+		err := row.Scan(&expiration.UserId, &expiration.Balance)
+		return expiration, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("LedgerRepo.GetExpirable - CollectRows: %w", err)
+	}
+
+	if len(expirations) == 0 {
+		return expirations, nil
+	}
+
+	userIds := make([]int, 0, len(expirations))
+	byUserId := make(map[int]*entity.Expiration, len(expirations))
+	for i := range expirations {
+		userIds = append(userIds, expirations[i].UserId)
+		byUserId[expirations[i].UserId] = &expirations[i]
+	}
+
+	// Entries are made in id order, entries of the same transaction share created_at.
+	sql, args, _ = r.Builder.
+		Select("id, created_at, sender_id, receiver_id, amount, kind").
+		From("ledger_entries").
+		Where("sender_id = ANY(?) OR receiver_id = ANY(?)", userIds, userIds).
+		OrderBy("id").
+		ToSql()
+
+	rows, err = r.GetQueryRunner(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("LedgerRepo.GetExpirable - Query entries: %w", err)
+	}
+
+	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.LedgerEntry, error) {
+		var entry entity.LedgerEntry
+		err := row.Scan(&entry.Id, &entry.CreatedAt, &entry.SenderId, &entry.ReceiverId, &entry.Amount, &entry.Kind)
+		return entry, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("LedgerRepo.GetExpirable - CollectRows entries: %w", err)
+	}
+
+	for _, entry := range entries {
+		for _, userId := range []*int{entry.SenderId, entry.ReceiverId} {
+			if userId == nil {
+				continue
+			}
+			if expiration, ok := byUserId[*userId]; ok {
+				expiration.Entries = append(expiration.Entries, entry)
+			}
+		}
+	}
+
+	return expirations, nil
+}
+
+// GetSentSince sums up the transfers the user has sent since the given time. Pending transfers are
+// counted when the coins are put in escrow, even if they are declined later.
+func (r *LedgerRepo) GetSentSince(ctx context.Context, senderId int, since time.Time) (entity.TransferStats, error) {
+	return r.getTransferStats(ctx, "LedgerRepo.GetSentSince", squirrel.Eq{
+		"kind":      []string{entity.LedgerKindTransfer, entity.LedgerKindEscrowHold},
+		"sender_id": senderId,
+	}, since)
+}
+
+// GetReceivedSince sums up the transfers the user has received since the given time, including accepted pending transfers.
+func (r *LedgerRepo) GetReceivedSince(ctx context.Context, receiverId int, since time.Time) (entity.TransferStats, error) {
+	return r.getTransferStats(ctx, "LedgerRepo.GetReceivedSince", squirrel.Eq{
+		"kind":        []string{entity.LedgerKindTransfer, entity.LedgerKindEscrowRelease},
+		"receiver_id": receiverId,
+	}, since)
+}
+
+func (r *LedgerRepo) getTransferStats(ctx context.Context, method string, where squirrel.Eq, since time.Time) (entity.TransferStats, error) {
+	sql, args, _ := r.Builder.
+		Select("COUNT(*), COALESCE(SUM(amount), 0)").
+		From("ledger_entries").
+		Where(where).
+		Where(squirrel.GtOrEq{"created_at": since}).
+		ToSql()
+
+	var stats entity.TransferStats
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(&stats.Count, &stats.Amount)
+	if err != nil {
+		return entity.TransferStats{}, fmt.Errorf("%s - QueryRow: %w", method, err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,499 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLedgerRepo_Append(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		entry entity.LedgerEntry
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	senderId, receiverId := 1, 49
+	createdAt := time.Now()
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         entity.LedgerEntry
+		wantErr      bool
+	}{
+		{
+			name: "transfer",
+			args: args{
+				ctx: context.Background(),
+				entry: entity.LedgerEntry{
+					SenderId:   &senderId,
+					ReceiverId: &receiverId,
+					Amount:     100,
+					Kind:       entity.LedgerKindTransfer,
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "created_at"}).
+					AddRow(7, createdAt)
+
+				m.ExpectQuery(`INSERT INTO ledger_entries \(sender_id, receiver_id, amount, kind, reason, created_by, pending_transfer_id\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7\) RETURNING id, created_at`).
+					WithArgs(args.entry.SenderId, args.entry.ReceiverId, args.entry.Amount, args.entry.Kind, args.entry.Reason, args.entry.CreatedBy, args.entry.PendingTransferId).
+					WillReturnRows(rows)
+			},
+			want: entity.LedgerEntry{
+				Id:         7,
+				CreatedAt:  createdAt,
+				SenderId:   &senderId,
+				ReceiverId: &receiverId,
+				Amount:     100,
+				Kind:       entity.LedgerKindTransfer,
+			},
+			wantErr: false,
+		},
+		{
+			name: "purchase without receiver",
+			args: args{
+				ctx: context.Background(),
+				entry: entity.LedgerEntry{
+					SenderId: &senderId,
+					Amount:   500,
+					Kind:     entity.LedgerKindPurchase,
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "created_at"}).
+					AddRow(8, createdAt)
+
+				m.ExpectQuery(`INSERT INTO ledger_entries`).
+					WithArgs(args.entry.SenderId, (*int)(nil), args.entry.Amount, args.entry.Kind, args.entry.Reason, args.entry.CreatedBy, args.entry.PendingTransferId).
+					WillReturnRows(rows)
+			},
+			want: entity.LedgerEntry{
+				Id:        8,
+				CreatedAt: createdAt,
+				SenderId:  &senderId,
+				Amount:    500,
+				Kind:      entity.LedgerKindPurchase,
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx: context.Background(),
+				entry: entity.LedgerEntry{
+					SenderId:   &senderId,
+					ReceiverId: &receiverId,
+					Amount:     100,
+					Kind:       entity.LedgerKindTransfer,
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`INSERT INTO ledger_entries`).
+					WithArgs(args.entry.SenderId, args.entry.ReceiverId, args.entry.Amount, args.entry.Kind, args.entry.Reason, args.entry.CreatedBy, args.entry.PendingTransferId).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			ledgerRepoMock := NewLedgerRepo(postgresMock)
+
+			got, err := ledgerRepoMock.Append(tc.args.ctx, tc.args.entry)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLedgerRepo_GetSentSince(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		senderId int
+		since    time.Time
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	since := time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         entity.TransferStats
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:      context.Background(),
+				senderId: 1,
+				since:    since,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"count", "sum"}).AddRow(3, 120)
+
+				m.ExpectQuery(`SELECT COUNT\(\*\), COALESCE\(SUM\(amount\), 0\) FROM ledger_entries WHERE kind IN \(\$1,\$2\) AND sender_id = \$3 AND created_at >= \$4`).
+					WithArgs(entity.LedgerKindTransfer, entity.LedgerKindEscrowHold, args.senderId, args.since).
+					WillReturnRows(rows)
+			},
+			want:    entity.TransferStats{Count: 3, Amount: 120},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:      context.Background(),
+				senderId: 1,
+				since:    since,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`SELECT COUNT`).
+					WithArgs(entity.LedgerKindTransfer, entity.LedgerKindEscrowHold, args.senderId, args.since).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			ledgerRepoMock := NewLedgerRepo(postgresMock)
+
+			got, err := ledgerRepoMock.GetSentSince(tc.args.ctx, tc.args.senderId, tc.args.since)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLedgerRepo_GetReceivedSince(t *testing.T) {
+	type args struct {
+		ctx        context.Context
+		receiverId int
+		since      time.Time
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	since := time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         entity.TransferStats
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:        context.Background(),
+				receiverId: 1,
+				since:      since,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"count", "sum"}).AddRow(2, 300)
+
+				m.ExpectQuery(`SELECT COUNT\(\*\), COALESCE\(SUM\(amount\), 0\) FROM ledger_entries WHERE kind IN \(\$1,\$2\) AND receiver_id = \$3 AND created_at >= \$4`).
+					WithArgs(entity.LedgerKindTransfer, entity.LedgerKindEscrowRelease, args.receiverId, args.since).
+					WillReturnRows(rows)
+			},
+			want:    entity.TransferStats{Count: 2, Amount: 300},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:        context.Background(),
+				receiverId: 1,
+				since:      since,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`SELECT COUNT`).
+					WithArgs(entity.LedgerKindTransfer, entity.LedgerKindEscrowRelease, args.receiverId, args.since).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			ledgerRepoMock := NewLedgerRepo(postgresMock)
+
+			got, err := ledgerRepoMock.GetReceivedSince(tc.args.ctx, tc.args.receiverId, tc.args.since)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLedgerRepo_AppendBatch(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		entries []entity.LedgerEntry
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	systemId, firstId, secondId := 1, 2, 3
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx: context.Background(),
+				entries: []entity.LedgerEntry{
+					{SenderId: &systemId, ReceiverId: &firstId, Amount: 1000, Kind: entity.LedgerKindAllowance},
+					{SenderId: &systemId, ReceiverId: &secondId, Amount: 1000, Kind: entity.LedgerKindAllowance},
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				first, second := args.entries[0], args.entries[1]
+				m.ExpectExec(`INSERT INTO ledger_entries \(sender_id, receiver_id, amount, kind, reason, created_by, pending_transfer_id\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7\),\(\$8,\$9,\$10,\$11,\$12,\$13,\$14\)`).
+					WithArgs(
+						first.SenderId, first.ReceiverId, first.Amount, first.Kind, first.Reason, first.CreatedBy, first.PendingTransferId,
+						second.SenderId, second.ReceiverId, second.Amount, second.Kind, second.Reason, second.CreatedBy, second.PendingTransferId,
+					).
+					WillReturnResult(pgxmock.NewResult("INSERT", 2))
+			},
+			wantErr: false,
+		},
+		{
+			name: "no entries",
+			args: args{
+				ctx:     context.Background(),
+				entries: nil,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {},
+			wantErr:      false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx: context.Background(),
+				entries: []entity.LedgerEntry{
+					{SenderId: &firstId, Amount: 200, Kind: entity.LedgerKindExpiration},
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				entry := args.entries[0]
+				m.ExpectExec(`INSERT INTO ledger_entries`).
+					WithArgs(entry.SenderId, (*int)(nil), entry.Amount, entry.Kind, entry.Reason, entry.CreatedBy, entry.PendingTransferId).
+					WillReturnError(errors.New("some exec error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			ledgerRepoMock := NewLedgerRepo(postgresMock)
+
+			err := ledgerRepoMock.AppendBatch(tc.args.ctx, tc.args.entries)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLedgerRepo_GetExpirable(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		before time.Time
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	before := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	grantedAt := before.AddDate(0, -1, 0)
+	systemId, firstId, secondId := 1, 2, 5
+
+	expectUsers := func(m pgxmock.PgxPoolIface, args args) *pgxmock.ExpectedQuery {
+		return m.ExpectQuery(`SELECT u.id, u.balance FROM \(SELECT COALESCE\(receiver_id, sender_id\) AS user_id, COALESCE\(SUM\(amount\) FILTER \(WHERE kind IN \(\$1, \$2\) AND created_at < \$3\), 0\) AS granted, COALESCE\(SUM\(amount\) FILTER \(WHERE kind = \$4\), 0\) AS expired FROM ledger_entries WHERE \(receiver_id IS NOT NULL OR kind = \$5\) GROUP BY user_id\) AS l JOIN users u ON l.user_id = u.id WHERE u.role <> \$6 AND l.granted > l.expired ORDER BY u.id`).
+			WithArgs(entity.LedgerKindMint, entity.LedgerKindAllowance, args.before, entity.LedgerKindExpiration, entity.LedgerKindExpiration, entity.RoleSystem)
+	}
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         []entity.Expiration
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:    context.Background(),
+				before: before,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				expectUsers(m, args).WillReturnRows(pgxmock.NewRows([]string{"id", "balance"}).
+					AddRow(firstId, 800).
+					AddRow(secondId, 1000))
+
+				m.ExpectQuery(`SELECT id, created_at, sender_id, receiver_id, amount, kind FROM ledger_entries WHERE sender_id = ANY\(\$1\) OR receiver_id = ANY\(\$2\) ORDER BY id`).
+					WithArgs([]int{firstId, secondId}, []int{firstId, secondId}).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "sender_id", "receiver_id", "amount", "kind"}).
+						AddRow(10, grantedAt, &systemId, &firstId, 300, entity.LedgerKindAllowance).
+						AddRow(11, grantedAt, &systemId, &secondId, 300, entity.LedgerKindAllowance).
+						AddRow(12, before, &secondId, &firstId, 100, entity.LedgerKindTransfer))
+			},
+			want: []entity.Expiration{
+				{UserId: firstId, Balance: 800, Before: before, Entries: []entity.LedgerEntry{
+					{Id: 10, CreatedAt: grantedAt, SenderId: &systemId, ReceiverId: &firstId, Amount: 300, Kind: entity.LedgerKindAllowance},
+					{Id: 12, CreatedAt: before, SenderId: &secondId, ReceiverId: &firstId, Amount: 100, Kind: entity.LedgerKindTransfer},
+				}},
+				{UserId: secondId, Balance: 1000, Before: before, Entries: []entity.LedgerEntry{
+					{Id: 11, CreatedAt: grantedAt, SenderId: &systemId, ReceiverId: &secondId, Amount: 300, Kind: entity.LedgerKindAllowance},
+					{Id: 12, CreatedAt: before, SenderId: &secondId, ReceiverId: &firstId, Amount: 100, Kind: entity.LedgerKindTransfer},
+				}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "nothing to expire",
+			args: args{
+				ctx:    context.Background(),
+				before: before,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				expectUsers(m, args).WillReturnRows(pgxmock.NewRows([]string{"id", "balance"}))
+			},
+			want:    []entity.Expiration{},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:    context.Background(),
+				before: before,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				expectUsers(m, args).WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "entries error",
+			args: args{
+				ctx:    context.Background(),
+				before: before,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				expectUsers(m, args).WillReturnRows(pgxmock.NewRows([]string{"id", "balance"}).AddRow(firstId, 800))
+				m.ExpectQuery(`SELECT id, created_at`).
+					WithArgs([]int{firstId}, []int{firstId}).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			ledgerRepoMock := NewLedgerRepo(postgresMock)
+
+			got, err := ledgerRepoMock.GetExpirable(tc.args.ctx, tc.args.before)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"time"
+)
+
+type LoginAttemptRepo struct {
+	*postgres.Postgres
+}
+
+func NewLoginAttemptRepo(pg *postgres.Postgres) *LoginAttemptRepo {
+	return &LoginAttemptRepo{pg}
+}
+
+// GetLockedUntil returns the latest active lockout among subjects or zero time.
+func (r *LoginAttemptRepo) GetLockedUntil(ctx context.Context, subjects []string) (time.Time, error) {
+	sql, args, _ := r.Builder.
+		Select("max(locked_until)").
+		From("login_attempts").
+		Where("subject = ANY(?)", subjects).
+		Where("locked_until > now()").
+		ToSql()
+
+	var lockedUntil *time.Time
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(&lockedUntil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("LoginAttemptRepo.GetLockedUntil - QueryRow: %w", err)
+	}
+
+	if lockedUntil == nil {
+		return time.Time{}, nil
+	}
+
+	return *lockedUntil, nil
+}
+
+// RegisterFailure increments the failure counter and returns its new value.
+// The counter starts over if the previous failure happened before resetBefore.
+func (r *LoginAttemptRepo) RegisterFailure(ctx context.Context, subject string, resetBefore time.Time) (int, error) {
+	sql, args, _ := r.Builder.
+		Insert("login_attempts").
+		Columns("subject, failures, last_failure_at").
+		Values(subject, 1, squirrel.Expr("now()")).
+		Suffix(`ON CONFLICT (subject) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failure_at < ? THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failure_at = now()
+			RETURNING failures`, resetBefore).
+		ToSql()
+
+	var failures int
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(&failures)
+	if err != nil {
+		return 0, fmt.Errorf("LoginAttemptRepo.RegisterFailure - QueryRow: %w", err)
+	}
+
+	return failures, nil
+}
+
+func (r *LoginAttemptRepo) Lock(ctx context.Context, subject string, until time.Time) error {
+	sql, args, _ := r.Builder.
+		Update("login_attempts").
+		Set("locked_until", until).
+		Where(squirrel.Eq{"subject": subject}).
+		ToSql()
+
+	_, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("LoginAttemptRepo.Lock - Exec: %w", err)
+	}
+
+	return nil
+}
+
+// Reset removes failures and lockout of the subject.
+func (r *LoginAttemptRepo) Reset(ctx context.Context, subject string) error {
+	sql, args, _ := r.Builder.
+		Delete("login_attempts").
+		Where(squirrel.Eq{"subject": subject}).
+		ToSql()
+
+	_, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("LoginAttemptRepo.Reset - Exec: %w", err)
+	}
+
+	return nil
+}
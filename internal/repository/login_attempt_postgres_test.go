@@ -0,0 +1,321 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLoginAttemptRepo_GetLockedUntil(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		subjects []string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	lockedUntil := time.Now().Add(time.Minute)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         time.Time
+		wantErr      bool
+	}{
+		{
+			name: "locked",
+			args: args{
+				ctx:      context.Background(),
+				subjects: []string{"user:name", "ip:127.0.0.1"},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"max"}).
+					AddRow(&lockedUntil)
+
+				m.ExpectQuery(`SELECT max\(locked_until\) FROM login_attempts WHERE subject = ANY\(\$1\) AND locked_until > now\(\)`).
+					WithArgs(args.subjects).
+					WillReturnRows(rows)
+			},
+			want:    lockedUntil,
+			wantErr: false,
+		},
+		{
+			name: "not locked",
+			args: args{
+				ctx:      context.Background(),
+				subjects: []string{"user:name"},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"max"}).
+					AddRow(nil)
+
+				m.ExpectQuery(`SELECT max\(locked_until\) FROM login_attempts`).
+					WithArgs(args.subjects).
+					WillReturnRows(rows)
+			},
+			want:    time.Time{},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:      context.Background(),
+				subjects: []string{"user:name"},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`SELECT max\(locked_until\) FROM login_attempts`).
+					WithArgs(args.subjects).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			loginAttemptRepoMock := NewLoginAttemptRepo(postgresMock)
+
+			got, err := loginAttemptRepoMock.GetLockedUntil(tc.args.ctx, tc.args.subjects)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLoginAttemptRepo_RegisterFailure(t *testing.T) {
+	type args struct {
+		ctx         context.Context
+		subject     string
+		resetBefore time.Time
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         int
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:         context.Background(),
+				subject:     "user:name",
+				resetBefore: time.Now().Add(-15 * time.Minute),
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"failures"}).
+					AddRow(3)
+
+				m.ExpectQuery(`INSERT INTO login_attempts \(subject, failures, last_failure_at\) VALUES \(\$1,\$2,now\(\)\) ON CONFLICT \(subject\) DO UPDATE SET`).
+					WithArgs(args.subject, 1, args.resetBefore).
+					WillReturnRows(rows)
+			},
+			want:    3,
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:         context.Background(),
+				subject:     "user:name",
+				resetBefore: time.Now().Add(-15 * time.Minute),
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`INSERT INTO login_attempts`).
+					WithArgs(args.subject, 1, args.resetBefore).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			loginAttemptRepoMock := NewLoginAttemptRepo(postgresMock)
+
+			got, err := loginAttemptRepoMock.RegisterFailure(tc.args.ctx, tc.args.subject, tc.args.resetBefore)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLoginAttemptRepo_Lock(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		subject string
+		until   time.Time
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:     context.Background(),
+				subject: "user:name",
+				until:   time.Now().Add(time.Minute),
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE login_attempts SET locked_until`).
+					WithArgs(args.until, args.subject).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:     context.Background(),
+				subject: "user:name",
+				until:   time.Now().Add(time.Minute),
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE login_attempts SET locked_until`).
+					WithArgs(args.until, args.subject).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			loginAttemptRepoMock := NewLoginAttemptRepo(postgresMock)
+
+			err := loginAttemptRepoMock.Lock(tc.args.ctx, tc.args.subject, tc.args.until)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLoginAttemptRepo_Reset(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		subject string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:     context.Background(),
+				subject: "user:name",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`DELETE FROM login_attempts WHERE subject = \$1`).
+					WithArgs(args.subject).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:     context.Background(),
+				subject: "user:name",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`DELETE FROM login_attempts`).
+					WithArgs(args.subject).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			loginAttemptRepoMock := NewLoginAttemptRepo(postgresMock)
+
+			err := loginAttemptRepoMock.Reset(tc.args.ctx, tc.args.subject)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
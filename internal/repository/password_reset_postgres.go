@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+)
+
+type PasswordResetRepo struct {
+	*postgres.Postgres
+}
+
+func NewPasswordResetRepo(pg *postgres.Postgres) *PasswordResetRepo {
+	return &PasswordResetRepo{pg}
+}
+
+func (r *PasswordResetRepo) Create(ctx context.Context, reset entity.PasswordReset) (int, error) {
+	sql, args, _ := r.Builder.
+		Insert("password_resets").
+		Columns("user_id, token_hash, created_by, expires_at").
+		Values(reset.UserId, reset.TokenHash, reset.CreatedBy, reset.ExpiresAt).
+		Suffix("RETURNING id").
+		ToSql()
+
+	var id int
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("PasswordResetRepo.Create - QueryRow: %w", err)
+	}
+
+	return id, nil
+}
+
+// Use marks a valid reset token as used and returns the user it was issued for.
+// ErrNotFound means the token is unknown, expired or already used.
+func (r *PasswordResetRepo) Use(ctx context.Context, tokenHash string) (int, error) {
+	sql, args, _ := r.Builder.
+		Update("password_resets").
+		Set("used_at", squirrel.Expr("now()")).
+		Where(squirrel.And{
+			squirrel.Eq{"token_hash": tokenHash, "used_at": nil},
+			squirrel.Expr("expires_at > now()"),
+		}).
+		Suffix("RETURNING user_id").
+		ToSql()
+
+	var userId int
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(&userId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrNotFound
+		}
+		return 0, fmt.Errorf("PasswordResetRepo.Use - QueryRow: %w", err)
+	}
+
+	return userId, nil
+}
+
+// InvalidateByUserId marks all unused reset tokens of the user as used, so only the latest one works.
+func (r *PasswordResetRepo) InvalidateByUserId(ctx context.Context, userId int) error {
+	sql, args, _ := r.Builder.
+		Update("password_resets").
+		Set("used_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"user_id": userId, "used_at": nil}).
+		ToSql()
+
+	_, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("PasswordResetRepo.InvalidateByUserId - Exec: %w", err)
+	}
+
+	return nil
+}
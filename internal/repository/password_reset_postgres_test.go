@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPasswordResetRepo_Create(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		reset entity.PasswordReset
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         int
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:   context.Background(),
+				reset: entity.PasswordReset{UserId: 2, TokenHash: "hash", CreatedBy: 1, ExpiresAt: expiresAt},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id"}).
+					AddRow(5)
+
+				m.ExpectQuery(`INSERT INTO password_resets \(user_id, token_hash, created_by, expires_at\)`).
+					WithArgs(args.reset.UserId, args.reset.TokenHash, args.reset.CreatedBy, args.reset.ExpiresAt).
+					WillReturnRows(rows)
+			},
+			want:    5,
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:   context.Background(),
+				reset: entity.PasswordReset{UserId: 2, TokenHash: "hash", CreatedBy: 1, ExpiresAt: expiresAt},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`INSERT INTO password_resets`).
+					WithArgs(args.reset.UserId, args.reset.TokenHash, args.reset.CreatedBy, args.reset.ExpiresAt).
+					WillReturnError(errors.New("some query error"))
+			},
+			want:    0,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			passwordResetRepoMock := NewPasswordResetRepo(postgresMock)
+
+			got, err := passwordResetRepoMock.Create(tc.args.ctx, tc.args.reset)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPasswordResetRepo_Use(t *testing.T) {
+	type args struct {
+		ctx       context.Context
+		tokenHash string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         int
+		wantErr      error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:       context.Background(),
+				tokenHash: "hash",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"user_id"}).
+					AddRow(2)
+
+				m.ExpectQuery(`UPDATE password_resets SET used_at = now\(\) WHERE \(token_hash = \$1 AND used_at IS NULL AND expires_at > now\(\)\) RETURNING user_id`).
+					WithArgs(args.tokenHash).
+					WillReturnRows(rows)
+			},
+			want:    2,
+			wantErr: nil,
+		},
+		{
+			name: "unknown, expired or used",
+			args: args{
+				ctx:       context.Background(),
+				tokenHash: "hash",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`UPDATE password_resets`).
+					WithArgs(args.tokenHash).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			want:    0,
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:       context.Background(),
+				tokenHash: "hash",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`UPDATE password_resets`).
+					WithArgs(args.tokenHash).
+					WillReturnError(errors.New("some query error"))
+			},
+			want:    0,
+			wantErr: errors.New("some query error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			passwordResetRepoMock := NewPasswordResetRepo(postgresMock)
+
+			got, err := passwordResetRepoMock.Use(tc.args.ctx, tc.args.tokenHash)
+			if tc.wantErr != nil {
+				assert.Error(t, err)
+				if errors.Is(tc.wantErr, ErrNotFound) {
+					assert.ErrorIs(t, err, ErrNotFound)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPasswordResetRepo_InvalidateByUserId(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		userId int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:    context.Background(),
+				userId: 2,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE password_resets SET used_at = now\(\) WHERE used_at IS NULL AND user_id = \$1`).
+					WithArgs(args.userId).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:    context.Background(),
+				userId: 2,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE password_resets`).
+					WithArgs(args.userId).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			passwordResetRepoMock := NewPasswordResetRepo(postgresMock)
+
+			err := passwordResetRepoMock.InvalidateByUserId(tc.args.ctx, tc.args.userId)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+)
+
+type PaymentRequestRepo struct {
+	*postgres.Postgres
+}
+
+func NewPaymentRequestRepo(pg *postgres.Postgres) *PaymentRequestRepo {
+	return &PaymentRequestRepo{pg}
+}
+
+func (r *PaymentRequestRepo) Create(ctx context.Context, request entity.PaymentRequest) (entity.PaymentRequest, error) {
+	sql, args, _ := r.Builder.
+		Insert("payment_requests").
+		Columns("requester_id, payer_id, amount, note").
+		Values(request.RequesterId, request.PayerId, request.Amount, request.Note).
+		Suffix("RETURNING id, status, created_at").
+		ToSql()
+
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(&request.Id, &request.Status, &request.CreatedAt)
+	if err != nil {
+		return entity.PaymentRequest{}, fmt.Errorf("PaymentRequestRepo.Create - QueryRow: %w", err)
+	}
+
+	return request, nil
+}
+
+func (r *PaymentRequestRepo) GetByRequesterId(ctx context.Context, requesterId int) ([]entity.PaymentRequest, error) {
+	return r.getRequests(ctx, "PaymentRequestRepo.GetByRequesterId", squirrel.Eq{"pr.requester_id": requesterId})
+}
+
+func (r *PaymentRequestRepo) GetByPayerId(ctx context.Context, payerId int) ([]entity.PaymentRequest, error) {
+	return r.getRequests(ctx, "PaymentRequestRepo.GetByPayerId", squirrel.Eq{"pr.payer_id": payerId})
+}
+
+// MarkPaid sets the status of a pending request to the payer to paid. The returned request has no PayerName.
+// It returns ErrNotFound if there is no such request.
+func (r *PaymentRequestRepo) MarkPaid(ctx context.Context, id, payerId int) (entity.PaymentRequest, error) {
+	sql, args, _ := r.Builder.
+		Update("payment_requests pr").
+		Set("status", entity.PaymentRequestStatusPaid).
+		Set("paid_at", squirrel.Expr("now()")).
+		From("users u").
+		Where(squirrel.Eq{"pr.id": id, "pr.payer_id": payerId, "pr.status": entity.PaymentRequestStatusPending}).
+		Where("u.id = pr.requester_id").
+		Suffix("RETURNING pr.id, pr.requester_id, u.name, pr.payer_id, pr.amount, pr.note, pr.status, pr.created_at, pr.paid_at").
+		ToSql()
+
+	var request entity.PaymentRequest
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(
+		&request.Id,
+		&request.RequesterId,
+		&request.RequesterName,
+		&request.PayerId,
+		&request.Amount,
+		&request.Note,
+		&request.Status,
+		&request.CreatedAt,
+		&request.PaidAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.PaymentRequest{}, ErrNotFound
+		}
+		return entity.PaymentRequest{}, fmt.Errorf("PaymentRequestRepo.MarkPaid - QueryRow: %w", err)
+	}
+
+	return request, nil
+}
+
+func (r *PaymentRequestRepo) getRequests(ctx context.Context, method string, where squirrel.Eq) ([]entity.PaymentRequest, error) {
+	sql, args, _ := r.Builder.
+		Select("pr.id, pr.requester_id, ru.name, pr.payer_id, pu.name, pr.amount, pr.note, pr.status, pr.created_at, pr.paid_at").
+		From("payment_requests pr").
+		Join("users ru ON pr.requester_id = ru.id").
+		Join("users pu ON pr.payer_id = pu.id").
+		Where(where).
+		OrderBy("pr.id").
+		ToSql()
+
+	rows, err := r.GetQueryRunner(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s - Query: %w", method, err)
+	}
+
+	requests, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.PaymentRequest, error) {
+		var request entity.PaymentRequest
+		err := row.Scan(
+			&request.Id,
+			&request.RequesterId,
+			&request.RequesterName,
+			&request.PayerId,
+			&request.PayerName,
+			&request.Amount,
+			&request.Note,
+			&request.Status,
+			&request.CreatedAt,
+			&request.PaidAt,
+		)
+		return request, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s - CollectRows: %w", method, err)
+	}
+
+	return requests, nil
+}
@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPaymentRequestRepo_Create(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		request entity.PaymentRequest
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	createdAt := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         entity.PaymentRequest
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:     context.Background(),
+				request: entity.PaymentRequest{RequesterId: 1, PayerId: 2, Amount: 50, Note: "gift"},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "status", "created_at"}).
+					AddRow(3, entity.PaymentRequestStatusPending, createdAt)
+
+				m.ExpectQuery(`INSERT INTO payment_requests \(requester_id, payer_id, amount, note\) VALUES \(\$1,\$2,\$3,\$4\) RETURNING id, status, created_at`).
+					WithArgs(args.request.RequesterId, args.request.PayerId, args.request.Amount, args.request.Note).
+					WillReturnRows(rows)
+			},
+			want: entity.PaymentRequest{
+				Id:          3,
+				RequesterId: 1,
+				PayerId:     2,
+				Amount:      50,
+				Note:        "gift",
+				Status:      entity.PaymentRequestStatusPending,
+				CreatedAt:   createdAt,
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:     context.Background(),
+				request: entity.PaymentRequest{RequesterId: 1, PayerId: 2, Amount: 50},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`INSERT INTO payment_requests`).
+					WithArgs(args.request.RequesterId, args.request.PayerId, args.request.Amount, args.request.Note).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			paymentRequestRepoMock := NewPaymentRequestRepo(postgresMock)
+
+			got, err := paymentRequestRepoMock.Create(tc.args.ctx, tc.args.request)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPaymentRequestRepo_GetByPayerId(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		payerId int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	createdAt := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	paidAt := createdAt.Add(time.Hour)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         []entity.PaymentRequest
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:     context.Background(),
+				payerId: 2,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "requester_id", "name", "payer_id", "name", "amount", "note", "status", "created_at", "paid_at"}).
+					AddRow(3, 1, "alice", 2, "bob", 50, "gift", entity.PaymentRequestStatusPending, createdAt, (*time.Time)(nil)).
+					AddRow(5, 4, "carol", 2, "bob", 20, "", entity.PaymentRequestStatusPaid, createdAt, &paidAt)
+
+				m.ExpectQuery(`SELECT pr.id, pr.requester_id, ru.name, pr.payer_id, pu.name, pr.amount, pr.note, pr.status, pr.created_at, pr.paid_at FROM payment_requests pr JOIN users ru ON pr.requester_id = ru.id JOIN users pu ON pr.payer_id = pu.id WHERE pr.payer_id = \$1 ORDER BY pr.id`).
+					WithArgs(args.payerId).
+					WillReturnRows(rows)
+			},
+			want: []entity.PaymentRequest{
+				{
+					Id:            3,
+					RequesterId:   1,
+					RequesterName: "alice",
+					PayerId:       2,
+					PayerName:     "bob",
+					Amount:        50,
+					Note:          "gift",
+					Status:        entity.PaymentRequestStatusPending,
+					CreatedAt:     createdAt,
+				},
+				{
+					Id:            5,
+					RequesterId:   4,
+					RequesterName: "carol",
+					PayerId:       2,
+					PayerName:     "bob",
+					Amount:        20,
+					Status:        entity.PaymentRequestStatusPaid,
+					CreatedAt:     createdAt,
+					PaidAt:        &paidAt,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:     context.Background(),
+				payerId: 2,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`SELECT (.+) FROM payment_requests pr`).
+					WithArgs(args.payerId).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			paymentRequestRepoMock := NewPaymentRequestRepo(postgresMock)
+
+			got, err := paymentRequestRepoMock.GetByPayerId(tc.args.ctx, tc.args.payerId)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPaymentRequestRepo_MarkPaid(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		id      int
+		payerId int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	createdAt := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	paidAt := createdAt.Add(time.Hour)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         entity.PaymentRequest
+		wantErr      error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:     context.Background(),
+				id:      3,
+				payerId: 2,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "requester_id", "name", "payer_id", "amount", "note", "status", "created_at", "paid_at"}).
+					AddRow(3, 1, "alice", 2, 50, "gift", entity.PaymentRequestStatusPaid, createdAt, &paidAt)
+
+				m.ExpectQuery(`UPDATE payment_requests pr SET status = \$1, paid_at = now\(\) FROM users u WHERE pr.id = \$2 AND pr.payer_id = \$3 AND pr.status = \$4 AND u.id = pr.requester_id RETURNING pr.id, pr.requester_id, u.name, pr.payer_id, pr.amount, pr.note, pr.status, pr.created_at, pr.paid_at`).
+					WithArgs(entity.PaymentRequestStatusPaid, args.id, args.payerId, entity.PaymentRequestStatusPending).
+					WillReturnRows(rows)
+			},
+			want: entity.PaymentRequest{
+				Id:            3,
+				RequesterId:   1,
+				RequesterName: "alice",
+				PayerId:       2,
+				Amount:        50,
+				Note:          "gift",
+				Status:        entity.PaymentRequestStatusPaid,
+				CreatedAt:     createdAt,
+				PaidAt:        &paidAt,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			args: args{
+				ctx:     context.Background(),
+				id:      3,
+				payerId: 2,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`UPDATE payment_requests pr`).
+					WithArgs(entity.PaymentRequestStatusPaid, args.id, args.payerId, entity.PaymentRequestStatusPending).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:     context.Background(),
+				id:      3,
+				payerId: 2,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`UPDATE payment_requests pr`).
+					WithArgs(entity.PaymentRequestStatusPaid, args.id, args.payerId, entity.PaymentRequestStatusPending).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: errors.New("some query error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			paymentRequestRepoMock := NewPaymentRequestRepo(postgresMock)
+
+			got, err := paymentRequestRepoMock.MarkPaid(tc.args.ctx, tc.args.id, tc.args.payerId)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
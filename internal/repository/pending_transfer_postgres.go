@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"time"
+)
+
+type PendingTransferRepo struct {
+	*postgres.Postgres
+}
+
+func NewPendingTransferRepo(pg *postgres.Postgres) *PendingTransferRepo {
+	return &PendingTransferRepo{pg}
+}
+
+func (r *PendingTransferRepo) Create(ctx context.Context, transfer entity.PendingTransfer) (entity.PendingTransfer, error) {
+	sql, args, _ := r.Builder.
+		Insert("pending_transfers").
+		Columns("sender_id, receiver_id, amount, expires_at").
+		Values(transfer.SenderId, transfer.ReceiverId, transfer.Amount, transfer.ExpiresAt).
+		Suffix("RETURNING id, status, created_at").
+		ToSql()
+
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(&transfer.Id, &transfer.Status, &transfer.CreatedAt)
+	if err != nil {
+		return entity.PendingTransfer{}, fmt.Errorf("PendingTransferRepo.Create - QueryRow: %w", err)
+	}
+
+	return transfer, nil
+}
+
+// Resolve sets the status of a pending transfer to the receiver that has not expired yet.
+// It returns ErrNotFound if there is no such transfer.
+func (r *PendingTransferRepo) Resolve(ctx context.Context, id, receiverId int, status string) (entity.PendingTransfer, error) {
+	sql, args, _ := r.Builder.
+		Update("pending_transfers").
+		Set("status", status).
+		Set("resolved_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": id, "receiver_id": receiverId, "status": entity.PendingTransferStatusPending}).
+		Where("expires_at > now()").
+		Suffix("RETURNING id, sender_id, receiver_id, amount, status, created_at, expires_at, resolved_at").
+		ToSql()
+
+	transfer, err := scanPendingTransfer(r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.PendingTransfer{}, ErrNotFound
+		}
+		return entity.PendingTransfer{}, fmt.Errorf("PendingTransferRepo.Resolve - QueryRow: %w", err)
+	}
+
+	return transfer, nil
+}
+
+// ExpireDue marks the pending transfers that expired before now and returns them.
+func (r *PendingTransferRepo) ExpireDue(ctx context.Context, now time.Time) ([]entity.PendingTransfer, error) {
+	sql, args, _ := r.Builder.
+		Update("pending_transfers").
+		Set("status", entity.PendingTransferStatusExpired).
+		Set("resolved_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"status": entity.PendingTransferStatusPending}).
+		Where(squirrel.LtOrEq{"expires_at": now}).
+		Suffix("RETURNING id, sender_id, receiver_id, amount, status, created_at, expires_at, resolved_at").
+		ToSql()
+
+	rows, err := r.GetQueryRunner(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("PendingTransferRepo.ExpireDue - Query: %w", err)
+	}
+
+	transfers, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.PendingTransfer, error) {
+		return scanPendingTransfer(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("PendingTransferRepo.ExpireDue - CollectRows: %w", err)
+	}
+
+	return transfers, nil
+}
+
+func scanPendingTransfer(row pgx.Row) (entity.PendingTransfer, error) {
+	var transfer entity.PendingTransfer
+	err := row.Scan(
+		&transfer.Id,
+		&transfer.SenderId,
+		&transfer.ReceiverId,
+		&transfer.Amount,
+		&transfer.Status,
+		&transfer.CreatedAt,
+		&transfer.ExpiresAt,
+		&transfer.ResolvedAt,
+	)
+	return transfer, err
+}
@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var pendingTransferColumns = []string{"id", "sender_id", "receiver_id", "amount", "status", "created_at", "expires_at", "resolved_at"}
+
+func TestPendingTransferRepo_Create(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		transfer entity.PendingTransfer
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	createdAt := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(72 * time.Hour)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         entity.PendingTransfer
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:      context.Background(),
+				transfer: entity.PendingTransfer{SenderId: 1, ReceiverId: 2, Amount: 100, ExpiresAt: expiresAt},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "status", "created_at"}).
+					AddRow(4, entity.PendingTransferStatusPending, createdAt)
+
+				m.ExpectQuery(`INSERT INTO pending_transfers \(sender_id, receiver_id, amount, expires_at\) VALUES \(\$1,\$2,\$3,\$4\) RETURNING id, status, created_at`).
+					WithArgs(args.transfer.SenderId, args.transfer.ReceiverId, args.transfer.Amount, args.transfer.ExpiresAt).
+					WillReturnRows(rows)
+			},
+			want: entity.PendingTransfer{
+				Id:         4,
+				SenderId:   1,
+				ReceiverId: 2,
+				Amount:     100,
+				Status:     entity.PendingTransferStatusPending,
+				CreatedAt:  createdAt,
+				ExpiresAt:  expiresAt,
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:      context.Background(),
+				transfer: entity.PendingTransfer{SenderId: 1, ReceiverId: 2, Amount: 100, ExpiresAt: expiresAt},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`INSERT INTO pending_transfers`).
+					WithArgs(args.transfer.SenderId, args.transfer.ReceiverId, args.transfer.Amount, args.transfer.ExpiresAt).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			pendingTransferRepoMock := NewPendingTransferRepo(postgresMock)
+
+			got, err := pendingTransferRepoMock.Create(tc.args.ctx, tc.args.transfer)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPendingTransferRepo_Resolve(t *testing.T) {
+	type args struct {
+		ctx        context.Context
+		id         int
+		receiverId int
+		status     string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	createdAt := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(72 * time.Hour)
+	resolvedAt := createdAt.Add(time.Hour)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         entity.PendingTransfer
+		wantErr      error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:        context.Background(),
+				id:         4,
+				receiverId: 2,
+				status:     entity.PendingTransferStatusAccepted,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows(pendingTransferColumns).
+					AddRow(4, 1, 2, 100, args.status, createdAt, expiresAt, &resolvedAt)
+
+				m.ExpectQuery(`UPDATE pending_transfers SET status = \$1, resolved_at = now\(\) WHERE id = \$2 AND receiver_id = \$3 AND status = \$4 AND expires_at > now\(\) RETURNING id, sender_id, receiver_id, amount, status, created_at, expires_at, resolved_at`).
+					WithArgs(args.status, args.id, args.receiverId, entity.PendingTransferStatusPending).
+					WillReturnRows(rows)
+			},
+			want: entity.PendingTransfer{
+				Id:         4,
+				SenderId:   1,
+				ReceiverId: 2,
+				Amount:     100,
+				Status:     entity.PendingTransferStatusAccepted,
+				CreatedAt:  createdAt,
+				ExpiresAt:  expiresAt,
+				ResolvedAt: &resolvedAt,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			args: args{
+				ctx:        context.Background(),
+				id:         4,
+				receiverId: 3,
+				status:     entity.PendingTransferStatusDeclined,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`UPDATE pending_transfers`).
+					WithArgs(args.status, args.id, args.receiverId, entity.PendingTransferStatusPending).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:        context.Background(),
+				id:         4,
+				receiverId: 2,
+				status:     entity.PendingTransferStatusAccepted,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`UPDATE pending_transfers`).
+					WithArgs(args.status, args.id, args.receiverId, entity.PendingTransferStatusPending).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: errors.New("some query error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			pendingTransferRepoMock := NewPendingTransferRepo(postgresMock)
+
+			got, err := pendingTransferRepoMock.Resolve(tc.args.ctx, tc.args.id, tc.args.receiverId, tc.args.status)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPendingTransferRepo_ExpireDue(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		now time.Time
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	now := time.Date(2025, 3, 17, 12, 0, 0, 0, time.UTC)
+	createdAt := now.Add(-72 * time.Hour)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         []entity.PendingTransfer
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx: context.Background(),
+				now: now,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows(pendingTransferColumns).
+					AddRow(4, 1, 2, 100, entity.PendingTransferStatusExpired, createdAt, now, &now).
+					AddRow(5, 3, 2, 20, entity.PendingTransferStatusExpired, createdAt, now, &now)
+
+				m.ExpectQuery(`UPDATE pending_transfers SET status = \$1, resolved_at = now\(\) WHERE status = \$2 AND expires_at <= \$3 RETURNING id, sender_id, receiver_id, amount, status, created_at, expires_at, resolved_at`).
+					WithArgs(entity.PendingTransferStatusExpired, entity.PendingTransferStatusPending, args.now).
+					WillReturnRows(rows)
+			},
+			want: []entity.PendingTransfer{
+				{Id: 4, SenderId: 1, ReceiverId: 2, Amount: 100, Status: entity.PendingTransferStatusExpired, CreatedAt: createdAt, ExpiresAt: now, ResolvedAt: &now},
+				{Id: 5, SenderId: 3, ReceiverId: 2, Amount: 20, Status: entity.PendingTransferStatusExpired, CreatedAt: createdAt, ExpiresAt: now, ResolvedAt: &now},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx: context.Background(),
+				now: now,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`UPDATE pending_transfers`).
+					WithArgs(entity.PendingTransferStatusExpired, entity.PendingTransferStatusPending, args.now).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			pendingTransferRepoMock := NewPendingTransferRepo(postgresMock)
+
+			got, err := pendingTransferRepoMock.ExpireDue(tc.args.ctx, tc.args.now)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+)
+
+type PurchaseRepo struct {
+	*postgres.Postgres
+}
+
+func NewPurchaseRepo(pg *postgres.Postgres) *PurchaseRepo {
+	return &PurchaseRepo{pg}
+}
+
+func (r *PurchaseRepo) Create(ctx context.Context, purchase entity.Purchase) (int, error) {
+	sql, args, _ := r.Builder.
+		Insert("purchases").
+		Columns("ledger_entry_id, user_id, item_id, quantity, price").
+		Values(purchase.LedgerEntryId, purchase.UserId, purchase.ItemId, purchase.Quantity, purchase.Price).
+		Suffix("RETURNING id").
+		ToSql()
+
+	var id int
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("PurchaseRepo.Create - QueryRow: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetForUpdate finds the line of the user's purchase and locks it until the end of the transaction.
+func (r *PurchaseRepo) GetForUpdate(ctx context.Context, userId, ledgerEntryId int, itemName string) (entity.Purchase, error) {
+	sql, args, _ := r.Builder.
+		Select("p.id, p.ledger_entry_id, p.user_id, p.item_id, i.name, p.quantity, p.price, p.created_at").
+		From("purchases p").
+		Join("items i ON p.item_id = i.id").
+		Where(squirrel.Eq{"p.user_id": userId, "p.ledger_entry_id": ledgerEntryId, "i.name": itemName}).
+		Suffix("FOR UPDATE OF p").
+		ToSql()
+
+	var purchase entity.Purchase
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(
+		&purchase.Id,
+		&purchase.LedgerEntryId,
+		&purchase.UserId,
+		&purchase.ItemId,
+		&purchase.ItemName,
+		&purchase.Quantity,
+		&purchase.Price,
+		&purchase.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Purchase{}, ErrNotFound
+		}
+		return entity.Purchase{}, fmt.Errorf("PurchaseRepo.GetForUpdate - QueryRow: %w", err)
+	}
+
+	return purchase, nil
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPurchaseRepo_Create(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		purchase entity.Purchase
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         int
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:      context.Background(),
+				purchase: entity.Purchase{LedgerEntryId: 50, UserId: 1, ItemId: 4, Quantity: 2, Price: 10},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id"}).AddRow(7)
+
+				m.ExpectQuery(`INSERT INTO purchases \(ledger_entry_id, user_id, item_id, quantity, price\) VALUES \(\$1,\$2,\$3,\$4,\$5\) RETURNING id`).
+					WithArgs(args.purchase.LedgerEntryId, args.purchase.UserId, args.purchase.ItemId, args.purchase.Quantity, args.purchase.Price).
+					WillReturnRows(rows)
+			},
+			want:    7,
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:      context.Background(),
+				purchase: entity.Purchase{LedgerEntryId: 50, UserId: 1, ItemId: 4, Quantity: 2, Price: 10},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`INSERT INTO purchases`).
+					WithArgs(args.purchase.LedgerEntryId, args.purchase.UserId, args.purchase.ItemId, args.purchase.Quantity, args.purchase.Price).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			purchaseRepoMock := NewPurchaseRepo(postgresMock)
+
+			got, err := purchaseRepoMock.Create(tc.args.ctx, tc.args.purchase)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPurchaseRepo_GetForUpdate(t *testing.T) {
+	type args struct {
+		ctx           context.Context
+		userId        int
+		ledgerEntryId int
+		itemName      string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	createdAt := time.Date(2025, 3, 11, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         entity.Purchase
+		wantErr      error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:           context.Background(),
+				userId:        1,
+				ledgerEntryId: 50,
+				itemName:      "socks",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "ledger_entry_id", "user_id", "item_id", "name", "quantity", "price", "created_at"}).
+					AddRow(7, args.ledgerEntryId, args.userId, 4, args.itemName, 2, 10, createdAt)
+
+				m.ExpectQuery(`SELECT p.id, p.ledger_entry_id, p.user_id, p.item_id, i.name, p.quantity, p.price, p.created_at FROM purchases p JOIN items i ON p.item_id = i.id WHERE i.name = \$1 AND p.ledger_entry_id = \$2 AND p.user_id = \$3 FOR UPDATE OF p`).
+					WithArgs(args.itemName, args.ledgerEntryId, args.userId).
+					WillReturnRows(rows)
+			},
+			want:    entity.Purchase{Id: 7, LedgerEntryId: 50, UserId: 1, ItemId: 4, ItemName: "socks", Quantity: 2, Price: 10, CreatedAt: createdAt},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			args: args{
+				ctx:           context.Background(),
+				userId:        1,
+				ledgerEntryId: 50,
+				itemName:      "socks",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`SELECT p.id`).
+					WithArgs(args.itemName, args.ledgerEntryId, args.userId).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			purchaseRepoMock := NewPurchaseRepo(postgresMock)
+
+			got, err := purchaseRepoMock.GetForUpdate(tc.args.ctx, tc.args.userId, tc.args.ledgerEntryId, tc.args.itemName)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+)
+
+type RefreshTokenRepo struct {
+	*postgres.Postgres
+}
+
+func NewRefreshTokenRepo(pg *postgres.Postgres) *RefreshTokenRepo {
+	return &RefreshTokenRepo{pg}
+}
+
+func (r *RefreshTokenRepo) Create(ctx context.Context, token entity.RefreshToken) error {
+	sql, args, _ := r.Builder.
+		Insert("refresh_tokens").
+		Columns("user_id, family_id, token_hash, expires_at").
+		Values(token.UserId, token.FamilyId, token.TokenHash, token.ExpiresAt).
+		ToSql()
+
+	_, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("RefreshTokenRepo.Create - Exec: %w", err)
+	}
+
+	return nil
+}
+
+// GetByHashForUpdate блокирует строку токена до конца транзакции, чтобы один токен нельзя было обменять дважды.
+func (r *RefreshTokenRepo) GetByHashForUpdate(ctx context.Context, tokenHash string) (entity.RefreshToken, error) {
+	sql, args, _ := r.Builder.
+		Select("id, user_id, family_id, token_hash, expires_at, created_at, revoked_at").
+		From("refresh_tokens").
+		Where("token_hash = ?", tokenHash).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	var token entity.RefreshToken
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(
+		&token.Id,
+		&token.UserId,
+		&token.FamilyId,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.CreatedAt,
+		&token.RevokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.RefreshToken{}, ErrNotFound
+		}
+		return entity.RefreshToken{}, fmt.Errorf("RefreshTokenRepo.GetByHashForUpdate - QueryRow: %w", err)
+	}
+
+	return token, nil
+}
+
+func (r *RefreshTokenRepo) Revoke(ctx context.Context, id int) error {
+	sql, args, _ := r.Builder.
+		Update("refresh_tokens").
+		Set("revoked_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": id, "revoked_at": nil}).
+		ToSql()
+
+	_, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("RefreshTokenRepo.Revoke - Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenRepo) RevokeFamily(ctx context.Context, familyId string) error {
+	sql, args, _ := r.Builder.
+		Update("refresh_tokens").
+		Set("revoked_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"family_id": familyId, "revoked_at": nil}).
+		ToSql()
+
+	_, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("RefreshTokenRepo.RevokeFamily - Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenRepo) RevokeByUserId(ctx context.Context, userId int) error {
+	sql, args, _ := r.Builder.
+		Update("refresh_tokens").
+		Set("revoked_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"user_id": userId, "revoked_at": nil}).
+		ToSql()
+
+	_, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("RefreshTokenRepo.RevokeByUserId - Exec: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,314 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRefreshTokenRepo_Create(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		token entity.RefreshToken
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx: context.Background(),
+				token: entity.RefreshToken{
+					UserId:    1,
+					FamilyId:  "family",
+					TokenHash: "hash",
+					ExpiresAt: expiresAt,
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`INSERT INTO refresh_tokens`).
+					WithArgs(args.token.UserId, args.token.FamilyId, args.token.TokenHash, args.token.ExpiresAt).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx: context.Background(),
+				token: entity.RefreshToken{
+					UserId:    1,
+					FamilyId:  "family",
+					TokenHash: "hash",
+					ExpiresAt: expiresAt,
+				},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`INSERT INTO refresh_tokens`).
+					WithArgs(args.token.UserId, args.token.FamilyId, args.token.TokenHash, args.token.ExpiresAt).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			refreshTokenRepoMock := NewRefreshTokenRepo(postgresMock)
+
+			err := refreshTokenRepoMock.Create(tc.args.ctx, tc.args.token)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRefreshTokenRepo_GetByHashForUpdate(t *testing.T) {
+	type args struct {
+		ctx       context.Context
+		tokenHash string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	now := time.Now()
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         entity.RefreshToken
+		wantErr      error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:       context.Background(),
+				tokenHash: "hash",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "user_id", "family_id", "token_hash", "expires_at", "created_at", "revoked_at"}).
+					AddRow(3, 1, "family", args.tokenHash, now.Add(time.Hour), now, nil)
+
+				m.ExpectQuery(`SELECT (.+) FROM refresh_tokens WHERE token_hash = \$1 FOR UPDATE`).
+					WithArgs(args.tokenHash).
+					WillReturnRows(rows)
+			},
+			want: entity.RefreshToken{
+				Id:        3,
+				UserId:    1,
+				FamilyId:  "family",
+				TokenHash: "hash",
+				ExpiresAt: now.Add(time.Hour),
+				CreatedAt: now,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			args: args{
+				ctx:       context.Background(),
+				tokenHash: "hash",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`SELECT (.+) FROM refresh_tokens`).
+					WithArgs(args.tokenHash).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			want:    entity.RefreshToken{},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			refreshTokenRepoMock := NewRefreshTokenRepo(postgresMock)
+
+			got, err := refreshTokenRepoMock.GetByHashForUpdate(tc.args.ctx, tc.args.tokenHash)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRefreshTokenRepo_RevokeFamily(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		familyId string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:      context.Background(),
+				familyId: "family",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE refresh_tokens SET revoked_at = now\(\) WHERE family_id = \$1 AND revoked_at IS NULL`).
+					WithArgs(args.familyId).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:      context.Background(),
+				familyId: "family",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE refresh_tokens`).
+					WithArgs(args.familyId).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			refreshTokenRepoMock := NewRefreshTokenRepo(postgresMock)
+
+			err := refreshTokenRepoMock.RevokeFamily(tc.args.ctx, tc.args.familyId)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRefreshTokenRepo_RevokeByUserId(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		userId int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:    context.Background(),
+				userId: 2,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE refresh_tokens SET revoked_at = now\(\) WHERE revoked_at IS NULL AND user_id = \$1`).
+					WithArgs(args.userId).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:    context.Background(),
+				userId: 2,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE refresh_tokens`).
+					WithArgs(args.userId).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			refreshTokenRepoMock := NewRefreshTokenRepo(postgresMock)
+
+			err := refreshTokenRepoMock.RevokeByUserId(tc.args.ctx, tc.args.userId)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
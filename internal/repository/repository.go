@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/spanwalla/merch-store/internal/entity"
 	"github.com/spanwalla/merch-store/pkg/postgres"
+	"time"
 )
 
 //go:generate mockgen -source=repository.go -destination=../mocks/repository/mock.go -package=repomocks
@@ -13,24 +14,132 @@ type Transactor interface {
 	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
-type Operation interface {
-	Upsert(ctx context.Context, operation entity.Operation) error
+type Ledger interface {
+	Append(ctx context.Context, entry entity.LedgerEntry) (entity.LedgerEntry, error)
+	AppendBatch(ctx context.Context, entries []entity.LedgerEntry) error
+	GetExpirable(ctx context.Context, before time.Time) ([]entity.Expiration, error)
+	GetSentSince(ctx context.Context, senderId int, since time.Time) (entity.TransferStats, error)
+	GetReceivedSince(ctx context.Context, receiverId int, since time.Time) (entity.TransferStats, error)
 }
 
 type Item interface {
 	GetItemByName(ctx context.Context, name string) (entity.Item, error)
+	GetLowStock(ctx context.Context, threshold int) ([]entity.Item, error)
+	TakeStock(ctx context.Context, id, quantity int) error
+	AddStock(ctx context.Context, id, quantity int) (entity.Item, error)
+	SetStock(ctx context.Context, id int, stock *int) (entity.Item, error)
 }
 
 type Sale interface {
 	Upsert(ctx context.Context, sale entity.Sale) error
+	Decrement(ctx context.Context, sale entity.Sale) error
+}
+
+type Purchase interface {
+	Create(ctx context.Context, purchase entity.Purchase) (int, error)
+	GetForUpdate(ctx context.Context, userId, ledgerEntryId int, itemName string) (entity.Purchase, error)
+}
+
+type Return interface {
+	Create(ctx context.Context, ret entity.Return) (int, error)
+	GetReturnedQuantity(ctx context.Context, purchaseId int) (int, error)
+	GetByUserId(ctx context.Context, userId int) ([]entity.Return, error)
+	GetByStatus(ctx context.Context, status string) ([]entity.Return, error)
+	Decide(ctx context.Context, id int, status string, decidedBy int) (entity.Return, error)
 }
 
 type User interface {
 	CreateUser(ctx context.Context, user entity.User) (int, error)
 	GetUserByName(ctx context.Context, username string) (entity.User, error)
+	GetUserById(ctx context.Context, id int) (entity.User, error)
 	GetUserIdByName(ctx context.Context, username string) (int, error)
+	GetUserIdsByNames(ctx context.Context, usernames []string) (map[string]int, error)
+	GetSystemUserId(ctx context.Context) (int, error)
+	UpdatePassword(ctx context.Context, id int, password string) error
+	UpdateRole(ctx context.Context, id int, role string) error
 	Withdraw(ctx context.Context, id, amount int) error
 	Deposit(ctx context.Context, id, amount int) error
+	DepositAll(ctx context.Context, amount int, roles []string) ([]int, error)
+	LockUsers(ctx context.Context, ids []int) error
+}
+
+type RefreshToken interface {
+	Create(ctx context.Context, token entity.RefreshToken) error
+	GetByHashForUpdate(ctx context.Context, tokenHash string) (entity.RefreshToken, error)
+	Revoke(ctx context.Context, id int) error
+	RevokeFamily(ctx context.Context, familyId string) error
+	RevokeByUserId(ctx context.Context, userId int) error
+}
+
+type PasswordReset interface {
+	Create(ctx context.Context, reset entity.PasswordReset) (int, error)
+	Use(ctx context.Context, tokenHash string) (int, error)
+	InvalidateByUserId(ctx context.Context, userId int) error
+}
+
+type RevokedToken interface {
+	Add(ctx context.Context, token entity.RevokedToken) error
+	GetActive(ctx context.Context) ([]entity.RevokedToken, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type RevokedUser interface {
+	Upsert(ctx context.Context, user entity.RevokedUser) error
+	GetActive(ctx context.Context) ([]entity.RevokedUser, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type LoginAttempt interface {
+	GetLockedUntil(ctx context.Context, subjects []string) (time.Time, error)
+	RegisterFailure(ctx context.Context, subject string, resetBefore time.Time) (int, error)
+	Lock(ctx context.Context, subject string, until time.Time) error
+	Reset(ctx context.Context, subject string) error
+}
+
+type APIKey interface {
+	Create(ctx context.Context, key entity.APIKey) (int, error)
+	GetActiveByHash(ctx context.Context, keyHash string) (entity.APIKey, error)
+	GetByUserId(ctx context.Context, userId int) ([]entity.APIKey, error)
+	Revoke(ctx context.Context, id int) error
+	Touch(ctx context.Context, id int, notBefore time.Time) error
+}
+
+type Invite interface {
+	Create(ctx context.Context, invite entity.Invite) (int, error)
+	Use(ctx context.Context, codeHash string) error
+}
+
+type Cart interface {
+	AddItem(ctx context.Context, userId, itemId, quantity int) error
+	RemoveItem(ctx context.Context, userId, itemId int) error
+	GetItems(ctx context.Context, userId int) ([]entity.CartItem, error)
+	TakeItems(ctx context.Context, userId int) ([]entity.CartItem, error)
+}
+
+type IdempotencyKey interface {
+	Create(ctx context.Context, key entity.IdempotencyKey) (int, error)
+	Get(ctx context.Context, userId int, key string) (entity.IdempotencyKey, error)
+	SaveResponse(ctx context.Context, id, statusCode int, response []byte) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type PendingTransfer interface {
+	Create(ctx context.Context, transfer entity.PendingTransfer) (entity.PendingTransfer, error)
+	Resolve(ctx context.Context, id, receiverId int, status string) (entity.PendingTransfer, error)
+	ExpireDue(ctx context.Context, now time.Time) ([]entity.PendingTransfer, error)
+}
+
+type PaymentRequest interface {
+	Create(ctx context.Context, request entity.PaymentRequest) (entity.PaymentRequest, error)
+	GetByRequesterId(ctx context.Context, requesterId int) ([]entity.PaymentRequest, error)
+	GetByPayerId(ctx context.Context, payerId int) ([]entity.PaymentRequest, error)
+	MarkPaid(ctx context.Context, id, payerId int) (entity.PaymentRequest, error)
+}
+
+type Job interface {
+	TryLock(ctx context.Context, job string) (bool, error)
+	CreateRun(ctx context.Context, run entity.JobRun) (int, error)
+	FinishRun(ctx context.Context, id int, status, details string) error
 }
 
 type UserReport interface {
@@ -38,19 +147,47 @@ type UserReport interface {
 }
 
 type Repositories struct {
-	Operation
+	Ledger
 	Item
 	Sale
+	Purchase
+	Return
 	User
 	UserReport
+	RefreshToken
+	PasswordReset
+	RevokedToken
+	RevokedUser
+	LoginAttempt
+	APIKey
+	Invite
+	IdempotencyKey
+	Cart
+	PendingTransfer
+	PaymentRequest
+	Job
 }
 
 func NewRepositories(pg *postgres.Postgres) *Repositories {
 	return &Repositories{
-		Operation:  NewOperationRepo(pg),
-		Item:       NewItemRepo(pg),
-		Sale:       NewSaleRepo(pg),
-		User:       NewUserRepo(pg),
-		UserReport: NewUserReportRepo(pg),
+		Ledger:          NewLedgerRepo(pg),
+		Item:            NewItemRepo(pg),
+		Sale:            NewSaleRepo(pg),
+		Purchase:        NewPurchaseRepo(pg),
+		Return:          NewReturnRepo(pg),
+		User:            NewUserRepo(pg),
+		UserReport:      NewUserReportRepo(pg),
+		RefreshToken:    NewRefreshTokenRepo(pg),
+		PasswordReset:   NewPasswordResetRepo(pg),
+		RevokedToken:    NewRevokedTokenRepo(pg),
+		RevokedUser:     NewRevokedUserRepo(pg),
+		LoginAttempt:    NewLoginAttemptRepo(pg),
+		APIKey:          NewAPIKeyRepo(pg),
+		Invite:          NewInviteRepo(pg),
+		IdempotencyKey:  NewIdempotencyKeyRepo(pg),
+		Cart:            NewCartRepo(pg),
+		PendingTransfer: NewPendingTransferRepo(pg),
+		PaymentRequest:  NewPaymentRequestRepo(pg),
+		Job:             NewJobRepo(pg),
 	}
 }
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+)
+
+type ReturnRepo struct {
+	*postgres.Postgres
+}
+
+func NewReturnRepo(pg *postgres.Postgres) *ReturnRepo {
+	return &ReturnRepo{pg}
+}
+
+func (r *ReturnRepo) Create(ctx context.Context, ret entity.Return) (int, error) {
+	sql, args, _ := r.Builder.
+		Insert("returns").
+		Columns("purchase_id, user_id, item_id, quantity, amount, reason").
+		Values(ret.PurchaseId, ret.UserId, ret.ItemId, ret.Quantity, ret.Amount, ret.Reason).
+		Suffix("RETURNING id").
+		ToSql()
+
+	var id int
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ReturnRepo.Create - QueryRow: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetReturnedQuantity counts the items of the purchase line that are returned or waiting for approval.
+func (r *ReturnRepo) GetReturnedQuantity(ctx context.Context, purchaseId int) (int, error) {
+	sql, args, _ := r.Builder.
+		Select("COALESCE(SUM(quantity), 0)").
+		From("returns").
+		Where(squirrel.Eq{
+			"purchase_id": purchaseId,
+			"status":      []string{entity.ReturnStatusPending, entity.ReturnStatusApproved},
+		}).
+		ToSql()
+
+	var quantity int
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(&quantity)
+	if err != nil {
+		return 0, fmt.Errorf("ReturnRepo.GetReturnedQuantity - QueryRow: %w", err)
+	}
+
+	return quantity, nil
+}
+
+func (r *ReturnRepo) GetByUserId(ctx context.Context, userId int) ([]entity.Return, error) {
+	return r.getReturns(ctx, "ReturnRepo.GetByUserId", squirrel.Eq{"r.user_id": userId})
+}
+
+func (r *ReturnRepo) GetByStatus(ctx context.Context, status string) ([]entity.Return, error) {
+	return r.getReturns(ctx, "ReturnRepo.GetByStatus", squirrel.Eq{"r.status": status})
+}
+
+// Decide sets the status of a pending return. ErrNotFound means there is no such pending return.
+func (r *ReturnRepo) Decide(ctx context.Context, id int, status string, decidedBy int) (entity.Return, error) {
+	sql, args, _ := r.Builder.
+		Update("returns").
+		Set("status", status).
+		Set("decided_at", squirrel.Expr("now()")).
+		Set("decided_by", decidedBy).
+		Where(squirrel.Eq{"id": id, "status": entity.ReturnStatusPending}).
+		Suffix("RETURNING id, purchase_id, user_id, item_id, quantity, amount, reason, status, created_at, decided_at, decided_by").
+		ToSql()
+
+	var ret entity.Return
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(
+		&ret.Id,
+		&ret.PurchaseId,
+		&ret.UserId,
+		&ret.ItemId,
+		&ret.Quantity,
+		&ret.Amount,
+		&ret.Reason,
+		&ret.Status,
+		&ret.CreatedAt,
+		&ret.DecidedAt,
+		&ret.DecidedBy,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Return{}, ErrNotFound
+		}
+		return entity.Return{}, fmt.Errorf("ReturnRepo.Decide - QueryRow: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (r *ReturnRepo) getReturns(ctx context.Context, method string, where squirrel.Eq) ([]entity.Return, error) {
+	sql, args, _ := r.Builder.
+		Select("r.id, r.purchase_id, r.user_id, r.item_id, i.name, r.quantity, r.amount, r.reason, r.status, r.created_at, r.decided_at, r.decided_by").
+		From("returns r").
+		Join("items i ON r.item_id = i.id").
+		Where(where).
+		OrderBy("r.id").
+		ToSql()
+
+	rows, err := r.GetQueryRunner(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s - Query: %w", method, err)
+	}
+
+	returns, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Return, error) {
+		var ret entity.Return
+		err := row.Scan(
+			&ret.Id,
+			&ret.PurchaseId,
+			&ret.UserId,
+			&ret.ItemId,
+			&ret.ItemName,
+			&ret.Quantity,
+			&ret.Amount,
+			&ret.Reason,
+			&ret.Status,
+			&ret.CreatedAt,
+			&ret.DecidedAt,
+			&ret.DecidedBy,
+		)
+		return ret, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s - CollectRows: %w", method, err)
+	}
+
+	return returns, nil
+}
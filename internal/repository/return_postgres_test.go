@@ -0,0 +1,326 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestReturnRepo_Create(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		ret entity.Return
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         int
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx: context.Background(),
+				ret: entity.Return{PurchaseId: 7, UserId: 1, ItemId: 4, Quantity: 1, Amount: 10, Reason: "wrong size"},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id"}).AddRow(3)
+
+				m.ExpectQuery(`INSERT INTO returns \(purchase_id, user_id, item_id, quantity, amount, reason\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6\) RETURNING id`).
+					WithArgs(args.ret.PurchaseId, args.ret.UserId, args.ret.ItemId, args.ret.Quantity, args.ret.Amount, args.ret.Reason).
+					WillReturnRows(rows)
+			},
+			want:    3,
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx: context.Background(),
+				ret: entity.Return{PurchaseId: 7, UserId: 1, ItemId: 4, Quantity: 1, Amount: 10},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`INSERT INTO returns`).
+					WithArgs(args.ret.PurchaseId, args.ret.UserId, args.ret.ItemId, args.ret.Quantity, args.ret.Amount, args.ret.Reason).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			returnRepoMock := NewReturnRepo(postgresMock)
+
+			got, err := returnRepoMock.Create(tc.args.ctx, tc.args.ret)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestReturnRepo_GetReturnedQuantity(t *testing.T) {
+	type args struct {
+		ctx        context.Context
+		purchaseId int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         int
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:        context.Background(),
+				purchaseId: 7,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"sum"}).AddRow(2)
+
+				m.ExpectQuery(`SELECT COALESCE\(SUM\(quantity\), 0\) FROM returns WHERE purchase_id = \$1 AND status IN \(\$2,\$3\)`).
+					WithArgs(args.purchaseId, entity.ReturnStatusPending, entity.ReturnStatusApproved).
+					WillReturnRows(rows)
+			},
+			want:    2,
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:        context.Background(),
+				purchaseId: 7,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`SELECT COALESCE`).
+					WithArgs(args.purchaseId, entity.ReturnStatusPending, entity.ReturnStatusApproved).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			returnRepoMock := NewReturnRepo(postgresMock)
+
+			got, err := returnRepoMock.GetReturnedQuantity(tc.args.ctx, tc.args.purchaseId)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestReturnRepo_GetByUserId(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		userId int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	createdAt := time.Date(2025, 3, 11, 12, 0, 0, 0, time.UTC)
+	decidedAt := createdAt.Add(time.Hour)
+	decidedBy := 2
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         []entity.Return
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:    context.Background(),
+				userId: 1,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "purchase_id", "user_id", "item_id", "name", "quantity", "amount", "reason", "status", "created_at", "decided_at", "decided_by"}).
+					AddRow(3, 7, args.userId, 4, "socks", 1, 10, "", entity.ReturnStatusApproved, createdAt, &decidedAt, &decidedBy).
+					AddRow(5, 8, args.userId, 6, "cup", 2, 40, "broken", entity.ReturnStatusPending, createdAt, nil, nil)
+
+				m.ExpectQuery(`SELECT r.id, r.purchase_id, r.user_id, r.item_id, i.name, r.quantity, r.amount, r.reason, r.status, r.created_at, r.decided_at, r.decided_by FROM returns r JOIN items i ON r.item_id = i.id WHERE r.user_id = \$1 ORDER BY r.id`).
+					WithArgs(args.userId).
+					WillReturnRows(rows)
+			},
+			want: []entity.Return{
+				{Id: 3, PurchaseId: 7, UserId: 1, ItemId: 4, ItemName: "socks", Quantity: 1, Amount: 10, Status: entity.ReturnStatusApproved, CreatedAt: createdAt, DecidedAt: &decidedAt, DecidedBy: &decidedBy},
+				{Id: 5, PurchaseId: 8, UserId: 1, ItemId: 6, ItemName: "cup", Quantity: 2, Amount: 40, Reason: "broken", Status: entity.ReturnStatusPending, CreatedAt: createdAt},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:    context.Background(),
+				userId: 1,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`SELECT r.id`).
+					WithArgs(args.userId).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			returnRepoMock := NewReturnRepo(postgresMock)
+
+			got, err := returnRepoMock.GetByUserId(tc.args.ctx, tc.args.userId)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestReturnRepo_Decide(t *testing.T) {
+	type args struct {
+		ctx       context.Context
+		id        int
+		status    string
+		decidedBy int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	createdAt := time.Date(2025, 3, 11, 12, 0, 0, 0, time.UTC)
+	decidedAt := createdAt.Add(time.Hour)
+	decidedBy := 2
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         entity.Return
+		wantErr      error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:       context.Background(),
+				id:        3,
+				status:    entity.ReturnStatusApproved,
+				decidedBy: decidedBy,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "purchase_id", "user_id", "item_id", "quantity", "amount", "reason", "status", "created_at", "decided_at", "decided_by"}).
+					AddRow(args.id, 7, 1, 4, 1, 10, "", args.status, createdAt, &decidedAt, &decidedBy)
+
+				m.ExpectQuery(`UPDATE returns SET status = \$1, decided_at = now\(\), decided_by = \$2 WHERE id = \$3 AND status = \$4 RETURNING id, purchase_id, user_id, item_id, quantity, amount, reason, status, created_at, decided_at, decided_by`).
+					WithArgs(args.status, args.decidedBy, args.id, entity.ReturnStatusPending).
+					WillReturnRows(rows)
+			},
+			want:    entity.Return{Id: 3, PurchaseId: 7, UserId: 1, ItemId: 4, Quantity: 1, Amount: 10, Status: entity.ReturnStatusApproved, CreatedAt: createdAt, DecidedAt: &decidedAt, DecidedBy: &decidedBy},
+			wantErr: nil,
+		},
+		{
+			name: "not pending",
+			args: args{
+				ctx:       context.Background(),
+				id:        3,
+				status:    entity.ReturnStatusRejected,
+				decidedBy: decidedBy,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`UPDATE returns`).
+					WithArgs(args.status, args.decidedBy, args.id, entity.ReturnStatusPending).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			returnRepoMock := NewReturnRepo(postgresMock)
+
+			got, err := returnRepoMock.Decide(tc.args.ctx, tc.args.id, tc.args.status, tc.args.decidedBy)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
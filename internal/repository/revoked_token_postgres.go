@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+)
+
+type RevokedTokenRepo struct {
+	*postgres.Postgres
+}
+
+func NewRevokedTokenRepo(pg *postgres.Postgres) *RevokedTokenRepo {
+	return &RevokedTokenRepo{pg}
+}
+
+func (r *RevokedTokenRepo) Add(ctx context.Context, token entity.RevokedToken) error {
+	sql, args, _ := r.Builder.
+		Insert("revoked_tokens").
+		Columns("jti, expires_at").
+		Values(token.Jti, token.ExpiresAt).
+		Suffix("ON CONFLICT (jti) DO NOTHING").
+		ToSql()
+
+	_, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("RevokedTokenRepo.Add - Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RevokedTokenRepo) GetActive(ctx context.Context) ([]entity.RevokedToken, error) {
+	sql, args, _ := r.Builder.
+		Select("jti, expires_at").
+		From("revoked_tokens").
+		Where("expires_at > now()").
+		ToSql()
+
+	rows, err := r.GetQueryRunner(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("RevokedTokenRepo.GetActive - Query: %w", err)
+	}
+
+	tokens, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.RevokedToken, error) {
+		var token entity.RevokedToken
+		err := row.Scan(&token.Jti, &token.ExpiresAt)
+		return token, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("RevokedTokenRepo.GetActive - CollectRows: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (r *RevokedTokenRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	sql, args, _ := r.Builder.
+		Delete("revoked_tokens").
+		Where("expires_at <= now()").
+		ToSql()
+
+	cmdTag, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("RevokedTokenRepo.DeleteExpired - Exec: %w", err)
+	}
+
+	return cmdTag.RowsAffected(), nil
+}
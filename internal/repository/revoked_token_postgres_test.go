@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRevokedTokenRepo_Add(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		token entity.RevokedToken
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:   context.Background(),
+				token: entity.RevokedToken{Jti: "jti", ExpiresAt: expiresAt},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`INSERT INTO revoked_tokens (.+) ON CONFLICT \(jti\) DO NOTHING`).
+					WithArgs(args.token.Jti, args.token.ExpiresAt).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:   context.Background(),
+				token: entity.RevokedToken{Jti: "jti", ExpiresAt: expiresAt},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`INSERT INTO revoked_tokens`).
+					WithArgs(args.token.Jti, args.token.ExpiresAt).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			revokedTokenRepoMock := NewRevokedTokenRepo(postgresMock)
+
+			err := revokedTokenRepoMock.Add(tc.args.ctx, tc.args.token)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRevokedTokenRepo_GetActive(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		want         []entity.RevokedToken
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"jti", "expires_at"}).
+					AddRow("first", expiresAt).
+					AddRow("second", expiresAt)
+
+				m.ExpectQuery(`SELECT jti, expires_at FROM revoked_tokens WHERE expires_at > now\(\)`).
+					WillReturnRows(rows)
+			},
+			want: []entity.RevokedToken{
+				{Jti: "first", ExpiresAt: expiresAt},
+				{Jti: "second", ExpiresAt: expiresAt},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery(`SELECT jti, expires_at FROM revoked_tokens`).
+					WillReturnError(errors.New("some query error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			revokedTokenRepoMock := NewRevokedTokenRepo(postgresMock)
+
+			got, err := revokedTokenRepoMock.GetActive(context.Background())
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRevokedTokenRepo_DeleteExpired(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		want         int64
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec(`DELETE FROM revoked_tokens WHERE expires_at <= now\(\)`).
+					WillReturnResult(pgxmock.NewResult("DELETE", 3))
+			},
+			want:    3,
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec(`DELETE FROM revoked_tokens`).
+					WillReturnError(errors.New("some query error"))
+			},
+			want:    0,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			revokedTokenRepoMock := NewRevokedTokenRepo(postgresMock)
+
+			got, err := revokedTokenRepoMock.DeleteExpired(context.Background())
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
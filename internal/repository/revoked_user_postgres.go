@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+)
+
+type RevokedUserRepo struct {
+	*postgres.Postgres
+}
+
+func NewRevokedUserRepo(pg *postgres.Postgres) *RevokedUserRepo {
+	return &RevokedUserRepo{pg}
+}
+
+func (r *RevokedUserRepo) Upsert(ctx context.Context, user entity.RevokedUser) error {
+	sql, args, _ := r.Builder.
+		Insert("revoked_users").
+		Columns("user_id, revoked_at, expires_at").
+		Values(user.UserId, user.RevokedAt, user.ExpiresAt).
+		Suffix("ON CONFLICT (user_id) DO UPDATE SET revoked_at = EXCLUDED.revoked_at, expires_at = EXCLUDED.expires_at").
+		ToSql()
+
+	_, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("RevokedUserRepo.Upsert - Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RevokedUserRepo) GetActive(ctx context.Context) ([]entity.RevokedUser, error) {
+	sql, args, _ := r.Builder.
+		Select("user_id, revoked_at, expires_at").
+		From("revoked_users").
+		Where("expires_at > now()").
+		ToSql()
+
+	rows, err := r.GetQueryRunner(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("RevokedUserRepo.GetActive - Query: %w", err)
+	}
+
+	users, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.RevokedUser, error) {
+		var user entity.RevokedUser
+		err := row.Scan(&user.UserId, &user.RevokedAt, &user.ExpiresAt)
+		return user, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("RevokedUserRepo.GetActive - CollectRows: %w", err)
+	}
+
+	return users, nil
+}
+
+func (r *RevokedUserRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	sql, args, _ := r.Builder.
+		Delete("revoked_users").
+		Where("expires_at <= now()").
+		ToSql()
+
+	cmdTag, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("RevokedUserRepo.DeleteExpired - Exec: %w", err)
+	}
+
+	return cmdTag.RowsAffected(), nil
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/pkg/postgres"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRevokedUserRepo_Upsert(t *testing.T) {
+	type args struct {
+		ctx  context.Context
+		user entity.RevokedUser
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	revokedAt := time.Now()
+	expiresAt := revokedAt.Add(time.Hour)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:  context.Background(),
+				user: entity.RevokedUser{UserId: 1, RevokedAt: revokedAt, ExpiresAt: expiresAt},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`INSERT INTO revoked_users (.+) ON CONFLICT \(user_id\) DO UPDATE`).
+					WithArgs(args.user.UserId, args.user.RevokedAt, args.user.ExpiresAt).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:  context.Background(),
+				user: entity.RevokedUser{UserId: 1, RevokedAt: revokedAt, ExpiresAt: expiresAt},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`INSERT INTO revoked_users`).
+					WithArgs(args.user.UserId, args.user.RevokedAt, args.user.ExpiresAt).
+					WillReturnError(errors.New("some query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			revokedUserRepoMock := NewRevokedUserRepo(postgresMock)
+
+			err := revokedUserRepoMock.Upsert(tc.args.ctx, tc.args.user)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRevokedUserRepo_GetActive(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	revokedAt := time.Now()
+	expiresAt := revokedAt.Add(time.Hour)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		want         []entity.RevokedUser
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"user_id", "revoked_at", "expires_at"}).
+					AddRow(1, revokedAt, expiresAt).
+					AddRow(2, revokedAt, expiresAt)
+
+				m.ExpectQuery(`SELECT user_id, revoked_at, expires_at FROM revoked_users WHERE expires_at > now\(\)`).
+					WillReturnRows(rows)
+			},
+			want: []entity.RevokedUser{
+				{UserId: 1, RevokedAt: revokedAt, ExpiresAt: expiresAt},
+				{UserId: 2, RevokedAt: revokedAt, ExpiresAt: expiresAt},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery(`SELECT user_id, revoked_at, expires_at FROM revoked_users`).
+					WillReturnError(errors.New("some query error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			revokedUserRepoMock := NewRevokedUserRepo(postgresMock)
+
+			got, err := revokedUserRepoMock.GetActive(context.Background())
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRevokedUserRepo_DeleteExpired(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		want         int64
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec(`DELETE FROM revoked_users WHERE expires_at <= now\(\)`).
+					WillReturnResult(pgxmock.NewResult("DELETE", 3))
+			},
+			want:    3,
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec(`DELETE FROM revoked_users`).
+					WillReturnError(errors.New("some query error"))
+			},
+			want:    0,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			revokedUserRepoMock := NewRevokedUserRepo(postgresMock)
+
+			got, err := revokedUserRepoMock.DeleteExpired(context.Background())
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fmt"
+	"github.com/Masterminds/squirrel"
 	"github.com/spanwalla/merch-store/internal/entity"
 	"github.com/spanwalla/merch-store/pkg/postgres"
 )
@@ -30,3 +31,26 @@ func (r *SaleRepo) Upsert(ctx context.Context, sale entity.Sale) error {
 
 	return nil
 }
+
+// Decrement takes items back from the user. ErrNotFound means the user has fewer of them.
+func (r *SaleRepo) Decrement(ctx context.Context, sale entity.Sale) error {
+	sql, args, _ := r.Builder.
+		Update("sales").
+		Set("quantity", squirrel.Expr("quantity - ?", sale.Quantity)).
+		Where(squirrel.And{
+			squirrel.Eq{"user_id": sale.UserId, "item_id": sale.ItemId},
+			squirrel.GtOrEq{"quantity": sale.Quantity},
+		}).
+		ToSql()
+
+	cmdTag, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("SaleRepo.Decrement - Exec: %w", err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
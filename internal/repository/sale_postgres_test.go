@@ -86,3 +86,71 @@ func TestSaleRepo_Upsert(t *testing.T) {
 		})
 	}
 }
+
+func TestSaleRepo_Decrement(t *testing.T) {
+	type args struct {
+		ctx  context.Context
+		sale entity.Sale
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:  context.Background(),
+				sale: entity.Sale{UserId: 1, ItemId: 10, Quantity: 2},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE sales SET quantity = quantity - \$1 WHERE \(item_id = \$2 AND user_id = \$3 AND quantity >= \$4\)`).
+					WithArgs(args.sale.Quantity, args.sale.ItemId, args.sale.UserId, args.sale.Quantity).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not enough items",
+			args: args{
+				ctx:  context.Background(),
+				sale: entity.Sale{UserId: 1, ItemId: 10, Quantity: 5},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE sales`).
+					WithArgs(args.sale.Quantity, args.sale.ItemId, args.sale.UserId, args.sale.Quantity).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			saleRepoMock := NewSaleRepo(postgresMock)
+
+			err := saleRepoMock.Decrement(tc.args.ctx, tc.args.sale)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
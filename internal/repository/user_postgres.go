@@ -22,8 +22,8 @@ func NewUserRepo(pg *postgres.Postgres) *UserRepo {
 func (r *UserRepo) CreateUser(ctx context.Context, user entity.User) (int, error) {
 	sql, args, _ := r.Builder.
 		Insert("users").
-		Columns("name, password").
-		Values(user.Name, user.Password).
+		Columns("name, password, role").
+		Values(user.Name, user.Password, user.Role).
 		Suffix("RETURNING id").
 		ToSql()
 
@@ -44,7 +44,7 @@ func (r *UserRepo) CreateUser(ctx context.Context, user entity.User) (int, error
 
 func (r *UserRepo) GetUserByName(ctx context.Context, username string) (entity.User, error) {
 	sql, args, _ := r.Builder.
-		Select("id, name, password, balance").
+		Select("id, name, password, balance, role").
 		From("users").
 		Where("name = ?", username).
 		ToSql()
@@ -55,6 +55,7 @@ func (r *UserRepo) GetUserByName(ctx context.Context, username string) (entity.U
 		&user.Name,
 		&user.Password,
 		&user.Balance,
+		&user.Role,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -66,6 +67,31 @@ func (r *UserRepo) GetUserByName(ctx context.Context, username string) (entity.U
 	return user, nil
 }
 
+func (r *UserRepo) GetUserById(ctx context.Context, id int) (entity.User, error) {
+	sql, args, _ := r.Builder.
+		Select("id, name, password, balance, role").
+		From("users").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+
+	var user entity.User
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(
+		&user.Id,
+		&user.Name,
+		&user.Password,
+		&user.Balance,
+		&user.Role,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.User{}, ErrNotFound
+		}
+		return entity.User{}, fmt.Errorf("UserRepo.GetUserById - QueryRow: %w", err)
+	}
+
+	return user, nil
+}
+
 func (r *UserRepo) GetUserIdByName(ctx context.Context, username string) (int, error) {
 	sql, args, _ := r.Builder.
 		Select("id").
@@ -85,6 +111,94 @@ func (r *UserRepo) GetUserIdByName(ctx context.Context, username string) (int, e
 	return userId, nil
 }
 
+// GetSystemUserId returns the id of the account that sends minted coins.
+func (r *UserRepo) GetSystemUserId(ctx context.Context) (int, error) {
+	sql, args, _ := r.Builder.
+		Select("id").
+		From("users").
+		Where(squirrel.Eq{"role": entity.RoleSystem}).
+		ToSql()
+
+	var userId int
+	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(&userId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrNotFound
+		}
+		return 0, fmt.Errorf("UserRepo.GetSystemUserId - QueryRow: %w", err)
+	}
+
+	return userId, nil
+}
+
+// GetUserIdsByNames maps the names of existing users to their ids, unknown names are left out.
+func (r *UserRepo) GetUserIdsByNames(ctx context.Context, usernames []string) (map[string]int, error) {
+	sql, args, _ := r.Builder.
+		Select("name, id").
+		From("users").
+		Where(squirrel.Eq{"name": usernames}).
+		ToSql()
+
+	rows, err := r.GetQueryRunner(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("UserRepo.GetUserIdsByNames - Query: %w", err)
+	}
+
+	users, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.User, error) {
+		var user entity.User
+		err := row.Scan(&user.Name, &user.Id)
+		return user, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("UserRepo.GetUserIdsByNames - CollectRows: %w", err)
+	}
+
+	userIds := make(map[string]int, len(users))
+	for _, user := range users {
+		userIds[user.Name] = user.Id
+	}
+
+	return userIds, nil
+}
+
+func (r *UserRepo) UpdatePassword(ctx context.Context, id int, password string) error {
+	sql, args, _ := r.Builder.
+		Update("users").
+		Set("password", password).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+
+	cmdTag, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("UserRepo.UpdatePassword - Exec: %w", err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepo) UpdateRole(ctx context.Context, id int, role string) error {
+	sql, args, _ := r.Builder.
+		Update("users").
+		Set("role", role).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+
+	cmdTag, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("UserRepo.UpdateRole - Exec: %w", err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
 func (r *UserRepo) Withdraw(ctx context.Context, id, amount int) error {
 	sql, args, _ := r.Builder.
 		Update("users").
@@ -125,3 +239,48 @@ func (r *UserRepo) Deposit(ctx context.Context, id, amount int) error {
 
 	return nil
 }
+
+// DepositAll credits every user with one of the roles and returns their ids.
+func (r *UserRepo) DepositAll(ctx context.Context, amount int, roles []string) ([]int, error) {
+	sql, args, _ := r.Builder.
+		Update("users").
+		Set("balance", squirrel.Expr("balance + ?", amount)).
+		Where(squirrel.Eq{"role": roles}).
+		Suffix("RETURNING id").
+		ToSql()
+
+	rows, err := r.GetQueryRunner(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("UserRepo.DepositAll - Query: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, fmt.Errorf("UserRepo.DepositAll - CollectRows: %w", err)
+	}
+
+	return ids, nil
+}
+
+// LockUsers locks the rows of the users in id order, so transactions that lock the same users cannot deadlock.
+// It returns ErrNotFound if some of the users do not exist.
+func (r *UserRepo) LockUsers(ctx context.Context, ids []int) error {
+	sql, args, _ := r.Builder.
+		Select("id").
+		From("users").
+		Where(squirrel.Eq{"id": ids}).
+		OrderBy("id").
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	cmdTag, err := r.GetQueryRunner(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("UserRepo.LockUsers - Exec: %w", err)
+	}
+
+	if cmdTag.RowsAffected() < int64(len(ids)) {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -35,6 +35,7 @@ func TestUserRepo_CreateUser(t *testing.T) {
 				user: entity.User{
 					Name:     "testUserMorty",
 					Password: "hisSuperPassword",
+					Role:     "user",
 				},
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
@@ -42,7 +43,7 @@ func TestUserRepo_CreateUser(t *testing.T) {
 					AddRow(1)
 
 				m.ExpectQuery(`INSERT INTO users`).
-					WithArgs(args.user.Name, args.user.Password).
+					WithArgs(args.user.Name, args.user.Password, args.user.Role).
 					WillReturnRows(rows)
 			},
 			want:    1,
@@ -55,11 +56,12 @@ func TestUserRepo_CreateUser(t *testing.T) {
 				user: entity.User{
 					Name:     "existentUser",
 					Password: "hisSuperPassword",
+					Role:     "user",
 				},
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
 				m.ExpectQuery(`INSERT INTO users`).
-					WithArgs(args.user.Name, args.user.Password).
+					WithArgs(args.user.Name, args.user.Password, args.user.Role).
 					WillReturnError(&pgconn.PgError{Code: "23505"})
 			},
 			want:    0,
@@ -72,11 +74,12 @@ func TestUserRepo_CreateUser(t *testing.T) {
 				user: entity.User{
 					Name:     "normalUser",
 					Password: "hisSuperPassword",
+					Role:     "user",
 				},
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
 				m.ExpectExec(`INSERT INTO users`).
-					WithArgs(args.user.Name, args.user.Password).
+					WithArgs(args.user.Name, args.user.Password, args.user.Role).
 					WillReturnError(errors.New("unexpected error"))
 			},
 			want:    0,
@@ -133,10 +136,10 @@ func TestUserRepo_GetUserByName(t *testing.T) {
 				username: "test-name",
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
-				rows := pgxmock.NewRows([]string{"id", "name", "password", "balance"}).
-					AddRow(1, "test-name", "password", 1000)
+				rows := pgxmock.NewRows([]string{"id", "name", "password", "balance", "role"}).
+					AddRow(1, "test-name", "password", 1000, "admin")
 
-				m.ExpectQuery(`SELECT id, name, password, balance`).
+				m.ExpectQuery(`SELECT id, name, password, balance, role`).
 					WithArgs(args.username).
 					WillReturnRows(rows)
 			},
@@ -145,6 +148,7 @@ func TestUserRepo_GetUserByName(t *testing.T) {
 				Name:     "test-name",
 				Password: "password",
 				Balance:  1000,
+				Role:     "admin",
 			},
 			wantErr: false,
 		},
@@ -155,7 +159,7 @@ func TestUserRepo_GetUserByName(t *testing.T) {
 				username: "wrongUsername",
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
-				m.ExpectQuery(`SELECT id, name, password, balance`).
+				m.ExpectQuery(`SELECT id, name, password, balance, role`).
 					WithArgs(args.username).
 					WillReturnError(pgx.ErrNoRows)
 			},
@@ -169,7 +173,7 @@ func TestUserRepo_GetUserByName(t *testing.T) {
 				username: "normalName1",
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
-				m.ExpectQuery(`SELECT id, name, password, balance`).
+				m.ExpectQuery(`SELECT id, name, password, balance, role`).
 					WithArgs(args.username).
 					WillReturnError(errors.New("unexpected error"))
 			},
@@ -205,6 +209,101 @@ func TestUserRepo_GetUserByName(t *testing.T) {
 	}
 }
 
+func TestUserRepo_GetUserById(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		id  int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         entity.User
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx: context.Background(),
+				id:  1,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id", "name", "password", "balance", "role"}).
+					AddRow(1, "test-name", "password", 1000, "admin")
+
+				m.ExpectQuery(`SELECT id, name, password, balance, role`).
+					WithArgs(args.id).
+					WillReturnRows(rows)
+			},
+			want: entity.User{
+				Id:       1,
+				Name:     "test-name",
+				Password: "password",
+				Balance:  1000,
+				Role:     "admin",
+			},
+			wantErr: false,
+		},
+		{
+			name: "user not found",
+			args: args{
+				ctx: context.Background(),
+				id:  2,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`SELECT id, name, password, balance, role`).
+					WithArgs(args.id).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			want:    entity.User{},
+			wantErr: true,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx: context.Background(),
+				id:  1,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`SELECT id, name, password, balance, role`).
+					WithArgs(args.id).
+					WillReturnError(errors.New("unexpected error"))
+			},
+			want:    entity.User{},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			userRepoMock := NewUserRepo(postgresMock)
+
+			got, err := userRepoMock.GetUserById(tc.args.ctx, tc.args.id)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
 func TestUserRepo_GetUserIdByName(t *testing.T) {
 	type args struct {
 		ctx      context.Context
@@ -294,6 +393,141 @@ func TestUserRepo_GetUserIdByName(t *testing.T) {
 	}
 }
 
+func TestUserRepo_GetSystemUserId(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		want         int
+		wantErr      error
+	}{
+		{
+			name: "success",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"id"}).
+					AddRow(1)
+
+				m.ExpectQuery(`SELECT id FROM users WHERE role = \$1`).
+					WithArgs(entity.RoleSystem).
+					WillReturnRows(rows)
+			},
+			want: 1,
+		},
+		{
+			name: "not found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery(`SELECT id FROM users WHERE role = \$1`).
+					WithArgs(entity.RoleSystem).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			userRepoMock := NewUserRepo(postgresMock)
+
+			got, err := userRepoMock.GetSystemUserId(context.Background())
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUserRepo_GetUserIdsByNames(t *testing.T) {
+	type args struct {
+		ctx       context.Context
+		usernames []string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         map[string]int
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:       context.Background(),
+				usernames: []string{"alice", "bob", "unknown"},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"name", "id"}).
+					AddRow("alice", 10).
+					AddRow("bob", 11)
+
+				m.ExpectQuery(`SELECT name, id FROM users WHERE name IN \(\$1,\$2,\$3\)`).
+					WithArgs(args.usernames[0], args.usernames[1], args.usernames[2]).
+					WillReturnRows(rows)
+			},
+			want:    map[string]int{"alice": 10, "bob": 11},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:       context.Background(),
+				usernames: []string{"alice"},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`SELECT name, id`).
+					WithArgs(args.usernames[0]).
+					WillReturnError(errors.New("unexpected error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			userRepoMock := NewUserRepo(postgresMock)
+
+			got, err := userRepoMock.GetUserIdsByNames(tc.args.ctx, tc.args.usernames)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
 func TestUserRepo_Withdraw(t *testing.T) {
 	type args struct {
 		ctx    context.Context
@@ -463,3 +697,348 @@ func TestUserRepo_Deposit(t *testing.T) {
 		})
 	}
 }
+
+func TestUserRepo_DepositAll(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		amount int
+		roles  []string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         []int
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:    context.Background(),
+				amount: 1000,
+				roles:  []string{entity.RoleUser, entity.RoleAdmin},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				rows := pgxmock.NewRows([]string{"id"}).
+					AddRow(1).
+					AddRow(2)
+
+				m.ExpectQuery(`UPDATE users SET balance = balance \+ \$1 WHERE role IN \(\$2,\$3\) RETURNING id`).
+					WithArgs(args.amount, args.roles[0], args.roles[1]).
+					WillReturnRows(rows)
+			},
+			want:    []int{1, 2},
+			wantErr: false,
+		},
+		{
+			name: "no users",
+			args: args{
+				ctx:    context.Background(),
+				amount: 1000,
+				roles:  []string{entity.RoleUser},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`UPDATE users`).
+					WithArgs(args.amount, args.roles[0]).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}))
+			},
+			want:    []int{},
+			wantErr: false,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:    context.Background(),
+				amount: 1000,
+				roles:  []string{entity.RoleUser},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery(`UPDATE users`).
+					WithArgs(args.amount, args.roles[0]).
+					WillReturnError(errors.New("unexpected error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			userRepoMock := NewUserRepo(postgresMock)
+
+			got, err := userRepoMock.DepositAll(tc.args.ctx, tc.args.amount, tc.args.roles)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUserRepo_UpdatePassword(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		id       int
+		password string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:      context.Background(),
+				id:       1,
+				password: "$argon2id$v=19$m=65536,t=3,p=2$c2FsdA$aGFzaA",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE users SET password`).
+					WithArgs(args.password, args.id).
+					WillReturnResult(pgxmock.NewResult(`UPDATE`, 1))
+			},
+			wantErr: false,
+		},
+		{
+			name: "user not found",
+			args: args{
+				ctx:      context.Background(),
+				id:       1,
+				password: "$argon2id$v=19$m=65536,t=3,p=2$c2FsdA$aGFzaA",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE users SET password`).
+					WithArgs(args.password, args.id).
+					WillReturnResult(pgxmock.NewResult(`UPDATE`, 0))
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:      context.Background(),
+				id:       1,
+				password: "$argon2id$v=19$m=65536,t=3,p=2$c2FsdA$aGFzaA",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE users SET password`).
+					WithArgs(args.password, args.id).
+					WillReturnError(errors.New("unexpected error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			userRepoMock := NewUserRepo(postgresMock)
+
+			err := userRepoMock.UpdatePassword(tc.args.ctx, tc.args.id, tc.args.password)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUserRepo_UpdateRole(t *testing.T) {
+	type args struct {
+		ctx  context.Context
+		id   int
+		role string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:  context.Background(),
+				id:   1,
+				role: "admin",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE users SET role`).
+					WithArgs(args.role, args.id).
+					WillReturnResult(pgxmock.NewResult(`UPDATE`, 1))
+			},
+			wantErr: false,
+		},
+		{
+			name: "user not found",
+			args: args{
+				ctx:  context.Background(),
+				id:   1,
+				role: "admin",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE users SET role`).
+					WithArgs(args.role, args.id).
+					WillReturnResult(pgxmock.NewResult(`UPDATE`, 0))
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx:  context.Background(),
+				id:   1,
+				role: "admin",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`UPDATE users SET role`).
+					WithArgs(args.role, args.id).
+					WillReturnError(errors.New("unexpected error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			userRepoMock := NewUserRepo(postgresMock)
+
+			err := userRepoMock.UpdateRole(tc.args.ctx, tc.args.id, tc.args.role)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUserRepo_LockUsers(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		ids []int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx: context.Background(),
+				ids: []int{2, 1},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`SELECT id FROM users WHERE id IN \(\$1,\$2\) ORDER BY id FOR UPDATE`).
+					WithArgs(2, 1).
+					WillReturnResult(pgxmock.NewResult(`SELECT`, 2))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "user not found",
+			args: args{
+				ctx: context.Background(),
+				ids: []int{2, 1},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`SELECT id FROM users`).
+					WithArgs(2, 1).
+					WillReturnResult(pgxmock.NewResult(`SELECT`, 1))
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "unknown error",
+			args: args{
+				ctx: context.Background(),
+				ids: []int{2, 1},
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec(`SELECT id FROM users`).
+					WithArgs(2, 1).
+					WillReturnError(errors.New("unexpected error"))
+			},
+			wantErr: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+				Pool:    poolMock,
+			}
+
+			userRepoMock := NewUserRepo(postgresMock)
+
+			err := userRepoMock.LockUsers(tc.args.ctx, tc.args.ids)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
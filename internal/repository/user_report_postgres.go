@@ -25,26 +25,72 @@ func (r *UserReportRepo) Get(ctx context.Context, id int) (entity.UserReport, er
 		From("items i").
 		LeftJoin("sales s ON i.id = s.item_id AND s.user_id = u.id")
 
+	// Transfers are summed per counterparty to keep the response shape of the former aggregated table.
+	// Escrow entries have one side only, an accepted pending transfer is its hold paired with the release.
+	sentTransfers := r.Builder.
+		Select("receiver_id", "amount").
+		From("ledger_entries").
+		Where("sender_id = u.id AND kind = 'transfer'").
+		Suffix("UNION ALL SELECT rl.receiver_id, rl.amount FROM ledger_entries h " +
+			"JOIN ledger_entries rl ON rl.pending_transfer_id = h.pending_transfer_id AND rl.kind = 'escrow_release' " +
+			"WHERE h.sender_id = u.id AND h.kind = 'escrow_hold'")
+
 	sentSubquery := r.Builder.
-		Select("jsonb_agg(jsonb_build_object('toUser', r.name, 'amount', o.amount))").
-		From("operations o").
-		Join("users r ON o.receiver_id = r.id").
-		Where("o.sender_id = u.id")
+		Select("jsonb_agg(jsonb_build_object('toUser', r.name, 'amount', l.amount))").
+		FromSelect(r.Builder.
+			Select("receiver_id", "SUM(amount) AS amount").
+			FromSelect(sentTransfers, "t").
+			GroupBy("receiver_id"), "l").
+		Join("users r ON l.receiver_id = r.id")
+
+	receivedTransfers := r.Builder.
+		Select("sender_id", "amount").
+		From("ledger_entries").
+		Where("receiver_id = u.id AND kind IN ('transfer', 'mint', 'allowance')").
+		Suffix("UNION ALL SELECT h.sender_id, rl.amount FROM ledger_entries rl " +
+			"JOIN ledger_entries h ON h.pending_transfer_id = rl.pending_transfer_id AND h.kind = 'escrow_hold' " +
+			"WHERE rl.receiver_id = u.id AND rl.kind = 'escrow_release'")
 
 	receivedSubquery := r.Builder.
-		Select("jsonb_agg(jsonb_build_object('fromUser', s.name, 'amount', o.amount))").
-		From("operations o").
-		Join("users s ON o.sender_id = s.id").
-		Where("o.receiver_id = u.id")
+		Select("jsonb_agg(jsonb_build_object('fromUser', s.name, 'amount', l.amount))").
+		FromSelect(r.Builder.
+			Select("sender_id", "SUM(amount) AS amount").
+			FromSelect(receivedTransfers, "t").
+			GroupBy("sender_id"), "l").
+		Join("users s ON l.sender_id = s.id")
+
+	refundsSubquery := r.Builder.
+		Select("jsonb_agg(jsonb_build_object('item', i.name, 'quantity', rf.quantity, 'amount', rf.amount))").
+		FromSelect(r.Builder.
+			Select("item_id", "SUM(quantity) AS quantity", "SUM(amount) AS amount").
+			From("returns").
+			Where("user_id = u.id AND status = 'approved'").
+			GroupBy("item_id"), "rf").
+		Join("items i ON rf.item_id = i.id")
+
+	// Expired transfers are hidden before they are returned to the sender.
+	incomingSubquery := r.Builder.
+		Select("jsonb_agg(jsonb_build_object('id', p.id, 'fromUser', s.name, 'amount', p.amount, 'expiresAt', p.expires_at) ORDER BY p.id)").
+		From("pending_transfers p").
+		Join("users s ON p.sender_id = s.id").
+		Where("p.receiver_id = u.id AND p.status = 'pending' AND p.expires_at > now()")
+
+	outgoingSubquery := r.Builder.
+		Select("jsonb_agg(jsonb_build_object('id', p.id, 'toUser', r.name, 'amount', p.amount, 'expiresAt', p.expires_at) ORDER BY p.id)").
+		From("pending_transfers p").
+		Join("users r ON p.receiver_id = r.id").
+		Where("p.sender_id = u.id AND p.status = 'pending' AND p.expires_at > now()")
 
 	inventorySql, _, _ := squirrel.Expr("(?) AS inventory", inventorySubquery).ToSql()
-	historySql, _, _ := squirrel.Expr("jsonb_build_object('sent', COALESCE((?), '[]'::jsonb), 'received', COALESCE((?), '[]'::jsonb)) AS coin_history", sentSubquery, receivedSubquery).ToSql()
+	historySql, _, _ := squirrel.Expr("jsonb_build_object('sent', COALESCE((?), '[]'::jsonb), 'received', COALESCE((?), '[]'::jsonb), 'refunds', COALESCE((?), '[]'::jsonb)) AS coin_history", sentSubquery, receivedSubquery, refundsSubquery).ToSql()
+	pendingSql, _, _ := squirrel.Expr("jsonb_build_object('incoming', COALESCE((?), '[]'::jsonb), 'outgoing', COALESCE((?), '[]'::jsonb)) AS pending_transfers", incomingSubquery, outgoingSubquery).ToSql()
 
 	sql, args, _ := r.Builder.
 		Select(
 			"u.balance",
 			inventorySql,
 			historySql,
+			pendingSql,
 		).
 		From("users u").
 		Where("u.id = ?", id).ToSql()
@@ -52,10 +98,12 @@ func (r *UserReportRepo) Get(ctx context.Context, id int) (entity.UserReport, er
 	var userReport entity.UserReport
 	var inventoryJSON []byte
 	var historyJSON []byte
+	var pendingJSON []byte
 	err := r.GetQueryRunner(ctx).QueryRow(ctx, sql, args...).Scan(
 		&userReport.Coins,
 		&inventoryJSON,
 		&historyJSON,
+		&pendingJSON,
 	)
 
 	if err != nil {
@@ -73,6 +121,10 @@ func (r *UserReportRepo) Get(ctx context.Context, id int) (entity.UserReport, er
 	if err != nil {
 		return entity.UserReport{}, fmt.Errorf("UserReportRepo.Get - Unmarshal History: %w", err)
 	}
+	err = json.Unmarshal(pendingJSON, &userReport.PendingTransfers)
+	if err != nil {
+		return entity.UserReport{}, fmt.Errorf("UserReportRepo.Get - Unmarshal PendingTransfers: %w", err)
+	}
 
 	return userReport, nil
 }
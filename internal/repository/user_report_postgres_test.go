@@ -11,6 +11,7 @@ import (
 	"github.com/spanwalla/merch-store/pkg/postgres"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestUserReportRepo_Get(t *testing.T) {
@@ -21,6 +22,8 @@ func TestUserReportRepo_Get(t *testing.T) {
 
 	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
 
+	expiresAt := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+
 	testCases := []struct {
 		name         string
 		args         args
@@ -66,14 +69,35 @@ func TestUserReportRepo_Get(t *testing.T) {
 							Amount: 5,
 						},
 					},
+					Refunds: []entity.RefundTransaction{
+						{
+							Item:     "book",
+							Quantity: 1,
+							Amount:   50,
+						},
+					},
+				}
+
+				expectedPendingTransfers := entity.PendingTransfers{
+					Incoming: []entity.IncomingTransfer{
+						{
+							Id:        3,
+							FromUser:  "user1",
+							Amount:    15,
+							ExpiresAt: expiresAt,
+						},
+					},
+					Outgoing: []entity.OutgoingTransfer{},
 				}
 
 				expectedInventoryJSON, _ := json.Marshal(expectedInventory)
 				expectedCoinHistoryJSON, _ := json.Marshal(expectedCoinHistory)
-				rows := pgxmock.NewRows([]string{"balance", "inventory", "coin_history"}).
-					AddRow(100, expectedInventoryJSON, expectedCoinHistoryJSON)
+				expectedPendingTransfersJSON, _ := json.Marshal(expectedPendingTransfers)
+				rows := pgxmock.NewRows([]string{"balance", "inventory", "coin_history", "pending_transfers"}).
+					AddRow(100, expectedInventoryJSON, expectedCoinHistoryJSON, expectedPendingTransfersJSON)
 
-				m.ExpectQuery(`SELECT u.balance`).
+				// Accepted pending transfers are found in the ledger by pairing the hold with the release.
+				m.ExpectQuery(`SELECT u.balance(.+)JOIN ledger_entries rl ON rl.pending_transfer_id = h.pending_transfer_id AND rl.kind = 'escrow_release'`).
 					WithArgs(args.id).
 					WillReturnRows(rows)
 			},
@@ -110,6 +134,24 @@ func TestUserReportRepo_Get(t *testing.T) {
 							Amount: 5,
 						},
 					},
+					Refunds: []entity.RefundTransaction{
+						{
+							Item:     "book",
+							Quantity: 1,
+							Amount:   50,
+						},
+					},
+				},
+				PendingTransfers: entity.PendingTransfers{
+					Incoming: []entity.IncomingTransfer{
+						{
+							Id:        3,
+							FromUser:  "user1",
+							Amount:    15,
+							ExpiresAt: expiresAt,
+						},
+					},
+					Outgoing: []entity.OutgoingTransfer{},
 				},
 			},
 			wantErr: false,
@@ -184,8 +226,8 @@ func TestUserReportRepo_Get(t *testing.T) {
 
 				expectedInventoryJSON, _ := json.Marshal(expectedInventory)
 				expectedCoinHistoryJSON, _ := json.Marshal(expectedCoinHistory)
-				rows := pgxmock.NewRows([]string{"balance", "inventory", "coin_history"}).
-					AddRow(100, append(expectedInventoryJSON, '1'), expectedCoinHistoryJSON)
+				rows := pgxmock.NewRows([]string{"balance", "inventory", "coin_history", "pending_transfers"}).
+					AddRow(100, append(expectedInventoryJSON, '1'), expectedCoinHistoryJSON, []byte(`{"incoming":[],"outgoing":[]}`))
 
 				m.ExpectQuery(`SELECT u.balance`).
 					WithArgs(args.id).
@@ -236,8 +278,8 @@ func TestUserReportRepo_Get(t *testing.T) {
 
 				expectedInventoryJSON, _ := json.Marshal(expectedInventory)
 				expectedCoinHistoryJSON, _ := json.Marshal(expectedCoinHistory)
-				rows := pgxmock.NewRows([]string{"balance", "inventory", "coin_history"}).
-					AddRow(100, expectedInventoryJSON, append(expectedCoinHistoryJSON, '!'))
+				rows := pgxmock.NewRows([]string{"balance", "inventory", "coin_history", "pending_transfers"}).
+					AddRow(100, expectedInventoryJSON, append(expectedCoinHistoryJSON, '!'), []byte(`{"incoming":[],"outgoing":[]}`))
 
 				m.ExpectQuery(`SELECT u.balance`).
 					WithArgs(args.id).
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/internal/repository"
+	"slices"
+	"time"
+)
+
+const (
+	apiKeyPrefix        = "msk_"
+	apiKeyDisplayLength = 12
+	// apiKeyTouchInterval bounds how often last_used_at is written for a busy key.
+	apiKeyTouchInterval = time.Minute
+)
+
+var apiKeyScopes = []string{
+	entity.ScopeReportRead,
+	entity.ScopeItemBuy,
+	entity.ScopeTransferSend,
+	entity.ScopeInviteCreate,
+}
+
+type APIKeyService struct {
+	userRepo   repository.User
+	apiKeyRepo repository.APIKey
+}
+
+func NewAPIKeyService(userRepo repository.User, apiKeyRepo repository.APIKey) *APIKeyService {
+	return &APIKeyService{
+		userRepo:   userRepo,
+		apiKeyRepo: apiKeyRepo,
+	}
+}
+
+// CreateServiceAccount creates a user which cannot log in with a password and can only own API keys.
+func (s *APIKeyService) CreateServiceAccount(ctx context.Context, name string) (int, error) {
+	if entity.IsReservedUserName(name) {
+		return 0, ErrUserAlreadyExists
+	}
+
+	userId, err := s.userRepo.CreateUser(ctx, entity.User{Name: name, Role: entity.RoleService})
+	if err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return 0, ErrUserAlreadyExists
+		}
+		log.Errorf("APIKeyService.CreateServiceAccount - userRepo.CreateUser: %v", err)
+		return 0, ErrCannotCreateUser
+	}
+
+	return userId, nil
+}
+
+// Create issues a new key for the service account. Only its hash is stored, so the key cannot be shown again.
+func (s *APIKeyService) Create(ctx context.Context, input APIKeyCreateInput) (APIKeyCreateOutput, error) {
+	if len(input.Scopes) == 0 {
+		return APIKeyCreateOutput{}, ErrInvalidScope
+	}
+	for _, scope := range input.Scopes {
+		if !slices.Contains(apiKeyScopes, scope) {
+			return APIKeyCreateOutput{}, ErrInvalidScope
+		}
+	}
+
+	user, err := s.serviceAccount(ctx, input.UserName)
+	if err != nil {
+		return APIKeyCreateOutput{}, err
+	}
+
+	token, err := newOpaqueToken()
+	if err != nil {
+		log.Errorf("APIKeyService.Create - newOpaqueToken: %v", err)
+		return APIKeyCreateOutput{}, ErrCannotCreateAPIKey
+	}
+	key := apiKeyPrefix + token
+
+	apiKey := entity.APIKey{
+		UserId:  user.Id,
+		Name:    input.Name,
+		Prefix:  key[:apiKeyDisplayLength],
+		KeyHash: hashOpaqueToken(key),
+		Scopes:  slices.Compact(slices.Sorted(slices.Values(input.Scopes))),
+	}
+
+	id, err := s.apiKeyRepo.Create(ctx, apiKey)
+	if err != nil {
+		log.Errorf("APIKeyService.Create - apiKeyRepo.Create: %v", err)
+		return APIKeyCreateOutput{}, ErrCannotCreateAPIKey
+	}
+
+	return APIKeyCreateOutput{Id: id, Key: key, Prefix: apiKey.Prefix, Scopes: apiKey.Scopes}, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context, userName string) ([]entity.APIKey, error) {
+	user, err := s.serviceAccount(ctx, userName)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := s.apiKeyRepo.GetByUserId(ctx, user.Id)
+	if err != nil {
+		log.Errorf("APIKeyService.List - apiKeyRepo.GetByUserId: %v", err)
+		return nil, ErrCannotGetAPIKeys
+	}
+
+	return keys, nil
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, id int) error {
+	err := s.apiKeyRepo.Revoke(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		log.Errorf("APIKeyService.Revoke - apiKeyRepo.Revoke: %v", err)
+		return ErrCannotRevokeAPIKey
+	}
+
+	return nil
+}
+
+// Verify returns the identity of the key owner with the scopes granted to the key.
+func (s *APIKeyService) Verify(ctx context.Context, key string) (AuthIdentity, error) {
+	apiKey, err := s.apiKeyRepo.GetActiveByHash(ctx, hashOpaqueToken(key))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return AuthIdentity{}, ErrInvalidAPIKey
+		}
+		log.Errorf("APIKeyService.Verify - apiKeyRepo.GetActiveByHash: %v", err)
+		return AuthIdentity{}, ErrCannotVerifyAPIKey
+	}
+
+	err = s.apiKeyRepo.Touch(ctx, apiKey.Id, time.Now().Add(-apiKeyTouchInterval))
+	if err != nil {
+		log.Errorf("APIKeyService.Verify - apiKeyRepo.Touch: %v", err)
+	}
+
+	return AuthIdentity{UserId: apiKey.UserId, Role: entity.RoleService, Scopes: apiKey.Scopes}, nil
+}
+
+func (s *APIKeyService) serviceAccount(ctx context.Context, name string) (entity.User, error) {
+	user, err := s.userRepo.GetUserByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return entity.User{}, ErrUserNotFound
+		}
+		log.Errorf("APIKeyService.serviceAccount - userRepo.GetUserByName: %v", err)
+		return entity.User{}, ErrCannotGetUser
+	}
+
+	if user.Role != entity.RoleService {
+		return entity.User{}, ErrNotServiceAccount
+	}
+
+	return user, nil
+}
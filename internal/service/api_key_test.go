@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/spanwalla/merch-store/internal/entity"
+	repomocks "github.com/spanwalla/merch-store/internal/mocks/repository"
+	"github.com/spanwalla/merch-store/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"strings"
+	"testing"
+)
+
+func TestAPIKeyService_CreateServiceAccount(t *testing.T) {
+	testCases := []struct {
+		name         string
+		userName     string
+		mockBehavior func(u *repomocks.MockUser)
+		want         int
+		wantErr      error
+	}{
+		{
+			name:     "success",
+			userName: "reward-bot",
+			mockBehavior: func(u *repomocks.MockUser) {
+				u.EXPECT().CreateUser(gomock.Any(), entity.User{Name: "reward-bot", Role: entity.RoleService}).
+					Return(7, nil)
+			},
+			want:    7,
+			wantErr: nil,
+		},
+		{
+			name:     "already exists",
+			userName: "reward-bot",
+			mockBehavior: func(u *repomocks.MockUser) {
+				u.EXPECT().CreateUser(gomock.Any(), gomock.Any()).
+					Return(0, repository.ErrAlreadyExists)
+			},
+			wantErr: ErrUserAlreadyExists,
+		},
+		{
+			name:         "reserved name",
+			userName:     "System",
+			mockBehavior: func(u *repomocks.MockUser) {},
+			wantErr:      ErrUserAlreadyExists,
+		},
+		{
+			name:     "create failed",
+			userName: "reward-bot",
+			mockBehavior: func(u *repomocks.MockUser) {
+				u.EXPECT().CreateUser(gomock.Any(), gomock.Any()).
+					Return(0, errors.New("some error"))
+			},
+			wantErr: ErrCannotCreateUser,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := repomocks.NewMockUser(ctrl)
+			tc.mockBehavior(userRepo)
+
+			s := NewAPIKeyService(userRepo, repomocks.NewMockAPIKey(ctrl))
+
+			got, err := s.CreateServiceAccount(context.Background(), tc.userName)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestAPIKeyService_Create(t *testing.T) {
+	type MockBehavior func(u *repomocks.MockUser, k *repomocks.MockAPIKey, input APIKeyCreateInput)
+
+	serviceAccount := entity.User{Id: 7, Name: "reward-bot", Role: entity.RoleService}
+
+	testCases := []struct {
+		name         string
+		input        APIKeyCreateInput
+		mockBehavior MockBehavior
+		wantScopes   []string
+		wantErr      error
+	}{
+		{
+			name:  "success",
+			input: APIKeyCreateInput{UserName: "reward-bot", Name: "rewards", Scopes: []string{"transfer:send", "report:read", "transfer:send"}},
+			mockBehavior: func(u *repomocks.MockUser, k *repomocks.MockAPIKey, input APIKeyCreateInput) {
+				u.EXPECT().GetUserByName(gomock.Any(), input.UserName).
+					Return(serviceAccount, nil)
+				k.EXPECT().Create(gomock.Any(), gomock.Cond(func(key entity.APIKey) bool {
+					return key.UserId == 7 && key.Name == "rewards" && len(key.KeyHash) == 64 && strings.HasPrefix(key.Prefix, apiKeyPrefix)
+				})).Return(1, nil)
+			},
+			wantScopes: []string{"report:read", "transfer:send"},
+			wantErr:    nil,
+		},
+		{
+			name:         "unknown scope",
+			input:        APIKeyCreateInput{UserName: "reward-bot", Name: "rewards", Scopes: []string{"admin:all"}},
+			mockBehavior: func(u *repomocks.MockUser, k *repomocks.MockAPIKey, input APIKeyCreateInput) {},
+			wantErr:      ErrInvalidScope,
+		},
+		{
+			name:         "no scopes",
+			input:        APIKeyCreateInput{UserName: "reward-bot", Name: "rewards"},
+			mockBehavior: func(u *repomocks.MockUser, k *repomocks.MockAPIKey, input APIKeyCreateInput) {},
+			wantErr:      ErrInvalidScope,
+		},
+		{
+			name:  "not a service account",
+			input: APIKeyCreateInput{UserName: "marcus", Name: "rewards", Scopes: []string{"report:read"}},
+			mockBehavior: func(u *repomocks.MockUser, k *repomocks.MockAPIKey, input APIKeyCreateInput) {
+				u.EXPECT().GetUserByName(gomock.Any(), input.UserName).
+					Return(entity.User{Id: 1, Name: "marcus", Role: entity.RoleUser}, nil)
+			},
+			wantErr: ErrNotServiceAccount,
+		},
+		{
+			name:  "user not found",
+			input: APIKeyCreateInput{UserName: "unknown", Name: "rewards", Scopes: []string{"report:read"}},
+			mockBehavior: func(u *repomocks.MockUser, k *repomocks.MockAPIKey, input APIKeyCreateInput) {
+				u.EXPECT().GetUserByName(gomock.Any(), input.UserName).
+					Return(entity.User{}, repository.ErrNotFound)
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name:  "create failed",
+			input: APIKeyCreateInput{UserName: "reward-bot", Name: "rewards", Scopes: []string{"report:read"}},
+			mockBehavior: func(u *repomocks.MockUser, k *repomocks.MockAPIKey, input APIKeyCreateInput) {
+				u.EXPECT().GetUserByName(gomock.Any(), input.UserName).
+					Return(serviceAccount, nil)
+				k.EXPECT().Create(gomock.Any(), gomock.Any()).
+					Return(0, errors.New("some error"))
+			},
+			wantErr: ErrCannotCreateAPIKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := repomocks.NewMockUser(ctrl)
+			apiKeyRepo := repomocks.NewMockAPIKey(ctrl)
+			tc.mockBehavior(userRepo, apiKeyRepo, tc.input)
+
+			s := NewAPIKeyService(userRepo, apiKeyRepo)
+
+			got, err := s.Create(context.Background(), tc.input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(got.Key, got.Prefix))
+			assert.Equal(t, tc.wantScopes, got.Scopes)
+		})
+	}
+}
+
+func TestAPIKeyService_Verify(t *testing.T) {
+	const key = "msk_key"
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(k *repomocks.MockAPIKey)
+		want         AuthIdentity
+		wantErr      error
+	}{
+		{
+			name: "success",
+			mockBehavior: func(k *repomocks.MockAPIKey) {
+				k.EXPECT().GetActiveByHash(gomock.Any(), hashOpaqueToken(key)).
+					Return(entity.APIKey{Id: 1, UserId: 7, Scopes: []string{"transfer:send"}}, nil)
+				k.EXPECT().Touch(gomock.Any(), 1, gomock.Any()).
+					Return(nil)
+			},
+			want:    AuthIdentity{UserId: 7, Role: entity.RoleService, Scopes: []string{"transfer:send"}},
+			wantErr: nil,
+		},
+		{
+			name: "touch failure is ignored",
+			mockBehavior: func(k *repomocks.MockAPIKey) {
+				k.EXPECT().GetActiveByHash(gomock.Any(), hashOpaqueToken(key)).
+					Return(entity.APIKey{Id: 1, UserId: 7, Scopes: []string{"transfer:send"}}, nil)
+				k.EXPECT().Touch(gomock.Any(), 1, gomock.Any()).
+					Return(errors.New("some error"))
+			},
+			want:    AuthIdentity{UserId: 7, Role: entity.RoleService, Scopes: []string{"transfer:send"}},
+			wantErr: nil,
+		},
+		{
+			name: "unknown or revoked key",
+			mockBehavior: func(k *repomocks.MockAPIKey) {
+				k.EXPECT().GetActiveByHash(gomock.Any(), hashOpaqueToken(key)).
+					Return(entity.APIKey{}, repository.ErrNotFound)
+			},
+			wantErr: ErrInvalidAPIKey,
+		},
+		{
+			name: "repo error",
+			mockBehavior: func(k *repomocks.MockAPIKey) {
+				k.EXPECT().GetActiveByHash(gomock.Any(), hashOpaqueToken(key)).
+					Return(entity.APIKey{}, errors.New("some error"))
+			},
+			wantErr: ErrCannotVerifyAPIKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			apiKeyRepo := repomocks.NewMockAPIKey(ctrl)
+			tc.mockBehavior(apiKeyRepo)
+
+			s := NewAPIKeyService(repomocks.NewMockUser(ctrl), apiKeyRepo)
+
+			got, err := s.Verify(context.Background(), key)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestAPIKeyService_Revoke(t *testing.T) {
+	testCases := []struct {
+		name    string
+		repoErr error
+		wantErr error
+	}{
+		{
+			name:    "success",
+			repoErr: nil,
+			wantErr: nil,
+		},
+		{
+			name:    "not found",
+			repoErr: repository.ErrNotFound,
+			wantErr: ErrAPIKeyNotFound,
+		},
+		{
+			name:    "repo error",
+			repoErr: errors.New("some error"),
+			wantErr: ErrCannotRevokeAPIKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			apiKeyRepo := repomocks.NewMockAPIKey(ctrl)
+			apiKeyRepo.EXPECT().Revoke(gomock.Any(), 1).Return(tc.repoErr)
+
+			s := NewAPIKeyService(repomocks.NewMockUser(ctrl), apiKeyRepo)
+
+			err := s.Revoke(context.Background(), 1)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
@@ -2,6 +2,10 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt"
@@ -9,29 +13,98 @@ import (
 	"github.com/spanwalla/merch-store/internal/entity"
 	"github.com/spanwalla/merch-store/internal/repository"
 	"github.com/spanwalla/merch-store/pkg/hasher"
+	"github.com/spanwalla/merch-store/pkg/jwks"
+	"sync"
 	"time"
 )
 
 type TokenClaims struct {
 	jwt.StandardClaims
 	UserId int
+	Role   string
+}
+
+// AuthMode defines how accounts are created.
+type AuthMode string
+
+const (
+	// AuthModeAutoRegister creates an account on the first login with an unknown name.
+	AuthModeAutoRegister AuthMode = "auto_register"
+	// AuthModeExplicit requires registration via Register before login.
+	AuthModeExplicit AuthMode = "explicit"
+	// AuthModeInviteOnly is AuthModeExplicit that also requires an invite code.
+	AuthModeInviteOnly AuthMode = "invite_only"
+)
+
+func ParseAuthMode(mode string) (AuthMode, error) {
+	switch AuthMode(mode) {
+	case AuthModeAutoRegister, AuthModeExplicit, AuthModeInviteOnly:
+		return AuthMode(mode), nil
+	default:
+		return "", fmt.Errorf("unknown auth mode %q", mode)
+	}
+}
+
+type AuthServiceConfig struct {
+	Mode            AuthMode
+	Keys            *jwks.KeySet
+	TokenTTL        time.Duration
+	RefreshTokenTTL time.Duration
+	InviteTTL       time.Duration
+	// PasswordResetTTL is how long a reset token created by an admin stays valid.
+	PasswordResetTTL time.Duration
+	Lockout          LoginThrottleConfig
+	// ProxyAutoProvision creates users authenticated by a trusted proxy on their first request.
+	ProxyAutoProvision bool
+	// CheckPassword checks the password of an account created on login in AuthModeAutoRegister
+	// against the password policy. Nil accepts any password.
+	CheckPassword func(password string) error
 }
 
 type AuthService struct {
-	userRepo       repository.User
-	passwordHasher hasher.PasswordHasher
-	signKey        string
-	tokenTTL       time.Duration
+	userRepo          repository.User
+	refreshTokenRepo  repository.RefreshToken
+	inviteRepo        repository.Invite
+	passwordResetRepo repository.PasswordReset
+	throttle          *LoginThrottle
+	revocations       *TokenRevocationStore
+	passwordHasher    hasher.PasswordHasher
+	transactor        repository.Transactor
+	cfg               AuthServiceConfig
+
+	dummyHashOnce sync.Once
+	dummyHash     string
 }
 
-func NewAuthService(userRepo repository.User, passwordHasher hasher.PasswordHasher, signKey string, tokenTTL time.Duration) *AuthService {
-	return &AuthService{userRepo: userRepo, passwordHasher: passwordHasher, signKey: signKey, tokenTTL: tokenTTL}
+func NewAuthService(userRepo repository.User, refreshTokenRepo repository.RefreshToken, inviteRepo repository.Invite, passwordResetRepo repository.PasswordReset, loginAttemptRepo repository.LoginAttempt, revocations *TokenRevocationStore, passwordHasher hasher.PasswordHasher, transactor repository.Transactor, cfg AuthServiceConfig) *AuthService {
+	return &AuthService{
+		userRepo:          userRepo,
+		refreshTokenRepo:  refreshTokenRepo,
+		inviteRepo:        inviteRepo,
+		passwordResetRepo: passwordResetRepo,
+		throttle:          NewLoginThrottle(loginAttemptRepo, cfg.Lockout),
+		revocations:       revocations,
+		passwordHasher:    passwordHasher,
+		transactor:        transactor,
+		cfg:               cfg,
+	}
 }
 
-func (s *AuthService) createUser(ctx context.Context, input AuthGenerateTokenInput) (int, error) {
+func (s *AuthService) createUser(ctx context.Context, name, password string) (int, error) {
+	if entity.IsReservedUserName(name) {
+		return 0, ErrUserAlreadyExists
+	}
+
+	hash, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		log.Errorf("AuthService.createUser - passwordHasher.Hash: %v", err)
+		return 0, ErrCannotCreateUser
+	}
+
 	user := entity.User{
-		Name:     input.Name,
-		Password: s.passwordHasher.Hash(input.Password),
+		Name:     name,
+		Password: hash,
+		Role:     entity.RoleUser,
 	}
 	userId, err := s.userRepo.CreateUser(ctx, user)
 	if err != nil {
@@ -44,60 +117,630 @@ func (s *AuthService) createUser(ctx context.Context, input AuthGenerateTokenInp
 	return userId, nil
 }
 
-func (s *AuthService) GenerateToken(ctx context.Context, input AuthGenerateTokenInput) (string, error) {
+func (s *AuthService) GenerateToken(ctx context.Context, input AuthGenerateTokenInput) (AuthTokens, error) {
+	err := s.throttle.Check(ctx, input.Name, input.IP)
+	if err != nil {
+		if errors.Is(err, ErrAccountLocked) {
+			return AuthTokens{}, err
+		}
+		log.Errorf("AuthService.GenerateToken - throttle.Check: %v", err)
+		return AuthTokens{}, ErrCannotCheckLoginAttempts
+	}
+
 	user, err := s.userRepo.GetUserByName(ctx, input.Name)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
+			// An unknown name gets the same answer as a wrong password, so names cannot be probed.
+			if s.cfg.Mode != AuthModeAutoRegister {
+				s.verifyDummyPassword(input.Password)
+				s.throttle.Fail(ctx, input.Name, input.IP)
+				return AuthTokens{}, ErrInvalidCredentials
+			}
+			if s.cfg.CheckPassword != nil {
+				err = s.cfg.CheckPassword(input.Password)
+				if err != nil {
+					return AuthTokens{}, err
+				}
+			}
+
 			var userId int
-			userId, err = s.createUser(ctx, input)
+			userId, err = s.createUser(ctx, input.Name, input.Password)
 			if err != nil {
-				return "", err
+				return AuthTokens{}, err
 			}
 			user.Id = userId
+			user.Role = entity.RoleUser
 		} else {
 			log.Errorf("AuthService.GenerateToken - userRepo.GetUserByName: %v", err)
-			return "", ErrCannotGetUser
+			return AuthTokens{}, ErrCannotGetUser
+		}
+	} else {
+		// Service accounts and users provisioned by a trusted proxy have no password.
+		if !entity.CanLogInWithPassword(user.Role) || user.Password == "" {
+			s.verifyDummyPassword(input.Password)
+			s.throttle.Fail(ctx, input.Name, input.IP)
+			return AuthTokens{}, ErrInvalidCredentials
+		}
+
+		var ok bool
+		ok, err = s.passwordHasher.Verify(input.Password, user.Password)
+		if err != nil {
+			log.Errorf("AuthService.GenerateToken - passwordHasher.Verify: %v", err)
+			return AuthTokens{}, ErrCannotVerifyPassword
+		}
+		if !ok {
+			s.throttle.Fail(ctx, input.Name, input.IP)
+			return AuthTokens{}, ErrInvalidCredentials
+		}
+		s.upgradePasswordHash(ctx, user, input.Password)
+
+		err = s.throttle.Reset(ctx, input.Name)
+		if err != nil {
+			log.Errorf("AuthService.GenerateToken - throttle.Reset: %v", err)
+		}
+	}
+
+	return s.issueTokens(ctx, user.Id, user.Role, "")
+}
+
+// Register creates an account and logs it in. In AuthModeInviteOnly the invite
+// code is consumed in the same transaction as the account is created.
+func (s *AuthService) Register(ctx context.Context, input AuthRegisterInput) (AuthTokens, error) {
+	if s.cfg.Mode == AuthModeInviteOnly && input.InviteCode == "" {
+		return AuthTokens{}, ErrInviteCodeRequired
+	}
+
+	var userId int
+	err := s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if s.cfg.Mode == AuthModeInviteOnly {
+			err := s.inviteRepo.Use(txCtx, hashOpaqueToken(input.InviteCode))
+			if err != nil {
+				if errors.Is(err, repository.ErrNotFound) {
+					return ErrInvalidInviteCode
+				}
+				log.Errorf("AuthService.Register - inviteRepo.Use: %v", err)
+				return ErrCannotCreateUser
+			}
 		}
-	} else if user.Password != s.passwordHasher.Hash(input.Password) {
-		return "", ErrWrongPassword
+
+		var err error
+		userId, err = s.createUser(txCtx, input.Name, input.Password)
+		return err
+	})
+	if err != nil {
+		return AuthTokens{}, err
+	}
+
+	return s.issueTokens(ctx, userId, entity.RoleUser, "")
+}
+
+// CreateInvite returns a new invite code. Only its hash is stored, so the code cannot be shown again.
+func (s *AuthService) CreateInvite(ctx context.Context, input AuthCreateInviteInput) (AuthInvite, error) {
+	code, err := newOpaqueToken()
+	if err != nil {
+		log.Errorf("AuthService.CreateInvite - newOpaqueToken: %v", err)
+		return AuthInvite{}, ErrCannotCreateInvite
+	}
+
+	invite := entity.Invite{
+		CodeHash:  hashOpaqueToken(code),
+		CreatedBy: input.UserId,
+		MaxUses:   input.MaxUses,
+		ExpiresAt: time.Now().Add(s.cfg.InviteTTL),
+	}
+	if invite.MaxUses <= 0 {
+		invite.MaxUses = 1
+	}
+
+	_, err = s.inviteRepo.Create(ctx, invite)
+	if err != nil {
+		log.Errorf("AuthService.CreateInvite - inviteRepo.Create: %v", err)
+		return AuthInvite{}, ErrCannotCreateInvite
+	}
+
+	return AuthInvite{Code: code, MaxUses: invite.MaxUses, ExpiresAt: invite.ExpiresAt}, nil
+}
+
+// RefreshToken exchanges a refresh token for a new token pair. The presented token
+// is revoked, and presenting it again revokes the whole family it belongs to.
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (AuthTokens, error) {
+	var (
+		tokens AuthTokens
+		reused bool
+	)
+
+	err := s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		// The transaction is retried after a serialization failure, only the last attempt counts.
+		tokens, reused = AuthTokens{}, false
+
+		token, err := s.refreshTokenRepo.GetByHashForUpdate(txCtx, hashOpaqueToken(refreshToken))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			log.Errorf("AuthService.RefreshToken - refreshTokenRepo.GetByHashForUpdate: %v", err)
+			return ErrCannotRefreshToken
+		}
+
+		// An already rotated token was presented again, so it has leaked: revoke
+		// the family to force both the attacker and the owner to log in again.
+		if token.RevokedAt != nil {
+			reused = true
+			err = s.refreshTokenRepo.RevokeFamily(txCtx, token.FamilyId)
+			if err != nil {
+				log.Errorf("AuthService.RefreshToken - refreshTokenRepo.RevokeFamily: %v", err)
+				return ErrCannotRefreshToken
+			}
+			return nil
+		}
+
+		if time.Now().After(token.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		err = s.refreshTokenRepo.Revoke(txCtx, token.Id)
+		if err != nil {
+			log.Errorf("AuthService.RefreshToken - refreshTokenRepo.Revoke: %v", err)
+			return ErrCannotRefreshToken
+		}
+
+		// The role may have changed since login, so it is read again.
+		user, err := s.userRepo.GetUserById(txCtx, token.UserId)
+		if err != nil {
+			log.Errorf("AuthService.RefreshToken - userRepo.GetUserById: %v", err)
+			return ErrCannotRefreshToken
+		}
+
+		tokens, err = s.issueTokens(txCtx, user.Id, user.Role, token.FamilyId)
+		return err
+	})
+	if err != nil {
+		return AuthTokens{}, err
+	}
+
+	if reused {
+		log.Warnf("AuthService.RefreshToken: refresh token reuse detected, family revoked")
+		return AuthTokens{}, ErrRefreshTokenReused
+	}
+
+	return tokens, nil
+}
+
+// issueTokens signs an access token and stores a new refresh token. An empty
+// familyId starts a new family, as on login.
+func (s *AuthService) issueTokens(ctx context.Context, userId int, role, familyId string) (AuthTokens, error) {
+	jti, err := newOpaqueToken()
+	if err != nil {
+		log.Errorf("AuthService.issueTokens - newOpaqueToken: %v", err)
+		return AuthTokens{}, ErrCannotSignToken
 	}
 
 	// Generate JWT
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &TokenClaims{
+	key := s.cfg.Keys.SigningKey()
+	token := jwt.NewWithClaims(key.Method, &TokenClaims{
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(s.tokenTTL).Unix(),
+			Id:        jti,
+			ExpiresAt: time.Now().Add(s.cfg.TokenTTL).Unix(),
 			IssuedAt:  time.Now().Unix(),
 		},
-		UserId: user.Id,
+		UserId: userId,
+		Role:   role,
 	})
+	token.Header["kid"] = key.Id
 
 	// Sign token
-	tokenString, err := token.SignedString([]byte(s.signKey))
+	accessToken, err := token.SignedString(key.PrivateKey)
+	if err != nil {
+		log.Errorf("AuthService.issueTokens - token.SignedString: %v", err)
+		return AuthTokens{}, ErrCannotSignToken
+	}
+
+	if familyId == "" {
+		familyId, err = newOpaqueToken()
+		if err != nil {
+			log.Errorf("AuthService.issueTokens - newOpaqueToken: %v", err)
+			return AuthTokens{}, ErrCannotIssueRefreshToken
+		}
+	}
+
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		log.Errorf("AuthService.issueTokens - newOpaqueToken: %v", err)
+		return AuthTokens{}, ErrCannotIssueRefreshToken
+	}
+
+	err = s.refreshTokenRepo.Create(ctx, entity.RefreshToken{
+		UserId:    userId,
+		FamilyId:  familyId,
+		TokenHash: hashOpaqueToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.cfg.RefreshTokenTTL),
+	})
+	if err != nil {
+		log.Errorf("AuthService.issueTokens - refreshTokenRepo.Create: %v", err)
+		return AuthTokens{}, ErrCannotIssueRefreshToken
+	}
+
+	return AuthTokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// upgradePasswordHash re-hashes the password with the current algorithm if the stored
+// hash is legacy or uses outdated parameters. Failures are logged, login proceeds anyway.
+func (s *AuthService) upgradePasswordHash(ctx context.Context, user entity.User, password string) {
+	if !s.passwordHasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	hash, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		log.Errorf("AuthService.upgradePasswordHash - passwordHasher.Hash: %v", err)
+		return
+	}
+
+	err = s.userRepo.UpdatePassword(ctx, user.Id, hash)
+	if err != nil {
+		log.Errorf("AuthService.upgradePasswordHash - userRepo.UpdatePassword: %v", err)
+	}
+}
+
+func (s *AuthService) VerifyToken(tokenString string) (AuthIdentity, error) {
+	claims, err := s.parseToken(tokenString)
+	if err != nil {
+		return AuthIdentity{}, err
+	}
+
+	if s.revocations.IsRevoked(claims.Id) || s.revocations.IsUserRevoked(claims.UserId, time.Unix(claims.IssuedAt, 0)) {
+		return AuthIdentity{}, ErrTokenRevoked
+	}
+
+	return AuthIdentity{UserId: claims.UserId, Role: claims.Role}, nil
+}
+
+// ProxyIdentity returns the identity of a user already authenticated by a trusted proxy.
+// Unknown users are created without a password if ProxyAutoProvision is set.
+func (s *AuthService) ProxyIdentity(ctx context.Context, userName string) (AuthIdentity, error) {
+	user, err := s.userRepo.GetUserByName(ctx, userName)
+	if errors.Is(err, repository.ErrNotFound) && s.cfg.ProxyAutoProvision && !entity.IsReservedUserName(userName) {
+		_, err = s.userRepo.CreateUser(ctx, entity.User{Name: userName, Role: entity.RoleUser})
+		if err != nil && !errors.Is(err, repository.ErrAlreadyExists) {
+			log.Errorf("AuthService.ProxyIdentity - userRepo.CreateUser: %v", err)
+			return AuthIdentity{}, ErrCannotCreateUser
+		}
+
+		// The user is read again, because a concurrent request may have created it first.
+		user, err = s.userRepo.GetUserByName(ctx, userName)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return AuthIdentity{}, ErrUserNotFound
+		}
+		log.Errorf("AuthService.ProxyIdentity - userRepo.GetUserByName: %v", err)
+		return AuthIdentity{}, ErrCannotGetUser
+	}
+
+	if !entity.CanLogInWithPassword(user.Role) {
+		return AuthIdentity{}, ErrServiceAccountLogin
+	}
+
+	return AuthIdentity{UserId: user.Id, Role: user.Role}, nil
+}
+
+// SetRole changes the role of the user and revokes the access tokens issued with the old one.
+// Refresh tokens stay valid, so clients get the new role on the next refresh.
+func (s *AuthService) SetRole(ctx context.Context, input AuthSetRoleInput) error {
+	user, err := s.userRepo.GetUserByName(ctx, input.UserName)
 	if err != nil {
-		log.Errorf("AuthService.GenerateToken - token.SignedString: %v", err)
-		return "", ErrCannotSignToken
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		log.Errorf("AuthService.SetRole - userRepo.GetUserByName: %v", err)
+		return ErrCannotGetUser
+	}
+
+	// Minted coins and allowances are sent by the account with the system role, it must keep it.
+	if user.Role == entity.RoleSystem || input.Role == entity.RoleSystem {
+		return ErrSystemAccountRole
+	}
+	// API keys always authenticate as a service account, whatever role the account has.
+	if user.Role != input.Role && (user.Role == entity.RoleService || input.Role == entity.RoleService) {
+		return ErrServiceAccountRole
+	}
+
+	if user.Role == input.Role {
+		return nil
+	}
+
+	err = s.userRepo.UpdateRole(ctx, user.Id, input.Role)
+	if err != nil {
+		log.Errorf("AuthService.SetRole - userRepo.UpdateRole: %v", err)
+		return ErrCannotSetRole
+	}
+
+	return s.revokeAccessTokens(ctx, user.Id)
+}
+
+// ChangePassword sets a new password after checking the current one. All tokens of the
+// user are revoked, so other sessions have to log in again.
+func (s *AuthService) ChangePassword(ctx context.Context, input AuthChangePasswordInput) error {
+	user, err := s.userRepo.GetUserById(ctx, input.UserId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		log.Errorf("AuthService.ChangePassword - userRepo.GetUserById: %v", err)
+		return ErrCannotGetUser
+	}
+
+	if !entity.CanLogInWithPassword(user.Role) || user.Password == "" {
+		return ErrWrongPassword
+	}
+
+	ok, err := s.passwordHasher.Verify(input.OldPassword, user.Password)
+	if err != nil {
+		log.Errorf("AuthService.ChangePassword - passwordHasher.Verify: %v", err)
+		return ErrCannotVerifyPassword
+	}
+	if !ok {
+		return ErrWrongPassword
+	}
+
+	hash, err := s.passwordHasher.Hash(input.NewPassword)
+	if err != nil {
+		log.Errorf("AuthService.ChangePassword - passwordHasher.Hash: %v", err)
+		return ErrCannotChangePassword
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		return s.setPassword(txCtx, user.Id, hash)
+	})
+	if err != nil {
+		return err
 	}
 
-	return tokenString, nil
+	return s.revokeAccessTokens(ctx, user.Id)
 }
 
-func (s *AuthService) VerifyToken(tokenString string) (int, error) {
+// CreatePasswordReset returns a one-time token the user can set a new password with.
+// Only its hash is stored, and previous unused tokens of the user stop working.
+func (s *AuthService) CreatePasswordReset(ctx context.Context, input AuthCreatePasswordResetInput) (AuthPasswordReset, error) {
+	user, err := s.userRepo.GetUserByName(ctx, input.UserName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return AuthPasswordReset{}, ErrUserNotFound
+		}
+		log.Errorf("AuthService.CreatePasswordReset - userRepo.GetUserByName: %v", err)
+		return AuthPasswordReset{}, ErrCannotGetUser
+	}
+
+	if !entity.CanLogInWithPassword(user.Role) {
+		return AuthPasswordReset{}, ErrPasswordNotAllowed
+	}
+
+	token, err := newOpaqueToken()
+	if err != nil {
+		log.Errorf("AuthService.CreatePasswordReset - newOpaqueToken: %v", err)
+		return AuthPasswordReset{}, ErrCannotCreatePasswordReset
+	}
+
+	reset := entity.PasswordReset{
+		UserId:    user.Id,
+		TokenHash: hashOpaqueToken(token),
+		CreatedBy: input.CreatedBy,
+		ExpiresAt: time.Now().Add(s.cfg.PasswordResetTTL),
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		err := s.passwordResetRepo.InvalidateByUserId(txCtx, user.Id)
+		if err != nil {
+			log.Errorf("AuthService.CreatePasswordReset - passwordResetRepo.InvalidateByUserId: %v", err)
+			return ErrCannotCreatePasswordReset
+		}
+
+		_, err = s.passwordResetRepo.Create(txCtx, reset)
+		if err != nil {
+			log.Errorf("AuthService.CreatePasswordReset - passwordResetRepo.Create: %v", err)
+			return ErrCannotCreatePasswordReset
+		}
+
+		return nil
+	})
+	if err != nil {
+		return AuthPasswordReset{}, err
+	}
+
+	return AuthPasswordReset{Token: token, ExpiresAt: reset.ExpiresAt}, nil
+}
+
+// ResetPassword consumes a reset token and sets a new password. All tokens of the user are revoked.
+func (s *AuthService) ResetPassword(ctx context.Context, input AuthResetPasswordInput) error {
+	hash, err := s.passwordHasher.Hash(input.NewPassword)
+	if err != nil {
+		log.Errorf("AuthService.ResetPassword - passwordHasher.Hash: %v", err)
+		return ErrCannotChangePassword
+	}
+
+	var userId int
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		userId, err = s.passwordResetRepo.Use(txCtx, hashOpaqueToken(input.Token))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrInvalidPasswordResetToken
+			}
+			log.Errorf("AuthService.ResetPassword - passwordResetRepo.Use: %v", err)
+			return ErrCannotChangePassword
+		}
+
+		// The role may have changed since the token was created.
+		user, err := s.userRepo.GetUserById(txCtx, userId)
+		if err != nil {
+			log.Errorf("AuthService.ResetPassword - userRepo.GetUserById: %v", err)
+			return ErrCannotChangePassword
+		}
+		if !entity.CanLogInWithPassword(user.Role) {
+			return ErrPasswordNotAllowed
+		}
+
+		return s.setPassword(txCtx, userId, hash)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.revokeAccessTokens(ctx, userId)
+}
+
+// setPassword stores the hash and revokes refresh tokens and pending reset tokens of the user.
+// It is meant to be called within a transaction.
+func (s *AuthService) setPassword(ctx context.Context, userId int, hash string) error {
+	err := s.userRepo.UpdatePassword(ctx, userId, hash)
+	if err != nil {
+		log.Errorf("AuthService.setPassword - userRepo.UpdatePassword: %v", err)
+		return ErrCannotChangePassword
+	}
+
+	err = s.refreshTokenRepo.RevokeByUserId(ctx, userId)
+	if err != nil {
+		log.Errorf("AuthService.setPassword - refreshTokenRepo.RevokeByUserId: %v", err)
+		return ErrCannotChangePassword
+	}
+
+	err = s.passwordResetRepo.InvalidateByUserId(ctx, userId)
+	if err != nil {
+		log.Errorf("AuthService.setPassword - passwordResetRepo.InvalidateByUserId: %v", err)
+		return ErrCannotChangePassword
+	}
+
+	return nil
+}
+
+// revokeAccessTokens revokes all access tokens issued to the user until now.
+func (s *AuthService) revokeAccessTokens(ctx context.Context, userId int) error {
+	now := time.Now()
+	err := s.revocations.RevokeUser(ctx, userId, now, now.Add(s.cfg.TokenTTL))
+	if err != nil {
+		log.Errorf("AuthService.revokeAccessTokens - revocations.RevokeUser: %v", err)
+		return ErrCannotRevokeToken
+	}
+
+	return nil
+}
+
+// Unlock removes the lockout and forgets failed logins of the username.
+func (s *AuthService) Unlock(ctx context.Context, userName string) error {
+	err := s.throttle.Reset(ctx, userName)
+	if err != nil {
+		log.Errorf("AuthService.Unlock - throttle.Reset: %v", err)
+		return ErrCannotUnlockUser
+	}
+
+	return nil
+}
+
+// Logout revokes the access token and, if given, the refresh token family of the same user.
+func (s *AuthService) Logout(ctx context.Context, input AuthLogoutInput) error {
+	claims, err := s.parseToken(input.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	err = s.revocations.Revoke(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		log.Errorf("AuthService.Logout - revocations.Revoke: %v", err)
+		return ErrCannotRevokeToken
+	}
+
+	if input.RefreshToken == "" {
+		return nil
+	}
+
+	// The row stays locked until the family is revoked, so a concurrent refresh cannot rotate the token in between.
+	return s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		token, err := s.refreshTokenRepo.GetByHashForUpdate(txCtx, hashOpaqueToken(input.RefreshToken))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil
+			}
+			log.Errorf("AuthService.Logout - refreshTokenRepo.GetByHashForUpdate: %v", err)
+			return ErrCannotRevokeToken
+		}
+
+		if token.UserId != claims.UserId {
+			return nil
+		}
+
+		err = s.refreshTokenRepo.RevokeFamily(txCtx, token.FamilyId)
+		if err != nil {
+			log.Errorf("AuthService.Logout - refreshTokenRepo.RevokeFamily: %v", err)
+			return ErrCannotRevokeToken
+		}
+
+		return nil
+	})
+}
+
+// JWKS returns the public keys tokens can be verified with.
+func (s *AuthService) JWKS() jwks.JWKS {
+	return s.cfg.Keys.JWKS()
+}
+
+func (s *AuthService) parseToken(tokenString string) (*TokenClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := s.cfg.Keys.Key(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %q", kid)
+		}
+
+		// The algorithm is bound to the key, not taken from the token header.
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 
-		return []byte(s.signKey), nil
+		return key.PublicKey, nil
 	})
 
 	if err != nil {
-		return 0, ErrCannotParseToken
+		return nil, ErrCannotParseToken
 	}
 
 	claims, ok := token.Claims.(*TokenClaims)
-	if !ok {
-		return 0, ErrCannotParseToken
+	if !ok || claims.Id == "" {
+		return nil, ErrCannotParseToken
 	}
 
-	return claims.UserId, nil
+	return claims, nil
+}
+
+// verifyDummyPassword takes as long as checking a real password, so that the response time
+// of a failed login does not tell whether the user exists or has a password.
+func (s *AuthService) verifyDummyPassword(password string) {
+	s.dummyHashOnce.Do(func() {
+		dummyPassword, err := newOpaqueToken()
+		if err != nil {
+			log.Errorf("AuthService.verifyDummyPassword - newOpaqueToken: %v", err)
+			return
+		}
+		s.dummyHash, err = s.passwordHasher.Hash(dummyPassword)
+		if err != nil {
+			log.Errorf("AuthService.verifyDummyPassword - passwordHasher.Hash: %v", err)
+		}
+	})
+
+	_, _ = s.passwordHasher.Verify(password, s.dummyHash)
+}
+
+// newOpaqueToken returns 256 random bits encoded as URL-safe base64.
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashOpaqueToken returns the hex-encoded SHA-256 of the token. Opaque tokens are
+// random enough that a fast unsalted hash is sufficient for storage.
+func hashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
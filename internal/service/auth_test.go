@@ -2,12 +2,14 @@ package service
 
 import (
 	"context"
+	"crypto/ed25519"
 	"errors"
 	"github.com/golang-jwt/jwt"
 	"github.com/spanwalla/merch-store/internal/entity"
 	hashermocks "github.com/spanwalla/merch-store/internal/mocks/hasher"
 	"github.com/spanwalla/merch-store/internal/mocks/repository"
 	"github.com/spanwalla/merch-store/internal/repository"
+	"github.com/spanwalla/merch-store/pkg/jwks"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"testing"
@@ -16,14 +18,16 @@ import (
 
 func TestAuthService_createUser(t *testing.T) {
 	const secret = "jwt_test_secret"
+	keys := newTestKeySet(t, "current")
 	const tokenTTL = 2 * time.Hour
+	const refreshTokenTTL = 30 * 24 * time.Hour
 
 	type args struct {
 		ctx   context.Context
 		input AuthGenerateTokenInput
 	}
 
-	type MockBehavior func(u *repomocks.MockUser, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args)
+	type MockBehavior func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args)
 
 	testCases := []struct {
 		name         string
@@ -41,10 +45,10 @@ func TestAuthService_createUser(t *testing.T) {
 					Password: "simplePa66!",
 				},
 			},
-			mockBehavior: func(u *repomocks.MockUser, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
 				h.EXPECT().Hash(args.input.Password).
-					Return(args.input.Password)
-				u.EXPECT().CreateUser(args.ctx, entity.User{Name: args.input.Name, Password: args.input.Password}).
+					Return(args.input.Password, nil)
+				u.EXPECT().CreateUser(args.ctx, entity.User{Name: args.input.Name, Password: args.input.Password, Role: entity.RoleUser}).
 					Return(1, nil)
 			},
 			want:    1,
@@ -59,15 +63,29 @@ func TestAuthService_createUser(t *testing.T) {
 					Password: "simplePa66!",
 				},
 			},
-			mockBehavior: func(u *repomocks.MockUser, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
 				h.EXPECT().Hash(args.input.Password).
-					Return(args.input.Password)
-				u.EXPECT().CreateUser(args.ctx, entity.User{Name: args.input.Name, Password: args.input.Password}).
+					Return(args.input.Password, nil)
+				u.EXPECT().CreateUser(args.ctx, entity.User{Name: args.input.Name, Password: args.input.Password, Role: entity.RoleUser}).
 					Return(0, repository.ErrAlreadyExists)
 			},
 			want:    0,
 			wantErr: true,
 		},
+		{
+			name: "reserved name",
+			args: args{
+				ctx: context.Background(),
+				input: AuthGenerateTokenInput{
+					Name:     "System",
+					Password: "simplePa66!",
+				},
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
+			},
+			want:    0,
+			wantErr: true,
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -75,12 +93,21 @@ func TestAuthService_createUser(t *testing.T) {
 			defer ctrl.Finish()
 
 			userRepo := repomocks.NewMockUser(ctrl)
+			refreshTokenRepo := repomocks.NewMockRefreshToken(ctrl)
+			inviteRepo := repomocks.NewMockInvite(ctrl)
+			revocations := NewTokenRevocationStore(repomocks.NewMockRevokedToken(ctrl), repomocks.NewMockRevokedUser(ctrl))
 			hasher := hashermocks.NewMockPasswordHasher(ctrl)
-			tc.mockBehavior(userRepo, hasher, secret, tokenTTL, tc.args)
+			transactor := repomocks.NewMockTransactor(ctrl)
+			tc.mockBehavior(userRepo, refreshTokenRepo, hasher, secret, tokenTTL, tc.args)
 
-			s := NewAuthService(userRepo, hasher, secret, tokenTTL)
+			s := NewAuthService(userRepo, refreshTokenRepo, inviteRepo, nil, nil, revocations, hasher, transactor, AuthServiceConfig{
+				Mode:            AuthModeAutoRegister,
+				Keys:            keys,
+				TokenTTL:        tokenTTL,
+				RefreshTokenTTL: refreshTokenTTL,
+			})
 
-			got, err := s.createUser(tc.args.ctx, tc.args.input)
+			got, err := s.createUser(tc.args.ctx, tc.args.input.Name, tc.args.input.Password)
 			if tc.wantErr {
 				assert.Error(t, err)
 				return
@@ -94,20 +121,26 @@ func TestAuthService_createUser(t *testing.T) {
 
 func TestAuthService_GenerateToken(t *testing.T) {
 	const secret = "jwt_test_secret"
+	keys := newTestKeySet(t, "current")
 	const tokenTTL = 2 * time.Hour
+	const refreshTokenTTL = 30 * 24 * time.Hour
 
 	type args struct {
 		ctx   context.Context
 		input AuthGenerateTokenInput
 	}
 
-	type MockBehavior func(u *repomocks.MockUser, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args)
+	type MockBehavior func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args)
+
+	errWeakPassword := errors.New("weak password")
 
 	testCases := []struct {
-		name         string
-		args         args
-		mockBehavior MockBehavior
-		wantErr      bool
+		name          string
+		mode          AuthMode
+		checkPassword func(password string) error
+		args          args
+		mockBehavior  MockBehavior
+		wantErr       bool
 	}{
 		{
 			name: "registration success",
@@ -118,13 +151,57 @@ func TestAuthService_GenerateToken(t *testing.T) {
 					Password: "simplePa66!",
 				},
 			},
-			mockBehavior: func(u *repomocks.MockUser, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
 				u.EXPECT().GetUserByName(args.ctx, args.input.Name).
 					Return(entity.User{}, repository.ErrNotFound)
 				h.EXPECT().Hash(args.input.Password).
-					Return(args.input.Password)
-				u.EXPECT().CreateUser(args.ctx, entity.User{Name: args.input.Name, Password: args.input.Password}).
+					Return(args.input.Password, nil)
+				u.EXPECT().CreateUser(args.ctx, entity.User{Name: args.input.Name, Password: args.input.Password, Role: entity.RoleUser}).
 					Return(1, nil)
+				rt.EXPECT().Create(args.ctx, gomock.Any()).
+					Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "registration with password breaking the policy",
+			checkPassword: func(password string) error {
+				return errWeakPassword
+			},
+			args: args{
+				ctx: context.Background(),
+				input: AuthGenerateTokenInput{
+					Name:     "marcus-web-designer",
+					Password: "Password1!",
+				},
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
+				u.EXPECT().GetUserByName(args.ctx, args.input.Name).
+					Return(entity.User{}, repository.ErrNotFound)
+			},
+			wantErr: true,
+		},
+		{
+			name: "existing user logs in with password breaking the policy",
+			checkPassword: func(password string) error {
+				return errWeakPassword
+			},
+			args: args{
+				ctx: context.Background(),
+				input: AuthGenerateTokenInput{
+					Name:     "marcus-web-designer",
+					Password: "Password1!",
+				},
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
+				u.EXPECT().GetUserByName(args.ctx, args.input.Name).
+					Return(entity.User{Id: 1, Name: args.input.Name, Password: "argon2id-hash"}, nil)
+				h.EXPECT().Verify(args.input.Password, "argon2id-hash").
+					Return(true, nil)
+				h.EXPECT().NeedsRehash("argon2id-hash").
+					Return(false)
+				rt.EXPECT().Create(args.ctx, gomock.Any()).
+					Return(nil)
 			},
 			wantErr: false,
 		},
@@ -137,11 +214,65 @@ func TestAuthService_GenerateToken(t *testing.T) {
 					Password: "simplePa66!",
 				},
 			},
-			mockBehavior: func(u *repomocks.MockUser, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
+				u.EXPECT().GetUserByName(args.ctx, args.input.Name).
+					Return(entity.User{Id: 1, Name: args.input.Name, Password: "argon2id-hash"}, nil)
+				h.EXPECT().Verify(args.input.Password, "argon2id-hash").
+					Return(true, nil)
+				h.EXPECT().NeedsRehash("argon2id-hash").
+					Return(false)
+				rt.EXPECT().Create(args.ctx, gomock.Any()).
+					Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "authorization success with legacy hash upgrade",
+			args: args{
+				ctx: context.Background(),
+				input: AuthGenerateTokenInput{
+					Name:     "marcus-web-designer",
+					Password: "simplePa66!",
+				},
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
+				u.EXPECT().GetUserByName(args.ctx, args.input.Name).
+					Return(entity.User{Id: 1, Name: args.input.Name, Password: "sha1-hash"}, nil)
+				h.EXPECT().Verify(args.input.Password, "sha1-hash").
+					Return(true, nil)
+				h.EXPECT().NeedsRehash("sha1-hash").
+					Return(true)
+				h.EXPECT().Hash(args.input.Password).
+					Return("argon2id-hash", nil)
+				u.EXPECT().UpdatePassword(args.ctx, 1, "argon2id-hash").
+					Return(nil)
+				rt.EXPECT().Create(args.ctx, gomock.Any()).
+					Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "authorization success when hash upgrade failed",
+			args: args{
+				ctx: context.Background(),
+				input: AuthGenerateTokenInput{
+					Name:     "marcus-web-designer",
+					Password: "simplePa66!",
+				},
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
 				u.EXPECT().GetUserByName(args.ctx, args.input.Name).
-					Return(entity.User{Id: 1, Name: args.input.Name, Password: args.input.Password}, nil)
+					Return(entity.User{Id: 1, Name: args.input.Name, Password: "sha1-hash"}, nil)
+				h.EXPECT().Verify(args.input.Password, "sha1-hash").
+					Return(true, nil)
+				h.EXPECT().NeedsRehash("sha1-hash").
+					Return(true)
 				h.EXPECT().Hash(args.input.Password).
-					Return(args.input.Password)
+					Return("argon2id-hash", nil)
+				u.EXPECT().UpdatePassword(args.ctx, 1, "argon2id-hash").
+					Return(errors.New("some error"))
+				rt.EXPECT().Create(args.ctx, gomock.Any()).
+					Return(nil)
 			},
 			wantErr: false,
 		},
@@ -154,11 +285,28 @@ func TestAuthService_GenerateToken(t *testing.T) {
 					Password: "simplePa66!",
 				},
 			},
-			mockBehavior: func(u *repomocks.MockUser, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
 				u.EXPECT().GetUserByName(args.ctx, args.input.Name).
 					Return(entity.User{Id: 1, Name: args.input.Name, Password: "another-password"}, nil)
-				h.EXPECT().Hash(args.input.Password).
-					Return(args.input.Password)
+				h.EXPECT().Verify(args.input.Password, "another-password").
+					Return(false, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "authorization failed due to unknown hash format",
+			args: args{
+				ctx: context.Background(),
+				input: AuthGenerateTokenInput{
+					Name:     "marcus-web-designer",
+					Password: "simplePa66!",
+				},
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
+				u.EXPECT().GetUserByName(args.ctx, args.input.Name).
+					Return(entity.User{Id: 1, Name: args.input.Name, Password: "garbage"}, nil)
+				h.EXPECT().Verify(args.input.Password, "garbage").
+					Return(false, errors.New("unknown password hash format"))
 			},
 			wantErr: true,
 		},
@@ -171,12 +319,53 @@ func TestAuthService_GenerateToken(t *testing.T) {
 					Password: "simplePa66!",
 				},
 			},
-			mockBehavior: func(u *repomocks.MockUser, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
 				u.EXPECT().GetUserByName(args.ctx, args.input.Name).
 					Return(entity.User{}, errors.New("some error"))
 			},
 			wantErr: true,
 		},
+		{
+			name: "unknown user in explicit mode",
+			mode: AuthModeExplicit,
+			args: args{
+				ctx: context.Background(),
+				input: AuthGenerateTokenInput{
+					Name:     "marcus-web-designer",
+					Password: "simplePa66!",
+				},
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
+				u.EXPECT().GetUserByName(args.ctx, args.input.Name).
+					Return(entity.User{}, repository.ErrNotFound)
+				h.EXPECT().Hash(gomock.Any()).
+					Return("dummy-hash", nil)
+				h.EXPECT().Verify(args.input.Password, "dummy-hash").
+					Return(false, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "refresh token not stored",
+			args: args{
+				ctx: context.Background(),
+				input: AuthGenerateTokenInput{
+					Name:     "marcus-web-designer",
+					Password: "simplePa66!",
+				},
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, h *hashermocks.MockPasswordHasher, s string, ttl time.Duration, args args) {
+				u.EXPECT().GetUserByName(args.ctx, args.input.Name).
+					Return(entity.User{Id: 1, Name: args.input.Name, Password: "argon2id-hash"}, nil)
+				h.EXPECT().Verify(args.input.Password, "argon2id-hash").
+					Return(true, nil)
+				h.EXPECT().NeedsRehash("argon2id-hash").
+					Return(false)
+				rt.EXPECT().Create(args.ctx, gomock.Any()).
+					Return(errors.New("some error"))
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -185,10 +374,25 @@ func TestAuthService_GenerateToken(t *testing.T) {
 			defer ctrl.Finish()
 
 			userRepo := repomocks.NewMockUser(ctrl)
+			refreshTokenRepo := repomocks.NewMockRefreshToken(ctrl)
+			inviteRepo := repomocks.NewMockInvite(ctrl)
+			revocations := NewTokenRevocationStore(repomocks.NewMockRevokedToken(ctrl), repomocks.NewMockRevokedUser(ctrl))
 			hasher := hashermocks.NewMockPasswordHasher(ctrl)
-			tc.mockBehavior(userRepo, hasher, secret, tokenTTL, tc.args)
+			transactor := repomocks.NewMockTransactor(ctrl)
+			tc.mockBehavior(userRepo, refreshTokenRepo, hasher, secret, tokenTTL, tc.args)
 
-			s := NewAuthService(userRepo, hasher, secret, tokenTTL)
+			mode := tc.mode
+			if mode == "" {
+				mode = AuthModeAutoRegister
+			}
+
+			s := NewAuthService(userRepo, refreshTokenRepo, inviteRepo, nil, nil, revocations, hasher, transactor, AuthServiceConfig{
+				Mode:            mode,
+				Keys:            keys,
+				TokenTTL:        tokenTTL,
+				RefreshTokenTTL: refreshTokenTTL,
+				CheckPassword:   tc.checkPassword,
+			})
 
 			got, err := s.GenerateToken(tc.args.ctx, tc.args.input)
 			if tc.wantErr {
@@ -197,62 +401,136 @@ func TestAuthService_GenerateToken(t *testing.T) {
 			}
 
 			assert.NoError(t, err)
-			assert.NotEmpty(t, got)
+			assert.NotEmpty(t, got.AccessToken)
+			assert.NotEmpty(t, got.RefreshToken)
 		})
 	}
 }
 
 func TestAuthService_VerifyToken(t *testing.T) {
-	const secret = "jwt_test_secret"
-	const wrongSecret = "wrong_secret"
+	// During rotation the previous key is kept for verification only.
+	currentKey, _ := jwks.GenerateEd25519("current")
+	previousKey, _ := jwks.GenerateEd25519("previous")
+	keys, _ := jwks.NewKeySet("current", currentKey, jwks.Key{Id: previousKey.Id, Method: previousKey.Method, PublicKey: previousKey.PublicKey})
+	wrongKeys := newTestKeySet(t, "current")
+	unknownKeys := newTestKeySet(t, "unknown")
 	const userId = 17
+	const role = "admin"
+	const revokedJti = "revoked-jti"
 	const tokenTTL = 2 * time.Hour
+	const refreshTokenTTL = 30 * 24 * time.Hour
 
 	type args struct {
 		tokenString string
 	}
 
-	generateJwt := func(id int, issuedAt time.Time, signKey string) string {
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, &TokenClaims{
+	generateJwt := func(jti string, issuedAt time.Time, key jwks.Key) string {
+		token := jwt.NewWithClaims(key.Method, &TokenClaims{
 			StandardClaims: jwt.StandardClaims{
+				Id:        jti,
 				ExpiresAt: issuedAt.Add(tokenTTL).Unix(),
 				IssuedAt:  issuedAt.Unix(),
 			},
 			UserId: userId,
+			Role:   role,
+		})
+		token.Header["kid"] = key.Id
+
+		tokenString, _ := token.SignedString(key.PrivateKey)
+		return tokenString
+	}
+
+	// HS256 token signed with the public key bytes must not pass as EdDSA.
+	hmacJwt := func() string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, &TokenClaims{
+			StandardClaims: jwt.StandardClaims{
+				Id:        "jti",
+				ExpiresAt: time.Now().Add(tokenTTL).Unix(),
+			},
+			UserId: userId,
 		})
+		token.Header["kid"] = keys.SigningKey().Id
 
-		tokenString, _ := token.SignedString([]byte(signKey))
+		tokenString, _ := token.SignedString([]byte(keys.SigningKey().PublicKey.(ed25519.PublicKey)))
 		return tokenString
 	}
 
 	testCases := []struct {
 		name    string
 		args    args
-		want    int
+		want    AuthIdentity
 		wantErr bool
 	}{
 		{
 			name: "success",
 			args: args{
-				tokenString: generateJwt(userId, time.Now(), secret),
+				tokenString: generateJwt("jti", time.Now(), keys.SigningKey()),
+			},
+			want:    AuthIdentity{UserId: userId, Role: role},
+			wantErr: false,
+		},
+		{
+			name: "signed by previous key",
+			args: args{
+				tokenString: generateJwt("jti", time.Now(), previousKey),
 			},
-			want:    userId,
+			want:    AuthIdentity{UserId: userId, Role: role},
 			wantErr: false,
 		},
 		{
 			name: "expired token",
 			args: args{
-				tokenString: generateJwt(userId, time.Now().Add(-10*time.Hour), secret),
+				tokenString: generateJwt("jti", time.Now().Add(-10*time.Hour), keys.SigningKey()),
 			},
-			want:    0,
+			want:    AuthIdentity{},
 			wantErr: true,
 		},
 		{
-			name: "wrong secret",
+			name: "wrong key",
 			args: args{
-				tokenString: generateJwt(userId, time.Now(), wrongSecret),
+				tokenString: generateJwt("jti", time.Now(), wrongKeys.SigningKey()),
 			},
-			want:    0,
+			want:    AuthIdentity{},
+			wantErr: true,
+		},
+		{
+			name: "unknown key id",
+			args: args{
+				tokenString: generateJwt("jti", time.Now(), unknownKeys.SigningKey()),
+			},
+			want:    AuthIdentity{},
+			wantErr: true,
+		},
+		{
+			name: "unexpected signing method",
+			args: args{
+				tokenString: hmacJwt(),
+			},
+			want:    AuthIdentity{},
+			wantErr: true,
+		},
+		{
+			name: "token without jti",
+			args: args{
+				tokenString: generateJwt("", time.Now(), keys.SigningKey()),
+			},
+			want:    AuthIdentity{},
+			wantErr: true,
+		},
+		{
+			name: "issued before role change",
+			args: args{
+				tokenString: generateJwt("jti", time.Now().Add(-time.Hour), keys.SigningKey()),
+			},
+			want:    AuthIdentity{},
+			wantErr: true,
+		},
+		{
+			name: "revoked token",
+			args: args{
+				tokenString: generateJwt(revokedJti, time.Now(), keys.SigningKey()),
+			},
+			want:    AuthIdentity{},
 			wantErr: true,
 		},
 	}
@@ -262,8 +540,23 @@ func TestAuthService_VerifyToken(t *testing.T) {
 			defer ctrl.Finish()
 
 			userRepo := repomocks.NewMockUser(ctrl)
+			refreshTokenRepo := repomocks.NewMockRefreshToken(ctrl)
+			inviteRepo := repomocks.NewMockInvite(ctrl)
+			revokedTokenRepo := repomocks.NewMockRevokedToken(ctrl)
+			revokedTokenRepo.EXPECT().Add(gomock.Any(), gomock.Any()).Return(nil)
+			revokedUserRepo := repomocks.NewMockRevokedUser(ctrl)
+			revokedUserRepo.EXPECT().Upsert(gomock.Any(), gomock.Any()).Return(nil)
+			revocations := NewTokenRevocationStore(revokedTokenRepo, revokedUserRepo)
+			_ = revocations.Revoke(context.Background(), revokedJti, time.Now().Add(tokenTTL))
+			_ = revocations.RevokeUser(context.Background(), userId, time.Now().Add(-30*time.Minute), time.Now().Add(tokenTTL))
 			hasher := hashermocks.NewMockPasswordHasher(ctrl)
-			s := NewAuthService(userRepo, hasher, secret, tokenTTL)
+			transactor := repomocks.NewMockTransactor(ctrl)
+			s := NewAuthService(userRepo, refreshTokenRepo, inviteRepo, nil, nil, revocations, hasher, transactor, AuthServiceConfig{
+				Mode:            AuthModeAutoRegister,
+				Keys:            keys,
+				TokenTTL:        tokenTTL,
+				RefreshTokenTTL: refreshTokenTTL,
+			})
 
 			got, err := s.VerifyToken(tc.args.tokenString)
 			if tc.wantErr {
@@ -276,3 +569,1319 @@ func TestAuthService_VerifyToken(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthService_RefreshToken(t *testing.T) {
+	const secret = "jwt_test_secret"
+	keys := newTestKeySet(t, "current")
+	const tokenTTL = 2 * time.Hour
+	const refreshTokenTTL = 30 * 24 * time.Hour
+	const refreshToken = "opaque-refresh-token"
+
+	type args struct {
+		ctx          context.Context
+		refreshToken string
+	}
+
+	type MockBehavior func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, t *repomocks.MockTransactor, args args)
+
+	withinTransaction := func(t *repomocks.MockTransactor, ctx context.Context) {
+		t.EXPECT().WithinTransaction(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+				return fn(ctx)
+			})
+	}
+
+	revokedAt := time.Now().Add(-time.Minute)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:          context.Background(),
+				refreshToken: refreshToken,
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, t *repomocks.MockTransactor, args args) {
+				withinTransaction(t, args.ctx)
+				rt.EXPECT().GetByHashForUpdate(args.ctx, hashOpaqueToken(args.refreshToken)).
+					Return(entity.RefreshToken{Id: 5, UserId: 17, FamilyId: "family", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+				rt.EXPECT().Revoke(args.ctx, 5).
+					Return(nil)
+				u.EXPECT().GetUserById(args.ctx, 17).
+					Return(entity.User{Id: 17, Role: "admin"}, nil)
+				rt.EXPECT().Create(args.ctx, gomock.Cond(func(token entity.RefreshToken) bool {
+					return token.UserId == 17 && token.FamilyId == "family"
+				})).
+					Return(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "get user failed",
+			args: args{
+				ctx:          context.Background(),
+				refreshToken: refreshToken,
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, t *repomocks.MockTransactor, args args) {
+				withinTransaction(t, args.ctx)
+				rt.EXPECT().GetByHashForUpdate(args.ctx, hashOpaqueToken(args.refreshToken)).
+					Return(entity.RefreshToken{Id: 5, UserId: 17, FamilyId: "family", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+				rt.EXPECT().Revoke(args.ctx, 5).
+					Return(nil)
+				u.EXPECT().GetUserById(args.ctx, 17).
+					Return(entity.User{}, errors.New("some error"))
+			},
+			wantErr: ErrCannotRefreshToken,
+		},
+		{
+			name: "unknown token",
+			args: args{
+				ctx:          context.Background(),
+				refreshToken: refreshToken,
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, t *repomocks.MockTransactor, args args) {
+				withinTransaction(t, args.ctx)
+				rt.EXPECT().GetByHashForUpdate(args.ctx, hashOpaqueToken(args.refreshToken)).
+					Return(entity.RefreshToken{}, repository.ErrNotFound)
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+		{
+			name: "expired token",
+			args: args{
+				ctx:          context.Background(),
+				refreshToken: refreshToken,
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, t *repomocks.MockTransactor, args args) {
+				withinTransaction(t, args.ctx)
+				rt.EXPECT().GetByHashForUpdate(args.ctx, hashOpaqueToken(args.refreshToken)).
+					Return(entity.RefreshToken{Id: 5, UserId: 17, FamilyId: "family", ExpiresAt: time.Now().Add(-time.Hour)}, nil)
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+		{
+			name: "reused token revokes family",
+			args: args{
+				ctx:          context.Background(),
+				refreshToken: refreshToken,
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, t *repomocks.MockTransactor, args args) {
+				withinTransaction(t, args.ctx)
+				rt.EXPECT().GetByHashForUpdate(args.ctx, hashOpaqueToken(args.refreshToken)).
+					Return(entity.RefreshToken{Id: 5, UserId: 17, FamilyId: "family", ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt}, nil)
+				rt.EXPECT().RevokeFamily(args.ctx, "family").
+					Return(nil)
+			},
+			wantErr: ErrRefreshTokenReused,
+		},
+		{
+			name: "retry after reuse was seen",
+			args: args{
+				ctx:          context.Background(),
+				refreshToken: refreshToken,
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, t *repomocks.MockTransactor, args args) {
+				// The first attempt saw the token rotated by a concurrent request that was rolled back.
+				t.EXPECT().WithinTransaction(args.ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+						_ = fn(ctx)
+						return fn(ctx)
+					})
+				rt.EXPECT().GetByHashForUpdate(args.ctx, hashOpaqueToken(args.refreshToken)).
+					Return(entity.RefreshToken{Id: 5, UserId: 17, FamilyId: "family", ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt}, nil)
+				rt.EXPECT().RevokeFamily(args.ctx, "family").
+					Return(nil)
+				rt.EXPECT().GetByHashForUpdate(args.ctx, hashOpaqueToken(args.refreshToken)).
+					Return(entity.RefreshToken{Id: 5, UserId: 17, FamilyId: "family", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+				rt.EXPECT().Revoke(args.ctx, 5).
+					Return(nil)
+				u.EXPECT().GetUserById(args.ctx, 17).
+					Return(entity.User{Id: 17, Role: "admin"}, nil)
+				rt.EXPECT().Create(args.ctx, gomock.Any()).
+					Return(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "get token failed for unknown reason",
+			args: args{
+				ctx:          context.Background(),
+				refreshToken: refreshToken,
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, t *repomocks.MockTransactor, args args) {
+				withinTransaction(t, args.ctx)
+				rt.EXPECT().GetByHashForUpdate(args.ctx, hashOpaqueToken(args.refreshToken)).
+					Return(entity.RefreshToken{}, errors.New("some error"))
+			},
+			wantErr: ErrCannotRefreshToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := repomocks.NewMockUser(ctrl)
+			refreshTokenRepo := repomocks.NewMockRefreshToken(ctrl)
+			inviteRepo := repomocks.NewMockInvite(ctrl)
+			revocations := NewTokenRevocationStore(repomocks.NewMockRevokedToken(ctrl), repomocks.NewMockRevokedUser(ctrl))
+			hasher := hashermocks.NewMockPasswordHasher(ctrl)
+			transactor := repomocks.NewMockTransactor(ctrl)
+			tc.mockBehavior(userRepo, refreshTokenRepo, transactor, tc.args)
+
+			s := NewAuthService(userRepo, refreshTokenRepo, inviteRepo, nil, nil, revocations, hasher, transactor, AuthServiceConfig{
+				Mode:            AuthModeAutoRegister,
+				Keys:            keys,
+				TokenTTL:        tokenTTL,
+				RefreshTokenTTL: refreshTokenTTL,
+			})
+
+			got, err := s.RefreshToken(tc.args.ctx, tc.args.refreshToken)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotEmpty(t, got.AccessToken)
+			assert.NotEmpty(t, got.RefreshToken)
+			assert.NotEqual(t, tc.args.refreshToken, got.RefreshToken)
+
+			identity, err := s.VerifyToken(got.AccessToken)
+			assert.NoError(t, err)
+			assert.Equal(t, AuthIdentity{UserId: 17, Role: "admin"}, identity)
+		})
+	}
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	keys := newTestKeySet(t, "current")
+	wrongKeys := newTestKeySet(t, "current")
+	const userId = 17
+	const tokenTTL = 2 * time.Hour
+	const refreshTokenTTL = 30 * 24 * time.Hour
+
+	generateJwt := func(jti string, key jwks.Key) string {
+		token := jwt.NewWithClaims(key.Method, &TokenClaims{
+			StandardClaims: jwt.StandardClaims{
+				Id:        jti,
+				ExpiresAt: time.Now().Add(tokenTTL).Unix(),
+				IssuedAt:  time.Now().Unix(),
+			},
+			UserId: userId,
+		})
+		token.Header["kid"] = key.Id
+
+		tokenString, _ := token.SignedString(key.PrivateKey)
+		return tokenString
+	}
+
+	type args struct {
+		ctx   context.Context
+		input AuthLogoutInput
+	}
+
+	type MockBehavior func(rt *repomocks.MockRefreshToken, rv *repomocks.MockRevokedToken, t *repomocks.MockTransactor, args args)
+
+	withinTransaction := func(t *repomocks.MockTransactor, ctx context.Context) {
+		t.EXPECT().WithinTransaction(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+				return fn(ctx)
+			})
+	}
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "access token only",
+			args: args{
+				ctx:   context.Background(),
+				input: AuthLogoutInput{AccessToken: generateJwt("jti", keys.SigningKey())},
+			},
+			mockBehavior: func(rt *repomocks.MockRefreshToken, rv *repomocks.MockRevokedToken, t *repomocks.MockTransactor, args args) {
+				rv.EXPECT().Add(args.ctx, gomock.Cond(func(token entity.RevokedToken) bool {
+					return token.Jti == "jti"
+				})).Return(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "with refresh token",
+			args: args{
+				ctx:   context.Background(),
+				input: AuthLogoutInput{AccessToken: generateJwt("jti", keys.SigningKey()), RefreshToken: "refresh"},
+			},
+			mockBehavior: func(rt *repomocks.MockRefreshToken, rv *repomocks.MockRevokedToken, t *repomocks.MockTransactor, args args) {
+				rv.EXPECT().Add(args.ctx, gomock.Any()).Return(nil)
+				withinTransaction(t, args.ctx)
+				rt.EXPECT().GetByHashForUpdate(args.ctx, hashOpaqueToken("refresh")).
+					Return(entity.RefreshToken{Id: 1, UserId: userId, FamilyId: "family"}, nil)
+				rt.EXPECT().RevokeFamily(args.ctx, "family").Return(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "refresh token of another user is ignored",
+			args: args{
+				ctx:   context.Background(),
+				input: AuthLogoutInput{AccessToken: generateJwt("jti", keys.SigningKey()), RefreshToken: "refresh"},
+			},
+			mockBehavior: func(rt *repomocks.MockRefreshToken, rv *repomocks.MockRevokedToken, t *repomocks.MockTransactor, args args) {
+				rv.EXPECT().Add(args.ctx, gomock.Any()).Return(nil)
+				withinTransaction(t, args.ctx)
+				rt.EXPECT().GetByHashForUpdate(args.ctx, hashOpaqueToken("refresh")).
+					Return(entity.RefreshToken{Id: 1, UserId: userId + 1, FamilyId: "family"}, nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "unknown refresh token is ignored",
+			args: args{
+				ctx:   context.Background(),
+				input: AuthLogoutInput{AccessToken: generateJwt("jti", keys.SigningKey()), RefreshToken: "refresh"},
+			},
+			mockBehavior: func(rt *repomocks.MockRefreshToken, rv *repomocks.MockRevokedToken, t *repomocks.MockTransactor, args args) {
+				rv.EXPECT().Add(args.ctx, gomock.Any()).Return(nil)
+				withinTransaction(t, args.ctx)
+				rt.EXPECT().GetByHashForUpdate(args.ctx, hashOpaqueToken("refresh")).
+					Return(entity.RefreshToken{}, repository.ErrNotFound)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "family revocation failed",
+			args: args{
+				ctx:   context.Background(),
+				input: AuthLogoutInput{AccessToken: generateJwt("jti", keys.SigningKey()), RefreshToken: "refresh"},
+			},
+			mockBehavior: func(rt *repomocks.MockRefreshToken, rv *repomocks.MockRevokedToken, t *repomocks.MockTransactor, args args) {
+				rv.EXPECT().Add(args.ctx, gomock.Any()).Return(nil)
+				withinTransaction(t, args.ctx)
+				rt.EXPECT().GetByHashForUpdate(args.ctx, hashOpaqueToken("refresh")).
+					Return(entity.RefreshToken{Id: 1, UserId: userId, FamilyId: "family"}, nil)
+				rt.EXPECT().RevokeFamily(args.ctx, "family").Return(errors.New("some error"))
+			},
+			wantErr: ErrCannotRevokeToken,
+		},
+		{
+			name: "invalid access token",
+			args: args{
+				ctx:   context.Background(),
+				input: AuthLogoutInput{AccessToken: generateJwt("jti", wrongKeys.SigningKey())},
+			},
+			mockBehavior: func(rt *repomocks.MockRefreshToken, rv *repomocks.MockRevokedToken, t *repomocks.MockTransactor, args args) {
+			},
+			wantErr: ErrCannotParseToken,
+		},
+		{
+			name: "revocation failed",
+			args: args{
+				ctx:   context.Background(),
+				input: AuthLogoutInput{AccessToken: generateJwt("jti", keys.SigningKey())},
+			},
+			mockBehavior: func(rt *repomocks.MockRefreshToken, rv *repomocks.MockRevokedToken, t *repomocks.MockTransactor, args args) {
+				rv.EXPECT().Add(args.ctx, gomock.Any()).Return(errors.New("some error"))
+			},
+			wantErr: ErrCannotRevokeToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := repomocks.NewMockUser(ctrl)
+			refreshTokenRepo := repomocks.NewMockRefreshToken(ctrl)
+			inviteRepo := repomocks.NewMockInvite(ctrl)
+			revokedTokenRepo := repomocks.NewMockRevokedToken(ctrl)
+			revocations := NewTokenRevocationStore(revokedTokenRepo, repomocks.NewMockRevokedUser(ctrl))
+			hasher := hashermocks.NewMockPasswordHasher(ctrl)
+			transactor := repomocks.NewMockTransactor(ctrl)
+			tc.mockBehavior(refreshTokenRepo, revokedTokenRepo, transactor, tc.args)
+
+			s := NewAuthService(userRepo, refreshTokenRepo, inviteRepo, nil, nil, revocations, hasher, transactor, AuthServiceConfig{
+				Mode:            AuthModeAutoRegister,
+				Keys:            keys,
+				TokenTTL:        tokenTTL,
+				RefreshTokenTTL: refreshTokenTTL,
+			})
+
+			err := s.Logout(tc.args.ctx, tc.args.input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			_, err = s.VerifyToken(tc.args.input.AccessToken)
+			assert.ErrorIs(t, err, ErrTokenRevoked)
+		})
+	}
+}
+
+func TestAuthService_Register(t *testing.T) {
+	const secret = "jwt_test_secret"
+	keys := newTestKeySet(t, "current")
+	const tokenTTL = 2 * time.Hour
+	const refreshTokenTTL = 30 * 24 * time.Hour
+
+	type args struct {
+		ctx   context.Context
+		input AuthRegisterInput
+	}
+
+	type MockBehavior func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, i *repomocks.MockInvite, h *hashermocks.MockPasswordHasher, args args)
+
+	testCases := []struct {
+		name         string
+		mode         AuthMode
+		args         args
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "explicit mode success",
+			mode: AuthModeExplicit,
+			args: args{
+				ctx:   context.Background(),
+				input: AuthRegisterInput{Name: "marcus-web-designer", Password: "simplePa66!"},
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, i *repomocks.MockInvite, h *hashermocks.MockPasswordHasher, args args) {
+				h.EXPECT().Hash(args.input.Password).Return("hash", nil)
+				u.EXPECT().CreateUser(args.ctx, entity.User{Name: args.input.Name, Password: "hash", Role: entity.RoleUser}).Return(1, nil)
+				rt.EXPECT().Create(args.ctx, gomock.Any()).Return(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "user already exists",
+			mode: AuthModeExplicit,
+			args: args{
+				ctx:   context.Background(),
+				input: AuthRegisterInput{Name: "marcus-web-designer", Password: "simplePa66!"},
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, i *repomocks.MockInvite, h *hashermocks.MockPasswordHasher, args args) {
+				h.EXPECT().Hash(args.input.Password).Return("hash", nil)
+				u.EXPECT().CreateUser(args.ctx, entity.User{Name: args.input.Name, Password: "hash", Role: entity.RoleUser}).Return(0, repository.ErrAlreadyExists)
+			},
+			wantErr: ErrUserAlreadyExists,
+		},
+		{
+			name: "invite only success",
+			mode: AuthModeInviteOnly,
+			args: args{
+				ctx:   context.Background(),
+				input: AuthRegisterInput{Name: "marcus-web-designer", Password: "simplePa66!", InviteCode: "invite"},
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, i *repomocks.MockInvite, h *hashermocks.MockPasswordHasher, args args) {
+				i.EXPECT().Use(args.ctx, hashOpaqueToken("invite")).Return(nil)
+				h.EXPECT().Hash(args.input.Password).Return("hash", nil)
+				u.EXPECT().CreateUser(args.ctx, entity.User{Name: args.input.Name, Password: "hash", Role: entity.RoleUser}).Return(1, nil)
+				rt.EXPECT().Create(args.ctx, gomock.Any()).Return(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "invite only without code",
+			mode: AuthModeInviteOnly,
+			args: args{
+				ctx:   context.Background(),
+				input: AuthRegisterInput{Name: "marcus-web-designer", Password: "simplePa66!"},
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, i *repomocks.MockInvite, h *hashermocks.MockPasswordHasher, args args) {
+			},
+			wantErr: ErrInviteCodeRequired,
+		},
+		{
+			name: "invite only with used up code",
+			mode: AuthModeInviteOnly,
+			args: args{
+				ctx:   context.Background(),
+				input: AuthRegisterInput{Name: "marcus-web-designer", Password: "simplePa66!", InviteCode: "invite"},
+			},
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, i *repomocks.MockInvite, h *hashermocks.MockPasswordHasher, args args) {
+				i.EXPECT().Use(args.ctx, hashOpaqueToken("invite")).Return(repository.ErrNotFound)
+			},
+			wantErr: ErrInvalidInviteCode,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := repomocks.NewMockUser(ctrl)
+			refreshTokenRepo := repomocks.NewMockRefreshToken(ctrl)
+			inviteRepo := repomocks.NewMockInvite(ctrl)
+			revocations := NewTokenRevocationStore(repomocks.NewMockRevokedToken(ctrl), repomocks.NewMockRevokedUser(ctrl))
+			hasher := hashermocks.NewMockPasswordHasher(ctrl)
+			transactor := repomocks.NewMockTransactor(ctrl)
+			transactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+					return fn(ctx)
+				}).AnyTimes()
+			tc.mockBehavior(userRepo, refreshTokenRepo, inviteRepo, hasher, tc.args)
+
+			s := NewAuthService(userRepo, refreshTokenRepo, inviteRepo, nil, nil, revocations, hasher, transactor, AuthServiceConfig{
+				Mode:            tc.mode,
+				Keys:            keys,
+				TokenTTL:        tokenTTL,
+				RefreshTokenTTL: refreshTokenTTL,
+			})
+
+			got, err := s.Register(tc.args.ctx, tc.args.input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotEmpty(t, got.AccessToken)
+			assert.NotEmpty(t, got.RefreshToken)
+		})
+	}
+}
+
+func TestAuthService_CreateInvite(t *testing.T) {
+	const inviteTTL = 24 * time.Hour
+
+	testCases := []struct {
+		name         string
+		input        AuthCreateInviteInput
+		mockBehavior func(i *repomocks.MockInvite)
+		wantMaxUses  int
+		wantErr      bool
+	}{
+		{
+			name:  "default max uses",
+			input: AuthCreateInviteInput{UserId: 1},
+			mockBehavior: func(i *repomocks.MockInvite) {
+				i.EXPECT().Create(gomock.Any(), gomock.Cond(func(invite entity.Invite) bool {
+					return invite.CreatedBy == 1 && invite.MaxUses == 1 && len(invite.CodeHash) == 64
+				})).Return(1, nil)
+			},
+			wantMaxUses: 1,
+			wantErr:     false,
+		},
+		{
+			name:  "custom max uses",
+			input: AuthCreateInviteInput{UserId: 1, MaxUses: 5},
+			mockBehavior: func(i *repomocks.MockInvite) {
+				i.EXPECT().Create(gomock.Any(), gomock.Cond(func(invite entity.Invite) bool {
+					return invite.MaxUses == 5
+				})).Return(1, nil)
+			},
+			wantMaxUses: 5,
+			wantErr:     false,
+		},
+		{
+			name:  "create failed",
+			input: AuthCreateInviteInput{UserId: 1},
+			mockBehavior: func(i *repomocks.MockInvite) {
+				i.EXPECT().Create(gomock.Any(), gomock.Any()).Return(0, errors.New("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			inviteRepo := repomocks.NewMockInvite(ctrl)
+			tc.mockBehavior(inviteRepo)
+
+			s := NewAuthService(nil, nil, inviteRepo, nil, nil, nil, nil, nil, AuthServiceConfig{InviteTTL: inviteTTL})
+
+			got, err := s.CreateInvite(context.Background(), tc.input)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotEmpty(t, got.Code)
+			assert.Equal(t, tc.wantMaxUses, got.MaxUses)
+			assert.WithinDuration(t, time.Now().Add(inviteTTL), got.ExpiresAt, time.Minute)
+		})
+	}
+}
+
+func TestAuthService_SetRole(t *testing.T) {
+	const tokenTTL = 2 * time.Hour
+
+	input := AuthSetRoleInput{UserName: "marcus-web-designer", Role: entity.RoleAdmin}
+
+	testCases := []struct {
+		name         string
+		role         string
+		mockBehavior func(u *repomocks.MockUser, ru *repomocks.MockRevokedUser)
+		wantRevoked  bool
+		wantErr      error
+	}{
+		{
+			name: "success",
+			mockBehavior: func(u *repomocks.MockUser, ru *repomocks.MockRevokedUser) {
+				u.EXPECT().GetUserByName(gomock.Any(), input.UserName).
+					Return(entity.User{Id: 1, Role: entity.RoleUser}, nil)
+				u.EXPECT().UpdateRole(gomock.Any(), 1, entity.RoleAdmin).
+					Return(nil)
+				ru.EXPECT().Upsert(gomock.Any(), gomock.Cond(func(user entity.RevokedUser) bool {
+					return user.UserId == 1 && user.ExpiresAt.Sub(user.RevokedAt) == tokenTTL
+				})).Return(nil)
+			},
+			wantRevoked: true,
+			wantErr:     nil,
+		},
+		{
+			name: "same role",
+			mockBehavior: func(u *repomocks.MockUser, ru *repomocks.MockRevokedUser) {
+				u.EXPECT().GetUserByName(gomock.Any(), input.UserName).
+					Return(entity.User{Id: 1, Role: entity.RoleAdmin}, nil)
+			},
+			wantRevoked: false,
+			wantErr:     nil,
+		},
+		{
+			name: "user not found",
+			mockBehavior: func(u *repomocks.MockUser, ru *repomocks.MockRevokedUser) {
+				u.EXPECT().GetUserByName(gomock.Any(), input.UserName).
+					Return(entity.User{}, repository.ErrNotFound)
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name: "system account",
+			mockBehavior: func(u *repomocks.MockUser, ru *repomocks.MockRevokedUser) {
+				u.EXPECT().GetUserByName(gomock.Any(), input.UserName).
+					Return(entity.User{Id: 1, Role: entity.RoleSystem}, nil)
+			},
+			wantErr: ErrSystemAccountRole,
+		},
+		{
+			name: "service account",
+			mockBehavior: func(u *repomocks.MockUser, ru *repomocks.MockRevokedUser) {
+				u.EXPECT().GetUserByName(gomock.Any(), input.UserName).
+					Return(entity.User{Id: 1, Role: entity.RoleService}, nil)
+			},
+			wantErr: ErrServiceAccountRole,
+		},
+		{
+			name: "to service account",
+			role: entity.RoleService,
+			mockBehavior: func(u *repomocks.MockUser, ru *repomocks.MockRevokedUser) {
+				u.EXPECT().GetUserByName(gomock.Any(), input.UserName).
+					Return(entity.User{Id: 1, Role: entity.RoleUser}, nil)
+			},
+			wantErr: ErrServiceAccountRole,
+		},
+		{
+			name: "to system account",
+			role: entity.RoleSystem,
+			mockBehavior: func(u *repomocks.MockUser, ru *repomocks.MockRevokedUser) {
+				u.EXPECT().GetUserByName(gomock.Any(), input.UserName).
+					Return(entity.User{Id: 1, Role: entity.RoleUser}, nil)
+			},
+			wantErr: ErrSystemAccountRole,
+		},
+		{
+			name: "update failed",
+			mockBehavior: func(u *repomocks.MockUser, ru *repomocks.MockRevokedUser) {
+				u.EXPECT().GetUserByName(gomock.Any(), input.UserName).
+					Return(entity.User{Id: 1, Role: entity.RoleUser}, nil)
+				u.EXPECT().UpdateRole(gomock.Any(), 1, entity.RoleAdmin).
+					Return(errors.New("some error"))
+			},
+			wantErr: ErrCannotSetRole,
+		},
+		{
+			name: "revoke failed",
+			mockBehavior: func(u *repomocks.MockUser, ru *repomocks.MockRevokedUser) {
+				u.EXPECT().GetUserByName(gomock.Any(), input.UserName).
+					Return(entity.User{Id: 1, Role: entity.RoleUser}, nil)
+				u.EXPECT().UpdateRole(gomock.Any(), 1, entity.RoleAdmin).
+					Return(nil)
+				ru.EXPECT().Upsert(gomock.Any(), gomock.Any()).
+					Return(errors.New("some error"))
+			},
+			wantErr: ErrCannotRevokeToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := repomocks.NewMockUser(ctrl)
+			revokedUserRepo := repomocks.NewMockRevokedUser(ctrl)
+			revocations := NewTokenRevocationStore(repomocks.NewMockRevokedToken(ctrl), revokedUserRepo)
+			tc.mockBehavior(userRepo, revokedUserRepo)
+
+			s := NewAuthService(userRepo, nil, nil, nil, nil, revocations, nil, nil, AuthServiceConfig{TokenTTL: tokenTTL})
+
+			setRoleInput := input
+			if tc.role != "" {
+				setRoleInput.Role = tc.role
+			}
+
+			err := s.SetRole(context.Background(), setRoleInput)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantRevoked, revocations.IsUserRevoked(1, time.Now().Add(-time.Minute)))
+		})
+	}
+}
+
+func TestAuthService_GenerateTokenDummyHash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := repomocks.NewMockUser(ctrl)
+	hasher := hashermocks.NewMockPasswordHasher(ctrl)
+
+	// The dummy hash is created once and checked on every failed login.
+	userRepo.EXPECT().GetUserByName(gomock.Any(), gomock.Any()).
+		Return(entity.User{}, repository.ErrNotFound).Times(2)
+	hasher.EXPECT().Hash(gomock.Any()).
+		Return("dummy-hash", nil).Times(1)
+	hasher.EXPECT().Verify(gomock.Any(), "dummy-hash").
+		Return(false, nil).Times(2)
+
+	s := NewAuthService(userRepo, nil, nil, nil, nil, nil, hasher, nil, AuthServiceConfig{Mode: AuthModeExplicit})
+
+	for _, name := range []string{"marcus-web-designer", "jane-web-designer"} {
+		_, err := s.GenerateToken(context.Background(), AuthGenerateTokenInput{Name: name, Password: "simplePa66!"})
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+}
+
+func TestAuthService_GenerateTokenThrottled(t *testing.T) {
+	keys := newTestKeySet(t, "current")
+	lockout := LoginThrottleConfig{Threshold: 5, IPThreshold: 50, BaseLockout: time.Second, MaxLockout: time.Minute, Window: time.Minute}
+	input := AuthGenerateTokenInput{Name: "marcus-web-designer", Password: "simplePa66!", IP: "10.0.0.1"}
+	subjects := []string{"user:" + input.Name, "ip:" + input.IP}
+
+	type MockBehavior func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, la *repomocks.MockLoginAttempt, h *hashermocks.MockPasswordHasher)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "locked out",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, la *repomocks.MockLoginAttempt, h *hashermocks.MockPasswordHasher) {
+				la.EXPECT().GetLockedUntil(gomock.Any(), subjects).
+					Return(time.Now().Add(time.Minute), nil)
+			},
+			wantErr: ErrAccountLocked,
+		},
+		{
+			name: "check failed",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, la *repomocks.MockLoginAttempt, h *hashermocks.MockPasswordHasher) {
+				la.EXPECT().GetLockedUntil(gomock.Any(), subjects).
+					Return(time.Time{}, errors.New("some error"))
+			},
+			wantErr: ErrCannotCheckLoginAttempts,
+		},
+		{
+			name: "wrong password counts failure",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, la *repomocks.MockLoginAttempt, h *hashermocks.MockPasswordHasher) {
+				la.EXPECT().GetLockedUntil(gomock.Any(), subjects).
+					Return(time.Time{}, nil)
+				u.EXPECT().GetUserByName(gomock.Any(), input.Name).
+					Return(entity.User{Id: 1, Name: input.Name, Password: "hash"}, nil)
+				h.EXPECT().Verify(input.Password, "hash").
+					Return(false, nil)
+				la.EXPECT().RegisterFailure(gomock.Any(), subjects[0], gomock.Any()).
+					Return(1, nil)
+				la.EXPECT().RegisterFailure(gomock.Any(), subjects[1], gomock.Any()).
+					Return(1, nil)
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "unknown user counts failure",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, la *repomocks.MockLoginAttempt, h *hashermocks.MockPasswordHasher) {
+				la.EXPECT().GetLockedUntil(gomock.Any(), subjects).
+					Return(time.Time{}, nil)
+				u.EXPECT().GetUserByName(gomock.Any(), input.Name).
+					Return(entity.User{}, repository.ErrNotFound)
+				h.EXPECT().Hash(gomock.Any()).
+					Return("dummy-hash", nil)
+				h.EXPECT().Verify(input.Password, "dummy-hash").
+					Return(false, nil)
+				la.EXPECT().RegisterFailure(gomock.Any(), subjects[0], gomock.Any()).
+					Return(1, nil)
+				la.EXPECT().RegisterFailure(gomock.Any(), subjects[1], gomock.Any()).
+					Return(1, nil)
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "service account cannot log in",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, la *repomocks.MockLoginAttempt, h *hashermocks.MockPasswordHasher) {
+				la.EXPECT().GetLockedUntil(gomock.Any(), subjects).
+					Return(time.Time{}, nil)
+				u.EXPECT().GetUserByName(gomock.Any(), input.Name).
+					Return(entity.User{Id: 1, Name: input.Name, Role: entity.RoleService}, nil)
+				h.EXPECT().Hash(gomock.Any()).
+					Return("dummy-hash", nil)
+				h.EXPECT().Verify(input.Password, "dummy-hash").
+					Return(false, nil)
+				la.EXPECT().RegisterFailure(gomock.Any(), subjects[0], gomock.Any()).
+					Return(1, nil)
+				la.EXPECT().RegisterFailure(gomock.Any(), subjects[1], gomock.Any()).
+					Return(1, nil)
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "system account cannot log in",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, la *repomocks.MockLoginAttempt, h *hashermocks.MockPasswordHasher) {
+				la.EXPECT().GetLockedUntil(gomock.Any(), subjects).
+					Return(time.Time{}, nil)
+				u.EXPECT().GetUserByName(gomock.Any(), input.Name).
+					Return(entity.User{Id: 1, Name: input.Name, Password: "hash", Role: entity.RoleSystem}, nil)
+				h.EXPECT().Hash(gomock.Any()).
+					Return("dummy-hash", nil)
+				h.EXPECT().Verify(input.Password, "dummy-hash").
+					Return(false, nil)
+				la.EXPECT().RegisterFailure(gomock.Any(), subjects[0], gomock.Any()).
+					Return(1, nil)
+				la.EXPECT().RegisterFailure(gomock.Any(), subjects[1], gomock.Any()).
+					Return(1, nil)
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "user without password cannot log in",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, la *repomocks.MockLoginAttempt, h *hashermocks.MockPasswordHasher) {
+				la.EXPECT().GetLockedUntil(gomock.Any(), subjects).
+					Return(time.Time{}, nil)
+				u.EXPECT().GetUserByName(gomock.Any(), input.Name).
+					Return(entity.User{Id: 1, Name: input.Name, Role: entity.RoleUser}, nil)
+				h.EXPECT().Hash(gomock.Any()).
+					Return("dummy-hash", nil)
+				h.EXPECT().Verify(input.Password, "dummy-hash").
+					Return(false, nil)
+				la.EXPECT().RegisterFailure(gomock.Any(), subjects[0], gomock.Any()).
+					Return(1, nil)
+				la.EXPECT().RegisterFailure(gomock.Any(), subjects[1], gomock.Any()).
+					Return(1, nil)
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "success resets failures",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, la *repomocks.MockLoginAttempt, h *hashermocks.MockPasswordHasher) {
+				la.EXPECT().GetLockedUntil(gomock.Any(), subjects).
+					Return(time.Time{}, nil)
+				u.EXPECT().GetUserByName(gomock.Any(), input.Name).
+					Return(entity.User{Id: 1, Name: input.Name, Password: "hash"}, nil)
+				h.EXPECT().Verify(input.Password, "hash").
+					Return(true, nil)
+				h.EXPECT().NeedsRehash("hash").
+					Return(false)
+				la.EXPECT().Reset(gomock.Any(), subjects[0]).
+					Return(nil)
+				rt.EXPECT().Create(gomock.Any(), gomock.Any()).
+					Return(nil)
+			},
+			wantErr: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := repomocks.NewMockUser(ctrl)
+			refreshTokenRepo := repomocks.NewMockRefreshToken(ctrl)
+			loginAttemptRepo := repomocks.NewMockLoginAttempt(ctrl)
+			hasher := hashermocks.NewMockPasswordHasher(ctrl)
+			tc.mockBehavior(userRepo, refreshTokenRepo, loginAttemptRepo, hasher)
+
+			s := NewAuthService(userRepo, refreshTokenRepo, nil, nil, loginAttemptRepo, nil, hasher, nil, AuthServiceConfig{
+				Mode:     AuthModeExplicit,
+				Keys:     keys,
+				TokenTTL: time.Hour,
+				Lockout:  lockout,
+			})
+
+			_, err := s.GenerateToken(context.Background(), input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthService_Unlock(t *testing.T) {
+	lockout := LoginThrottleConfig{Threshold: 5}
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(la *repomocks.MockLoginAttempt)
+		wantErr      error
+	}{
+		{
+			name: "success",
+			mockBehavior: func(la *repomocks.MockLoginAttempt) {
+				la.EXPECT().Reset(gomock.Any(), "user:marcus-web-designer").Return(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "reset failed",
+			mockBehavior: func(la *repomocks.MockLoginAttempt) {
+				la.EXPECT().Reset(gomock.Any(), "user:marcus-web-designer").Return(errors.New("some error"))
+			},
+			wantErr: ErrCannotUnlockUser,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			loginAttemptRepo := repomocks.NewMockLoginAttempt(ctrl)
+			tc.mockBehavior(loginAttemptRepo)
+
+			s := NewAuthService(nil, nil, nil, nil, loginAttemptRepo, nil, nil, nil, AuthServiceConfig{Lockout: lockout})
+
+			err := s.Unlock(context.Background(), "marcus-web-designer")
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestAuthService_ChangePassword(t *testing.T) {
+	const tokenTTL = 2 * time.Hour
+
+	type MockBehavior func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, pr *repomocks.MockPasswordReset, ru *repomocks.MockRevokedUser, h *hashermocks.MockPasswordHasher)
+
+	input := AuthChangePasswordInput{UserId: 1, OldPassword: "Old_Passw0rd!", NewPassword: "New_Passw0rd!"}
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantRevoked  bool
+		wantErr      error
+	}{
+		{
+			name: "success",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, pr *repomocks.MockPasswordReset, ru *repomocks.MockRevokedUser, h *hashermocks.MockPasswordHasher) {
+				u.EXPECT().GetUserById(gomock.Any(), 1).
+					Return(entity.User{Id: 1, Password: "old-hash", Role: entity.RoleUser}, nil)
+				h.EXPECT().Verify(input.OldPassword, "old-hash").Return(true, nil)
+				h.EXPECT().Hash(input.NewPassword).Return("new-hash", nil)
+				u.EXPECT().UpdatePassword(gomock.Any(), 1, "new-hash").Return(nil)
+				rt.EXPECT().RevokeByUserId(gomock.Any(), 1).Return(nil)
+				pr.EXPECT().InvalidateByUserId(gomock.Any(), 1).Return(nil)
+				ru.EXPECT().Upsert(gomock.Any(), gomock.Cond(func(user entity.RevokedUser) bool {
+					return user.UserId == 1 && user.ExpiresAt.Sub(user.RevokedAt) == tokenTTL
+				})).Return(nil)
+			},
+			wantRevoked: true,
+			wantErr:     nil,
+		},
+		{
+			name: "wrong old password",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, pr *repomocks.MockPasswordReset, ru *repomocks.MockRevokedUser, h *hashermocks.MockPasswordHasher) {
+				u.EXPECT().GetUserById(gomock.Any(), 1).
+					Return(entity.User{Id: 1, Password: "old-hash", Role: entity.RoleUser}, nil)
+				h.EXPECT().Verify(input.OldPassword, "old-hash").Return(false, nil)
+			},
+			wantErr: ErrWrongPassword,
+		},
+		{
+			name: "service account",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, pr *repomocks.MockPasswordReset, ru *repomocks.MockRevokedUser, h *hashermocks.MockPasswordHasher) {
+				u.EXPECT().GetUserById(gomock.Any(), 1).
+					Return(entity.User{Id: 1, Role: entity.RoleService}, nil)
+			},
+			wantErr: ErrWrongPassword,
+		},
+		{
+			name: "update failed",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, pr *repomocks.MockPasswordReset, ru *repomocks.MockRevokedUser, h *hashermocks.MockPasswordHasher) {
+				u.EXPECT().GetUserById(gomock.Any(), 1).
+					Return(entity.User{Id: 1, Password: "old-hash", Role: entity.RoleUser}, nil)
+				h.EXPECT().Verify(input.OldPassword, "old-hash").Return(true, nil)
+				h.EXPECT().Hash(input.NewPassword).Return("new-hash", nil)
+				u.EXPECT().UpdatePassword(gomock.Any(), 1, "new-hash").Return(errors.New("some error"))
+			},
+			wantErr: ErrCannotChangePassword,
+		},
+		{
+			name: "revoke refresh tokens failed",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, pr *repomocks.MockPasswordReset, ru *repomocks.MockRevokedUser, h *hashermocks.MockPasswordHasher) {
+				u.EXPECT().GetUserById(gomock.Any(), 1).
+					Return(entity.User{Id: 1, Password: "old-hash", Role: entity.RoleUser}, nil)
+				h.EXPECT().Verify(input.OldPassword, "old-hash").Return(true, nil)
+				h.EXPECT().Hash(input.NewPassword).Return("new-hash", nil)
+				u.EXPECT().UpdatePassword(gomock.Any(), 1, "new-hash").Return(nil)
+				rt.EXPECT().RevokeByUserId(gomock.Any(), 1).Return(errors.New("some error"))
+			},
+			wantErr: ErrCannotChangePassword,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := repomocks.NewMockUser(ctrl)
+			refreshTokenRepo := repomocks.NewMockRefreshToken(ctrl)
+			passwordResetRepo := repomocks.NewMockPasswordReset(ctrl)
+			revokedUserRepo := repomocks.NewMockRevokedUser(ctrl)
+			revocations := NewTokenRevocationStore(repomocks.NewMockRevokedToken(ctrl), revokedUserRepo)
+			hasher := hashermocks.NewMockPasswordHasher(ctrl)
+			transactor := repomocks.NewMockTransactor(ctrl)
+			transactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+					return fn(ctx)
+				}).AnyTimes()
+			tc.mockBehavior(userRepo, refreshTokenRepo, passwordResetRepo, revokedUserRepo, hasher)
+
+			s := NewAuthService(userRepo, refreshTokenRepo, nil, passwordResetRepo, nil, revocations, hasher, transactor, AuthServiceConfig{TokenTTL: tokenTTL})
+
+			err := s.ChangePassword(context.Background(), input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantRevoked, revocations.IsUserRevoked(1, time.Now().Add(-time.Minute)))
+		})
+	}
+}
+
+func TestAuthService_CreatePasswordReset(t *testing.T) {
+	const resetTTL = time.Hour
+
+	input := AuthCreatePasswordResetInput{UserName: "marcus-web-designer", CreatedBy: 10}
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(u *repomocks.MockUser, pr *repomocks.MockPasswordReset)
+		wantErr      error
+	}{
+		{
+			name: "success",
+			mockBehavior: func(u *repomocks.MockUser, pr *repomocks.MockPasswordReset) {
+				u.EXPECT().GetUserByName(gomock.Any(), input.UserName).Return(entity.User{Id: 1, Role: entity.RoleUser}, nil)
+				pr.EXPECT().InvalidateByUserId(gomock.Any(), 1).Return(nil)
+				pr.EXPECT().Create(gomock.Any(), gomock.Cond(func(reset entity.PasswordReset) bool {
+					return reset.UserId == 1 && reset.CreatedBy == 10 && len(reset.TokenHash) == 64
+				})).Return(1, nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "user not found",
+			mockBehavior: func(u *repomocks.MockUser, pr *repomocks.MockPasswordReset) {
+				u.EXPECT().GetUserByName(gomock.Any(), input.UserName).Return(entity.User{}, repository.ErrNotFound)
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name: "service account",
+			mockBehavior: func(u *repomocks.MockUser, pr *repomocks.MockPasswordReset) {
+				u.EXPECT().GetUserByName(gomock.Any(), input.UserName).Return(entity.User{Id: 1, Role: entity.RoleService}, nil)
+			},
+			wantErr: ErrPasswordNotAllowed,
+		},
+		{
+			name: "system account",
+			mockBehavior: func(u *repomocks.MockUser, pr *repomocks.MockPasswordReset) {
+				u.EXPECT().GetUserByName(gomock.Any(), input.UserName).Return(entity.User{Id: 1, Role: entity.RoleSystem}, nil)
+			},
+			wantErr: ErrPasswordNotAllowed,
+		},
+		{
+			name: "create failed",
+			mockBehavior: func(u *repomocks.MockUser, pr *repomocks.MockPasswordReset) {
+				u.EXPECT().GetUserByName(gomock.Any(), input.UserName).Return(entity.User{Id: 1, Role: entity.RoleUser}, nil)
+				pr.EXPECT().InvalidateByUserId(gomock.Any(), 1).Return(nil)
+				pr.EXPECT().Create(gomock.Any(), gomock.Any()).Return(0, errors.New("some error"))
+			},
+			wantErr: ErrCannotCreatePasswordReset,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := repomocks.NewMockUser(ctrl)
+			passwordResetRepo := repomocks.NewMockPasswordReset(ctrl)
+			transactor := repomocks.NewMockTransactor(ctrl)
+			transactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+					return fn(ctx)
+				}).AnyTimes()
+			tc.mockBehavior(userRepo, passwordResetRepo)
+
+			s := NewAuthService(userRepo, nil, nil, passwordResetRepo, nil, nil, nil, transactor, AuthServiceConfig{PasswordResetTTL: resetTTL})
+
+			got, err := s.CreatePasswordReset(context.Background(), input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotEmpty(t, got.Token)
+			assert.WithinDuration(t, time.Now().Add(resetTTL), got.ExpiresAt, time.Minute)
+		})
+	}
+}
+
+func TestAuthService_ResetPassword(t *testing.T) {
+	type MockBehavior func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, pr *repomocks.MockPasswordReset, ru *repomocks.MockRevokedUser, h *hashermocks.MockPasswordHasher)
+
+	input := AuthResetPasswordInput{Token: "reset-token", NewPassword: "New_Passw0rd!"}
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "success",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, pr *repomocks.MockPasswordReset, ru *repomocks.MockRevokedUser, h *hashermocks.MockPasswordHasher) {
+				h.EXPECT().Hash(input.NewPassword).Return("new-hash", nil)
+				pr.EXPECT().Use(gomock.Any(), hashOpaqueToken(input.Token)).Return(1, nil)
+				u.EXPECT().GetUserById(gomock.Any(), 1).Return(entity.User{Id: 1, Role: entity.RoleUser}, nil)
+				u.EXPECT().UpdatePassword(gomock.Any(), 1, "new-hash").Return(nil)
+				rt.EXPECT().RevokeByUserId(gomock.Any(), 1).Return(nil)
+				pr.EXPECT().InvalidateByUserId(gomock.Any(), 1).Return(nil)
+				ru.EXPECT().Upsert(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "invalid token",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, pr *repomocks.MockPasswordReset, ru *repomocks.MockRevokedUser, h *hashermocks.MockPasswordHasher) {
+				h.EXPECT().Hash(input.NewPassword).Return("new-hash", nil)
+				pr.EXPECT().Use(gomock.Any(), hashOpaqueToken(input.Token)).Return(0, repository.ErrNotFound)
+			},
+			wantErr: ErrInvalidPasswordResetToken,
+		},
+		{
+			name: "service account",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, pr *repomocks.MockPasswordReset, ru *repomocks.MockRevokedUser, h *hashermocks.MockPasswordHasher) {
+				h.EXPECT().Hash(input.NewPassword).Return("new-hash", nil)
+				pr.EXPECT().Use(gomock.Any(), hashOpaqueToken(input.Token)).Return(1, nil)
+				u.EXPECT().GetUserById(gomock.Any(), 1).Return(entity.User{Id: 1, Role: entity.RoleService}, nil)
+			},
+			wantErr: ErrPasswordNotAllowed,
+		},
+		{
+			name: "system account",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, pr *repomocks.MockPasswordReset, ru *repomocks.MockRevokedUser, h *hashermocks.MockPasswordHasher) {
+				h.EXPECT().Hash(input.NewPassword).Return("new-hash", nil)
+				pr.EXPECT().Use(gomock.Any(), hashOpaqueToken(input.Token)).Return(1, nil)
+				u.EXPECT().GetUserById(gomock.Any(), 1).Return(entity.User{Id: 1, Role: entity.RoleSystem}, nil)
+			},
+			wantErr: ErrPasswordNotAllowed,
+		},
+		{
+			name: "use failed",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, pr *repomocks.MockPasswordReset, ru *repomocks.MockRevokedUser, h *hashermocks.MockPasswordHasher) {
+				h.EXPECT().Hash(input.NewPassword).Return("new-hash", nil)
+				pr.EXPECT().Use(gomock.Any(), hashOpaqueToken(input.Token)).Return(0, errors.New("some error"))
+			},
+			wantErr: ErrCannotChangePassword,
+		},
+		{
+			name: "hash failed",
+			mockBehavior: func(u *repomocks.MockUser, rt *repomocks.MockRefreshToken, pr *repomocks.MockPasswordReset, ru *repomocks.MockRevokedUser, h *hashermocks.MockPasswordHasher) {
+				h.EXPECT().Hash(input.NewPassword).Return("", errors.New("some error"))
+			},
+			wantErr: ErrCannotChangePassword,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := repomocks.NewMockUser(ctrl)
+			refreshTokenRepo := repomocks.NewMockRefreshToken(ctrl)
+			passwordResetRepo := repomocks.NewMockPasswordReset(ctrl)
+			revokedUserRepo := repomocks.NewMockRevokedUser(ctrl)
+			revocations := NewTokenRevocationStore(repomocks.NewMockRevokedToken(ctrl), revokedUserRepo)
+			hasher := hashermocks.NewMockPasswordHasher(ctrl)
+			transactor := repomocks.NewMockTransactor(ctrl)
+			transactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+					return fn(ctx)
+				}).AnyTimes()
+			tc.mockBehavior(userRepo, refreshTokenRepo, passwordResetRepo, revokedUserRepo, hasher)
+
+			s := NewAuthService(userRepo, refreshTokenRepo, nil, passwordResetRepo, nil, revocations, hasher, transactor, AuthServiceConfig{TokenTTL: time.Hour})
+
+			err := s.ResetPassword(context.Background(), input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, revocations.IsUserRevoked(1, time.Now().Add(-time.Minute)))
+		})
+	}
+}
+
+func TestAuthService_ProxyIdentity(t *testing.T) {
+	const name = "marcus-web-designer"
+
+	testCases := []struct {
+		name          string
+		autoProvision bool
+		mockBehavior  func(u *repomocks.MockUser)
+		want          AuthIdentity
+		wantErr       error
+	}{
+		{
+			name:          "existing user",
+			autoProvision: true,
+			mockBehavior: func(u *repomocks.MockUser) {
+				u.EXPECT().GetUserByName(gomock.Any(), name).
+					Return(entity.User{Id: 1, Name: name, Role: entity.RoleAdmin}, nil)
+			},
+			want:    AuthIdentity{UserId: 1, Role: entity.RoleAdmin},
+			wantErr: nil,
+		},
+		{
+			name:          "provisioned without password",
+			autoProvision: true,
+			mockBehavior: func(u *repomocks.MockUser) {
+				u.EXPECT().GetUserByName(gomock.Any(), name).
+					Return(entity.User{}, repository.ErrNotFound)
+				u.EXPECT().CreateUser(gomock.Any(), entity.User{Name: name, Role: entity.RoleUser}).
+					Return(2, nil)
+				u.EXPECT().GetUserByName(gomock.Any(), name).
+					Return(entity.User{Id: 2, Name: name, Role: entity.RoleUser}, nil)
+			},
+			want:    AuthIdentity{UserId: 2, Role: entity.RoleUser},
+			wantErr: nil,
+		},
+		{
+			name:          "created by concurrent request",
+			autoProvision: true,
+			mockBehavior: func(u *repomocks.MockUser) {
+				u.EXPECT().GetUserByName(gomock.Any(), name).
+					Return(entity.User{}, repository.ErrNotFound)
+				u.EXPECT().CreateUser(gomock.Any(), gomock.Any()).
+					Return(0, repository.ErrAlreadyExists)
+				u.EXPECT().GetUserByName(gomock.Any(), name).
+					Return(entity.User{Id: 2, Name: name, Role: entity.RoleUser}, nil)
+			},
+			want:    AuthIdentity{UserId: 2, Role: entity.RoleUser},
+			wantErr: nil,
+		},
+		{
+			name:          "provisioning disabled",
+			autoProvision: false,
+			mockBehavior: func(u *repomocks.MockUser) {
+				u.EXPECT().GetUserByName(gomock.Any(), name).
+					Return(entity.User{}, repository.ErrNotFound)
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name:          "service account",
+			autoProvision: true,
+			mockBehavior: func(u *repomocks.MockUser) {
+				u.EXPECT().GetUserByName(gomock.Any(), name).
+					Return(entity.User{Id: 3, Name: name, Role: entity.RoleService}, nil)
+			},
+			wantErr: ErrServiceAccountLogin,
+		},
+		{
+			name:          "system account",
+			autoProvision: true,
+			mockBehavior: func(u *repomocks.MockUser) {
+				u.EXPECT().GetUserByName(gomock.Any(), name).
+					Return(entity.User{Id: 1, Name: name, Role: entity.RoleSystem}, nil)
+			},
+			wantErr: ErrServiceAccountLogin,
+		},
+		{
+			name:          "create failed",
+			autoProvision: true,
+			mockBehavior: func(u *repomocks.MockUser) {
+				u.EXPECT().GetUserByName(gomock.Any(), name).
+					Return(entity.User{}, repository.ErrNotFound)
+				u.EXPECT().CreateUser(gomock.Any(), gomock.Any()).
+					Return(0, errors.New("some error"))
+			},
+			wantErr: ErrCannotCreateUser,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := repomocks.NewMockUser(ctrl)
+			tc.mockBehavior(userRepo)
+
+			s := NewAuthService(userRepo, nil, nil, nil, nil, nil, nil, nil, AuthServiceConfig{ProxyAutoProvision: tc.autoProvision})
+
+			got, err := s.ProxyIdentity(context.Background(), name)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func newTestKeySet(t *testing.T, kid string) *jwks.KeySet {
+	key, err := jwks.GenerateEd25519(kid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := jwks.NewKeySet(kid, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return keys
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/internal/repository"
+	"slices"
+	"strings"
+)
+
+type CartService struct {
+	cartRepo     repository.Cart
+	itemRepo     repository.Item
+	userRepo     repository.User
+	saleRepo     repository.Sale
+	ledgerRepo   repository.Ledger
+	purchaseRepo repository.Purchase
+	transactor   repository.Transactor
+}
+
+func NewCartService(cartRepo repository.Cart, itemRepo repository.Item, userRepo repository.User, saleRepo repository.Sale, ledgerRepo repository.Ledger, purchaseRepo repository.Purchase, transactor repository.Transactor) *CartService {
+	return &CartService{
+		cartRepo:     cartRepo,
+		itemRepo:     itemRepo,
+		userRepo:     userRepo,
+		saleRepo:     saleRepo,
+		ledgerRepo:   ledgerRepo,
+		purchaseRepo: purchaseRepo,
+		transactor:   transactor,
+	}
+}
+
+func (s *CartService) AddItem(ctx context.Context, input CartAddItemInput) error {
+	if input.Quantity < 1 {
+		return ErrInvalidQuantity
+	}
+
+	item, err := s.itemRepo.GetItemByName(ctx, input.ItemName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrItemNotFound
+		}
+		log.Errorf("CartService.AddItem - itemRepo.GetItemByName: %v", err)
+		return ErrCannotUpdateCart
+	}
+
+	err = s.cartRepo.AddItem(ctx, input.UserId, item.Id, input.Quantity)
+	if err != nil {
+		log.Errorf("CartService.AddItem - cartRepo.AddItem: %v", err)
+		return ErrCannotUpdateCart
+	}
+
+	return nil
+}
+
+func (s *CartService) RemoveItem(ctx context.Context, input CartRemoveItemInput) error {
+	item, err := s.itemRepo.GetItemByName(ctx, input.ItemName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrItemNotFound
+		}
+		log.Errorf("CartService.RemoveItem - itemRepo.GetItemByName: %v", err)
+		return ErrCannotUpdateCart
+	}
+
+	err = s.cartRepo.RemoveItem(ctx, input.UserId, item.Id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrCartItemNotFound
+		}
+		log.Errorf("CartService.RemoveItem - cartRepo.RemoveItem: %v", err)
+		return ErrCannotUpdateCart
+	}
+
+	return nil
+}
+
+func (s *CartService) Get(ctx context.Context, userId int) (CartContents, error) {
+	items, err := s.cartRepo.GetItems(ctx, userId)
+	if err != nil {
+		log.Errorf("CartService.Get - cartRepo.GetItems: %v", err)
+		return CartContents{}, ErrCannotGetCart
+	}
+
+	lines, total := newCartLines(items)
+	return CartContents{Lines: lines, Total: total}, nil
+}
+
+// Checkout buys everything in the cart in one transaction: either all lines are bought and the cart
+// is emptied, or nothing changes. A *CheckoutError names the line the checkout failed on.
+func (s *CartService) Checkout(ctx context.Context, userId int) (CartReceipt, error) {
+	var receipt CartReceipt
+
+	err := s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		items, err := s.cartRepo.TakeItems(txCtx, userId)
+		if err != nil {
+			log.Errorf("CartService.Checkout - cartRepo.TakeItems: %v", err)
+			return ErrCannotCheckout
+		}
+
+		if len(items) == 0 {
+			return ErrCartEmpty
+		}
+
+		slices.SortFunc(items, func(a, b entity.CartItem) int {
+			return strings.Compare(a.ItemName, b.ItemName)
+		})
+		lines, total := newCartLines(items)
+
+		if total > maxPayable {
+			return s.balanceError(txCtx, userId, lines)
+		}
+
+		err = s.userRepo.Withdraw(txCtx, userId, total)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return s.balanceError(txCtx, userId, lines)
+			}
+			log.Errorf("CartService.Checkout - userRepo.Withdraw: %v", err)
+			return ErrCannotCheckout
+		}
+
+		entry, err := s.ledgerRepo.Append(txCtx, entity.LedgerEntry{
+			SenderId: &userId,
+			Amount:   total,
+			Kind:     entity.LedgerKindPurchase,
+		})
+		if err != nil {
+			log.Errorf("CartService.Checkout - ledgerRepo.Append: %v", err)
+			return ErrCannotCheckout
+		}
+
+		for _, item := range items {
+			err = s.buyLine(txCtx, userId, entry.Id, item)
+			if err != nil {
+				return &CheckoutError{ItemName: item.ItemName, Quantity: item.Quantity, Err: err}
+			}
+		}
+
+		receipt = CartReceipt{
+			Id:          entry.Id,
+			Lines:       lines,
+			Total:       total,
+			PurchasedAt: entry.CreatedAt,
+		}
+		return nil
+	})
+	if err != nil {
+		return CartReceipt{}, err
+	}
+
+	return receipt, nil
+}
+
+func (s *CartService) buyLine(ctx context.Context, userId, ledgerEntryId int, item entity.CartItem) error {
+	err := s.itemRepo.TakeStock(ctx, item.ItemId, item.Quantity)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrItemOutOfStock
+		}
+		log.Errorf("CartService.buyLine - itemRepo.TakeStock: %v", err)
+		return ErrCannotBuyItem
+	}
+
+	err = s.saleRepo.Upsert(ctx, entity.Sale{
+		UserId:   userId,
+		ItemId:   item.ItemId,
+		Quantity: item.Quantity,
+	})
+	if err != nil {
+		log.Errorf("CartService.buyLine - saleRepo.Upsert: %v", err)
+		return ErrCannotBuyItem
+	}
+
+	_, err = s.purchaseRepo.Create(ctx, entity.Purchase{
+		LedgerEntryId: ledgerEntryId,
+		UserId:        userId,
+		ItemId:        item.ItemId,
+		Quantity:      item.Quantity,
+		Price:         item.Price,
+	})
+	if err != nil {
+		log.Errorf("CartService.buyLine - purchaseRepo.Create: %v", err)
+		return ErrCannotBuyItem
+	}
+
+	return nil
+}
+
+// balanceError finds the first line the balance is not enough for.
+func (s *CartService) balanceError(ctx context.Context, userId int, lines []CartLine) error {
+	user, err := s.userRepo.GetUserById(ctx, userId)
+	if err != nil {
+		log.Errorf("CartService.balanceError - userRepo.GetUserById: %v", err)
+		return ErrNotEnoughBalance
+	}
+
+	spent := 0
+	for _, line := range lines {
+		spent += line.Total
+		if spent > user.Balance {
+			return &CheckoutError{ItemName: line.ItemName, Quantity: line.Quantity, Err: ErrNotEnoughBalance}
+		}
+	}
+
+	return ErrNotEnoughBalance
+}
+
+func newCartLines(items []entity.CartItem) ([]CartLine, int) {
+	lines := make([]CartLine, 0, len(items))
+	total := 0
+	for _, item := range items {
+		lines = append(lines, CartLine{
+			ItemName: item.ItemName,
+			Quantity: item.Quantity,
+			Price:    item.Price,
+			Total:    item.Price * item.Quantity,
+		})
+		total += item.Price * item.Quantity
+	}
+
+	return lines, total
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/spanwalla/merch-store/internal/entity"
+	repomocks "github.com/spanwalla/merch-store/internal/mocks/repository"
+	"github.com/spanwalla/merch-store/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+type cartMocks struct {
+	cart       *repomocks.MockCart
+	item       *repomocks.MockItem
+	user       *repomocks.MockUser
+	sale       *repomocks.MockSale
+	ledger     *repomocks.MockLedger
+	purchase   *repomocks.MockPurchase
+	transactor *repomocks.MockTransactor
+}
+
+func newCartMocks(ctrl *gomock.Controller) cartMocks {
+	return cartMocks{
+		cart:       repomocks.NewMockCart(ctrl),
+		item:       repomocks.NewMockItem(ctrl),
+		user:       repomocks.NewMockUser(ctrl),
+		sale:       repomocks.NewMockSale(ctrl),
+		ledger:     repomocks.NewMockLedger(ctrl),
+		purchase:   repomocks.NewMockPurchase(ctrl),
+		transactor: repomocks.NewMockTransactor(ctrl),
+	}
+}
+
+func (m cartMocks) service() *CartService {
+	return NewCartService(m.cart, m.item, m.user, m.sale, m.ledger, m.purchase, m.transactor)
+}
+
+func TestCartService_AddItem(t *testing.T) {
+	testCases := []struct {
+		name         string
+		input        CartAddItemInput
+		mockBehavior func(m cartMocks, input CartAddItemInput)
+		wantErr      error
+	}{
+		{
+			name:  "success",
+			input: CartAddItemInput{UserId: 13, ItemName: "socks", Quantity: 2},
+			mockBehavior: func(m cartMocks, input CartAddItemInput) {
+				m.item.EXPECT().GetItemByName(gomock.Any(), input.ItemName).Return(entity.Item{Id: 4, Name: "socks", Price: 10}, nil)
+				m.cart.EXPECT().AddItem(gomock.Any(), input.UserId, 4, input.Quantity).Return(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name:         "zero quantity",
+			input:        CartAddItemInput{UserId: 13, ItemName: "socks"},
+			mockBehavior: func(m cartMocks, input CartAddItemInput) {},
+			wantErr:      ErrInvalidQuantity,
+		},
+		{
+			name:  "item does not exist",
+			input: CartAddItemInput{UserId: 13, ItemName: "bad-item-name", Quantity: 1},
+			mockBehavior: func(m cartMocks, input CartAddItemInput) {
+				m.item.EXPECT().GetItemByName(gomock.Any(), input.ItemName).Return(entity.Item{}, repository.ErrNotFound)
+			},
+			wantErr: ErrItemNotFound,
+		},
+		{
+			name:  "add failed",
+			input: CartAddItemInput{UserId: 13, ItemName: "socks", Quantity: 2},
+			mockBehavior: func(m cartMocks, input CartAddItemInput) {
+				m.item.EXPECT().GetItemByName(gomock.Any(), input.ItemName).Return(entity.Item{Id: 4, Name: "socks", Price: 10}, nil)
+				m.cart.EXPECT().AddItem(gomock.Any(), input.UserId, 4, input.Quantity).Return(errors.New("some error"))
+			},
+			wantErr: ErrCannotUpdateCart,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newCartMocks(ctrl)
+			tc.mockBehavior(m, tc.input)
+
+			err := m.service().AddItem(context.Background(), tc.input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCartService_RemoveItem(t *testing.T) {
+	testCases := []struct {
+		name         string
+		input        CartRemoveItemInput
+		mockBehavior func(m cartMocks, input CartRemoveItemInput)
+		wantErr      error
+	}{
+		{
+			name:  "success",
+			input: CartRemoveItemInput{UserId: 13, ItemName: "socks"},
+			mockBehavior: func(m cartMocks, input CartRemoveItemInput) {
+				m.item.EXPECT().GetItemByName(gomock.Any(), input.ItemName).Return(entity.Item{Id: 4, Name: "socks", Price: 10}, nil)
+				m.cart.EXPECT().RemoveItem(gomock.Any(), input.UserId, 4).Return(nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name:  "not in the cart",
+			input: CartRemoveItemInput{UserId: 13, ItemName: "socks"},
+			mockBehavior: func(m cartMocks, input CartRemoveItemInput) {
+				m.item.EXPECT().GetItemByName(gomock.Any(), input.ItemName).Return(entity.Item{Id: 4, Name: "socks", Price: 10}, nil)
+				m.cart.EXPECT().RemoveItem(gomock.Any(), input.UserId, 4).Return(repository.ErrNotFound)
+			},
+			wantErr: ErrCartItemNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newCartMocks(ctrl)
+			tc.mockBehavior(m, tc.input)
+
+			err := m.service().RemoveItem(context.Background(), tc.input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCartService_Checkout(t *testing.T) {
+	const userId = 13
+
+	purchasedAt := time.Now()
+	items := []entity.CartItem{
+		{ItemId: 7, ItemName: "t-shirt", Price: 80, Quantity: 1},
+		{ItemId: 4, ItemName: "socks", Price: 10, Quantity: 2},
+		{ItemId: 3, ItemName: "cup", Price: 20, Quantity: 1},
+	}
+	lines := []CartLine{
+		{ItemName: "cup", Quantity: 1, Price: 20, Total: 20},
+		{ItemName: "socks", Quantity: 2, Price: 10, Total: 20},
+		{ItemName: "t-shirt", Quantity: 1, Price: 80, Total: 80},
+	}
+
+	passThrough := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(m cartMocks)
+		want         CartReceipt
+		wantErr      error
+		wantLine     string
+	}{
+		{
+			name: "success",
+			mockBehavior: func(m cartMocks) {
+				m.transactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(passThrough)
+				m.cart.EXPECT().TakeItems(gomock.Any(), userId).Return(append([]entity.CartItem(nil), items...), nil)
+				m.user.EXPECT().Withdraw(gomock.Any(), userId, 120).Return(nil)
+				m.ledger.EXPECT().Append(gomock.Any(), gomock.Cond(func(entry entity.LedgerEntry) bool {
+					return *entry.SenderId == userId && entry.ReceiverId == nil && entry.Amount == 120 && entry.Kind == entity.LedgerKindPurchase
+				})).Return(entity.LedgerEntry{Id: 50, CreatedAt: purchasedAt}, nil)
+				m.item.EXPECT().TakeStock(gomock.Any(), 3, 1).Return(nil)
+				m.sale.EXPECT().Upsert(gomock.Any(), entity.Sale{UserId: userId, ItemId: 3, Quantity: 1}).Return(nil)
+				m.item.EXPECT().TakeStock(gomock.Any(), 4, 2).Return(nil)
+				m.sale.EXPECT().Upsert(gomock.Any(), entity.Sale{UserId: userId, ItemId: 4, Quantity: 2}).Return(nil)
+				m.item.EXPECT().TakeStock(gomock.Any(), 7, 1).Return(nil)
+				m.sale.EXPECT().Upsert(gomock.Any(), entity.Sale{UserId: userId, ItemId: 7, Quantity: 1}).Return(nil)
+				m.purchase.EXPECT().Create(gomock.Any(), entity.Purchase{LedgerEntryId: 50, UserId: userId, ItemId: 3, Quantity: 1, Price: 20}).Return(1, nil)
+				m.purchase.EXPECT().Create(gomock.Any(), entity.Purchase{LedgerEntryId: 50, UserId: userId, ItemId: 4, Quantity: 2, Price: 10}).Return(2, nil)
+				m.purchase.EXPECT().Create(gomock.Any(), entity.Purchase{LedgerEntryId: 50, UserId: userId, ItemId: 7, Quantity: 1, Price: 80}).Return(3, nil)
+			},
+			want: CartReceipt{
+				Id:          50,
+				Lines:       lines,
+				Total:       120,
+				PurchasedAt: purchasedAt,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "empty cart",
+			mockBehavior: func(m cartMocks) {
+				m.transactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(passThrough)
+				m.cart.EXPECT().TakeItems(gomock.Any(), userId).Return(nil, nil)
+			},
+			wantErr: ErrCartEmpty,
+		},
+		{
+			name: "not enough balance for a line",
+			mockBehavior: func(m cartMocks) {
+				m.transactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(passThrough)
+				m.cart.EXPECT().TakeItems(gomock.Any(), userId).Return(append([]entity.CartItem(nil), items...), nil)
+				m.user.EXPECT().Withdraw(gomock.Any(), userId, 120).Return(repository.ErrNotFound)
+				m.user.EXPECT().GetUserById(gomock.Any(), userId).Return(entity.User{Id: userId, Balance: 50}, nil)
+			},
+			wantErr:  ErrNotEnoughBalance,
+			wantLine: "t-shirt",
+		},
+		{
+			name: "sale failed",
+			mockBehavior: func(m cartMocks) {
+				m.transactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(passThrough)
+				m.cart.EXPECT().TakeItems(gomock.Any(), userId).Return(append([]entity.CartItem(nil), items...), nil)
+				m.user.EXPECT().Withdraw(gomock.Any(), userId, 120).Return(nil)
+				m.ledger.EXPECT().Append(gomock.Any(), gomock.Any()).Return(entity.LedgerEntry{Id: 50, CreatedAt: purchasedAt}, nil)
+				m.item.EXPECT().TakeStock(gomock.Any(), 3, 1).Return(nil)
+				m.sale.EXPECT().Upsert(gomock.Any(), entity.Sale{UserId: userId, ItemId: 3, Quantity: 1}).Return(nil)
+				m.purchase.EXPECT().Create(gomock.Any(), gomock.Any()).Return(1, nil)
+				m.item.EXPECT().TakeStock(gomock.Any(), 4, 2).Return(nil)
+				m.sale.EXPECT().Upsert(gomock.Any(), entity.Sale{UserId: userId, ItemId: 4, Quantity: 2}).Return(errors.New("some error"))
+			},
+			wantErr:  ErrCannotBuyItem,
+			wantLine: "socks",
+		},
+		{
+			name: "sold out",
+			mockBehavior: func(m cartMocks) {
+				m.transactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(passThrough)
+				m.cart.EXPECT().TakeItems(gomock.Any(), userId).Return(append([]entity.CartItem(nil), items...), nil)
+				m.user.EXPECT().Withdraw(gomock.Any(), userId, 120).Return(nil)
+				m.ledger.EXPECT().Append(gomock.Any(), gomock.Any()).Return(entity.LedgerEntry{Id: 50, CreatedAt: purchasedAt}, nil)
+				m.item.EXPECT().TakeStock(gomock.Any(), 3, 1).Return(repository.ErrNotFound)
+			},
+			wantErr:  ErrItemOutOfStock,
+			wantLine: "cup",
+		},
+		{
+			name: "take failed",
+			mockBehavior: func(m cartMocks) {
+				m.transactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(passThrough)
+				m.cart.EXPECT().TakeItems(gomock.Any(), userId).Return(nil, errors.New("some error"))
+			},
+			wantErr: ErrCannotCheckout,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newCartMocks(ctrl)
+			tc.mockBehavior(m)
+
+			got, err := m.service().Checkout(context.Background(), userId)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+
+				var checkoutErr *CheckoutError
+				if assert.Equal(t, len(tc.wantLine) > 0, errors.As(err, &checkoutErr)) && checkoutErr != nil {
+					assert.Equal(t, tc.wantLine, checkoutErr.ItemName)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
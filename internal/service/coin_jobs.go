@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/internal/repository"
+	"time"
+)
+
+const (
+	JobAllowance                 = "allowance"
+	JobCoinExpiration            = "coin_expiration"
+	JobPendingTransferExpiration = "pending_transfer_expiration"
+)
+
+type CoinJobsConfig struct {
+	// AllowanceAmount is credited to every user and admin on each run of the allowance job.
+	AllowanceAmount int
+	// ExpirationMonths is how long received coins are kept.
+	ExpirationMonths int
+}
+
+// CoinJobs are the scheduled jobs that move coins without a request from a user.
+type CoinJobs struct {
+	userRepo    repository.User
+	ledgerRepo  repository.Ledger
+	pendingRepo repository.PendingTransfer
+	cfg         CoinJobsConfig
+}
+
+func NewCoinJobs(userRepo repository.User, ledgerRepo repository.Ledger, pendingRepo repository.PendingTransfer, cfg CoinJobsConfig) *CoinJobs {
+	return &CoinJobs{
+		userRepo:    userRepo,
+		ledgerRepo:  ledgerRepo,
+		pendingRepo: pendingRepo,
+		cfg:         cfg,
+	}
+}
+
+// Allowance credits every employee with the allowance on behalf of the system account.
+func (j *CoinJobs) Allowance(ctx context.Context, _ time.Time) (string, error) {
+	systemId, err := j.userRepo.GetSystemUserId(ctx)
+	if err != nil {
+		return "", fmt.Errorf("CoinJobs.Allowance - userRepo.GetSystemUserId: %w", err)
+	}
+
+	userIds, err := j.userRepo.DepositAll(ctx, j.cfg.AllowanceAmount, []string{entity.RoleUser, entity.RoleAdmin})
+	if err != nil {
+		return "", fmt.Errorf("CoinJobs.Allowance - userRepo.DepositAll: %w", err)
+	}
+
+	entries := make([]entity.LedgerEntry, 0, len(userIds))
+	for _, userId := range userIds {
+		entries = append(entries, entity.LedgerEntry{
+			SenderId:   &systemId,
+			ReceiverId: &userId,
+			Amount:     j.cfg.AllowanceAmount,
+			Kind:       entity.LedgerKindAllowance,
+		})
+	}
+
+	err = j.ledgerRepo.AppendBatch(ctx, entries)
+	if err != nil {
+		return "", fmt.Errorf("CoinJobs.Allowance - ledgerRepo.AppendBatch: %w", err)
+	}
+
+	return fmt.Sprintf("credited %d coins to %d users", j.cfg.AllowanceAmount, len(userIds)), nil
+}
+
+// Expire takes out the minted and allowance coins received more than ExpirationMonths before the
+// scheduled time and not spent yet.
+func (j *CoinJobs) Expire(ctx context.Context, scheduledAt time.Time) (string, error) {
+	before := scheduledAt.AddDate(0, -j.cfg.ExpirationMonths, 0)
+	expirations, err := j.ledgerRepo.GetExpirable(ctx, before)
+	if err != nil {
+		return "", fmt.Errorf("CoinJobs.Expire - ledgerRepo.GetExpirable: %w", err)
+	}
+
+	reason := fmt.Sprintf("received before %s", before.Format(time.DateOnly))
+	entries := make([]entity.LedgerEntry, 0, len(expirations))
+	var total int
+	for _, expiration := range expirations {
+		amount := expiration.Amount()
+		if amount == 0 {
+			continue
+		}
+
+		err = j.userRepo.Withdraw(ctx, expiration.UserId, amount)
+		if err != nil {
+			// The user has spent the coins in the meantime, they expire on the next run if still there.
+			if errors.Is(err, repository.ErrNotFound) {
+				log.Debugf("CoinJobs.Expire: user %d spent %d expiring coins", expiration.UserId, amount)
+				continue
+			}
+			return "", fmt.Errorf("CoinJobs.Expire - userRepo.Withdraw: %w", err)
+		}
+
+		entries = append(entries, entity.LedgerEntry{
+			SenderId: &expiration.UserId,
+			Amount:   amount,
+			Kind:     entity.LedgerKindExpiration,
+			Reason:   reason,
+		})
+		total += amount
+	}
+
+	err = j.ledgerRepo.AppendBatch(ctx, entries)
+	if err != nil {
+		return "", fmt.Errorf("CoinJobs.Expire - ledgerRepo.AppendBatch: %w", err)
+	}
+
+	return fmt.Sprintf("expired %d coins of %d users %s", total, len(entries), reason), nil
+}
+
+// ReturnExpiredTransfers returns the coins of pending transfers that were not accepted in time to their senders.
+func (j *CoinJobs) ReturnExpiredTransfers(ctx context.Context, scheduledAt time.Time) (string, error) {
+	transfers, err := j.pendingRepo.ExpireDue(ctx, scheduledAt)
+	if err != nil {
+		return "", fmt.Errorf("CoinJobs.ReturnExpiredTransfers - pendingRepo.ExpireDue: %w", err)
+	}
+
+	inLockOrder(transfers, func(transfer entity.PendingTransfer) int { return transfer.SenderId })
+
+	entries := make([]entity.LedgerEntry, 0, len(transfers))
+	var total int
+	for _, transfer := range transfers {
+		err = j.userRepo.Deposit(ctx, transfer.SenderId, transfer.Amount)
+		if err != nil {
+			return "", fmt.Errorf("CoinJobs.ReturnExpiredTransfers - userRepo.Deposit: %w", err)
+		}
+
+		entries = append(entries, entity.LedgerEntry{
+			ReceiverId:        &transfer.SenderId,
+			Amount:            transfer.Amount,
+			Kind:              entity.LedgerKindEscrowRefund,
+			PendingTransferId: &transfer.Id,
+		})
+		total += transfer.Amount
+	}
+
+	err = j.ledgerRepo.AppendBatch(ctx, entries)
+	if err != nil {
+		return "", fmt.Errorf("CoinJobs.ReturnExpiredTransfers - ledgerRepo.AppendBatch: %w", err)
+	}
+
+	return fmt.Sprintf("returned %d coins of %d expired transfers", total, len(transfers)), nil
+}
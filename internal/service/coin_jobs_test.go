@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/spanwalla/merch-store/internal/entity"
+	repomocks "github.com/spanwalla/merch-store/internal/mocks/repository"
+	"github.com/spanwalla/merch-store/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCoinJobs_Allowance(t *testing.T) {
+	systemId, firstId, secondId := 1, 2, 3
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(u *repomocks.MockUser, l *repomocks.MockLedger)
+		want         string
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger) {
+				u.EXPECT().GetSystemUserId(gomock.Any()).Return(systemId, nil)
+				u.EXPECT().DepositAll(gomock.Any(), 1000, []string{entity.RoleUser, entity.RoleAdmin}).Return([]int{firstId, secondId}, nil)
+				l.EXPECT().AppendBatch(gomock.Any(), []entity.LedgerEntry{
+					{SenderId: &systemId, ReceiverId: &firstId, Amount: 1000, Kind: entity.LedgerKindAllowance},
+					{SenderId: &systemId, ReceiverId: &secondId, Amount: 1000, Kind: entity.LedgerKindAllowance},
+				}).Return(nil)
+			},
+			want:    "credited 1000 coins to 2 users",
+			wantErr: false,
+		},
+		{
+			name: "no system account",
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger) {
+				u.EXPECT().GetSystemUserId(gomock.Any()).Return(0, repository.ErrNotFound)
+			},
+			wantErr: true,
+		},
+		{
+			name: "cannot append",
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger) {
+				u.EXPECT().GetSystemUserId(gomock.Any()).Return(systemId, nil)
+				u.EXPECT().DepositAll(gomock.Any(), 1000, gomock.Any()).Return([]int{firstId}, nil)
+				l.EXPECT().AppendBatch(gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := repomocks.NewMockUser(ctrl)
+			ledgerRepo := repomocks.NewMockLedger(ctrl)
+			tc.mockBehavior(userRepo, ledgerRepo)
+
+			jobs := NewCoinJobs(userRepo, ledgerRepo, repomocks.NewMockPendingTransfer(ctrl), CoinJobsConfig{AllowanceAmount: 1000})
+
+			got, err := jobs.Allowance(context.Background(), time.Now())
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestCoinJobs_Expire(t *testing.T) {
+	scheduledAt := time.Date(2025, 4, 1, 1, 0, 0, 0, time.UTC)
+	before := time.Date(2024, 4, 1, 1, 0, 0, 0, time.UTC)
+	firstId, secondId := 2, 3
+	reason := "received before 2024-04-01"
+
+	expirations := []entity.Expiration{
+		{UserId: firstId, Balance: 1300, Before: before, Entries: []entity.LedgerEntry{
+			newLedgerEntry(entity.LedgerKindAllowance, 1, firstId, 300, before.AddDate(0, -1, 0)),
+			newLedgerEntry(entity.LedgerKindTransfer, 4, firstId, 500, before.AddDate(0, 1, 0)),
+		}},
+		{UserId: secondId, Balance: 50, Before: before, Entries: []entity.LedgerEntry{
+			newLedgerEntry(entity.LedgerKindMint, 1, secondId, 1000, before.AddDate(0, -2, 0)),
+			newLedgerEntry(entity.LedgerKindExpiration, secondId, 0, 200, before.AddDate(0, -1, 0)),
+			newLedgerEntry(entity.LedgerKindPurchase, secondId, 0, 750, before.AddDate(0, 1, 0)),
+		}},
+		{UserId: 4, Balance: 100, Before: before, Entries: []entity.LedgerEntry{
+			newLedgerEntry(entity.LedgerKindAllowance, 1, 4, 300, before.AddDate(0, -1, 0)),
+			newLedgerEntry(entity.LedgerKindPurchase, 4, 0, 300, before.AddDate(0, 0, -1)),
+			newLedgerEntry(entity.LedgerKindTransfer, firstId, 4, 100, before.AddDate(0, 1, 0)),
+		}},
+	}
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(u *repomocks.MockUser, l *repomocks.MockLedger)
+		want         string
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger) {
+				l.EXPECT().GetExpirable(gomock.Any(), before).Return(expirations, nil)
+				u.EXPECT().Withdraw(gomock.Any(), firstId, 300).Return(nil)
+				u.EXPECT().Withdraw(gomock.Any(), secondId, 50).Return(nil)
+				l.EXPECT().AppendBatch(gomock.Any(), []entity.LedgerEntry{
+					{SenderId: &firstId, Amount: 300, Kind: entity.LedgerKindExpiration, Reason: reason},
+					{SenderId: &secondId, Amount: 50, Kind: entity.LedgerKindExpiration, Reason: reason},
+				}).Return(nil)
+			},
+			want:    "expired 350 coins of 2 users " + reason,
+			wantErr: false,
+		},
+		{
+			name: "spent in the meantime",
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger) {
+				l.EXPECT().GetExpirable(gomock.Any(), before).Return(expirations, nil)
+				u.EXPECT().Withdraw(gomock.Any(), firstId, 300).Return(repository.ErrNotFound)
+				u.EXPECT().Withdraw(gomock.Any(), secondId, 50).Return(nil)
+				l.EXPECT().AppendBatch(gomock.Any(), []entity.LedgerEntry{
+					{SenderId: &secondId, Amount: 50, Kind: entity.LedgerKindExpiration, Reason: reason},
+				}).Return(nil)
+			},
+			want:    "expired 50 coins of 1 users " + reason,
+			wantErr: false,
+		},
+		{
+			name: "cannot withdraw",
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger) {
+				l.EXPECT().GetExpirable(gomock.Any(), before).Return(expirations[:1], nil)
+				u.EXPECT().Withdraw(gomock.Any(), firstId, 300).Return(errors.New("some error"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "cannot get expirable",
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger) {
+				l.EXPECT().GetExpirable(gomock.Any(), before).Return(nil, errors.New("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := repomocks.NewMockUser(ctrl)
+			ledgerRepo := repomocks.NewMockLedger(ctrl)
+			tc.mockBehavior(userRepo, ledgerRepo)
+
+			jobs := NewCoinJobs(userRepo, ledgerRepo, repomocks.NewMockPendingTransfer(ctrl), CoinJobsConfig{ExpirationMonths: 12})
+
+			got, err := jobs.Expire(context.Background(), scheduledAt)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestCoinJobs_ExpireTwice(t *testing.T) {
+	scheduledAt := time.Date(2025, 4, 1, 1, 0, 0, 0, time.UTC)
+	before := time.Date(2024, 4, 1, 1, 0, 0, 0, time.UTC)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := repomocks.NewMockUser(ctrl)
+	ledgerRepo := repomocks.NewMockLedger(ctrl)
+
+	// The mocks keep the entries and balances the way the ledger and the users table would.
+	holdings := []entity.Expiration{
+		{UserId: 2, Balance: 1300, Before: before, Entries: []entity.LedgerEntry{
+			newLedgerEntry(entity.LedgerKindAllowance, 1, 2, 300, before.AddDate(0, -1, 0)),
+			newLedgerEntry(entity.LedgerKindTransfer, 3, 2, 500, before.AddDate(0, 1, 0)),
+		}},
+		{UserId: 3, Balance: 700, Before: before, Entries: []entity.LedgerEntry{
+			newLedgerEntry(entity.LedgerKindMint, 1, 3, 1000, before.AddDate(0, -1, 0)),
+			newLedgerEntry(entity.LedgerKindPurchase, 3, 0, 500, before.AddDate(0, 0, -1)),
+			newLedgerEntry(entity.LedgerKindTransfer, 4, 3, 200, before.AddDate(0, 1, 0)),
+		}},
+	}
+	ledgerRepo.EXPECT().GetExpirable(gomock.Any(), before).
+		DoAndReturn(func(context.Context, time.Time) ([]entity.Expiration, error) {
+			return slices.Clone(holdings), nil
+		}).Times(2)
+	userRepo.EXPECT().Withdraw(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, userId, amount int) error {
+			for i := range holdings {
+				if holdings[i].UserId == userId {
+					holdings[i].Balance -= amount
+				}
+			}
+			return nil
+		}).Times(2)
+	ledgerRepo.EXPECT().AppendBatch(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, entries []entity.LedgerEntry) error {
+			for _, entry := range entries {
+				for i := range holdings {
+					if holdings[i].UserId == *entry.SenderId {
+						holdings[i].Entries = append(holdings[i].Entries, entry)
+					}
+				}
+			}
+			return nil
+		}).Times(2)
+
+	jobs := NewCoinJobs(userRepo, ledgerRepo, repomocks.NewMockPendingTransfer(ctrl), CoinJobsConfig{ExpirationMonths: 12})
+
+	got, err := jobs.Expire(context.Background(), scheduledAt)
+	assert.NoError(t, err)
+	assert.Equal(t, "expired 800 coins of 2 users received before 2024-04-01", got)
+
+	got, err = jobs.Expire(context.Background(), scheduledAt)
+	assert.NoError(t, err)
+	assert.Equal(t, "expired 0 coins of 0 users received before 2024-04-01", got)
+}
+
+func TestExpiration_Amount(t *testing.T) {
+	const userId = 2
+	before := time.Date(2024, 4, 1, 1, 0, 0, 0, time.UTC)
+	old, recent := before.AddDate(0, -2, 0), before.AddDate(0, 1, 0)
+
+	testCases := []struct {
+		name    string
+		balance int
+		entries []entity.LedgerEntry
+		want    int
+	}{
+		{
+			name:    "unspent grant",
+			balance: 300,
+			entries: []entity.LedgerEntry{
+				newLedgerEntry(entity.LedgerKindAllowance, 1, userId, 300, old),
+			},
+			want: 300,
+		},
+		{
+			name:    "initial balance is spent first",
+			balance: 800,
+			entries: []entity.LedgerEntry{
+				newLedgerEntry(entity.LedgerKindAllowance, 1, userId, 300, old),
+				newLedgerEntry(entity.LedgerKindPurchase, userId, 0, 500, old.AddDate(0, 0, 1)),
+			},
+			want: 300,
+		},
+		{
+			name:    "second grant before the first one expires",
+			balance: 300,
+			entries: []entity.LedgerEntry{
+				newLedgerEntry(entity.LedgerKindAllowance, 1, userId, 300, old),
+				newLedgerEntry(entity.LedgerKindTransfer, 3, userId, 300, old.AddDate(0, 0, 1)),
+				newLedgerEntry(entity.LedgerKindAllowance, 1, userId, 300, recent),
+				newLedgerEntry(entity.LedgerKindPurchase, userId, 0, 600, recent.AddDate(0, 0, 1)),
+			},
+			want: 0,
+		},
+		{
+			name:    "spent down to the second grant",
+			balance: 200,
+			entries: []entity.LedgerEntry{
+				newLedgerEntry(entity.LedgerKindMint, 1, userId, 300, old),
+				newLedgerEntry(entity.LedgerKindAllowance, 1, userId, 300, old.AddDate(0, 0, 1)),
+				newLedgerEntry(entity.LedgerKindTransfer, userId, 3, 400, recent),
+			},
+			want: 200,
+		},
+		{
+			name:    "earlier expiration took the oldest grant",
+			balance: 300,
+			entries: []entity.LedgerEntry{
+				newLedgerEntry(entity.LedgerKindAllowance, 1, userId, 300, old),
+				newLedgerEntry(entity.LedgerKindTransfer, 3, userId, 300, old.AddDate(0, 0, 1)),
+				newLedgerEntry(entity.LedgerKindExpiration, userId, 0, 300, old.AddDate(0, 0, 2)),
+				newLedgerEntry(entity.LedgerKindMint, 1, userId, 300, old.AddDate(0, 0, 3)),
+				newLedgerEntry(entity.LedgerKindPurchase, userId, 0, 300, recent),
+			},
+			want: 300,
+		},
+		{
+			name:    "grant after the expiration time",
+			balance: 300,
+			entries: []entity.LedgerEntry{
+				newLedgerEntry(entity.LedgerKindAllowance, 1, userId, 300, recent),
+			},
+			want: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expiration := entity.Expiration{UserId: userId, Balance: tc.balance, Before: before, Entries: tc.entries}
+			assert.Equal(t, tc.want, expiration.Amount())
+		})
+	}
+}
+
+// newLedgerEntry makes an entry between the users, 0 stands for no user.
+func newLedgerEntry(kind string, senderId, receiverId, amount int, createdAt time.Time) entity.LedgerEntry {
+	entry := entity.LedgerEntry{CreatedAt: createdAt, Amount: amount, Kind: kind}
+	if senderId != 0 {
+		entry.SenderId = &senderId
+	}
+	if receiverId != 0 {
+		entry.ReceiverId = &receiverId
+	}
+	return entry
+}
+
+func TestCoinJobs_ReturnExpiredTransfers(t *testing.T) {
+	scheduledAt := time.Date(2025, 3, 17, 12, 0, 0, 0, time.UTC)
+	firstId, secondId := 2, 5
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(u *repomocks.MockUser, l *repomocks.MockLedger, p *repomocks.MockPendingTransfer)
+		want         string
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger, p *repomocks.MockPendingTransfer) {
+				p.EXPECT().ExpireDue(gomock.Any(), scheduledAt).Return([]entity.PendingTransfer{
+					{Id: 7, SenderId: secondId, ReceiverId: 3, Amount: 20},
+					{Id: 8, SenderId: firstId, ReceiverId: 3, Amount: 100},
+				}, nil)
+				gomock.InOrder(
+					u.EXPECT().Deposit(gomock.Any(), firstId, 100).Return(nil),
+					u.EXPECT().Deposit(gomock.Any(), secondId, 20).Return(nil),
+				)
+				l.EXPECT().AppendBatch(gomock.Any(), []entity.LedgerEntry{
+					{ReceiverId: &firstId, Amount: 100, Kind: entity.LedgerKindEscrowRefund, PendingTransferId: intPtr(8)},
+					{ReceiverId: &secondId, Amount: 20, Kind: entity.LedgerKindEscrowRefund, PendingTransferId: intPtr(7)},
+				}).Return(nil)
+			},
+			want:    "returned 120 coins of 2 expired transfers",
+			wantErr: false,
+		},
+		{
+			name: "cannot expire",
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger, p *repomocks.MockPendingTransfer) {
+				p.EXPECT().ExpireDue(gomock.Any(), scheduledAt).Return(nil, errors.New("some error"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "cannot deposit",
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger, p *repomocks.MockPendingTransfer) {
+				p.EXPECT().ExpireDue(gomock.Any(), scheduledAt).Return([]entity.PendingTransfer{{Id: 8, SenderId: firstId, ReceiverId: 3, Amount: 100}}, nil)
+				u.EXPECT().Deposit(gomock.Any(), firstId, 100).Return(errors.New("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := repomocks.NewMockUser(ctrl)
+			ledgerRepo := repomocks.NewMockLedger(ctrl)
+			pendingRepo := repomocks.NewMockPendingTransfer(ctrl)
+			tc.mockBehavior(userRepo, ledgerRepo, pendingRepo)
+
+			jobs := NewCoinJobs(userRepo, ledgerRepo, pendingRepo, CoinJobsConfig{})
+
+			got, err := jobs.ReturnExpiredTransfers(context.Background(), scheduledAt)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
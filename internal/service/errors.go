@@ -1,22 +1,179 @@
 package service
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 var (
-	ErrCannotSignToken  = errors.New("cannot sign token")
-	ErrCannotParseToken = errors.New("cannot parse token")
+	ErrCannotSignToken   = errors.New("cannot sign token")
+	ErrCannotParseToken  = errors.New("cannot parse token")
+	ErrTokenRevoked      = errors.New("token revoked")
+	ErrCannotRevokeToken = errors.New("cannot revoke token")
+
+	ErrInvalidRefreshToken     = errors.New("invalid refresh token")
+	ErrRefreshTokenReused      = errors.New("refresh token reused")
+	ErrCannotRefreshToken      = errors.New("cannot refresh token")
+	ErrCannotIssueRefreshToken = errors.New("cannot issue refresh token")
+
+	ErrWrongPassword        = errors.New("wrong password")
+	ErrInvalidCredentials   = errors.New("invalid username or password")
+	ErrCannotVerifyPassword = errors.New("cannot verify password")
+	ErrCannotGetUser        = errors.New("cannot get user")
+	ErrUserAlreadyExists    = errors.New("user already exists")
+	ErrCannotCreateUser     = errors.New("cannot create user")
+	ErrCannotSetRole        = errors.New("cannot set role")
+	ErrSystemAccountRole    = errors.New("the role of the system account cannot be changed")
+	ErrServiceAccountRole   = errors.New("service accounts cannot change roles with other accounts")
+	ErrServiceAccountLogin  = errors.New("service accounts cannot log in")
+	ErrPasswordNotAllowed   = errors.New("the account cannot have a password")
+
+	ErrCannotChangePassword      = errors.New("cannot change password")
+	ErrInvalidPasswordResetToken = errors.New("invalid password reset token")
+	ErrCannotCreatePasswordReset = errors.New("cannot create password reset")
 
-	ErrWrongPassword     = errors.New("wrong password")
-	ErrCannotGetUser     = errors.New("cannot get user")
-	ErrUserAlreadyExists = errors.New("user already exists")
-	ErrCannotCreateUser  = errors.New("cannot create user")
+	ErrAccountLocked            = errors.New("too many failed login attempts")
+	ErrCannotCheckLoginAttempts = errors.New("cannot check login attempts")
+	ErrCannotUnlockUser         = errors.New("cannot unlock user")
+
+	ErrInvalidAPIKey      = errors.New("invalid api key")
+	ErrCannotVerifyAPIKey = errors.New("cannot verify api key")
+	ErrNotServiceAccount  = errors.New("user is not a service account")
+	ErrInvalidScope       = errors.New("invalid scope")
+	ErrCannotCreateAPIKey = errors.New("cannot create api key")
+	ErrCannotGetAPIKeys   = errors.New("cannot get api keys")
+	ErrAPIKeyNotFound     = errors.New("api key not found")
+	ErrCannotRevokeAPIKey = errors.New("cannot revoke api key")
+
+	ErrInviteCodeRequired = errors.New("invite code required")
+	ErrInvalidInviteCode  = errors.New("invalid invite code")
+	ErrCannotCreateInvite = errors.New("cannot create invite")
 
 	ErrNotEnoughBalance    = errors.New("not enough balance")
 	ErrItemNotFound        = errors.New("item not found")
+	ErrItemOutOfStock      = errors.New("item is out of stock")
 	ErrCannotBuyItem       = errors.New("cannot buy item")
+	ErrInvalidQuantity     = errors.New("quantity must be positive")
 	ErrUserNotFound        = errors.New("user not found")
 	ErrCannotTransferCoins = errors.New("cannot transfer coins")
 	ErrSelfTransfer        = errors.New("cannot transfer coins to yourself")
+	ErrDuplicateRecipient  = errors.New("duplicate recipient")
+	ErrBatchRejected       = errors.New("batch has invalid transfers")
+	ErrLimitExceeded       = errors.New("transfer limit exceeded")
+	ErrInvalidAmount       = errors.New("amount must be positive")
+	ErrMintReasonRequired  = errors.New("reason is required")
+	ErrCannotMintCoins     = errors.New("cannot mint coins")
+
+	ErrPendingTransferNotFound = errors.New("pending transfer not found")
+
+	ErrPaymentRequestNotFound   = errors.New("pending payment request not found")
+	ErrCannotRequestPayment     = errors.New("cannot request payment")
+	ErrCannotGetPaymentRequests = errors.New("cannot get payment requests")
+
+	ErrCartEmpty        = errors.New("cart is empty")
+	ErrCartItemNotFound = errors.New("item is not in the cart")
+	ErrCannotUpdateCart = errors.New("cannot update cart")
+	ErrCannotGetCart    = errors.New("cannot get cart")
+	ErrCannotCheckout   = errors.New("cannot check out")
+
+	ErrPurchaseNotFound       = errors.New("purchase not found")
+	ErrReturnWindowExpired    = errors.New("return window has expired")
+	ErrReturnQuantityExceeded = errors.New("cannot return more items than were bought")
+	ErrReturnNotFound         = errors.New("pending return not found")
+	ErrCannotRequestReturn    = errors.New("cannot request return")
+	ErrCannotGetReturns       = errors.New("cannot get returns")
+	ErrCannotDecideReturn     = errors.New("cannot decide on return")
 
 	ErrCannotGetReport = errors.New("cannot get report")
+
+	ErrInvalidStock       = errors.New("stock must not be negative")
+	ErrStockLimitExceeded = errors.New("stock limit exceeded")
+	ErrCannotGetItems     = errors.New("cannot get items")
+	ErrCannotUpdateStock  = errors.New("cannot update stock")
+
+	ErrInvalidIdempotencyKey   = errors.New("invalid idempotency key")
+	ErrIdempotencyKeyReused    = errors.New("idempotency key reused with another request")
+	ErrCannotUseIdempotencyKey = errors.New("cannot use idempotency key")
 )
+
+// LockedError is returned while the username or the client IP is locked out. It matches ErrAccountLocked.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return ErrAccountLocked.Error()
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrAccountLocked
+}
+
+// CheckoutError names the cart line a checkout failed on. It matches the error of the line.
+type CheckoutError struct {
+	ItemName string
+	Quantity int
+	Err      error
+}
+
+func (e *CheckoutError) Error() string {
+	return fmt.Sprintf("%s x%d: %v", e.ItemName, e.Quantity, e.Err)
+}
+
+func (e *CheckoutError) Unwrap() error {
+	return e.Err
+}
+
+// BatchTransferError is returned when an all-or-nothing batch has invalid transfers. It matches ErrBatchRejected.
+type BatchTransferError struct {
+	Results []PaymentTransferResult
+}
+
+func (e *BatchTransferError) Error() string {
+	return ErrBatchRejected.Error()
+}
+
+func (e *BatchTransferError) Unwrap() error {
+	return ErrBatchRejected
+}
+
+// UnknownUsersError lists the users that were not found. It matches ErrUserNotFound.
+type UnknownUsersError struct {
+	UserNames []string
+}
+
+func (e *UnknownUsersError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrUserNotFound, strings.Join(e.UserNames, ", "))
+}
+
+func (e *UnknownUsersError) Unwrap() error {
+	return ErrUserNotFound
+}
+
+// Transfer limits reported by LimitError.
+const (
+	LimitTransferAmount = "transfer_amount"
+	LimitDailyAmount    = "daily_amount"
+	LimitDailyCount     = "daily_count"
+	LimitDailyReceived  = "daily_received"
+)
+
+// LimitError names the transfer limit that was hit. It matches ErrLimitExceeded.
+type LimitError struct {
+	Limit string
+	Max   int
+	// Used is the part of the limit used up before the rejected transfer.
+	Used int
+	// UserName is the recipient whose LimitDailyReceived was hit.
+	UserName string
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v: %s is %d, %d used", ErrLimitExceeded, e.Limit, e.Max, e.Used)
+}
+
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/internal/repository"
+	"time"
+)
+
+const (
+	idempotencyKeyMaxLength = 255
+
+	JobIdempotencyKeyExpiration = "idempotency_key_expiration"
+)
+
+type IdempotencyServiceConfig struct {
+	// KeyTTL is how long a response is replayed, after that the key may be used again.
+	KeyTTL time.Duration
+}
+
+type IdempotencyService struct {
+	idempotencyKeyRepo repository.IdempotencyKey
+	transactor         repository.Transactor
+	cfg                IdempotencyServiceConfig
+}
+
+func NewIdempotencyService(idempotencyKeyRepo repository.IdempotencyKey, transactor repository.Transactor, cfg IdempotencyServiceConfig) *IdempotencyService {
+	return &IdempotencyService{
+		idempotencyKeyRepo: idempotencyKeyRepo,
+		transactor:         transactor,
+		cfg:                cfg,
+	}
+}
+
+// Do runs fn at most once per key of the user and returns its response, or the saved one when the key
+// is sent again. The key is recorded in the transaction fn runs in, so it is kept only if fn succeeds
+// and a failed request may be retried with the same key. Without a key fn simply runs.
+func (s *IdempotencyService) Do(ctx context.Context, input IdempotencyInput, fn func(ctx context.Context) (IdempotentResponse, error)) (IdempotentResponse, error) {
+	if len(input.Key) == 0 {
+		return fn(ctx)
+	}
+
+	if len(input.Key) > idempotencyKeyMaxLength {
+		return IdempotentResponse{}, ErrInvalidIdempotencyKey
+	}
+
+	fingerprint := sha256.Sum256(input.Request)
+	key := entity.IdempotencyKey{
+		UserId:      input.UserId,
+		Key:         input.Key,
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		ExpiresAt:   time.Now().Add(s.cfg.KeyTTL),
+	}
+
+	var response IdempotentResponse
+	err := s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		// Create waits for a concurrent request with the same key, so the saved response is complete.
+		id, err := s.idempotencyKeyRepo.Create(txCtx, key)
+		if err != nil {
+			if errors.Is(err, repository.ErrAlreadyExists) {
+				response, err = s.replay(txCtx, key)
+				return err
+			}
+			log.Errorf("IdempotencyService.Do - idempotencyKeyRepo.Create: %v", err)
+			return ErrCannotUseIdempotencyKey
+		}
+
+		response, err = fn(txCtx)
+		if err != nil {
+			return err
+		}
+
+		err = s.idempotencyKeyRepo.SaveResponse(txCtx, id, response.StatusCode, response.Body)
+		if err != nil {
+			log.Errorf("IdempotencyService.Do - idempotencyKeyRepo.SaveResponse: %v", err)
+			return ErrCannotUseIdempotencyKey
+		}
+
+		return nil
+	})
+	if err != nil {
+		return IdempotentResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (s *IdempotencyService) replay(ctx context.Context, key entity.IdempotencyKey) (IdempotentResponse, error) {
+	saved, err := s.idempotencyKeyRepo.Get(ctx, key.UserId, key.Key)
+	if err != nil {
+		log.Errorf("IdempotencyService.replay - idempotencyKeyRepo.Get: %v", err)
+		return IdempotentResponse{}, ErrCannotUseIdempotencyKey
+	}
+
+	if saved.Fingerprint != key.Fingerprint {
+		return IdempotentResponse{}, ErrIdempotencyKeyReused
+	}
+
+	return IdempotentResponse{
+		StatusCode: saved.StatusCode,
+		Body:       saved.Response,
+		Replayed:   true,
+	}, nil
+}
+
+// DeleteExpiredKeys is the scheduled job that deletes the keys whose responses are no longer replayed.
+func (s *IdempotencyService) DeleteExpiredKeys(ctx context.Context, _ time.Time) (string, error) {
+	deleted, err := s.idempotencyKeyRepo.DeleteExpired(ctx)
+	if err != nil {
+		return "", fmt.Errorf("IdempotencyService.DeleteExpiredKeys - idempotencyKeyRepo.DeleteExpired: %w", err)
+	}
+
+	return fmt.Sprintf("deleted %d expired keys", deleted), nil
+}
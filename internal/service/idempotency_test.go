@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/spanwalla/merch-store/internal/entity"
+	repomocks "github.com/spanwalla/merch-store/internal/mocks/repository"
+	"github.com/spanwalla/merch-store/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyService_Do(t *testing.T) {
+	type MockBehavior func(k *repomocks.MockIdempotencyKey, t *repomocks.MockTransactor, key entity.IdempotencyKey)
+
+	request := []byte(`POST /api/sendCoin {"toUser":"hoody","amount":10}`)
+	sum := sha256.Sum256(request)
+	key := entity.IdempotencyKey{UserId: 13, Key: "retry-1", Fingerprint: hex.EncodeToString(sum[:])}
+
+	passThrough := func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}
+
+	const keyTTL = 24 * time.Hour
+	claimed := func(key entity.IdempotencyKey) any {
+		return gomock.Cond(func(got entity.IdempotencyKey) bool {
+			return got.UserId == key.UserId && got.Key == key.Key && got.Fingerprint == key.Fingerprint &&
+				time.Until(got.ExpiresAt).Round(time.Minute) == keyTTL
+		})
+	}
+
+	testCases := []struct {
+		name         string
+		input        IdempotencyInput
+		mockBehavior MockBehavior
+		fnErr        error
+		wantCalls    int
+		want         IdempotentResponse
+		wantErr      error
+	}{
+		{
+			name:         "no key",
+			input:        IdempotencyInput{UserId: 13, Request: request},
+			mockBehavior: func(k *repomocks.MockIdempotencyKey, t *repomocks.MockTransactor, key entity.IdempotencyKey) {},
+			wantCalls:    1,
+			want:         IdempotentResponse{StatusCode: 200, Body: []byte(`{}`)},
+			wantErr:      nil,
+		},
+		{
+			name:  "first request",
+			input: IdempotencyInput{UserId: 13, Key: "retry-1", Request: request},
+			mockBehavior: func(k *repomocks.MockIdempotencyKey, t *repomocks.MockTransactor, key entity.IdempotencyKey) {
+				t.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(passThrough)
+				k.EXPECT().Create(gomock.Any(), claimed(key)).Return(5, nil)
+				k.EXPECT().SaveResponse(gomock.Any(), 5, 200, []byte(`{}`)).Return(nil)
+			},
+			wantCalls: 1,
+			want:      IdempotentResponse{StatusCode: 200, Body: []byte(`{}`)},
+			wantErr:   nil,
+		},
+		{
+			name:  "replay",
+			input: IdempotencyInput{UserId: 13, Key: "retry-1", Request: request},
+			mockBehavior: func(k *repomocks.MockIdempotencyKey, t *repomocks.MockTransactor, key entity.IdempotencyKey) {
+				t.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(passThrough)
+				k.EXPECT().Create(gomock.Any(), claimed(key)).Return(0, repository.ErrAlreadyExists)
+
+				saved := key
+				saved.Id, saved.StatusCode, saved.Response = 5, 200, []byte(`{"saved":true}`)
+				k.EXPECT().Get(gomock.Any(), key.UserId, key.Key).Return(saved, nil)
+			},
+			wantCalls: 0,
+			want:      IdempotentResponse{StatusCode: 200, Body: []byte(`{"saved":true}`), Replayed: true},
+			wantErr:   nil,
+		},
+		{
+			name:  "key reused with another request",
+			input: IdempotencyInput{UserId: 13, Key: "retry-1", Request: request},
+			mockBehavior: func(k *repomocks.MockIdempotencyKey, t *repomocks.MockTransactor, key entity.IdempotencyKey) {
+				t.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(passThrough)
+				k.EXPECT().Create(gomock.Any(), claimed(key)).Return(0, repository.ErrAlreadyExists)
+
+				saved := key
+				saved.Fingerprint = strings.Repeat("0", 64)
+				k.EXPECT().Get(gomock.Any(), key.UserId, key.Key).Return(saved, nil)
+			},
+			wantCalls: 0,
+			wantErr:   ErrIdempotencyKeyReused,
+		},
+		{
+			name:  "request failed",
+			input: IdempotencyInput{UserId: 13, Key: "retry-1", Request: request},
+			mockBehavior: func(k *repomocks.MockIdempotencyKey, t *repomocks.MockTransactor, key entity.IdempotencyKey) {
+				t.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(passThrough)
+				k.EXPECT().Create(gomock.Any(), claimed(key)).Return(5, nil)
+			},
+			fnErr:     ErrNotEnoughBalance,
+			wantCalls: 1,
+			wantErr:   ErrNotEnoughBalance,
+		},
+		{
+			name:         "key too long",
+			input:        IdempotencyInput{UserId: 13, Key: strings.Repeat("k", idempotencyKeyMaxLength+1), Request: request},
+			mockBehavior: func(k *repomocks.MockIdempotencyKey, t *repomocks.MockTransactor, key entity.IdempotencyKey) {},
+			wantCalls:    0,
+			wantErr:      ErrInvalidIdempotencyKey,
+		},
+		{
+			name:  "create failed",
+			input: IdempotencyInput{UserId: 13, Key: "retry-1", Request: request},
+			mockBehavior: func(k *repomocks.MockIdempotencyKey, t *repomocks.MockTransactor, key entity.IdempotencyKey) {
+				t.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(passThrough)
+				k.EXPECT().Create(gomock.Any(), claimed(key)).Return(0, errors.New("some error"))
+			},
+			wantCalls: 0,
+			wantErr:   ErrCannotUseIdempotencyKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			idempotencyKeyRepo := repomocks.NewMockIdempotencyKey(ctrl)
+			transactor := repomocks.NewMockTransactor(ctrl)
+			tc.mockBehavior(idempotencyKeyRepo, transactor, key)
+
+			s := NewIdempotencyService(idempotencyKeyRepo, transactor, IdempotencyServiceConfig{KeyTTL: keyTTL})
+
+			calls := 0
+			got, err := s.Do(context.Background(), tc.input, func(ctx context.Context) (IdempotentResponse, error) {
+				calls++
+				if tc.fnErr != nil {
+					return IdempotentResponse{}, tc.fnErr
+				}
+				return IdempotentResponse{StatusCode: 200, Body: []byte(`{}`)}, nil
+			})
+			assert.Equal(t, tc.wantCalls, calls)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestIdempotencyService_DeleteExpiredKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	idempotencyKeyRepo := repomocks.NewMockIdempotencyKey(ctrl)
+	s := NewIdempotencyService(idempotencyKeyRepo, nil, IdempotencyServiceConfig{KeyTTL: time.Hour})
+
+	idempotencyKeyRepo.EXPECT().DeleteExpired(gomock.Any()).Return(int64(3), nil)
+	details, err := s.DeleteExpiredKeys(context.Background(), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "deleted 3 expired keys", details)
+
+	idempotencyKeyRepo.EXPECT().DeleteExpired(gomock.Any()).Return(int64(0), errors.New("some error"))
+	_, err = s.DeleteExpiredKeys(context.Background(), time.Now())
+	assert.Error(t, err)
+}
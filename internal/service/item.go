@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/internal/repository"
+)
+
+type ItemService struct {
+	itemRepo repository.Item
+}
+
+func NewItemService(itemRepo repository.Item) *ItemService {
+	return &ItemService{itemRepo: itemRepo}
+}
+
+// Restock adds items to the stock. The stock of an item with unlimited stock stays unlimited.
+func (s *ItemService) Restock(ctx context.Context, input ItemRestockInput) (entity.Item, error) {
+	if input.Quantity <= 0 {
+		return entity.Item{}, ErrInvalidQuantity
+	}
+	if input.Quantity > entity.MaxItemStock {
+		return entity.Item{}, ErrStockLimitExceeded
+	}
+
+	item, err := s.itemRepo.GetItemByName(ctx, input.ItemName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return entity.Item{}, ErrItemNotFound
+		}
+		log.Errorf("ItemService.Restock - itemRepo.GetItemByName: %v", err)
+		return entity.Item{}, ErrCannotUpdateStock
+	}
+
+	item, err = s.itemRepo.AddStock(ctx, item.Id, input.Quantity)
+	if err != nil {
+		// The item exists, so the update only misses it when the stock would exceed the limit.
+		if errors.Is(err, repository.ErrNotFound) {
+			return entity.Item{}, ErrStockLimitExceeded
+		}
+		log.Errorf("ItemService.Restock - itemRepo.AddStock: %v", err)
+		return entity.Item{}, ErrCannotUpdateStock
+	}
+
+	return item, nil
+}
+
+func (s *ItemService) SetStock(ctx context.Context, input ItemSetStockInput) (entity.Item, error) {
+	if input.Stock != nil && *input.Stock < 0 {
+		return entity.Item{}, ErrInvalidStock
+	}
+	if input.Stock != nil && *input.Stock > entity.MaxItemStock {
+		return entity.Item{}, ErrStockLimitExceeded
+	}
+
+	item, err := s.itemRepo.GetItemByName(ctx, input.ItemName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return entity.Item{}, ErrItemNotFound
+		}
+		log.Errorf("ItemService.SetStock - itemRepo.GetItemByName: %v", err)
+		return entity.Item{}, ErrCannotUpdateStock
+	}
+
+	item, err = s.itemRepo.SetStock(ctx, item.Id, input.Stock)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return entity.Item{}, ErrItemNotFound
+		}
+		log.Errorf("ItemService.SetStock - itemRepo.SetStock: %v", err)
+		return entity.Item{}, ErrCannotUpdateStock
+	}
+
+	return item, nil
+}
+
+// LowStock returns the items with at most threshold items left, items with unlimited stock are never low.
+func (s *ItemService) LowStock(ctx context.Context, threshold int) ([]entity.Item, error) {
+	items, err := s.itemRepo.GetLowStock(ctx, threshold)
+	if err != nil {
+		log.Errorf("ItemService.LowStock - itemRepo.GetLowStock: %v", err)
+		return nil, ErrCannotGetItems
+	}
+	return items, nil
+}
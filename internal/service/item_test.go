@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/spanwalla/merch-store/internal/entity"
+	repomocks "github.com/spanwalla/merch-store/internal/mocks/repository"
+	"github.com/spanwalla/merch-store/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"math"
+	"testing"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestItemService_Restock(t *testing.T) {
+	cup := entity.Item{Id: 3, Name: "cup", Price: 20, Stock: intPtr(2)}
+
+	testCases := []struct {
+		name         string
+		input        ItemRestockInput
+		mockBehavior func(i *repomocks.MockItem)
+		want         entity.Item
+		wantErr      error
+	}{
+		{
+			name:  "success",
+			input: ItemRestockInput{ItemName: "cup", Quantity: 10},
+			mockBehavior: func(i *repomocks.MockItem) {
+				i.EXPECT().GetItemByName(gomock.Any(), "cup").Return(cup, nil)
+				i.EXPECT().AddStock(gomock.Any(), cup.Id, 10).Return(entity.Item{Id: 3, Name: "cup", Price: 20, Stock: intPtr(12)}, nil)
+			},
+			want: entity.Item{Id: 3, Name: "cup", Price: 20, Stock: intPtr(12)},
+		},
+		{
+			name:         "zero quantity",
+			input:        ItemRestockInput{ItemName: "cup"},
+			mockBehavior: func(i *repomocks.MockItem) {},
+			wantErr:      ErrInvalidQuantity,
+		},
+		{
+			name:  "item does not exist",
+			input: ItemRestockInput{ItemName: "bad-item-name", Quantity: 10},
+			mockBehavior: func(i *repomocks.MockItem) {
+				i.EXPECT().GetItemByName(gomock.Any(), "bad-item-name").Return(entity.Item{}, repository.ErrNotFound)
+			},
+			wantErr: ErrItemNotFound,
+		},
+		{
+			name:  "stock limit exceeded",
+			input: ItemRestockInput{ItemName: "cup", Quantity: 10},
+			mockBehavior: func(i *repomocks.MockItem) {
+				i.EXPECT().GetItemByName(gomock.Any(), "cup").Return(cup, nil)
+				i.EXPECT().AddStock(gomock.Any(), cup.Id, 10).Return(entity.Item{}, repository.ErrNotFound)
+			},
+			wantErr: ErrStockLimitExceeded,
+		},
+		{
+			name:         "quantity over the limit",
+			input:        ItemRestockInput{ItemName: "cup", Quantity: math.MaxInt32 + 1},
+			mockBehavior: func(i *repomocks.MockItem) {},
+			wantErr:      ErrStockLimitExceeded,
+		},
+		{
+			name:  "unlimited stock stays unlimited",
+			input: ItemRestockInput{ItemName: "cup", Quantity: 10},
+			mockBehavior: func(i *repomocks.MockItem) {
+				i.EXPECT().GetItemByName(gomock.Any(), "cup").Return(entity.Item{Id: 3, Name: "cup", Price: 20}, nil)
+				i.EXPECT().AddStock(gomock.Any(), 3, 10).Return(entity.Item{Id: 3, Name: "cup", Price: 20}, nil)
+			},
+			want: entity.Item{Id: 3, Name: "cup", Price: 20},
+		},
+		{
+			name:  "update failed",
+			input: ItemRestockInput{ItemName: "cup", Quantity: 10},
+			mockBehavior: func(i *repomocks.MockItem) {
+				i.EXPECT().GetItemByName(gomock.Any(), "cup").Return(cup, nil)
+				i.EXPECT().AddStock(gomock.Any(), cup.Id, 10).Return(entity.Item{}, errors.New("some error"))
+			},
+			wantErr: ErrCannotUpdateStock,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			i := repomocks.NewMockItem(ctrl)
+			tc.mockBehavior(i)
+
+			got, err := NewItemService(i).Restock(context.Background(), tc.input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestItemService_SetStock(t *testing.T) {
+	cup := entity.Item{Id: 3, Name: "cup", Price: 20, Stock: intPtr(2)}
+
+	testCases := []struct {
+		name         string
+		input        ItemSetStockInput
+		mockBehavior func(i *repomocks.MockItem)
+		want         entity.Item
+		wantErr      error
+	}{
+		{
+			name:  "success",
+			input: ItemSetStockInput{ItemName: "cup", Stock: intPtr(0)},
+			mockBehavior: func(i *repomocks.MockItem) {
+				i.EXPECT().GetItemByName(gomock.Any(), "cup").Return(cup, nil)
+				i.EXPECT().SetStock(gomock.Any(), cup.Id, intPtr(0)).Return(entity.Item{Id: 3, Name: "cup", Price: 20, Stock: intPtr(0)}, nil)
+			},
+			want: entity.Item{Id: 3, Name: "cup", Price: 20, Stock: intPtr(0)},
+		},
+		{
+			name:  "unlimited",
+			input: ItemSetStockInput{ItemName: "cup"},
+			mockBehavior: func(i *repomocks.MockItem) {
+				i.EXPECT().GetItemByName(gomock.Any(), "cup").Return(cup, nil)
+				i.EXPECT().SetStock(gomock.Any(), cup.Id, nil).Return(entity.Item{Id: 3, Name: "cup", Price: 20}, nil)
+			},
+			want: entity.Item{Id: 3, Name: "cup", Price: 20},
+		},
+		{
+			name:         "negative stock",
+			input:        ItemSetStockInput{ItemName: "cup", Stock: intPtr(-1)},
+			mockBehavior: func(i *repomocks.MockItem) {},
+			wantErr:      ErrInvalidStock,
+		},
+		{
+			name:         "stock limit exceeded",
+			input:        ItemSetStockInput{ItemName: "cup", Stock: intPtr(math.MaxInt32 + 1)},
+			mockBehavior: func(i *repomocks.MockItem) {},
+			wantErr:      ErrStockLimitExceeded,
+		},
+		{
+			name:  "item does not exist",
+			input: ItemSetStockInput{ItemName: "bad-item-name", Stock: intPtr(5)},
+			mockBehavior: func(i *repomocks.MockItem) {
+				i.EXPECT().GetItemByName(gomock.Any(), "bad-item-name").Return(entity.Item{}, repository.ErrNotFound)
+			},
+			wantErr: ErrItemNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			i := repomocks.NewMockItem(ctrl)
+			tc.mockBehavior(i)
+
+			got, err := NewItemService(i).SetStock(context.Background(), tc.input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestItemService_LowStock(t *testing.T) {
+	testCases := []struct {
+		name         string
+		mockBehavior func(i *repomocks.MockItem)
+		want         []entity.Item
+		wantErr      error
+	}{
+		{
+			name: "success",
+			mockBehavior: func(i *repomocks.MockItem) {
+				i.EXPECT().GetLowStock(gomock.Any(), 5).Return([]entity.Item{{Id: 3, Name: "cup", Price: 20, Stock: intPtr(0)}}, nil)
+			},
+			want: []entity.Item{{Id: 3, Name: "cup", Price: 20, Stock: intPtr(0)}},
+		},
+		{
+			name: "repo error",
+			mockBehavior: func(i *repomocks.MockItem) {
+				i.EXPECT().GetLowStock(gomock.Any(), 5).Return(nil, errors.New("some error"))
+			},
+			wantErr: ErrCannotGetItems,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			i := repomocks.NewMockItem(ctrl)
+			tc.mockBehavior(i)
+
+			got, err := NewItemService(i).LowStock(context.Background(), 5)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
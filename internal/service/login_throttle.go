@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spanwalla/merch-store/internal/repository"
+	"time"
+)
+
+const maxLockoutShift = 30
+
+type LoginThrottleConfig struct {
+	// Threshold is the number of failures per username before lockout, 0 disables it.
+	Threshold int
+	// IPThreshold is the number of failures per client IP before lockout, 0 disables it.
+	IPThreshold int
+	BaseLockout time.Duration
+	MaxLockout  time.Duration
+	// Window is the time after the last failure when the counter starts over.
+	Window time.Duration
+}
+
+// LoginThrottle counts failed logins per username and per client IP. Each failure
+// over the threshold locks the subject out for twice as long as the previous one.
+type LoginThrottle struct {
+	loginAttemptRepo repository.LoginAttempt
+	cfg              LoginThrottleConfig
+}
+
+func NewLoginThrottle(loginAttemptRepo repository.LoginAttempt, cfg LoginThrottleConfig) *LoginThrottle {
+	return &LoginThrottle{
+		loginAttemptRepo: loginAttemptRepo,
+		cfg:              cfg,
+	}
+}
+
+// Check returns *LockedError if the username or the ip is locked out.
+func (t *LoginThrottle) Check(ctx context.Context, name, ip string) error {
+	subjects := t.subjects(name, ip)
+	if len(subjects) == 0 {
+		return nil
+	}
+
+	lockedUntil, err := t.loginAttemptRepo.GetLockedUntil(ctx, subjects)
+	if err != nil {
+		return fmt.Errorf("LoginThrottle.Check - loginAttemptRepo.GetLockedUntil: %w", err)
+	}
+
+	if retryAfter := time.Until(lockedUntil); retryAfter > 0 {
+		return &LockedError{RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// Fail registers a failed login. Errors are logged, a failed login is reported to the client anyway.
+func (t *LoginThrottle) Fail(ctx context.Context, name, ip string) {
+	if t.cfg.Threshold > 0 {
+		t.fail(ctx, userSubject(name), t.cfg.Threshold)
+	}
+	if len(ip) > 0 && t.cfg.IPThreshold > 0 {
+		t.fail(ctx, ipSubject(ip), t.cfg.IPThreshold)
+	}
+}
+
+// Reset forgets failures of the username, e.g. after a successful login or on unlock by admin.
+func (t *LoginThrottle) Reset(ctx context.Context, name string) error {
+	if t.cfg.Threshold <= 0 {
+		return nil
+	}
+
+	err := t.loginAttemptRepo.Reset(ctx, userSubject(name))
+	if err != nil {
+		return fmt.Errorf("LoginThrottle.Reset - loginAttemptRepo.Reset: %w", err)
+	}
+
+	return nil
+}
+
+func (t *LoginThrottle) fail(ctx context.Context, subject string, threshold int) {
+	failures, err := t.loginAttemptRepo.RegisterFailure(ctx, subject, time.Now().Add(-t.cfg.Window))
+	if err != nil {
+		log.Errorf("LoginThrottle.fail - loginAttemptRepo.RegisterFailure: %v", err)
+		return
+	}
+
+	if failures < threshold {
+		return
+	}
+
+	err = t.loginAttemptRepo.Lock(ctx, subject, time.Now().Add(t.lockout(failures-threshold)))
+	if err != nil {
+		log.Errorf("LoginThrottle.fail - loginAttemptRepo.Lock: %v", err)
+		return
+	}
+
+	log.Warnf("LoginThrottle.fail: %s locked out after %d failures", subject, failures)
+}
+
+func (t *LoginThrottle) lockout(excess int) time.Duration {
+	if excess >= maxLockoutShift {
+		return t.cfg.MaxLockout
+	}
+	return min(t.cfg.BaseLockout<<excess, t.cfg.MaxLockout)
+}
+
+// subjects returns the subjects to check, each of them only if its threshold is set.
+func (t *LoginThrottle) subjects(name, ip string) []string {
+	var subjects []string
+	if t.cfg.Threshold > 0 {
+		subjects = append(subjects, userSubject(name))
+	}
+	if len(ip) > 0 && t.cfg.IPThreshold > 0 {
+		subjects = append(subjects, ipSubject(ip))
+	}
+	return subjects
+}
+
+func userSubject(name string) string {
+	return "user:" + name
+}
+
+func ipSubject(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	repomocks "github.com/spanwalla/merch-store/internal/mocks/repository"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestLoginThrottle_Check(t *testing.T) {
+	cfg := LoginThrottleConfig{Threshold: 5, IPThreshold: 50, BaseLockout: time.Second, MaxLockout: time.Minute, Window: time.Minute}
+
+	testCases := []struct {
+		name         string
+		cfg          LoginThrottleConfig
+		ip           string
+		mockBehavior func(r *repomocks.MockLoginAttempt)
+		wantLocked   bool
+		wantErr      bool
+	}{
+		{
+			name: "not locked",
+			cfg:  cfg,
+			ip:   "10.0.0.1",
+			mockBehavior: func(r *repomocks.MockLoginAttempt) {
+				r.EXPECT().GetLockedUntil(gomock.Any(), []string{"user:name", "ip:10.0.0.1"}).
+					Return(time.Time{}, nil)
+			},
+			wantLocked: false,
+			wantErr:    false,
+		},
+		{
+			name: "locked",
+			cfg:  cfg,
+			ip:   "10.0.0.1",
+			mockBehavior: func(r *repomocks.MockLoginAttempt) {
+				r.EXPECT().GetLockedUntil(gomock.Any(), []string{"user:name", "ip:10.0.0.1"}).
+					Return(time.Now().Add(time.Minute), nil)
+			},
+			wantLocked: true,
+			wantErr:    true,
+		},
+		{
+			name: "without ip",
+			cfg:  cfg,
+			mockBehavior: func(r *repomocks.MockLoginAttempt) {
+				r.EXPECT().GetLockedUntil(gomock.Any(), []string{"user:name"}).
+					Return(time.Time{}, nil)
+			},
+			wantLocked: false,
+			wantErr:    false,
+		},
+		{
+			name:         "disabled",
+			cfg:          LoginThrottleConfig{},
+			ip:           "10.0.0.1",
+			mockBehavior: func(r *repomocks.MockLoginAttempt) {},
+			wantLocked:   false,
+			wantErr:      false,
+		},
+		{
+			name: "ip only",
+			cfg:  LoginThrottleConfig{IPThreshold: 50, BaseLockout: time.Second, MaxLockout: time.Minute, Window: time.Minute},
+			ip:   "10.0.0.1",
+			mockBehavior: func(r *repomocks.MockLoginAttempt) {
+				r.EXPECT().GetLockedUntil(gomock.Any(), []string{"ip:10.0.0.1"}).
+					Return(time.Now().Add(time.Minute), nil)
+			},
+			wantLocked: true,
+			wantErr:    true,
+		},
+		{
+			name:         "ip only without ip",
+			cfg:          LoginThrottleConfig{IPThreshold: 50, BaseLockout: time.Second, MaxLockout: time.Minute, Window: time.Minute},
+			mockBehavior: func(r *repomocks.MockLoginAttempt) {},
+			wantLocked:   false,
+			wantErr:      false,
+		},
+		{
+			name: "repo error",
+			cfg:  cfg,
+			ip:   "10.0.0.1",
+			mockBehavior: func(r *repomocks.MockLoginAttempt) {
+				r.EXPECT().GetLockedUntil(gomock.Any(), gomock.Any()).
+					Return(time.Time{}, errors.New("some error"))
+			},
+			wantLocked: false,
+			wantErr:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			loginAttemptRepo := repomocks.NewMockLoginAttempt(ctrl)
+			tc.mockBehavior(loginAttemptRepo)
+
+			s := NewLoginThrottle(loginAttemptRepo, tc.cfg)
+
+			err := s.Check(context.Background(), "name", tc.ip)
+			if !tc.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.Error(t, err)
+			assert.Equal(t, tc.wantLocked, errors.Is(err, ErrAccountLocked))
+
+			var lockedErr *LockedError
+			if errors.As(err, &lockedErr) {
+				assert.InDelta(t, time.Minute.Seconds(), lockedErr.RetryAfter.Seconds(), 1)
+			}
+		})
+	}
+}
+
+func TestLoginThrottle_Fail(t *testing.T) {
+	cfg := LoginThrottleConfig{Threshold: 5, IPThreshold: 50, BaseLockout: 30 * time.Second, MaxLockout: 15 * time.Minute, Window: 15 * time.Minute}
+
+	lockedFor := func(want time.Duration) any {
+		return gomock.Cond(func(until time.Time) bool {
+			return time.Until(until).Round(time.Second) == want
+		})
+	}
+
+	testCases := []struct {
+		name         string
+		cfg          LoginThrottleConfig
+		ip           string
+		mockBehavior func(r *repomocks.MockLoginAttempt)
+	}{
+		{
+			name: "below threshold",
+			cfg:  cfg,
+			ip:   "10.0.0.1",
+			mockBehavior: func(r *repomocks.MockLoginAttempt) {
+				r.EXPECT().RegisterFailure(gomock.Any(), "user:name", gomock.Any()).Return(4, nil)
+				r.EXPECT().RegisterFailure(gomock.Any(), "ip:10.0.0.1", gomock.Any()).Return(4, nil)
+			},
+		},
+		{
+			name: "user reached threshold",
+			cfg:  cfg,
+			ip:   "10.0.0.1",
+			mockBehavior: func(r *repomocks.MockLoginAttempt) {
+				r.EXPECT().RegisterFailure(gomock.Any(), "user:name", gomock.Any()).Return(5, nil)
+				r.EXPECT().Lock(gomock.Any(), "user:name", lockedFor(30*time.Second)).Return(nil)
+				r.EXPECT().RegisterFailure(gomock.Any(), "ip:10.0.0.1", gomock.Any()).Return(5, nil)
+			},
+		},
+		{
+			name: "exponential backoff",
+			cfg:  cfg,
+			mockBehavior: func(r *repomocks.MockLoginAttempt) {
+				r.EXPECT().RegisterFailure(gomock.Any(), "user:name", gomock.Any()).Return(8, nil)
+				r.EXPECT().Lock(gomock.Any(), "user:name", lockedFor(4*time.Minute)).Return(nil)
+			},
+		},
+		{
+			name: "max lockout",
+			cfg:  cfg,
+			mockBehavior: func(r *repomocks.MockLoginAttempt) {
+				r.EXPECT().RegisterFailure(gomock.Any(), "user:name", gomock.Any()).Return(100, nil)
+				r.EXPECT().Lock(gomock.Any(), "user:name", lockedFor(15*time.Minute)).Return(nil)
+			},
+		},
+		{
+			name: "ip reached threshold",
+			cfg:  cfg,
+			ip:   "10.0.0.1",
+			mockBehavior: func(r *repomocks.MockLoginAttempt) {
+				r.EXPECT().RegisterFailure(gomock.Any(), "user:name", gomock.Any()).Return(1, nil)
+				r.EXPECT().RegisterFailure(gomock.Any(), "ip:10.0.0.1", gomock.Any()).Return(51, nil)
+				r.EXPECT().Lock(gomock.Any(), "ip:10.0.0.1", lockedFor(time.Minute)).Return(nil)
+			},
+		},
+		{
+			name: "ip only",
+			cfg:  LoginThrottleConfig{IPThreshold: 50, BaseLockout: 30 * time.Second, MaxLockout: 15 * time.Minute, Window: 15 * time.Minute},
+			ip:   "10.0.0.1",
+			mockBehavior: func(r *repomocks.MockLoginAttempt) {
+				r.EXPECT().RegisterFailure(gomock.Any(), "ip:10.0.0.1", gomock.Any()).Return(50, nil)
+				r.EXPECT().Lock(gomock.Any(), "ip:10.0.0.1", lockedFor(30*time.Second)).Return(nil)
+			},
+		},
+		{
+			name:         "disabled",
+			cfg:          LoginThrottleConfig{},
+			ip:           "10.0.0.1",
+			mockBehavior: func(r *repomocks.MockLoginAttempt) {},
+		},
+		{
+			name: "repo error is ignored",
+			cfg:  cfg,
+			ip:   "10.0.0.1",
+			mockBehavior: func(r *repomocks.MockLoginAttempt) {
+				r.EXPECT().RegisterFailure(gomock.Any(), "user:name", gomock.Any()).Return(0, errors.New("some error"))
+				r.EXPECT().RegisterFailure(gomock.Any(), "ip:10.0.0.1", gomock.Any()).Return(1, nil)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			loginAttemptRepo := repomocks.NewMockLoginAttempt(ctrl)
+			tc.mockBehavior(loginAttemptRepo)
+
+			s := NewLoginThrottle(loginAttemptRepo, tc.cfg)
+			s.Fail(context.Background(), "name", tc.ip)
+		})
+	}
+}
+
+func TestLoginThrottle_Reset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	loginAttemptRepo := repomocks.NewMockLoginAttempt(ctrl)
+	loginAttemptRepo.EXPECT().Reset(gomock.Any(), "user:name").Return(nil)
+
+	err := NewLoginThrottle(loginAttemptRepo, LoginThrottleConfig{Threshold: 5}).Reset(context.Background(), "name")
+	assert.NoError(t, err)
+
+	// Only the username is reset, its failures are not counted without a threshold.
+	err = NewLoginThrottle(loginAttemptRepo, LoginThrottleConfig{IPThreshold: 50}).Reset(context.Background(), "name")
+	assert.NoError(t, err)
+}
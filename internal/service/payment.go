@@ -1,32 +1,63 @@
 package service
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spanwalla/merch-store/internal/entity"
 	"github.com/spanwalla/merch-store/internal/repository"
+	"math"
+	"slices"
+	"strings"
+	"time"
 )
 
+// maxPayable is the largest balance the INT column holds, a larger total could never be paid for.
+const maxPayable = math.MaxInt32
+
+// PaymentServiceConfig holds the transfer limits, a limit of 0 is not enforced. Days are counted in UTC.
+type PaymentServiceConfig struct {
+	MaxTransferAmount      int
+	MaxDailyTransferAmount int
+	MaxDailyTransfers      int
+	// MaxDailyReceivedAmount caps the coins a single user can receive by transfers per day.
+	MaxDailyReceivedAmount int
+	// PendingTransferTTL is how long the receiver has to accept a pending transfer.
+	PendingTransferTTL time.Duration
+}
+
 type PaymentService struct {
-	userRepo      repository.User
-	itemRepo      repository.Item
-	operationRepo repository.Operation
-	saleRepo      repository.Sale
-	transactor    repository.Transactor
+	userRepo     repository.User
+	itemRepo     repository.Item
+	ledgerRepo   repository.Ledger
+	saleRepo     repository.Sale
+	purchaseRepo repository.Purchase
+	pendingRepo  repository.PendingTransfer
+	requestRepo  repository.PaymentRequest
+	transactor   repository.Transactor
+	cfg          PaymentServiceConfig
 }
 
-func NewPaymentService(userRepo repository.User, itemRepo repository.Item, operationRepo repository.Operation, saleRepo repository.Sale, transactor repository.Transactor) *PaymentService {
+func NewPaymentService(userRepo repository.User, itemRepo repository.Item, ledgerRepo repository.Ledger, saleRepo repository.Sale, purchaseRepo repository.Purchase, pendingRepo repository.PendingTransfer, requestRepo repository.PaymentRequest, transactor repository.Transactor, cfg PaymentServiceConfig) *PaymentService {
 	return &PaymentService{
-		userRepo:      userRepo,
-		itemRepo:      itemRepo,
-		operationRepo: operationRepo,
-		saleRepo:      saleRepo,
-		transactor:    transactor,
+		userRepo:     userRepo,
+		itemRepo:     itemRepo,
+		ledgerRepo:   ledgerRepo,
+		saleRepo:     saleRepo,
+		purchaseRepo: purchaseRepo,
+		pendingRepo:  pendingRepo,
+		requestRepo:  requestRepo,
+		transactor:   transactor,
+		cfg:          cfg,
 	}
 }
 
 func (s *PaymentService) Transfer(ctx context.Context, input PaymentTransferInput) error {
+	if err := s.checkTransferAmount(input.Amount); err != nil {
+		return err
+	}
+
 	toUserId, err := s.userRepo.GetUserIdByName(ctx, input.ToUserName)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
@@ -40,59 +71,550 @@ func (s *PaymentService) Transfer(ctx context.Context, input PaymentTransferInpu
 		return ErrSelfTransfer
 	}
 
-	operation := entity.Operation{
-		SenderId:   input.FromUserId,
-		ReceiverId: toUserId,
-		Amount:     input.Amount,
+	return s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		return s.transfer(txCtx, input.FromUserId, toUserId, input.ToUserName, input.Amount)
+	})
+}
+
+// transfer moves the coins from one user to another within the transaction of txCtx.
+func (s *PaymentService) transfer(txCtx context.Context, fromUserId, toUserId int, toUserName string, amount int) error {
+	// Withdraw and Deposit would lock the rows in the direction of the transfer, and two users sending
+	// coins to each other could deadlock. Both rows are locked in id order first.
+	err := s.userRepo.LockUsers(txCtx, []int{min(fromUserId, toUserId), max(fromUserId, toUserId)})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		log.Errorf("PaymentService.transfer - userRepo.LockUsers: %v", err)
+		return ErrCannotTransferCoins
 	}
 
-	return s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
-		err = s.userRepo.Withdraw(txCtx, operation.SenderId, operation.Amount)
+	err = s.userRepo.Withdraw(txCtx, fromUserId, amount)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotEnoughBalance
+		}
+		log.Errorf("PaymentService.transfer - userRepo.Withdraw: %v", err)
+		return ErrCannotTransferCoins
+	}
+
+	err = s.checkSenderLimits(txCtx, fromUserId, 1, amount)
+	if err != nil {
+		return err
+	}
+
+	err = s.userRepo.Deposit(txCtx, toUserId, amount)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		log.Errorf("PaymentService.transfer - userRepo.Deposit: %v", err)
+		return ErrCannotTransferCoins
+	}
+
+	err = s.checkReceiverLimit(txCtx, toUserId, toUserName, amount)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.ledgerRepo.Append(txCtx, entity.LedgerEntry{
+		SenderId:   &fromUserId,
+		ReceiverId: &toUserId,
+		Amount:     amount,
+		Kind:       entity.LedgerKindTransfer,
+	})
+	if err != nil {
+		log.Errorf("PaymentService.transfer - ledgerRepo.Append: %v", err)
+		return ErrCannotTransferCoins
+	}
+
+	return nil
+}
+
+// TransferPending puts the coins in escrow until the receiver accepts the transfer. Transfers that are
+// declined or not accepted within PendingTransferTTL are returned to the sender.
+func (s *PaymentService) TransferPending(ctx context.Context, input PaymentTransferInput) (entity.PendingTransfer, error) {
+	if err := s.checkTransferAmount(input.Amount); err != nil {
+		return entity.PendingTransfer{}, err
+	}
+
+	toUserId, err := s.userRepo.GetUserIdByName(ctx, input.ToUserName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return entity.PendingTransfer{}, ErrUserNotFound
+		}
+		log.Errorf("PaymentService.TransferPending - userRepo.GetUserIdByName: %v", err)
+		return entity.PendingTransfer{}, err
+	}
+
+	if toUserId == input.FromUserId {
+		return entity.PendingTransfer{}, ErrSelfTransfer
+	}
+
+	var transfer entity.PendingTransfer
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		err = s.userRepo.Withdraw(txCtx, input.FromUserId, input.Amount)
 		if err != nil {
 			if errors.Is(err, repository.ErrNotFound) {
 				return ErrNotEnoughBalance
 			}
-			log.Errorf("PaymentService.Transfer - userRepo.Withdraw: %v", err)
+			log.Errorf("PaymentService.TransferPending - userRepo.Withdraw: %v", err)
+			return ErrCannotTransferCoins
+		}
+
+		err = s.checkSenderLimits(txCtx, input.FromUserId, 1, input.Amount)
+		if err != nil {
+			return err
+		}
+
+		transfer, err = s.pendingRepo.Create(txCtx, entity.PendingTransfer{
+			SenderId:   input.FromUserId,
+			ReceiverId: toUserId,
+			Amount:     input.Amount,
+			ExpiresAt:  time.Now().Add(s.cfg.PendingTransferTTL),
+		})
+		if err != nil {
+			log.Errorf("PaymentService.TransferPending - pendingRepo.Create: %v", err)
+			return ErrCannotTransferCoins
+		}
+
+		_, err = s.ledgerRepo.Append(txCtx, entity.LedgerEntry{
+			SenderId:          &input.FromUserId,
+			Amount:            input.Amount,
+			Kind:              entity.LedgerKindEscrowHold,
+			PendingTransferId: &transfer.Id,
+		})
+		if err != nil {
+			log.Errorf("PaymentService.TransferPending - ledgerRepo.Append: %v", err)
+			return ErrCannotTransferCoins
+		}
+
+		return nil
+	})
+	if err != nil {
+		return entity.PendingTransfer{}, err
+	}
+
+	return transfer, nil
+}
+
+// AcceptTransfer releases the coins of a pending transfer to the receiver. Transfer limits of the
+// receiver are checked on acceptance.
+func (s *PaymentService) AcceptTransfer(ctx context.Context, input PaymentResolveTransferInput) error {
+	return s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		transfer, err := s.pendingRepo.Resolve(txCtx, input.TransferId, input.UserId, entity.PendingTransferStatusAccepted)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrPendingTransferNotFound
+			}
+			log.Errorf("PaymentService.AcceptTransfer - pendingRepo.Resolve: %v", err)
+			return ErrCannotTransferCoins
+		}
+
+		err = s.userRepo.Deposit(txCtx, transfer.ReceiverId, transfer.Amount)
+		if err != nil {
+			log.Errorf("PaymentService.AcceptTransfer - userRepo.Deposit: %v", err)
+			return ErrCannotTransferCoins
+		}
+
+		err = s.checkReceiverLimit(txCtx, transfer.ReceiverId, "", transfer.Amount)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.ledgerRepo.Append(txCtx, entity.LedgerEntry{
+			ReceiverId:        &transfer.ReceiverId,
+			Amount:            transfer.Amount,
+			Kind:              entity.LedgerKindEscrowRelease,
+			PendingTransferId: &transfer.Id,
+		})
+		if err != nil {
+			log.Errorf("PaymentService.AcceptTransfer - ledgerRepo.Append: %v", err)
+			return ErrCannotTransferCoins
+		}
+
+		return nil
+	})
+}
+
+// DeclineTransfer returns the coins of a pending transfer to the sender.
+func (s *PaymentService) DeclineTransfer(ctx context.Context, input PaymentResolveTransferInput) error {
+	return s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		transfer, err := s.pendingRepo.Resolve(txCtx, input.TransferId, input.UserId, entity.PendingTransferStatusDeclined)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrPendingTransferNotFound
+			}
+			log.Errorf("PaymentService.DeclineTransfer - pendingRepo.Resolve: %v", err)
+			return ErrCannotTransferCoins
+		}
+
+		err = s.userRepo.Deposit(txCtx, transfer.SenderId, transfer.Amount)
+		if err != nil {
+			log.Errorf("PaymentService.DeclineTransfer - userRepo.Deposit: %v", err)
+			return ErrCannotTransferCoins
+		}
+
+		_, err = s.ledgerRepo.Append(txCtx, entity.LedgerEntry{
+			ReceiverId:        &transfer.SenderId,
+			Amount:            transfer.Amount,
+			Kind:              entity.LedgerKindEscrowRefund,
+			PendingTransferId: &transfer.Id,
+		})
+		if err != nil {
+			log.Errorf("PaymentService.DeclineTransfer - ledgerRepo.Append: %v", err)
+			return ErrCannotTransferCoins
+		}
+
+		return nil
+	})
+}
+
+// RequestPayment asks each of the payers for input.Amount coins. Either requests to all payers are created
+// or none of them.
+func (s *PaymentService) RequestPayment(ctx context.Context, input PaymentRequestInput) ([]entity.PaymentRequest, error) {
+	if err := s.checkTransferAmount(input.Amount); err != nil {
+		return nil, err
+	}
+
+	userIds, err := s.userRepo.GetUserIdsByNames(ctx, input.PayerNames)
+	if err != nil {
+		log.Errorf("PaymentService.RequestPayment - userRepo.GetUserIdsByNames: %v", err)
+		return nil, ErrCannotRequestPayment
+	}
+
+	requests := make([]entity.PaymentRequest, 0, len(input.PayerNames))
+	seen := make(map[int]struct{}, len(input.PayerNames))
+	var unknown []string
+	for _, name := range input.PayerNames {
+		id, ok := userIds[name]
+		_, duplicate := seen[id]
+		switch {
+		case !ok:
+			unknown = append(unknown, name)
+		case id == input.RequesterId:
+			return nil, ErrSelfTransfer
+		case duplicate:
+			return nil, ErrDuplicateRecipient
+		default:
+			seen[id] = struct{}{}
+			requests = append(requests, entity.PaymentRequest{
+				RequesterId: input.RequesterId,
+				PayerId:     id,
+				PayerName:   name,
+				Amount:      input.Amount,
+				Note:        input.Note,
+			})
+		}
+	}
+
+	if len(unknown) > 0 {
+		return nil, &UnknownUsersError{UserNames: unknown}
+	}
+
+	var created []entity.PaymentRequest
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		// The transaction is retried after a serialization failure, only the last attempt counts.
+		created = make([]entity.PaymentRequest, 0, len(requests))
+		for _, request := range requests {
+			request, err = s.requestRepo.Create(txCtx, request)
+			if err != nil {
+				log.Errorf("PaymentService.RequestPayment - requestRepo.Create: %v", err)
+				return ErrCannotRequestPayment
+			}
+			created = append(created, request)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// IncomingPaymentRequests lists the requests the user was asked to pay.
+func (s *PaymentService) IncomingPaymentRequests(ctx context.Context, userId int) ([]entity.PaymentRequest, error) {
+	requests, err := s.requestRepo.GetByPayerId(ctx, userId)
+	if err != nil {
+		log.Errorf("PaymentService.IncomingPaymentRequests - requestRepo.GetByPayerId: %v", err)
+		return nil, ErrCannotGetPaymentRequests
+	}
+
+	return requests, nil
+}
+
+// OutgoingPaymentRequests lists the requests made by the user.
+func (s *PaymentService) OutgoingPaymentRequests(ctx context.Context, userId int) ([]entity.PaymentRequest, error) {
+	requests, err := s.requestRepo.GetByRequesterId(ctx, userId)
+	if err != nil {
+		log.Errorf("PaymentService.OutgoingPaymentRequests - requestRepo.GetByRequesterId: %v", err)
+		return nil, ErrCannotGetPaymentRequests
+	}
+
+	return requests, nil
+}
+
+// PayRequest transfers the requested coins to the requester like Transfer does and marks the request paid
+// in the same transaction.
+func (s *PaymentService) PayRequest(ctx context.Context, input PaymentPayRequestInput) error {
+	return s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		request, err := s.requestRepo.MarkPaid(txCtx, input.RequestId, input.UserId)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrPaymentRequestNotFound
+			}
+			log.Errorf("PaymentService.PayRequest - requestRepo.MarkPaid: %v", err)
 			return ErrCannotTransferCoins
 		}
 
-		err = s.userRepo.Deposit(txCtx, operation.ReceiverId, operation.Amount)
+		// The limits may have changed since the request was made.
+		if err = s.checkTransferAmount(request.Amount); err != nil {
+			return err
+		}
+
+		return s.transfer(txCtx, input.UserId, request.RequesterId, request.RequesterName, request.Amount)
+	})
+}
+
+// BatchTransfer resolves every recipient first and then makes all valid transfers in one transaction.
+func (s *PaymentService) BatchTransfer(ctx context.Context, input PaymentBatchTransferInput) (PaymentBatchTransferOutput, error) {
+	names := make([]string, 0, len(input.Transfers))
+	for _, transfer := range input.Transfers {
+		names = append(names, transfer.ToUserName)
+	}
+
+	userIds, err := s.userRepo.GetUserIdsByNames(ctx, names)
+	if err != nil {
+		log.Errorf("PaymentService.BatchTransfer - userRepo.GetUserIdsByNames: %v", err)
+		return PaymentBatchTransferOutput{}, ErrCannotTransferCoins
+	}
+
+	output := PaymentBatchTransferOutput{Results: make([]PaymentTransferResult, 0, len(input.Transfers))}
+	entries := make([]entity.LedgerEntry, 0, len(input.Transfers))
+	recipients := make(map[int]string, len(input.Transfers))
+	rejected := false
+
+	for _, transfer := range input.Transfers {
+		result := PaymentTransferResult{ToUserName: transfer.ToUserName, Amount: transfer.Amount}
+
+		toUserId, ok := userIds[transfer.ToUserName]
+		_, duplicate := recipients[toUserId]
+		switch {
+		case !ok:
+			result.Err = ErrUserNotFound
+		case toUserId == input.FromUserId:
+			result.Err = ErrSelfTransfer
+		case duplicate:
+			result.Err = ErrDuplicateRecipient
+		default:
+			result.Err = s.checkTransferAmount(transfer.Amount)
+		}
+
+		if result.Err == nil {
+			recipients[toUserId] = transfer.ToUserName
+			output.Total += transfer.Amount
+			entries = append(entries, entity.LedgerEntry{
+				SenderId:   &input.FromUserId,
+				ReceiverId: &toUserId,
+				Amount:     transfer.Amount,
+				Kind:       entity.LedgerKindTransfer,
+			})
+		}
+
+		rejected = rejected || result.Err != nil
+		output.Results = append(output.Results, result)
+	}
+
+	if rejected && input.AllOrNothing {
+		return PaymentBatchTransferOutput{}, &BatchTransferError{Results: output.Results}
+	}
+
+	if len(entries) == 0 {
+		return output, nil
+	}
+
+	if output.Total > maxPayable {
+		return PaymentBatchTransferOutput{}, ErrNotEnoughBalance
+	}
+
+	inLockOrder(entries, func(entry entity.LedgerEntry) int { return *entry.ReceiverId })
+
+	// The sender is locked in id order together with the recipients, a batch to them could come the other way.
+	lockIds := make([]int, 0, len(entries)+1)
+	lockIds = append(lockIds, input.FromUserId)
+	for _, entry := range entries {
+		lockIds = append(lockIds, *entry.ReceiverId)
+	}
+	slices.Sort(lockIds)
+
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		err = s.userRepo.LockUsers(txCtx, lockIds)
 		if err != nil {
 			if errors.Is(err, repository.ErrNotFound) {
 				return ErrUserNotFound
 			}
-			log.Errorf("PaymentService.Transfer - userRepo.Deposit: %v", err)
+			log.Errorf("PaymentService.BatchTransfer - userRepo.LockUsers: %v", err)
 			return ErrCannotTransferCoins
 		}
 
-		err = s.operationRepo.Upsert(txCtx, operation)
+		err = s.userRepo.Withdraw(txCtx, input.FromUserId, output.Total)
 		if err != nil {
-			log.Errorf("PaymentService.Transfer - operationRepo.Upsert: %v", err)
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotEnoughBalance
+			}
+			log.Errorf("PaymentService.BatchTransfer - userRepo.Withdraw: %v", err)
 			return ErrCannotTransferCoins
 		}
 
+		err = s.checkSenderLimits(txCtx, input.FromUserId, len(entries), output.Total)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			err = s.userRepo.Deposit(txCtx, *entry.ReceiverId, entry.Amount)
+			if err != nil {
+				if errors.Is(err, repository.ErrNotFound) {
+					return ErrUserNotFound
+				}
+				log.Errorf("PaymentService.BatchTransfer - userRepo.Deposit: %v", err)
+				return ErrCannotTransferCoins
+			}
+
+			err = s.checkReceiverLimit(txCtx, *entry.ReceiverId, recipients[*entry.ReceiverId], entry.Amount)
+			if err != nil {
+				return err
+			}
+
+			_, err = s.ledgerRepo.Append(txCtx, entry)
+			if err != nil {
+				log.Errorf("PaymentService.BatchTransfer - ledgerRepo.Append: %v", err)
+				return ErrCannotTransferCoins
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return PaymentBatchTransferOutput{}, err
+	}
+
+	return output, nil
+}
+
+// Mint credits input.Amount of new coins to each of the users on behalf of the system account. Either all users
+// get the coins or none of them.
+func (s *PaymentService) Mint(ctx context.Context, input PaymentMintInput) (PaymentMintOutput, error) {
+	if input.Amount < 1 {
+		return PaymentMintOutput{}, ErrInvalidAmount
+	}
+
+	if len(strings.TrimSpace(input.Reason)) == 0 {
+		return PaymentMintOutput{}, ErrMintReasonRequired
+	}
+
+	systemId, err := s.userRepo.GetSystemUserId(ctx)
+	if err != nil {
+		log.Errorf("PaymentService.Mint - userRepo.GetSystemUserId: %v", err)
+		return PaymentMintOutput{}, ErrCannotMintCoins
+	}
+
+	userIds, err := s.userRepo.GetUserIdsByNames(ctx, input.UserNames)
+	if err != nil {
+		log.Errorf("PaymentService.Mint - userRepo.GetUserIdsByNames: %v", err)
+		return PaymentMintOutput{}, ErrCannotMintCoins
+	}
+
+	receiverIds := make([]int, 0, len(input.UserNames))
+	seen := make(map[int]struct{}, len(input.UserNames))
+	var unknown []string
+	for _, name := range input.UserNames {
+		id, ok := userIds[name]
+		_, duplicate := seen[id]
+		switch {
+		case !ok || id == systemId:
+			unknown = append(unknown, name)
+		case duplicate:
+			return PaymentMintOutput{}, ErrDuplicateRecipient
+		default:
+			seen[id] = struct{}{}
+			receiverIds = append(receiverIds, id)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return PaymentMintOutput{}, &UnknownUsersError{UserNames: unknown}
+	}
+
+	inLockOrder(receiverIds, func(id int) int { return id })
+
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		for _, receiverId := range receiverIds {
+			err = s.userRepo.Deposit(txCtx, receiverId, input.Amount)
+			if err != nil {
+				log.Errorf("PaymentService.Mint - userRepo.Deposit: %v", err)
+				return ErrCannotMintCoins
+			}
+
+			_, err = s.ledgerRepo.Append(txCtx, entity.LedgerEntry{
+				SenderId:   &systemId,
+				ReceiverId: &receiverId,
+				Amount:     input.Amount,
+				Kind:       entity.LedgerKindMint,
+				Reason:     input.Reason,
+				CreatedBy:  &input.AdminId,
+			})
+			if err != nil {
+				log.Errorf("PaymentService.Mint - ledgerRepo.Append: %v", err)
+				return ErrCannotMintCoins
+			}
+		}
+
 		return nil
 	})
+	if err != nil {
+		return PaymentMintOutput{}, err
+	}
+
+	return PaymentMintOutput{Users: len(receiverIds), Total: input.Amount * len(receiverIds)}, nil
 }
 
-func (s *PaymentService) BuyItem(ctx context.Context, input PaymentBuyItemInput) error {
+// BuyItem buys input.Quantity items at once and withdraws their total price in one transaction.
+func (s *PaymentService) BuyItem(ctx context.Context, input PaymentBuyItemInput) (PaymentReceipt, error) {
+	if input.Quantity < 1 {
+		return PaymentReceipt{}, ErrInvalidQuantity
+	}
+
 	item, err := s.itemRepo.GetItemByName(ctx, input.ItemName)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			return ErrItemNotFound
+			return PaymentReceipt{}, ErrItemNotFound
 		}
 		log.Errorf("PaymentService.BuyItem - itemRepo.GetItemByName: %v", err)
-		return ErrCannotBuyItem
+		return PaymentReceipt{}, ErrCannotBuyItem
+	}
+
+	if input.Quantity > maxPayable/max(item.Price, 1) {
+		return PaymentReceipt{}, ErrNotEnoughBalance
 	}
+	total := item.Price * input.Quantity
 
 	sale := entity.Sale{
 		UserId:   input.UserId,
 		ItemId:   item.Id,
-		Quantity: 1,
+		Quantity: input.Quantity,
 	}
 
-	return s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
-		err = s.userRepo.Withdraw(txCtx, input.UserId, item.Price)
+	entry := entity.LedgerEntry{
+		SenderId: &input.UserId,
+		Amount:   total,
+		Kind:     entity.LedgerKindPurchase,
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		err = s.userRepo.Withdraw(txCtx, input.UserId, total)
 		if err != nil {
 			if errors.Is(err, repository.ErrNotFound) {
 				return ErrNotEnoughBalance
@@ -101,12 +623,114 @@ func (s *PaymentService) BuyItem(ctx context.Context, input PaymentBuyItemInput)
 			return ErrCannotBuyItem
 		}
 
+		err = s.itemRepo.TakeStock(txCtx, item.Id, input.Quantity)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrItemOutOfStock
+			}
+			log.Errorf("PaymentService.BuyItem - itemRepo.TakeStock: %v", err)
+			return ErrCannotBuyItem
+		}
+
 		err = s.saleRepo.Upsert(txCtx, sale)
 		if err != nil {
 			log.Errorf("PaymentService.BuyItem - saleRepo.Upsert: %v", err)
 			return ErrCannotBuyItem
 		}
 
+		entry, err = s.ledgerRepo.Append(txCtx, entry)
+		if err != nil {
+			log.Errorf("PaymentService.BuyItem - ledgerRepo.Append: %v", err)
+			return ErrCannotBuyItem
+		}
+
+		_, err = s.purchaseRepo.Create(txCtx, entity.Purchase{
+			LedgerEntryId: entry.Id,
+			UserId:        input.UserId,
+			ItemId:        item.Id,
+			Quantity:      input.Quantity,
+			Price:         item.Price,
+		})
+		if err != nil {
+			log.Errorf("PaymentService.BuyItem - purchaseRepo.Create: %v", err)
+			return ErrCannotBuyItem
+		}
+
+		return nil
+	})
+	if err != nil {
+		return PaymentReceipt{}, err
+	}
+
+	return PaymentReceipt{
+		Id:          entry.Id,
+		ItemName:    item.Name,
+		Quantity:    input.Quantity,
+		Price:       item.Price,
+		Total:       total,
+		PurchasedAt: entry.CreatedAt,
+	}, nil
+}
+
+func (s *PaymentService) checkTransferAmount(amount int) error {
+	if s.cfg.MaxTransferAmount > 0 && amount > s.cfg.MaxTransferAmount {
+		return &LimitError{Limit: LimitTransferAmount, Max: s.cfg.MaxTransferAmount}
+	}
+	return nil
+}
+
+// checkSenderLimits must be called after Withdraw, whose row lock makes concurrent transfers of the sender wait for
+// the transaction and then count its ledger entries.
+func (s *PaymentService) checkSenderLimits(ctx context.Context, senderId, count, amount int) error {
+	if s.cfg.MaxDailyTransfers == 0 && s.cfg.MaxDailyTransferAmount == 0 {
+		return nil
+	}
+
+	sent, err := s.ledgerRepo.GetSentSince(ctx, senderId, startOfDay())
+	if err != nil {
+		log.Errorf("PaymentService.checkSenderLimits - ledgerRepo.GetSentSince: %v", err)
+		return ErrCannotTransferCoins
+	}
+
+	if s.cfg.MaxDailyTransfers > 0 && sent.Count+count > s.cfg.MaxDailyTransfers {
+		return &LimitError{Limit: LimitDailyCount, Max: s.cfg.MaxDailyTransfers, Used: sent.Count}
+	}
+
+	if s.cfg.MaxDailyTransferAmount > 0 && sent.Amount+amount > s.cfg.MaxDailyTransferAmount {
+		return &LimitError{Limit: LimitDailyAmount, Max: s.cfg.MaxDailyTransferAmount, Used: sent.Amount}
+	}
+
+	return nil
+}
+
+// checkReceiverLimit must be called after Deposit, which locks the row of the recipient like Withdraw does for the sender.
+func (s *PaymentService) checkReceiverLimit(ctx context.Context, receiverId int, receiverName string, amount int) error {
+	if s.cfg.MaxDailyReceivedAmount == 0 {
 		return nil
+	}
+
+	received, err := s.ledgerRepo.GetReceivedSince(ctx, receiverId, startOfDay())
+	if err != nil {
+		log.Errorf("PaymentService.checkReceiverLimit - ledgerRepo.GetReceivedSince: %v", err)
+		return ErrCannotTransferCoins
+	}
+
+	if received.Amount+amount > s.cfg.MaxDailyReceivedAmount {
+		return &LimitError{Limit: LimitDailyReceived, Max: s.cfg.MaxDailyReceivedAmount, Used: received.Amount, UserName: receiverName}
+	}
+
+	return nil
+}
+
+// inLockOrder sorts the items by the id of the user whose balance each of them changes. Balances are
+// updated in this order by every transaction, so two of them crediting the same users cannot deadlock.
+func inLockOrder[T any](items []T, userId func(T) int) {
+	slices.SortFunc(items, func(a, b T) int {
+		return cmp.Compare(userId(a), userId(b))
 	})
 }
+
+// startOfDay returns the last midnight in UTC.
+func startOfDay() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"testing"
+	"time"
 )
 
 func TestPaymentService_BuyItem(t *testing.T) {
@@ -17,13 +18,17 @@ func TestPaymentService_BuyItem(t *testing.T) {
 		input PaymentBuyItemInput
 	}
 
-	type MockBehavior func(u *repomocks.MockUser, i *repomocks.MockItem, o *repomocks.MockOperation, s *repomocks.MockSale, t *repomocks.MockTransactor, args args)
+	type MockBehavior func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args)
+
+	purchasedAt := time.Now()
+	errTransaction := errors.New("transaction error")
 
 	testCases := []struct {
 		name         string
 		args         args
 		mockBehavior MockBehavior
-		wantErr      bool
+		want         PaymentReceipt
+		wantErr      error
 	}{
 		{
 			name: "success",
@@ -32,9 +37,10 @@ func TestPaymentService_BuyItem(t *testing.T) {
 				input: PaymentBuyItemInput{
 					UserId:   13,
 					ItemName: "hoody",
+					Quantity: 1,
 				},
 			},
-			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, o *repomocks.MockOperation, s *repomocks.MockSale, t *repomocks.MockTransactor, args args) {
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
 				fakeItem := entity.Item{
 					Id:    10,
 					Name:  args.input.ItemName,
@@ -49,6 +55,7 @@ func TestPaymentService_BuyItem(t *testing.T) {
 					})
 
 				u.EXPECT().Withdraw(gomock.Any(), args.input.UserId, fakeItem.Price).Return(nil)
+				i.EXPECT().TakeStock(gomock.Any(), fakeItem.Id, 1).Return(nil)
 
 				expectedSale := entity.Sale{
 					UserId:   args.input.UserId,
@@ -57,8 +64,91 @@ func TestPaymentService_BuyItem(t *testing.T) {
 				}
 
 				s.EXPECT().Upsert(gomock.Any(), expectedSale).Return(nil)
+
+				expectedEntry := entity.LedgerEntry{
+					SenderId: &args.input.UserId,
+					Amount:   fakeItem.Price,
+					Kind:     entity.LedgerKindPurchase,
+				}
+
+				savedEntry := expectedEntry
+				savedEntry.Id, savedEntry.CreatedAt = 31, purchasedAt
+				l.EXPECT().Append(gomock.Any(), expectedEntry).Return(savedEntry, nil)
+
+				expectedPurchase := entity.Purchase{
+					LedgerEntryId: savedEntry.Id,
+					UserId:        args.input.UserId,
+					ItemId:        fakeItem.Id,
+					Quantity:      1,
+					Price:         fakeItem.Price,
+				}
+
+				p.EXPECT().Create(gomock.Any(), expectedPurchase).Return(1, nil)
+			},
+			want: PaymentReceipt{
+				Id:          31,
+				ItemName:    "hoody",
+				Quantity:    1,
+				Price:       100,
+				Total:       100,
+				PurchasedAt: purchasedAt,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "several items",
+			args: args{
+				ctx: context.Background(),
+				input: PaymentBuyItemInput{
+					UserId:   13,
+					ItemName: "socks",
+					Quantity: 3,
+				},
+			},
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
+				fakeItem := entity.Item{
+					Id:    4,
+					Name:  args.input.ItemName,
+					Price: 10,
+				}
+
+				i.EXPECT().GetItemByName(args.ctx, args.input.ItemName).Return(fakeItem, nil)
+
+				t.EXPECT().WithinTransaction(args.ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					})
+
+				u.EXPECT().Withdraw(gomock.Any(), args.input.UserId, 30).Return(nil)
+				i.EXPECT().TakeStock(gomock.Any(), fakeItem.Id, 3).Return(nil)
+				s.EXPECT().Upsert(gomock.Any(), entity.Sale{UserId: args.input.UserId, ItemId: fakeItem.Id, Quantity: 3}).Return(nil)
+				l.EXPECT().Append(gomock.Any(), gomock.Cond(func(entry entity.LedgerEntry) bool {
+					return entry.Amount == 30 && entry.Kind == entity.LedgerKindPurchase
+				})).Return(entity.LedgerEntry{Id: 32, CreatedAt: purchasedAt}, nil)
+				p.EXPECT().Create(gomock.Any(), entity.Purchase{LedgerEntryId: 32, UserId: args.input.UserId, ItemId: fakeItem.Id, Quantity: 3, Price: 10}).Return(1, nil)
+			},
+			want: PaymentReceipt{
+				Id:          32,
+				ItemName:    "socks",
+				Quantity:    3,
+				Price:       10,
+				Total:       30,
+				PurchasedAt: purchasedAt,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "zero quantity",
+			args: args{
+				ctx: context.Background(),
+				input: PaymentBuyItemInput{
+					UserId:   13,
+					ItemName: "socks",
+				},
 			},
-			wantErr: false,
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
+			},
+			wantErr: ErrInvalidQuantity,
 		},
 		{
 			name: "item does not exist",
@@ -67,12 +157,13 @@ func TestPaymentService_BuyItem(t *testing.T) {
 				input: PaymentBuyItemInput{
 					UserId:   13,
 					ItemName: "bad-item-name",
+					Quantity: 1,
 				},
 			},
-			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, o *repomocks.MockOperation, s *repomocks.MockSale, t *repomocks.MockTransactor, args args) {
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
 				i.EXPECT().GetItemByName(args.ctx, args.input.ItemName).Return(entity.Item{}, repository.ErrNotFound)
 			},
-			wantErr: true,
+			wantErr: ErrItemNotFound,
 		},
 		{
 			name: "not enough money",
@@ -81,9 +172,10 @@ func TestPaymentService_BuyItem(t *testing.T) {
 				input: PaymentBuyItemInput{
 					UserId:   13,
 					ItemName: "powerbank",
+					Quantity: 1,
 				},
 			},
-			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, o *repomocks.MockOperation, s *repomocks.MockSale, t *repomocks.MockTransactor, args args) {
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
 				fakeItem := entity.Item{
 					Id:    10,
 					Name:  args.input.ItemName,
@@ -99,7 +191,38 @@ func TestPaymentService_BuyItem(t *testing.T) {
 
 				u.EXPECT().Withdraw(gomock.Any(), args.input.UserId, fakeItem.Price).Return(repository.ErrNotFound)
 			},
-			wantErr: true,
+			wantErr: ErrNotEnoughBalance,
+		},
+		{
+			name: "out of stock",
+			args: args{
+				ctx: context.Background(),
+				input: PaymentBuyItemInput{
+					UserId:   13,
+					ItemName: "cup",
+					Quantity: 2,
+				},
+			},
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
+				stock := 1
+				fakeItem := entity.Item{
+					Id:    3,
+					Name:  args.input.ItemName,
+					Price: 20,
+					Stock: &stock,
+				}
+
+				i.EXPECT().GetItemByName(args.ctx, args.input.ItemName).Return(fakeItem, nil)
+
+				t.EXPECT().WithinTransaction(args.ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					})
+
+				u.EXPECT().Withdraw(gomock.Any(), args.input.UserId, 40).Return(nil)
+				i.EXPECT().TakeStock(gomock.Any(), fakeItem.Id, 2).Return(repository.ErrNotFound)
+			},
+			wantErr: ErrItemOutOfStock,
 		},
 		{
 			name: "transaction error",
@@ -108,9 +231,10 @@ func TestPaymentService_BuyItem(t *testing.T) {
 				input: PaymentBuyItemInput{
 					UserId:   13,
 					ItemName: "hoody",
+					Quantity: 1,
 				},
 			},
-			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, o *repomocks.MockOperation, s *repomocks.MockSale, t *repomocks.MockTransactor, args args) {
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
 				fakeItem := entity.Item{
 					Id:    10,
 					Name:  args.input.ItemName,
@@ -121,10 +245,10 @@ func TestPaymentService_BuyItem(t *testing.T) {
 
 				t.EXPECT().WithinTransaction(args.ctx, gomock.Any()).
 					DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
-						return errors.New("transaction error")
+						return errTransaction
 					})
 			},
-			wantErr: true,
+			wantErr: errTransaction,
 		},
 	}
 	for _, tc := range testCases {
@@ -134,19 +258,21 @@ func TestPaymentService_BuyItem(t *testing.T) {
 
 			userRepo := repomocks.NewMockUser(ctrl)
 			itemRepo := repomocks.NewMockItem(ctrl)
-			operationRepo := repomocks.NewMockOperation(ctrl)
+			ledgerRepo := repomocks.NewMockLedger(ctrl)
 			saleRepo := repomocks.NewMockSale(ctrl)
+			purchaseRepo := repomocks.NewMockPurchase(ctrl)
 			transactor := repomocks.NewMockTransactor(ctrl)
-			tc.mockBehavior(userRepo, itemRepo, operationRepo, saleRepo, transactor, tc.args)
-			s := NewPaymentService(userRepo, itemRepo, operationRepo, saleRepo, transactor)
+			tc.mockBehavior(userRepo, itemRepo, ledgerRepo, saleRepo, purchaseRepo, transactor, tc.args)
+			s := NewPaymentService(userRepo, itemRepo, ledgerRepo, saleRepo, purchaseRepo, repomocks.NewMockPendingTransfer(ctrl), repomocks.NewMockPaymentRequest(ctrl), transactor, PaymentServiceConfig{})
 
-			err := s.BuyItem(tc.args.ctx, tc.args.input)
-			if tc.wantErr {
-				assert.Error(t, err)
+			got, err := s.BuyItem(tc.args.ctx, tc.args.input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
 				return
 			}
 
 			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
 		})
 	}
 }
@@ -157,13 +283,22 @@ func TestPaymentService_Transfer(t *testing.T) {
 		input PaymentTransferInput
 	}
 
-	type MockBehavior func(u *repomocks.MockUser, i *repomocks.MockItem, o *repomocks.MockOperation, s *repomocks.MockSale, t *repomocks.MockTransactor, args args)
+	type MockBehavior func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args)
+
+	errTransaction := errors.New("transaction error")
+	limits := PaymentServiceConfig{
+		MaxTransferAmount:      100,
+		MaxDailyTransferAmount: 300,
+		MaxDailyTransfers:      5,
+		MaxDailyReceivedAmount: 500,
+	}
 
 	testCases := []struct {
 		name         string
 		args         args
+		cfg          PaymentServiceConfig
 		mockBehavior MockBehavior
-		wantErr      bool
+		wantErr      error
 	}{
 		{
 			name: "success",
@@ -175,7 +310,7 @@ func TestPaymentService_Transfer(t *testing.T) {
 					Amount:     10,
 				},
 			},
-			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, o *repomocks.MockOperation, s *repomocks.MockSale, t *repomocks.MockTransactor, args args) {
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
 				toUserId := 495
 				u.EXPECT().GetUserIdByName(args.ctx, args.input.ToUserName).Return(toUserId, nil)
 
@@ -184,18 +319,70 @@ func TestPaymentService_Transfer(t *testing.T) {
 						return fn(ctx)
 					})
 
+				u.EXPECT().LockUsers(gomock.Any(), []int{args.input.FromUserId, toUserId}).Return(nil)
 				u.EXPECT().Withdraw(gomock.Any(), args.input.FromUserId, args.input.Amount).Return(nil)
 				u.EXPECT().Deposit(gomock.Any(), toUserId, args.input.Amount).Return(nil)
 
-				expectedOperation := entity.Operation{
-					SenderId:   args.input.FromUserId,
-					ReceiverId: toUserId,
+				expectedEntry := entity.LedgerEntry{
+					SenderId:   &args.input.FromUserId,
+					ReceiverId: &toUserId,
 					Amount:     args.input.Amount,
+					Kind:       entity.LedgerKindTransfer,
 				}
 
-				o.EXPECT().Upsert(gomock.Any(), expectedOperation).Return(nil)
+				l.EXPECT().Append(gomock.Any(), expectedEntry).Return(expectedEntry, nil)
 			},
-			wantErr: false,
+			wantErr: nil,
+		},
+		{
+			name: "locks users in id order",
+			args: args{
+				ctx: context.Background(),
+				input: PaymentTransferInput{
+					FromUserId: 13,
+					ToUserName: "hoody",
+					Amount:     10,
+				},
+			},
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
+				toUserId := 7
+				u.EXPECT().GetUserIdByName(args.ctx, args.input.ToUserName).Return(toUserId, nil)
+
+				t.EXPECT().WithinTransaction(args.ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					})
+
+				gomock.InOrder(
+					u.EXPECT().LockUsers(gomock.Any(), []int{toUserId, args.input.FromUserId}).Return(nil),
+					u.EXPECT().Withdraw(gomock.Any(), args.input.FromUserId, args.input.Amount).Return(nil),
+					u.EXPECT().Deposit(gomock.Any(), toUserId, args.input.Amount).Return(nil),
+				)
+				l.EXPECT().Append(gomock.Any(), gomock.Any()).Return(entity.LedgerEntry{}, nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "sender deleted",
+			args: args{
+				ctx: context.Background(),
+				input: PaymentTransferInput{
+					FromUserId: 13,
+					ToUserName: "hoody",
+					Amount:     10,
+				},
+			},
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
+				u.EXPECT().GetUserIdByName(args.ctx, args.input.ToUserName).Return(495, nil)
+
+				t.EXPECT().WithinTransaction(args.ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					})
+
+				u.EXPECT().LockUsers(gomock.Any(), []int{args.input.FromUserId, 495}).Return(repository.ErrNotFound)
+			},
+			wantErr: ErrUserNotFound,
 		},
 		{
 			name: "not enough coins",
@@ -207,7 +394,7 @@ func TestPaymentService_Transfer(t *testing.T) {
 					Amount:     1005,
 				},
 			},
-			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, o *repomocks.MockOperation, s *repomocks.MockSale, t *repomocks.MockTransactor, args args) {
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
 				toUserId := 10039
 				u.EXPECT().GetUserIdByName(args.ctx, args.input.ToUserName).Return(toUserId, nil)
 
@@ -216,9 +403,10 @@ func TestPaymentService_Transfer(t *testing.T) {
 						return fn(ctx)
 					})
 
+				u.EXPECT().LockUsers(gomock.Any(), []int{args.input.FromUserId, toUserId}).Return(nil)
 				u.EXPECT().Withdraw(gomock.Any(), args.input.FromUserId, args.input.Amount).Return(repository.ErrNotFound)
 			},
-			wantErr: true,
+			wantErr: ErrNotEnoughBalance,
 		},
 		{
 			name: "user does not exist",
@@ -230,10 +418,10 @@ func TestPaymentService_Transfer(t *testing.T) {
 					Amount:     100,
 				},
 			},
-			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, o *repomocks.MockOperation, s *repomocks.MockSale, t *repomocks.MockTransactor, args args) {
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
 				u.EXPECT().GetUserIdByName(args.ctx, args.input.ToUserName).Return(0, repository.ErrNotFound)
 			},
-			wantErr: true,
+			wantErr: ErrUserNotFound,
 		},
 		{
 			name: "self transfer",
@@ -245,11 +433,11 @@ func TestPaymentService_Transfer(t *testing.T) {
 					Amount:     100,
 				},
 			},
-			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, o *repomocks.MockOperation, s *repomocks.MockSale, t *repomocks.MockTransactor, args args) {
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
 				toUserId := args.input.FromUserId
 				u.EXPECT().GetUserIdByName(args.ctx, args.input.ToUserName).Return(toUserId, nil)
 			},
-			wantErr: true,
+			wantErr: ErrSelfTransfer,
 		},
 		{
 			name: "transaction error",
@@ -261,16 +449,138 @@ func TestPaymentService_Transfer(t *testing.T) {
 					Amount:     100,
 				},
 			},
-			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, o *repomocks.MockOperation, s *repomocks.MockSale, t *repomocks.MockTransactor, args args) {
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
+				toUserId := 495
+				u.EXPECT().GetUserIdByName(args.ctx, args.input.ToUserName).Return(toUserId, nil)
+
+				t.EXPECT().WithinTransaction(args.ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return errTransaction
+					})
+			},
+			wantErr: errTransaction,
+		},
+		{
+			name: "within limits",
+			args: args{
+				ctx: context.Background(),
+				input: PaymentTransferInput{
+					FromUserId: 13,
+					ToUserName: "hoody",
+					Amount:     100,
+				},
+			},
+			cfg: limits,
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
+				toUserId := 495
+				u.EXPECT().GetUserIdByName(args.ctx, args.input.ToUserName).Return(toUserId, nil)
+
+				t.EXPECT().WithinTransaction(args.ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					})
+
+				u.EXPECT().LockUsers(gomock.Any(), []int{args.input.FromUserId, toUserId}).Return(nil)
+				u.EXPECT().Withdraw(gomock.Any(), args.input.FromUserId, args.input.Amount).Return(nil)
+				l.EXPECT().GetSentSince(gomock.Any(), args.input.FromUserId, gomock.Any()).Return(entity.TransferStats{Count: 4, Amount: 200}, nil)
+				u.EXPECT().Deposit(gomock.Any(), toUserId, args.input.Amount).Return(nil)
+				l.EXPECT().GetReceivedSince(gomock.Any(), toUserId, gomock.Any()).Return(entity.TransferStats{Count: 2, Amount: 400}, nil)
+				l.EXPECT().Append(gomock.Any(), gomock.Any()).Return(entity.LedgerEntry{}, nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "amount over limit",
+			args: args{
+				ctx: context.Background(),
+				input: PaymentTransferInput{
+					FromUserId: 13,
+					ToUserName: "hoody",
+					Amount:     101,
+				},
+			},
+			cfg: limits,
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
+			},
+			wantErr: &LimitError{Limit: LimitTransferAmount, Max: 100},
+		},
+		{
+			name: "daily count exceeded",
+			args: args{
+				ctx: context.Background(),
+				input: PaymentTransferInput{
+					FromUserId: 13,
+					ToUserName: "hoody",
+					Amount:     10,
+				},
+			},
+			cfg: limits,
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
+				u.EXPECT().GetUserIdByName(args.ctx, args.input.ToUserName).Return(495, nil)
+
+				t.EXPECT().WithinTransaction(args.ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					})
+
+				u.EXPECT().LockUsers(gomock.Any(), []int{args.input.FromUserId, 495}).Return(nil)
+				u.EXPECT().Withdraw(gomock.Any(), args.input.FromUserId, args.input.Amount).Return(nil)
+				l.EXPECT().GetSentSince(gomock.Any(), args.input.FromUserId, gomock.Any()).Return(entity.TransferStats{Count: 5, Amount: 50}, nil)
+			},
+			wantErr: &LimitError{Limit: LimitDailyCount, Max: 5, Used: 5},
+		},
+		{
+			name: "daily amount exceeded",
+			args: args{
+				ctx: context.Background(),
+				input: PaymentTransferInput{
+					FromUserId: 13,
+					ToUserName: "hoody",
+					Amount:     60,
+				},
+			},
+			cfg: limits,
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
+				u.EXPECT().GetUserIdByName(args.ctx, args.input.ToUserName).Return(495, nil)
+
+				t.EXPECT().WithinTransaction(args.ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					})
+
+				u.EXPECT().LockUsers(gomock.Any(), []int{args.input.FromUserId, 495}).Return(nil)
+				u.EXPECT().Withdraw(gomock.Any(), args.input.FromUserId, args.input.Amount).Return(nil)
+				l.EXPECT().GetSentSince(gomock.Any(), args.input.FromUserId, gomock.Any()).Return(entity.TransferStats{Count: 3, Amount: 250}, nil)
+			},
+			wantErr: &LimitError{Limit: LimitDailyAmount, Max: 300, Used: 250},
+		},
+		{
+			name: "recipient cap exceeded",
+			args: args{
+				ctx: context.Background(),
+				input: PaymentTransferInput{
+					FromUserId: 13,
+					ToUserName: "hoody",
+					Amount:     60,
+				},
+			},
+			cfg: limits,
+			mockBehavior: func(u *repomocks.MockUser, i *repomocks.MockItem, l *repomocks.MockLedger, s *repomocks.MockSale, p *repomocks.MockPurchase, t *repomocks.MockTransactor, args args) {
 				toUserId := 495
 				u.EXPECT().GetUserIdByName(args.ctx, args.input.ToUserName).Return(toUserId, nil)
 
 				t.EXPECT().WithinTransaction(args.ctx, gomock.Any()).
 					DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
-						return errors.New("transaction error")
+						return fn(ctx)
 					})
+
+				u.EXPECT().LockUsers(gomock.Any(), []int{args.input.FromUserId, toUserId}).Return(nil)
+				u.EXPECT().Withdraw(gomock.Any(), args.input.FromUserId, args.input.Amount).Return(nil)
+				l.EXPECT().GetSentSince(gomock.Any(), args.input.FromUserId, gomock.Any()).Return(entity.TransferStats{}, nil)
+				u.EXPECT().Deposit(gomock.Any(), toUserId, args.input.Amount).Return(nil)
+				l.EXPECT().GetReceivedSince(gomock.Any(), toUserId, gomock.Any()).Return(entity.TransferStats{Count: 9, Amount: 450}, nil)
 			},
-			wantErr: true,
+			wantErr: &LimitError{Limit: LimitDailyReceived, Max: 500, Used: 450, UserName: "hoody"},
 		},
 	}
 	for _, tc := range testCases {
@@ -280,15 +590,17 @@ func TestPaymentService_Transfer(t *testing.T) {
 
 			userRepo := repomocks.NewMockUser(ctrl)
 			itemRepo := repomocks.NewMockItem(ctrl)
-			operationRepo := repomocks.NewMockOperation(ctrl)
+			ledgerRepo := repomocks.NewMockLedger(ctrl)
 			saleRepo := repomocks.NewMockSale(ctrl)
+			purchaseRepo := repomocks.NewMockPurchase(ctrl)
 			transactor := repomocks.NewMockTransactor(ctrl)
-			tc.mockBehavior(userRepo, itemRepo, operationRepo, saleRepo, transactor, tc.args)
-			s := NewPaymentService(userRepo, itemRepo, operationRepo, saleRepo, transactor)
+			tc.mockBehavior(userRepo, itemRepo, ledgerRepo, saleRepo, purchaseRepo, transactor, tc.args)
+			s := NewPaymentService(userRepo, itemRepo, ledgerRepo, saleRepo, purchaseRepo, repomocks.NewMockPendingTransfer(ctrl), repomocks.NewMockPaymentRequest(ctrl), transactor, tc.cfg)
 
 			err := s.Transfer(tc.args.ctx, tc.args.input)
-			if tc.wantErr {
-				assert.Error(t, err)
+			if tc.wantErr != nil {
+				// Limit errors carry the details of the limit, so they are compared by value.
+				assert.Equal(t, tc.wantErr, err)
 				return
 			}
 
@@ -296,3 +608,768 @@ func TestPaymentService_Transfer(t *testing.T) {
 		})
 	}
 }
+
+func TestPaymentService_BatchTransfer(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		input PaymentBatchTransferInput
+	}
+
+	type MockBehavior func(u *repomocks.MockUser, l *repomocks.MockLedger, t *repomocks.MockTransactor, args args)
+
+	passThrough := func(t *repomocks.MockTransactor) {
+		t.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+				return fn(ctx)
+			})
+	}
+
+	transfers := []PaymentBatchTransfer{
+		{ToUserName: "bob", Amount: 10},
+		{ToUserName: "alice", Amount: 20},
+		{ToUserName: "ghost", Amount: 30},
+		{ToUserName: "bob", Amount: 40},
+		{ToUserName: "me", Amount: 50},
+	}
+	userIds := map[string]int{"bob": 20, "alice": 15, "me": 13}
+
+	testCases := []struct {
+		name         string
+		args         args
+		cfg          PaymentServiceConfig
+		mockBehavior MockBehavior
+		want         PaymentBatchTransferOutput
+		wantErr      error
+	}{
+		{
+			name: "skip invalid transfers",
+			args: args{
+				ctx:   context.Background(),
+				input: PaymentBatchTransferInput{FromUserId: 13, Transfers: transfers},
+			},
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger, t *repomocks.MockTransactor, args args) {
+				u.EXPECT().GetUserIdsByNames(args.ctx, []string{"bob", "alice", "ghost", "bob", "me"}).Return(userIds, nil)
+				passThrough(t)
+
+				u.EXPECT().LockUsers(gomock.Any(), []int{13, 15, 20}).Return(nil)
+				u.EXPECT().Withdraw(gomock.Any(), 13, 30).Return(nil)
+				// Recipients are credited in id order.
+				gomock.InOrder(
+					u.EXPECT().Deposit(gomock.Any(), 15, 20).Return(nil),
+					u.EXPECT().Deposit(gomock.Any(), 20, 10).Return(nil),
+				)
+				l.EXPECT().Append(gomock.Any(), gomock.Any()).Return(entity.LedgerEntry{}, nil).Times(2)
+			},
+			want: PaymentBatchTransferOutput{
+				Results: []PaymentTransferResult{
+					{ToUserName: "bob", Amount: 10},
+					{ToUserName: "alice", Amount: 20},
+					{ToUserName: "ghost", Amount: 30, Err: ErrUserNotFound},
+					{ToUserName: "bob", Amount: 40, Err: ErrDuplicateRecipient},
+					{ToUserName: "me", Amount: 50, Err: ErrSelfTransfer},
+				},
+				Total: 30,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "all or nothing",
+			args: args{
+				ctx:   context.Background(),
+				input: PaymentBatchTransferInput{FromUserId: 13, Transfers: transfers, AllOrNothing: true},
+			},
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger, t *repomocks.MockTransactor, args args) {
+				u.EXPECT().GetUserIdsByNames(args.ctx, gomock.Any()).Return(userIds, nil)
+			},
+			wantErr: ErrBatchRejected,
+		},
+		{
+			name: "nothing to transfer",
+			args: args{
+				ctx: context.Background(),
+				input: PaymentBatchTransferInput{FromUserId: 13, Transfers: []PaymentBatchTransfer{
+					{ToUserName: "ghost", Amount: 10},
+				}},
+			},
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger, t *repomocks.MockTransactor, args args) {
+				u.EXPECT().GetUserIdsByNames(args.ctx, gomock.Any()).Return(userIds, nil)
+			},
+			want: PaymentBatchTransferOutput{
+				Results: []PaymentTransferResult{{ToUserName: "ghost", Amount: 10, Err: ErrUserNotFound}},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not enough coins",
+			args: args{
+				ctx: context.Background(),
+				input: PaymentBatchTransferInput{FromUserId: 13, Transfers: []PaymentBatchTransfer{
+					{ToUserName: "bob", Amount: 600},
+					{ToUserName: "alice", Amount: 600},
+				}},
+			},
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger, t *repomocks.MockTransactor, args args) {
+				u.EXPECT().GetUserIdsByNames(args.ctx, gomock.Any()).Return(userIds, nil)
+				passThrough(t)
+
+				u.EXPECT().LockUsers(gomock.Any(), []int{13, 15, 20}).Return(nil)
+				u.EXPECT().Withdraw(gomock.Any(), 13, 1200).Return(repository.ErrNotFound)
+			},
+			wantErr: ErrNotEnoughBalance,
+		},
+		{
+			name: "limits",
+			args: args{
+				ctx: context.Background(),
+				input: PaymentBatchTransferInput{FromUserId: 13, Transfers: []PaymentBatchTransfer{
+					{ToUserName: "bob", Amount: 150},
+					{ToUserName: "alice", Amount: 100},
+				}},
+			},
+			cfg: PaymentServiceConfig{MaxTransferAmount: 100, MaxDailyTransfers: 5, MaxDailyReceivedAmount: 500},
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger, t *repomocks.MockTransactor, args args) {
+				u.EXPECT().GetUserIdsByNames(args.ctx, gomock.Any()).Return(userIds, nil)
+				passThrough(t)
+
+				u.EXPECT().LockUsers(gomock.Any(), []int{13, 15}).Return(nil)
+				u.EXPECT().Withdraw(gomock.Any(), 13, 100).Return(nil)
+				l.EXPECT().GetSentSince(gomock.Any(), 13, gomock.Any()).Return(entity.TransferStats{Count: 4, Amount: 400}, nil)
+				u.EXPECT().Deposit(gomock.Any(), 15, 100).Return(nil)
+				l.EXPECT().GetReceivedSince(gomock.Any(), 15, gomock.Any()).Return(entity.TransferStats{Count: 1, Amount: 100}, nil)
+				l.EXPECT().Append(gomock.Any(), gomock.Any()).Return(entity.LedgerEntry{}, nil)
+			},
+			want: PaymentBatchTransferOutput{
+				Results: []PaymentTransferResult{
+					{ToUserName: "bob", Amount: 150, Err: &LimitError{Limit: LimitTransferAmount, Max: 100}},
+					{ToUserName: "alice", Amount: 100},
+				},
+				Total: 100,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "recipient cap exceeded",
+			args: args{
+				ctx: context.Background(),
+				input: PaymentBatchTransferInput{FromUserId: 13, Transfers: []PaymentBatchTransfer{
+					{ToUserName: "bob", Amount: 100},
+				}},
+			},
+			cfg: PaymentServiceConfig{MaxDailyReceivedAmount: 500},
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger, t *repomocks.MockTransactor, args args) {
+				u.EXPECT().GetUserIdsByNames(args.ctx, gomock.Any()).Return(userIds, nil)
+				passThrough(t)
+
+				u.EXPECT().LockUsers(gomock.Any(), []int{13, 20}).Return(nil)
+				u.EXPECT().Withdraw(gomock.Any(), 13, 100).Return(nil)
+				u.EXPECT().Deposit(gomock.Any(), 20, 100).Return(nil)
+				l.EXPECT().GetReceivedSince(gomock.Any(), 20, gomock.Any()).Return(entity.TransferStats{Count: 5, Amount: 450}, nil)
+			},
+			wantErr: ErrLimitExceeded,
+		},
+		{
+			name: "deposit failed",
+			args: args{
+				ctx: context.Background(),
+				input: PaymentBatchTransferInput{FromUserId: 13, Transfers: []PaymentBatchTransfer{
+					{ToUserName: "bob", Amount: 10},
+				}},
+			},
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger, t *repomocks.MockTransactor, args args) {
+				u.EXPECT().GetUserIdsByNames(args.ctx, gomock.Any()).Return(userIds, nil)
+				passThrough(t)
+
+				u.EXPECT().LockUsers(gomock.Any(), []int{13, 20}).Return(nil)
+				u.EXPECT().Withdraw(gomock.Any(), 13, 10).Return(nil)
+				u.EXPECT().Deposit(gomock.Any(), 20, 10).Return(errors.New("some error"))
+			},
+			wantErr: ErrCannotTransferCoins,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := repomocks.NewMockUser(ctrl)
+			ledgerRepo := repomocks.NewMockLedger(ctrl)
+			transactor := repomocks.NewMockTransactor(ctrl)
+			tc.mockBehavior(userRepo, ledgerRepo, transactor, tc.args)
+			s := NewPaymentService(userRepo, repomocks.NewMockItem(ctrl), ledgerRepo, repomocks.NewMockSale(ctrl), repomocks.NewMockPurchase(ctrl), repomocks.NewMockPendingTransfer(ctrl), repomocks.NewMockPaymentRequest(ctrl), transactor, tc.cfg)
+
+			got, err := s.BatchTransfer(tc.args.ctx, tc.args.input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestPaymentService_Mint(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		input PaymentMintInput
+	}
+
+	type MockBehavior func(u *repomocks.MockUser, l *repomocks.MockLedger, t *repomocks.MockTransactor, args args)
+
+	userIds := map[string]int{"bob": 20, "alice": 15, entity.SystemUserName: 1}
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         PaymentMintOutput
+		wantErr      error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx:   context.Background(),
+				input: PaymentMintInput{AdminId: 7, UserNames: []string{"bob", "alice"}, Amount: 200, Reason: "hackathon"},
+			},
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger, t *repomocks.MockTransactor, args args) {
+				u.EXPECT().GetSystemUserId(args.ctx).Return(1, nil)
+				u.EXPECT().GetUserIdsByNames(args.ctx, []string{"bob", "alice"}).Return(userIds, nil)
+
+				t.EXPECT().WithinTransaction(args.ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					})
+
+				systemId, adminId := 1, 7
+				for _, receiverId := range []int{15, 20} {
+					u.EXPECT().Deposit(gomock.Any(), receiverId, 200).Return(nil)
+					l.EXPECT().Append(gomock.Any(), entity.LedgerEntry{
+						SenderId:   &systemId,
+						ReceiverId: &receiverId,
+						Amount:     200,
+						Kind:       entity.LedgerKindMint,
+						Reason:     "hackathon",
+						CreatedBy:  &adminId,
+					}).Return(entity.LedgerEntry{}, nil)
+				}
+			},
+			want:    PaymentMintOutput{Users: 2, Total: 400},
+			wantErr: nil,
+		},
+		{
+			name: "empty reason",
+			args: args{
+				ctx:   context.Background(),
+				input: PaymentMintInput{AdminId: 7, UserNames: []string{"bob"}, Amount: 200, Reason: "  "},
+			},
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger, t *repomocks.MockTransactor, args args) {},
+			wantErr:      ErrMintReasonRequired,
+		},
+		{
+			name: "zero amount",
+			args: args{
+				ctx:   context.Background(),
+				input: PaymentMintInput{AdminId: 7, UserNames: []string{"bob"}, Reason: "hackathon"},
+			},
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger, t *repomocks.MockTransactor, args args) {},
+			wantErr:      ErrInvalidAmount,
+		},
+		{
+			name: "unknown users",
+			args: args{
+				ctx:   context.Background(),
+				input: PaymentMintInput{AdminId: 7, UserNames: []string{"bob", "ghost", entity.SystemUserName}, Amount: 200, Reason: "hackathon"},
+			},
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger, t *repomocks.MockTransactor, args args) {
+				u.EXPECT().GetSystemUserId(args.ctx).Return(1, nil)
+				u.EXPECT().GetUserIdsByNames(args.ctx, gomock.Any()).Return(userIds, nil)
+			},
+			wantErr: &UnknownUsersError{UserNames: []string{"ghost", entity.SystemUserName}},
+		},
+		{
+			name: "duplicate user",
+			args: args{
+				ctx:   context.Background(),
+				input: PaymentMintInput{AdminId: 7, UserNames: []string{"bob", "bob"}, Amount: 200, Reason: "hackathon"},
+			},
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger, t *repomocks.MockTransactor, args args) {
+				u.EXPECT().GetSystemUserId(args.ctx).Return(1, nil)
+				u.EXPECT().GetUserIdsByNames(args.ctx, gomock.Any()).Return(userIds, nil)
+			},
+			wantErr: ErrDuplicateRecipient,
+		},
+		{
+			name: "no system account",
+			args: args{
+				ctx:   context.Background(),
+				input: PaymentMintInput{AdminId: 7, UserNames: []string{"bob"}, Amount: 200, Reason: "hackathon"},
+			},
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger, t *repomocks.MockTransactor, args args) {
+				u.EXPECT().GetSystemUserId(args.ctx).Return(0, repository.ErrNotFound)
+			},
+			wantErr: ErrCannotMintCoins,
+		},
+		{
+			name: "deposit failed",
+			args: args{
+				ctx:   context.Background(),
+				input: PaymentMintInput{AdminId: 7, UserNames: []string{"bob"}, Amount: 200, Reason: "hackathon"},
+			},
+			mockBehavior: func(u *repomocks.MockUser, l *repomocks.MockLedger, t *repomocks.MockTransactor, args args) {
+				u.EXPECT().GetSystemUserId(args.ctx).Return(1, nil)
+				u.EXPECT().GetUserIdsByNames(args.ctx, gomock.Any()).Return(userIds, nil)
+
+				t.EXPECT().WithinTransaction(args.ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+						return fn(ctx)
+					})
+
+				u.EXPECT().Deposit(gomock.Any(), 20, 200).Return(errors.New("some error"))
+			},
+			wantErr: ErrCannotMintCoins,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := repomocks.NewMockUser(ctrl)
+			ledgerRepo := repomocks.NewMockLedger(ctrl)
+			transactor := repomocks.NewMockTransactor(ctrl)
+			tc.mockBehavior(userRepo, ledgerRepo, transactor, tc.args)
+			s := NewPaymentService(userRepo, repomocks.NewMockItem(ctrl), ledgerRepo, repomocks.NewMockSale(ctrl), repomocks.NewMockPurchase(ctrl), repomocks.NewMockPendingTransfer(ctrl), repomocks.NewMockPaymentRequest(ctrl), transactor, PaymentServiceConfig{})
+
+			got, err := s.Mint(tc.args.ctx, tc.args.input)
+			if tc.wantErr != nil {
+				assert.Equal(t, tc.wantErr, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+type paymentMocks struct {
+	user       *repomocks.MockUser
+	ledger     *repomocks.MockLedger
+	pending    *repomocks.MockPendingTransfer
+	request    *repomocks.MockPaymentRequest
+	transactor *repomocks.MockTransactor
+}
+
+func newPaymentMocks(ctrl *gomock.Controller) paymentMocks {
+	m := paymentMocks{
+		user:       repomocks.NewMockUser(ctrl),
+		ledger:     repomocks.NewMockLedger(ctrl),
+		pending:    repomocks.NewMockPendingTransfer(ctrl),
+		request:    repomocks.NewMockPaymentRequest(ctrl),
+		transactor: repomocks.NewMockTransactor(ctrl),
+	}
+
+	m.transactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		}).AnyTimes()
+
+	return m
+}
+
+// service leaves out the repos of items and purchases, transfers do not use them.
+func (m paymentMocks) service(cfg PaymentServiceConfig) *PaymentService {
+	return NewPaymentService(m.user, nil, m.ledger, nil, nil, m.pending, m.request, m.transactor, cfg)
+}
+
+func TestPaymentService_TransferPending(t *testing.T) {
+	input := PaymentTransferInput{FromUserId: 1, ToUserName: "bob", Amount: 100}
+	cfg := PaymentServiceConfig{PendingTransferTTL: 72 * time.Hour}
+	fromUserId := 1
+
+	testCases := []struct {
+		name         string
+		input        PaymentTransferInput
+		cfg          PaymentServiceConfig
+		mockBehavior func(m paymentMocks)
+		want         entity.PendingTransfer
+		wantErr      error
+	}{
+		{
+			name:  "success",
+			input: input,
+			cfg:   cfg,
+			mockBehavior: func(m paymentMocks) {
+				m.user.EXPECT().GetUserIdByName(gomock.Any(), "bob").Return(2, nil)
+				m.user.EXPECT().Withdraw(gomock.Any(), 1, 100).Return(nil)
+				m.pending.EXPECT().Create(gomock.Any(), gomock.Cond(func(transfer entity.PendingTransfer) bool {
+					ttl := time.Until(transfer.ExpiresAt)
+					return transfer.SenderId == 1 && transfer.ReceiverId == 2 && transfer.Amount == 100 &&
+						ttl > 71*time.Hour && ttl <= 72*time.Hour
+				})).Return(entity.PendingTransfer{Id: 4, SenderId: 1, ReceiverId: 2, Amount: 100, Status: entity.PendingTransferStatusPending}, nil)
+				m.ledger.EXPECT().Append(gomock.Any(), entity.LedgerEntry{
+					SenderId:          &fromUserId,
+					Amount:            100,
+					Kind:              entity.LedgerKindEscrowHold,
+					PendingTransferId: intPtr(4),
+				}).Return(entity.LedgerEntry{}, nil)
+			},
+			want:    entity.PendingTransfer{Id: 4, SenderId: 1, ReceiverId: 2, Amount: 100, Status: entity.PendingTransferStatusPending},
+			wantErr: nil,
+		},
+		{
+			name:  "user not found",
+			input: input,
+			cfg:   cfg,
+			mockBehavior: func(m paymentMocks) {
+				m.user.EXPECT().GetUserIdByName(gomock.Any(), "bob").Return(0, repository.ErrNotFound)
+			},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name:  "self transfer",
+			input: input,
+			cfg:   cfg,
+			mockBehavior: func(m paymentMocks) {
+				m.user.EXPECT().GetUserIdByName(gomock.Any(), "bob").Return(1, nil)
+			},
+			wantErr: ErrSelfTransfer,
+		},
+		{
+			name:  "not enough balance",
+			input: input,
+			cfg:   cfg,
+			mockBehavior: func(m paymentMocks) {
+				m.user.EXPECT().GetUserIdByName(gomock.Any(), "bob").Return(2, nil)
+				m.user.EXPECT().Withdraw(gomock.Any(), 1, 100).Return(repository.ErrNotFound)
+			},
+			wantErr: ErrNotEnoughBalance,
+		},
+		{
+			name:  "daily limit",
+			input: input,
+			cfg:   PaymentServiceConfig{PendingTransferTTL: time.Hour, MaxDailyTransferAmount: 150},
+			mockBehavior: func(m paymentMocks) {
+				m.user.EXPECT().GetUserIdByName(gomock.Any(), "bob").Return(2, nil)
+				m.user.EXPECT().Withdraw(gomock.Any(), 1, 100).Return(nil)
+				m.ledger.EXPECT().GetSentSince(gomock.Any(), 1, gomock.Any()).Return(entity.TransferStats{Count: 1, Amount: 100}, nil)
+			},
+			wantErr: &LimitError{Limit: LimitDailyAmount, Max: 150, Used: 100},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newPaymentMocks(ctrl)
+			tc.mockBehavior(m)
+
+			got, err := m.service(tc.cfg).TransferPending(context.Background(), tc.input)
+			if tc.wantErr != nil {
+				assert.Equal(t, tc.wantErr, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestPaymentService_AcceptTransfer(t *testing.T) {
+	input := PaymentResolveTransferInput{UserId: 2, TransferId: 4}
+	transfer := entity.PendingTransfer{Id: 4, SenderId: 1, ReceiverId: 2, Amount: 100, Status: entity.PendingTransferStatusAccepted}
+
+	testCases := []struct {
+		name         string
+		cfg          PaymentServiceConfig
+		mockBehavior func(m paymentMocks)
+		wantErr      error
+	}{
+		{
+			name: "success",
+			cfg:  PaymentServiceConfig{},
+			mockBehavior: func(m paymentMocks) {
+				m.pending.EXPECT().Resolve(gomock.Any(), 4, 2, entity.PendingTransferStatusAccepted).Return(transfer, nil)
+				m.user.EXPECT().Deposit(gomock.Any(), 2, 100).Return(nil)
+				m.ledger.EXPECT().Append(gomock.Any(), entity.LedgerEntry{
+					ReceiverId:        &transfer.ReceiverId,
+					Amount:            100,
+					Kind:              entity.LedgerKindEscrowRelease,
+					PendingTransferId: intPtr(4),
+				}).Return(entity.LedgerEntry{}, nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			cfg:  PaymentServiceConfig{},
+			mockBehavior: func(m paymentMocks) {
+				m.pending.EXPECT().Resolve(gomock.Any(), 4, 2, entity.PendingTransferStatusAccepted).Return(entity.PendingTransfer{}, repository.ErrNotFound)
+			},
+			wantErr: ErrPendingTransferNotFound,
+		},
+		{
+			name: "received limit",
+			cfg:  PaymentServiceConfig{MaxDailyReceivedAmount: 150},
+			mockBehavior: func(m paymentMocks) {
+				m.pending.EXPECT().Resolve(gomock.Any(), 4, 2, entity.PendingTransferStatusAccepted).Return(transfer, nil)
+				m.user.EXPECT().Deposit(gomock.Any(), 2, 100).Return(nil)
+				m.ledger.EXPECT().GetReceivedSince(gomock.Any(), 2, gomock.Any()).Return(entity.TransferStats{Count: 1, Amount: 60}, nil)
+			},
+			wantErr: &LimitError{Limit: LimitDailyReceived, Max: 150, Used: 60},
+		},
+		{
+			name: "cannot deposit",
+			cfg:  PaymentServiceConfig{},
+			mockBehavior: func(m paymentMocks) {
+				m.pending.EXPECT().Resolve(gomock.Any(), 4, 2, entity.PendingTransferStatusAccepted).Return(transfer, nil)
+				m.user.EXPECT().Deposit(gomock.Any(), 2, 100).Return(errors.New("some error"))
+			},
+			wantErr: ErrCannotTransferCoins,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newPaymentMocks(ctrl)
+			tc.mockBehavior(m)
+
+			err := m.service(tc.cfg).AcceptTransfer(context.Background(), input)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestPaymentService_DeclineTransfer(t *testing.T) {
+	input := PaymentResolveTransferInput{UserId: 2, TransferId: 4}
+	transfer := entity.PendingTransfer{Id: 4, SenderId: 1, ReceiverId: 2, Amount: 100, Status: entity.PendingTransferStatusDeclined}
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(m paymentMocks)
+		wantErr      error
+	}{
+		{
+			name: "success",
+			mockBehavior: func(m paymentMocks) {
+				m.pending.EXPECT().Resolve(gomock.Any(), 4, 2, entity.PendingTransferStatusDeclined).Return(transfer, nil)
+				m.user.EXPECT().Deposit(gomock.Any(), 1, 100).Return(nil)
+				m.ledger.EXPECT().Append(gomock.Any(), entity.LedgerEntry{
+					ReceiverId:        &transfer.SenderId,
+					Amount:            100,
+					Kind:              entity.LedgerKindEscrowRefund,
+					PendingTransferId: intPtr(4),
+				}).Return(entity.LedgerEntry{}, nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			mockBehavior: func(m paymentMocks) {
+				m.pending.EXPECT().Resolve(gomock.Any(), 4, 2, entity.PendingTransferStatusDeclined).Return(entity.PendingTransfer{}, repository.ErrNotFound)
+			},
+			wantErr: ErrPendingTransferNotFound,
+		},
+		{
+			name: "cannot append",
+			mockBehavior: func(m paymentMocks) {
+				m.pending.EXPECT().Resolve(gomock.Any(), 4, 2, entity.PendingTransferStatusDeclined).Return(transfer, nil)
+				m.user.EXPECT().Deposit(gomock.Any(), 1, 100).Return(nil)
+				m.ledger.EXPECT().Append(gomock.Any(), gomock.Any()).Return(entity.LedgerEntry{}, errors.New("some error"))
+			},
+			wantErr: ErrCannotTransferCoins,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newPaymentMocks(ctrl)
+			tc.mockBehavior(m)
+
+			err := m.service(PaymentServiceConfig{}).DeclineTransfer(context.Background(), input)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestPaymentService_RequestPayment(t *testing.T) {
+	input := PaymentRequestInput{RequesterId: 1, PayerNames: []string{"bob", "carol"}, Amount: 50, Note: "gift"}
+	createdAt := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name         string
+		input        PaymentRequestInput
+		cfg          PaymentServiceConfig
+		mockBehavior func(m paymentMocks)
+		want         []entity.PaymentRequest
+		wantErr      error
+	}{
+		{
+			name:  "success",
+			input: input,
+			mockBehavior: func(m paymentMocks) {
+				m.user.EXPECT().GetUserIdsByNames(gomock.Any(), input.PayerNames).Return(map[string]int{"bob": 2, "carol": 3}, nil)
+				for i, payerId := range []int{2, 3} {
+					request := entity.PaymentRequest{RequesterId: 1, PayerId: payerId, PayerName: input.PayerNames[i], Amount: 50, Note: "gift"}
+					created := request
+					created.Id = 10 + i
+					created.Status = entity.PaymentRequestStatusPending
+					created.CreatedAt = createdAt
+					m.request.EXPECT().Create(gomock.Any(), request).Return(created, nil)
+				}
+			},
+			want: []entity.PaymentRequest{
+				{Id: 10, RequesterId: 1, PayerId: 2, PayerName: "bob", Amount: 50, Note: "gift", Status: entity.PaymentRequestStatusPending, CreatedAt: createdAt},
+				{Id: 11, RequesterId: 1, PayerId: 3, PayerName: "carol", Amount: 50, Note: "gift", Status: entity.PaymentRequestStatusPending, CreatedAt: createdAt},
+			},
+			wantErr: nil,
+		},
+		{
+			name:         "amount over limit",
+			input:        input,
+			cfg:          PaymentServiceConfig{MaxTransferAmount: 40},
+			mockBehavior: func(m paymentMocks) {},
+			wantErr:      &LimitError{Limit: LimitTransferAmount, Max: 40},
+		},
+		{
+			name:  "unknown payers",
+			input: PaymentRequestInput{RequesterId: 1, PayerNames: []string{"bob", "dave", "eve"}, Amount: 50},
+			mockBehavior: func(m paymentMocks) {
+				m.user.EXPECT().GetUserIdsByNames(gomock.Any(), []string{"bob", "dave", "eve"}).Return(map[string]int{"bob": 2}, nil)
+			},
+			wantErr: &UnknownUsersError{UserNames: []string{"dave", "eve"}},
+		},
+		{
+			name:  "self request",
+			input: PaymentRequestInput{RequesterId: 1, PayerNames: []string{"alice"}, Amount: 50},
+			mockBehavior: func(m paymentMocks) {
+				m.user.EXPECT().GetUserIdsByNames(gomock.Any(), []string{"alice"}).Return(map[string]int{"alice": 1}, nil)
+			},
+			wantErr: ErrSelfTransfer,
+		},
+		{
+			name:  "duplicate payer",
+			input: PaymentRequestInput{RequesterId: 1, PayerNames: []string{"bob", "bob"}, Amount: 50},
+			mockBehavior: func(m paymentMocks) {
+				m.user.EXPECT().GetUserIdsByNames(gomock.Any(), []string{"bob", "bob"}).Return(map[string]int{"bob": 2}, nil)
+			},
+			wantErr: ErrDuplicateRecipient,
+		},
+		{
+			name:  "cannot create",
+			input: input,
+			mockBehavior: func(m paymentMocks) {
+				m.user.EXPECT().GetUserIdsByNames(gomock.Any(), input.PayerNames).Return(map[string]int{"bob": 2, "carol": 3}, nil)
+				m.request.EXPECT().Create(gomock.Any(), gomock.Any()).Return(entity.PaymentRequest{}, errors.New("some error"))
+			},
+			wantErr: ErrCannotRequestPayment,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newPaymentMocks(ctrl)
+			tc.mockBehavior(m)
+
+			got, err := m.service(tc.cfg).RequestPayment(context.Background(), tc.input)
+			assert.Equal(t, tc.wantErr, err)
+			if tc.wantErr == nil {
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestPaymentService_PayRequest(t *testing.T) {
+	input := PaymentPayRequestInput{UserId: 2, RequestId: 10}
+	request := entity.PaymentRequest{Id: 10, RequesterId: 1, RequesterName: "alice", PayerId: 2, Amount: 50, Status: entity.PaymentRequestStatusPaid}
+	payerId := 2
+
+	testCases := []struct {
+		name         string
+		cfg          PaymentServiceConfig
+		mockBehavior func(m paymentMocks)
+		wantErr      error
+	}{
+		{
+			name: "success",
+			mockBehavior: func(m paymentMocks) {
+				m.request.EXPECT().MarkPaid(gomock.Any(), 10, 2).Return(request, nil)
+				m.user.EXPECT().LockUsers(gomock.Any(), []int{1, 2}).Return(nil)
+				m.user.EXPECT().Withdraw(gomock.Any(), 2, 50).Return(nil)
+				m.user.EXPECT().Deposit(gomock.Any(), 1, 50).Return(nil)
+				m.ledger.EXPECT().Append(gomock.Any(), entity.LedgerEntry{
+					SenderId:   &payerId,
+					ReceiverId: &request.RequesterId,
+					Amount:     50,
+					Kind:       entity.LedgerKindTransfer,
+				}).Return(entity.LedgerEntry{}, nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not found",
+			mockBehavior: func(m paymentMocks) {
+				m.request.EXPECT().MarkPaid(gomock.Any(), 10, 2).Return(entity.PaymentRequest{}, repository.ErrNotFound)
+			},
+			wantErr: ErrPaymentRequestNotFound,
+		},
+		{
+			name: "amount over limit",
+			cfg:  PaymentServiceConfig{MaxTransferAmount: 40},
+			mockBehavior: func(m paymentMocks) {
+				m.request.EXPECT().MarkPaid(gomock.Any(), 10, 2).Return(request, nil)
+			},
+			wantErr: &LimitError{Limit: LimitTransferAmount, Max: 40},
+		},
+		{
+			name: "not enough balance",
+			mockBehavior: func(m paymentMocks) {
+				m.request.EXPECT().MarkPaid(gomock.Any(), 10, 2).Return(request, nil)
+				m.user.EXPECT().LockUsers(gomock.Any(), []int{1, 2}).Return(nil)
+				m.user.EXPECT().Withdraw(gomock.Any(), 2, 50).Return(repository.ErrNotFound)
+			},
+			wantErr: ErrNotEnoughBalance,
+		},
+		{
+			name: "received limit",
+			cfg:  PaymentServiceConfig{MaxDailyReceivedAmount: 80},
+			mockBehavior: func(m paymentMocks) {
+				m.request.EXPECT().MarkPaid(gomock.Any(), 10, 2).Return(request, nil)
+				m.user.EXPECT().LockUsers(gomock.Any(), []int{1, 2}).Return(nil)
+				m.user.EXPECT().Withdraw(gomock.Any(), 2, 50).Return(nil)
+				m.user.EXPECT().Deposit(gomock.Any(), 1, 50).Return(nil)
+				m.ledger.EXPECT().GetReceivedSince(gomock.Any(), 1, gomock.Any()).Return(entity.TransferStats{Count: 1, Amount: 40}, nil)
+			},
+			wantErr: &LimitError{Limit: LimitDailyReceived, Max: 80, Used: 40, UserName: "alice"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newPaymentMocks(ctrl)
+			tc.mockBehavior(m)
+
+			err := m.service(tc.cfg).PayRequest(context.Background(), input)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/internal/repository"
+	"time"
+)
+
+type ReturnServiceConfig struct {
+	// Window is how long after a purchase its items can be returned.
+	Window time.Duration
+}
+
+type ReturnService struct {
+	purchaseRepo repository.Purchase
+	returnRepo   repository.Return
+	saleRepo     repository.Sale
+	itemRepo     repository.Item
+	userRepo     repository.User
+	ledgerRepo   repository.Ledger
+	transactor   repository.Transactor
+	cfg          ReturnServiceConfig
+}
+
+func NewReturnService(purchaseRepo repository.Purchase, returnRepo repository.Return, saleRepo repository.Sale, itemRepo repository.Item, userRepo repository.User, ledgerRepo repository.Ledger, transactor repository.Transactor, cfg ReturnServiceConfig) *ReturnService {
+	return &ReturnService{
+		purchaseRepo: purchaseRepo,
+		returnRepo:   returnRepo,
+		saleRepo:     saleRepo,
+		itemRepo:     itemRepo,
+		userRepo:     userRepo,
+		ledgerRepo:   ledgerRepo,
+		transactor:   transactor,
+		cfg:          cfg,
+	}
+}
+
+// Request creates a pending return for items of the purchase. Nothing is refunded until an admin approves it.
+func (s *ReturnService) Request(ctx context.Context, input ReturnRequestInput) (entity.Return, error) {
+	if input.Quantity < 1 {
+		return entity.Return{}, ErrInvalidQuantity
+	}
+
+	var ret entity.Return
+	err := s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		// The lock keeps concurrent requests from returning more items than were bought.
+		purchase, err := s.purchaseRepo.GetForUpdate(txCtx, input.UserId, input.PurchaseId, input.ItemName)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrPurchaseNotFound
+			}
+			log.Errorf("ReturnService.Request - purchaseRepo.GetForUpdate: %v", err)
+			return ErrCannotRequestReturn
+		}
+
+		if time.Since(purchase.CreatedAt) > s.cfg.Window {
+			return ErrReturnWindowExpired
+		}
+
+		returned, err := s.returnRepo.GetReturnedQuantity(txCtx, purchase.Id)
+		if err != nil {
+			log.Errorf("ReturnService.Request - returnRepo.GetReturnedQuantity: %v", err)
+			return ErrCannotRequestReturn
+		}
+
+		if returned+input.Quantity > purchase.Quantity {
+			return ErrReturnQuantityExceeded
+		}
+
+		ret = entity.Return{
+			PurchaseId: purchase.Id,
+			UserId:     input.UserId,
+			ItemId:     purchase.ItemId,
+			ItemName:   purchase.ItemName,
+			Quantity:   input.Quantity,
+			Amount:     purchase.Price * input.Quantity,
+			Reason:     input.Reason,
+			Status:     entity.ReturnStatusPending,
+		}
+
+		ret.Id, err = s.returnRepo.Create(txCtx, ret)
+		if err != nil {
+			log.Errorf("ReturnService.Request - returnRepo.Create: %v", err)
+			return ErrCannotRequestReturn
+		}
+
+		return nil
+	})
+	if err != nil {
+		return entity.Return{}, err
+	}
+
+	return ret, nil
+}
+
+func (s *ReturnService) List(ctx context.Context, userId int) ([]entity.Return, error) {
+	returns, err := s.returnRepo.GetByUserId(ctx, userId)
+	if err != nil {
+		log.Errorf("ReturnService.List - returnRepo.GetByUserId: %v", err)
+		return nil, ErrCannotGetReturns
+	}
+
+	return returns, nil
+}
+
+func (s *ReturnService) ListByStatus(ctx context.Context, status string) ([]entity.Return, error) {
+	returns, err := s.returnRepo.GetByStatus(ctx, status)
+	if err != nil {
+		log.Errorf("ReturnService.ListByStatus - returnRepo.GetByStatus: %v", err)
+		return nil, ErrCannotGetReturns
+	}
+
+	return returns, nil
+}
+
+// Approve takes the items back into stock and credits their price to the user in one transaction.
+func (s *ReturnService) Approve(ctx context.Context, input ReturnDecideInput) error {
+	return s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		ret, err := s.returnRepo.Decide(txCtx, input.Id, entity.ReturnStatusApproved, input.AdminId)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrReturnNotFound
+			}
+			log.Errorf("ReturnService.Approve - returnRepo.Decide: %v", err)
+			return ErrCannotDecideReturn
+		}
+
+		err = s.saleRepo.Decrement(txCtx, entity.Sale{
+			UserId:   ret.UserId,
+			ItemId:   ret.ItemId,
+			Quantity: ret.Quantity,
+		})
+		if err != nil {
+			log.Errorf("ReturnService.Approve - saleRepo.Decrement: %v", err)
+			return ErrCannotDecideReturn
+		}
+
+		// Purchases lock the user row before the item row, a refund locks them in the same order,
+		// so that it cannot deadlock with a purchase of the same item by the same user.
+		err = s.userRepo.Deposit(txCtx, ret.UserId, ret.Amount)
+		if err != nil {
+			log.Errorf("ReturnService.Approve - userRepo.Deposit: %v", err)
+			return ErrCannotDecideReturn
+		}
+
+		// Returned items go back on sale.
+		_, err = s.itemRepo.AddStock(txCtx, ret.ItemId, ret.Quantity)
+		if err != nil {
+			log.Errorf("ReturnService.Approve - itemRepo.AddStock: %v", err)
+			return ErrCannotDecideReturn
+		}
+
+		_, err = s.ledgerRepo.Append(txCtx, entity.LedgerEntry{
+			ReceiverId: &ret.UserId,
+			Amount:     ret.Amount,
+			Kind:       entity.LedgerKindRefund,
+		})
+		if err != nil {
+			log.Errorf("ReturnService.Approve - ledgerRepo.Append: %v", err)
+			return ErrCannotDecideReturn
+		}
+
+		return nil
+	})
+}
+
+func (s *ReturnService) Reject(ctx context.Context, input ReturnDecideInput) error {
+	_, err := s.returnRepo.Decide(ctx, input.Id, entity.ReturnStatusRejected, input.AdminId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrReturnNotFound
+		}
+		log.Errorf("ReturnService.Reject - returnRepo.Decide: %v", err)
+		return ErrCannotDecideReturn
+	}
+
+	return nil
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/spanwalla/merch-store/internal/entity"
+	repomocks "github.com/spanwalla/merch-store/internal/mocks/repository"
+	"github.com/spanwalla/merch-store/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+type returnMocks struct {
+	purchase   *repomocks.MockPurchase
+	ret        *repomocks.MockReturn
+	sale       *repomocks.MockSale
+	item       *repomocks.MockItem
+	user       *repomocks.MockUser
+	ledger     *repomocks.MockLedger
+	transactor *repomocks.MockTransactor
+}
+
+func newReturnMocks(ctrl *gomock.Controller) returnMocks {
+	m := returnMocks{
+		purchase:   repomocks.NewMockPurchase(ctrl),
+		ret:        repomocks.NewMockReturn(ctrl),
+		sale:       repomocks.NewMockSale(ctrl),
+		item:       repomocks.NewMockItem(ctrl),
+		user:       repomocks.NewMockUser(ctrl),
+		ledger:     repomocks.NewMockLedger(ctrl),
+		transactor: repomocks.NewMockTransactor(ctrl),
+	}
+
+	m.transactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		}).AnyTimes()
+
+	return m
+}
+
+func (m returnMocks) service() *ReturnService {
+	return NewReturnService(m.purchase, m.ret, m.sale, m.item, m.user, m.ledger, m.transactor, ReturnServiceConfig{Window: 14 * 24 * time.Hour})
+}
+
+func TestReturnService_Request(t *testing.T) {
+	input := ReturnRequestInput{UserId: 13, PurchaseId: 50, ItemName: "socks", Quantity: 2, Reason: "wrong size"}
+	purchase := entity.Purchase{Id: 7, LedgerEntryId: 50, UserId: 13, ItemId: 4, ItemName: "socks", Quantity: 3, Price: 10, CreatedAt: time.Now().Add(-time.Hour)}
+
+	testCases := []struct {
+		name         string
+		input        ReturnRequestInput
+		mockBehavior func(m returnMocks)
+		want         entity.Return
+		wantErr      error
+	}{
+		{
+			name:  "success",
+			input: input,
+			mockBehavior: func(m returnMocks) {
+				m.purchase.EXPECT().GetForUpdate(gomock.Any(), input.UserId, input.PurchaseId, input.ItemName).Return(purchase, nil)
+				m.ret.EXPECT().GetReturnedQuantity(gomock.Any(), purchase.Id).Return(1, nil)
+				m.ret.EXPECT().Create(gomock.Any(), entity.Return{
+					PurchaseId: purchase.Id,
+					UserId:     input.UserId,
+					ItemId:     purchase.ItemId,
+					ItemName:   purchase.ItemName,
+					Quantity:   2,
+					Amount:     20,
+					Reason:     input.Reason,
+					Status:     entity.ReturnStatusPending,
+				}).Return(3, nil)
+			},
+			want: entity.Return{
+				Id:         3,
+				PurchaseId: purchase.Id,
+				UserId:     input.UserId,
+				ItemId:     purchase.ItemId,
+				ItemName:   purchase.ItemName,
+				Quantity:   2,
+				Amount:     20,
+				Reason:     input.Reason,
+				Status:     entity.ReturnStatusPending,
+			},
+			wantErr: nil,
+		},
+		{
+			name:  "purchase not found",
+			input: input,
+			mockBehavior: func(m returnMocks) {
+				m.purchase.EXPECT().GetForUpdate(gomock.Any(), input.UserId, input.PurchaseId, input.ItemName).Return(entity.Purchase{}, repository.ErrNotFound)
+			},
+			wantErr: ErrPurchaseNotFound,
+		},
+		{
+			name:  "window expired",
+			input: input,
+			mockBehavior: func(m returnMocks) {
+				expired := purchase
+				expired.CreatedAt = time.Now().Add(-15 * 24 * time.Hour)
+				m.purchase.EXPECT().GetForUpdate(gomock.Any(), input.UserId, input.PurchaseId, input.ItemName).Return(expired, nil)
+			},
+			wantErr: ErrReturnWindowExpired,
+		},
+		{
+			name:  "more than bought",
+			input: input,
+			mockBehavior: func(m returnMocks) {
+				m.purchase.EXPECT().GetForUpdate(gomock.Any(), input.UserId, input.PurchaseId, input.ItemName).Return(purchase, nil)
+				m.ret.EXPECT().GetReturnedQuantity(gomock.Any(), purchase.Id).Return(2, nil)
+			},
+			wantErr: ErrReturnQuantityExceeded,
+		},
+		{
+			name:         "zero quantity",
+			input:        ReturnRequestInput{UserId: 13, PurchaseId: 50, ItemName: "socks"},
+			mockBehavior: func(m returnMocks) {},
+			wantErr:      ErrInvalidQuantity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newReturnMocks(ctrl)
+			tc.mockBehavior(m)
+
+			got, err := m.service().Request(context.Background(), tc.input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestReturnService_Approve(t *testing.T) {
+	input := ReturnDecideInput{Id: 3, AdminId: 1}
+	approved := entity.Return{Id: 3, PurchaseId: 7, UserId: 13, ItemId: 4, Quantity: 2, Amount: 20, Status: entity.ReturnStatusApproved}
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(m returnMocks)
+		wantErr      error
+	}{
+		{
+			name: "success",
+			mockBehavior: func(m returnMocks) {
+				m.ret.EXPECT().Decide(gomock.Any(), input.Id, entity.ReturnStatusApproved, input.AdminId).Return(approved, nil)
+				m.sale.EXPECT().Decrement(gomock.Any(), entity.Sale{UserId: 13, ItemId: 4, Quantity: 2}).Return(nil)
+				gomock.InOrder(
+					m.user.EXPECT().Deposit(gomock.Any(), 13, 20).Return(nil),
+					m.item.EXPECT().AddStock(gomock.Any(), 4, 2).Return(entity.Item{Id: 4}, nil),
+				)
+				m.ledger.EXPECT().Append(gomock.Any(), gomock.Cond(func(entry entity.LedgerEntry) bool {
+					return entry.SenderId == nil && *entry.ReceiverId == 13 && entry.Amount == 20 && entry.Kind == entity.LedgerKindRefund
+				})).Return(entity.LedgerEntry{Id: 60}, nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not pending",
+			mockBehavior: func(m returnMocks) {
+				m.ret.EXPECT().Decide(gomock.Any(), input.Id, entity.ReturnStatusApproved, input.AdminId).Return(entity.Return{}, repository.ErrNotFound)
+			},
+			wantErr: ErrReturnNotFound,
+		},
+		{
+			name: "items are gone",
+			mockBehavior: func(m returnMocks) {
+				m.ret.EXPECT().Decide(gomock.Any(), input.Id, entity.ReturnStatusApproved, input.AdminId).Return(approved, nil)
+				m.sale.EXPECT().Decrement(gomock.Any(), entity.Sale{UserId: 13, ItemId: 4, Quantity: 2}).Return(repository.ErrNotFound)
+			},
+			wantErr: ErrCannotDecideReturn,
+		},
+		{
+			name: "deposit failed",
+			mockBehavior: func(m returnMocks) {
+				m.ret.EXPECT().Decide(gomock.Any(), input.Id, entity.ReturnStatusApproved, input.AdminId).Return(approved, nil)
+				m.sale.EXPECT().Decrement(gomock.Any(), entity.Sale{UserId: 13, ItemId: 4, Quantity: 2}).Return(nil)
+				m.user.EXPECT().Deposit(gomock.Any(), 13, 20).Return(errors.New("some error"))
+			},
+			wantErr: ErrCannotDecideReturn,
+		},
+		{
+			name: "restock failed",
+			mockBehavior: func(m returnMocks) {
+				m.ret.EXPECT().Decide(gomock.Any(), input.Id, entity.ReturnStatusApproved, input.AdminId).Return(approved, nil)
+				m.sale.EXPECT().Decrement(gomock.Any(), entity.Sale{UserId: 13, ItemId: 4, Quantity: 2}).Return(nil)
+				m.user.EXPECT().Deposit(gomock.Any(), 13, 20).Return(nil)
+				m.item.EXPECT().AddStock(gomock.Any(), 4, 2).Return(entity.Item{}, errors.New("some error"))
+			},
+			wantErr: ErrCannotDecideReturn,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newReturnMocks(ctrl)
+			tc.mockBehavior(m)
+
+			err := m.service().Approve(context.Background(), input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestReturnService_Reject(t *testing.T) {
+	input := ReturnDecideInput{Id: 3, AdminId: 1}
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(m returnMocks)
+		wantErr      error
+	}{
+		{
+			name: "success",
+			mockBehavior: func(m returnMocks) {
+				m.ret.EXPECT().Decide(gomock.Any(), input.Id, entity.ReturnStatusRejected, input.AdminId).Return(entity.Return{Id: 3}, nil)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "not pending",
+			mockBehavior: func(m returnMocks) {
+				m.ret.EXPECT().Decide(gomock.Any(), input.Id, entity.ReturnStatusRejected, input.AdminId).Return(entity.Return{}, repository.ErrNotFound)
+			},
+			wantErr: ErrReturnNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newReturnMocks(ctrl)
+			tc.mockBehavior(m)
+
+			err := m.service().Reject(context.Background(), input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
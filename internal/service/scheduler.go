@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/internal/repository"
+	"github.com/spanwalla/merch-store/pkg/cron"
+	"sync"
+	"time"
+)
+
+// Job is run by the Scheduler within a transaction. Run returns a summary that is saved with the run.
+type Job struct {
+	Name     string
+	Schedule *cron.Schedule
+	Run      func(ctx context.Context, scheduledAt time.Time) (string, error)
+}
+
+// Scheduler runs jobs on their schedules in UTC. Every replica runs the scheduler, an advisory lock
+// and the job_runs table make sure that each scheduled run is done once. Runs missed while no
+// replica was up are not caught up.
+type Scheduler struct {
+	jobRepo    repository.Job
+	transactor repository.Transactor
+	jobs       []Job
+}
+
+func NewScheduler(jobRepo repository.Job, transactor repository.Transactor) *Scheduler {
+	return &Scheduler{
+		jobRepo:    jobRepo,
+		transactor: transactor,
+	}
+}
+
+// Add must be called before Run.
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Run starts every job on its schedule and blocks until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runSchedule(ctx, job)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) runSchedule(ctx context.Context, job Job) {
+	for {
+		scheduledAt := job.Schedule.Next(time.Now().UTC())
+		if scheduledAt.IsZero() {
+			log.Warnf("Scheduler.Run: job %s with schedule %q will never run", job.Name, job.Schedule)
+			return
+		}
+		log.Debugf("Scheduler.Run: job %s scheduled at %s", job.Name, scheduledAt)
+
+		timer := time.NewTimer(time.Until(scheduledAt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := s.runJob(ctx, job, scheduledAt); err != nil {
+			log.Errorf("Scheduler.Run - job %s: %v", job.Name, err)
+		}
+	}
+}
+
+// runJob runs the job unless another replica has already done or is doing the run. A failed run is
+// rolled back and recorded with the error, it is not retried.
+func (s *Scheduler) runJob(ctx context.Context, job Job, scheduledAt time.Time) error {
+	var jobErr error
+	err := s.transactor.WithinTransaction(ctx, func(txCtx context.Context) error {
+		// The transaction is retried after a serialization failure, only the last attempt counts.
+		jobErr = nil
+
+		locked, err := s.jobRepo.TryLock(txCtx, job.Name)
+		if err != nil {
+			return fmt.Errorf("Scheduler.runJob - jobRepo.TryLock: %w", err)
+		}
+		if !locked {
+			log.Debugf("Scheduler.runJob: job %s is running on another replica", job.Name)
+			return nil
+		}
+
+		// The row is not visible to other replicas until the run is committed, they skip it afterwards.
+		runId, err := s.jobRepo.CreateRun(txCtx, entity.JobRun{
+			Job:         job.Name,
+			ScheduledAt: scheduledAt,
+			Status:      entity.JobRunStatusRunning,
+		})
+		if err != nil {
+			if errors.Is(err, repository.ErrAlreadyExists) {
+				log.Debugf("Scheduler.runJob: job %s scheduled at %s has already run", job.Name, scheduledAt)
+				return nil
+			}
+			return fmt.Errorf("Scheduler.runJob - jobRepo.CreateRun: %w", err)
+		}
+
+		details, err := job.Run(txCtx, scheduledAt)
+		if err != nil {
+			jobErr = err
+			return err
+		}
+
+		err = s.jobRepo.FinishRun(txCtx, runId, entity.JobRunStatusSucceeded, details)
+		if err != nil {
+			return fmt.Errorf("Scheduler.runJob - jobRepo.FinishRun: %w", err)
+		}
+
+		log.Infof("Scheduler.runJob: job %s scheduled at %s succeeded: %s", job.Name, scheduledAt, details)
+		return nil
+	})
+	if jobErr == nil {
+		return err
+	}
+
+	finishedAt := time.Now()
+	_, err = s.jobRepo.CreateRun(ctx, entity.JobRun{
+		Job:         job.Name,
+		ScheduledAt: scheduledAt,
+		FinishedAt:  &finishedAt,
+		Status:      entity.JobRunStatusFailed,
+		Details:     jobErr.Error(),
+	})
+	if err != nil && !errors.Is(err, repository.ErrAlreadyExists) {
+		log.Errorf("Scheduler.runJob - jobRepo.CreateRun: %v", err)
+	}
+
+	return jobErr
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/spanwalla/merch-store/internal/entity"
+	repomocks "github.com/spanwalla/merch-store/internal/mocks/repository"
+	"github.com/spanwalla/merch-store/internal/repository"
+	"github.com/spanwalla/merch-store/pkg/cron"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestScheduler_runJob(t *testing.T) {
+	scheduledAt := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	running := entity.JobRun{Job: "allowance", ScheduledAt: scheduledAt, Status: entity.JobRunStatusRunning}
+	jobErr := errors.New("some job error")
+
+	testCases := []struct {
+		name         string
+		jobErr       error
+		mockBehavior func(j *repomocks.MockJob)
+		wantRun      bool
+		wantErr      error
+	}{
+		{
+			name:   "success",
+			jobErr: nil,
+			mockBehavior: func(j *repomocks.MockJob) {
+				j.EXPECT().TryLock(gomock.Any(), "allowance").Return(true, nil)
+				j.EXPECT().CreateRun(gomock.Any(), running).Return(5, nil)
+				j.EXPECT().FinishRun(gomock.Any(), 5, entity.JobRunStatusSucceeded, "done").Return(nil)
+			},
+			wantRun: true,
+			wantErr: nil,
+		},
+		{
+			name:   "running on another replica",
+			jobErr: nil,
+			mockBehavior: func(j *repomocks.MockJob) {
+				j.EXPECT().TryLock(gomock.Any(), "allowance").Return(false, nil)
+			},
+			wantRun: false,
+			wantErr: nil,
+		},
+		{
+			name:   "already run",
+			jobErr: nil,
+			mockBehavior: func(j *repomocks.MockJob) {
+				j.EXPECT().TryLock(gomock.Any(), "allowance").Return(true, nil)
+				j.EXPECT().CreateRun(gomock.Any(), running).Return(0, repository.ErrAlreadyExists)
+			},
+			wantRun: false,
+			wantErr: nil,
+		},
+		{
+			name:   "job failed",
+			jobErr: jobErr,
+			mockBehavior: func(j *repomocks.MockJob) {
+				j.EXPECT().TryLock(gomock.Any(), "allowance").Return(true, nil)
+				j.EXPECT().CreateRun(gomock.Any(), running).Return(5, nil)
+				j.EXPECT().CreateRun(gomock.Any(), gomock.Cond(func(run entity.JobRun) bool {
+					return run.Job == "allowance" && run.ScheduledAt.Equal(scheduledAt) && run.FinishedAt != nil &&
+						run.Status == entity.JobRunStatusFailed && run.Details == jobErr.Error()
+				})).Return(6, nil)
+			},
+			wantRun: true,
+			wantErr: jobErr,
+		},
+		{
+			name:   "lock error",
+			jobErr: nil,
+			mockBehavior: func(j *repomocks.MockJob) {
+				j.EXPECT().TryLock(gomock.Any(), "allowance").Return(false, errors.New("some error"))
+			},
+			wantRun: false,
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			jobRepo := repomocks.NewMockJob(ctrl)
+			transactor := repomocks.NewMockTransactor(ctrl)
+			transactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+					return fn(ctx)
+				}).AnyTimes()
+			tc.mockBehavior(jobRepo)
+
+			schedule, _ := cron.Parse("@monthly")
+			var ran bool
+			job := Job{
+				Name:     "allowance",
+				Schedule: schedule,
+				Run: func(ctx context.Context, at time.Time) (string, error) {
+					ran = true
+					assert.Equal(t, scheduledAt, at)
+					return "done", tc.jobErr
+				},
+			}
+
+			err := NewScheduler(jobRepo, transactor).runJob(context.Background(), job, scheduledAt)
+			assert.Equal(t, tc.wantRun, ran)
+			if tc.wantErr != nil {
+				assert.ErrorContains(t, err, tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/spanwalla/merch-store/internal/entity"
 	"github.com/spanwalla/merch-store/internal/repository"
 	"github.com/spanwalla/merch-store/pkg/hasher"
+	"github.com/spanwalla/merch-store/pkg/jwks"
 	"time"
 )
 
@@ -13,11 +14,104 @@ import (
 type AuthGenerateTokenInput struct {
 	Name     string
 	Password string
+	IP       string
+}
+
+type AuthRegisterInput struct {
+	Name       string
+	Password   string
+	InviteCode string
+}
+
+type AuthCreateInviteInput struct {
+	UserId  int
+	MaxUses int
+}
+
+type AuthInvite struct {
+	Code      string
+	MaxUses   int
+	ExpiresAt time.Time
+}
+
+type AuthTokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+type AuthIdentity struct {
+	UserId int
+	Role   string
+	// Scopes limit requests made with an API key, nil means the identity is not restricted.
+	Scopes []string
+}
+
+type AuthSetRoleInput struct {
+	UserName string
+	Role     string
+}
+
+type AuthChangePasswordInput struct {
+	UserId      int
+	OldPassword string
+	NewPassword string
+}
+
+type AuthCreatePasswordResetInput struct {
+	UserName  string
+	CreatedBy int
+}
+
+type AuthPasswordReset struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+type AuthResetPasswordInput struct {
+	Token       string
+	NewPassword string
+}
+
+type AuthLogoutInput struct {
+	AccessToken  string
+	RefreshToken string
 }
 
 type Auth interface {
-	GenerateToken(ctx context.Context, input AuthGenerateTokenInput) (string, error)
-	VerifyToken(tokenString string) (int, error)
+	GenerateToken(ctx context.Context, input AuthGenerateTokenInput) (AuthTokens, error)
+	Register(ctx context.Context, input AuthRegisterInput) (AuthTokens, error)
+	CreateInvite(ctx context.Context, input AuthCreateInviteInput) (AuthInvite, error)
+	RefreshToken(ctx context.Context, refreshToken string) (AuthTokens, error)
+	VerifyToken(tokenString string) (AuthIdentity, error)
+	ProxyIdentity(ctx context.Context, userName string) (AuthIdentity, error)
+	SetRole(ctx context.Context, input AuthSetRoleInput) error
+	Unlock(ctx context.Context, userName string) error
+	ChangePassword(ctx context.Context, input AuthChangePasswordInput) error
+	CreatePasswordReset(ctx context.Context, input AuthCreatePasswordResetInput) (AuthPasswordReset, error)
+	ResetPassword(ctx context.Context, input AuthResetPasswordInput) error
+	Logout(ctx context.Context, input AuthLogoutInput) error
+	JWKS() jwks.JWKS
+}
+
+type APIKeyCreateInput struct {
+	UserName string
+	Name     string
+	Scopes   []string
+}
+
+type APIKeyCreateOutput struct {
+	Id     int
+	Key    string
+	Prefix string
+	Scopes []string
+}
+
+type APIKey interface {
+	CreateServiceAccount(ctx context.Context, name string) (int, error)
+	Create(ctx context.Context, input APIKeyCreateInput) (APIKeyCreateOutput, error)
+	List(ctx context.Context, userName string) ([]entity.APIKey, error)
+	Revoke(ctx context.Context, id int) error
+	Verify(ctx context.Context, key string) (AuthIdentity, error)
 }
 
 type PaymentTransferInput struct {
@@ -26,14 +120,184 @@ type PaymentTransferInput struct {
 	Amount     int
 }
 
+type PaymentResolveTransferInput struct {
+	UserId     int
+	TransferId int
+}
+
+type PaymentRequestInput struct {
+	RequesterId int
+	PayerNames  []string
+	// Amount is requested from each of the payers.
+	Amount int
+	Note   string
+}
+
+type PaymentPayRequestInput struct {
+	UserId    int
+	RequestId int
+}
+
+type PaymentBatchTransfer struct {
+	ToUserName string
+	Amount     int
+}
+
+type PaymentBatchTransferInput struct {
+	FromUserId int
+	Transfers  []PaymentBatchTransfer
+	// AllOrNothing rejects the whole batch if any transfer is invalid, otherwise invalid transfers are skipped.
+	AllOrNothing bool
+}
+
+type PaymentTransferResult struct {
+	ToUserName string
+	Amount     int
+	// Err tells why the transfer was not made.
+	Err error
+}
+
+type PaymentBatchTransferOutput struct {
+	Results []PaymentTransferResult
+	// Total is the sum of the transfers made.
+	Total int
+}
+
+type PaymentMintInput struct {
+	AdminId   int
+	UserNames []string
+	// Amount is credited to each of the users.
+	Amount int
+	Reason string
+}
+
+type PaymentMintOutput struct {
+	Users int
+	Total int
+}
+
 type PaymentBuyItemInput struct {
 	UserId   int
 	ItemName string
+	Quantity int
+}
+
+type PaymentReceipt struct {
+	Id          int
+	ItemName    string
+	Quantity    int
+	Price       int
+	Total       int
+	PurchasedAt time.Time
 }
 
 type Payment interface {
 	Transfer(ctx context.Context, input PaymentTransferInput) error
-	BuyItem(ctx context.Context, input PaymentBuyItemInput) error
+	TransferPending(ctx context.Context, input PaymentTransferInput) (entity.PendingTransfer, error)
+	AcceptTransfer(ctx context.Context, input PaymentResolveTransferInput) error
+	DeclineTransfer(ctx context.Context, input PaymentResolveTransferInput) error
+	RequestPayment(ctx context.Context, input PaymentRequestInput) ([]entity.PaymentRequest, error)
+	IncomingPaymentRequests(ctx context.Context, userId int) ([]entity.PaymentRequest, error)
+	OutgoingPaymentRequests(ctx context.Context, userId int) ([]entity.PaymentRequest, error)
+	PayRequest(ctx context.Context, input PaymentPayRequestInput) error
+	BatchTransfer(ctx context.Context, input PaymentBatchTransferInput) (PaymentBatchTransferOutput, error)
+	Mint(ctx context.Context, input PaymentMintInput) (PaymentMintOutput, error)
+	BuyItem(ctx context.Context, input PaymentBuyItemInput) (PaymentReceipt, error)
+}
+
+type CartAddItemInput struct {
+	UserId   int
+	ItemName string
+	Quantity int
+}
+
+type CartRemoveItemInput struct {
+	UserId   int
+	ItemName string
+}
+
+type CartLine struct {
+	ItemName string
+	Quantity int
+	Price    int
+	Total    int
+}
+
+type CartContents struct {
+	Lines []CartLine
+	Total int
+}
+
+type CartReceipt struct {
+	Id          int
+	Lines       []CartLine
+	Total       int
+	PurchasedAt time.Time
+}
+
+type Cart interface {
+	AddItem(ctx context.Context, input CartAddItemInput) error
+	RemoveItem(ctx context.Context, input CartRemoveItemInput) error
+	Get(ctx context.Context, userId int) (CartContents, error)
+	Checkout(ctx context.Context, userId int) (CartReceipt, error)
+}
+
+type ReturnRequestInput struct {
+	UserId int
+	// PurchaseId is the id on the purchase receipt.
+	PurchaseId int
+	ItemName   string
+	Quantity   int
+	Reason     string
+}
+
+type ReturnDecideInput struct {
+	Id      int
+	AdminId int
+}
+
+type Return interface {
+	Request(ctx context.Context, input ReturnRequestInput) (entity.Return, error)
+	List(ctx context.Context, userId int) ([]entity.Return, error)
+	ListByStatus(ctx context.Context, status string) ([]entity.Return, error)
+	Approve(ctx context.Context, input ReturnDecideInput) error
+	Reject(ctx context.Context, input ReturnDecideInput) error
+}
+
+type ItemRestockInput struct {
+	ItemName string
+	Quantity int
+}
+
+type ItemSetStockInput struct {
+	ItemName string
+	// Stock is nil to make the stock unlimited.
+	Stock *int
+}
+
+type Item interface {
+	Restock(ctx context.Context, input ItemRestockInput) (entity.Item, error)
+	SetStock(ctx context.Context, input ItemSetStockInput) (entity.Item, error)
+	LowStock(ctx context.Context, threshold int) ([]entity.Item, error)
+}
+
+type IdempotencyInput struct {
+	UserId int
+	Key    string
+	// Request identifies the call, the same key sent with another request is rejected.
+	Request []byte
+}
+
+type IdempotentResponse struct {
+	StatusCode int
+	Body       []byte
+	// Replayed is set when the response was saved by an earlier request with the same key.
+	Replayed bool
+}
+
+type Idempotency interface {
+	Do(ctx context.Context, input IdempotencyInput, fn func(ctx context.Context) (IdempotentResponse, error)) (IdempotentResponse, error)
+	DeleteExpiredKeys(ctx context.Context, scheduledAt time.Time) (string, error)
 }
 
 type UserReport interface {
@@ -42,22 +306,35 @@ type UserReport interface {
 
 type Services struct {
 	Auth
+	APIKey
 	Payment
+	Cart
+	Return
+	Item
 	UserReport
+	Idempotency
 }
 
 type Dependencies struct {
-	Repos      *repository.Repositories
-	Hasher     hasher.PasswordHasher
-	SignKey    string
-	TokenTTL   time.Duration
-	Transactor repository.Transactor
+	Repos             *repository.Repositories
+	Hasher            hasher.PasswordHasher
+	AuthConfig        AuthServiceConfig
+	PaymentConfig     PaymentServiceConfig
+	ReturnConfig      ReturnServiceConfig
+	IdempotencyConfig IdempotencyServiceConfig
+	Revocations       *TokenRevocationStore
+	Transactor        repository.Transactor
 }
 
 func NewServices(deps Dependencies) *Services {
 	return &Services{
-		Auth:       NewAuthService(deps.Repos.User, deps.Hasher, deps.SignKey, deps.TokenTTL),
-		Payment:    NewPaymentService(deps.Repos.User, deps.Repos.Item, deps.Repos.Operation, deps.Repos.Sale, deps.Transactor),
-		UserReport: NewUserReportService(deps.Repos.UserReport),
+		Auth:        NewAuthService(deps.Repos.User, deps.Repos.RefreshToken, deps.Repos.Invite, deps.Repos.PasswordReset, deps.Repos.LoginAttempt, deps.Revocations, deps.Hasher, deps.Transactor, deps.AuthConfig),
+		APIKey:      NewAPIKeyService(deps.Repos.User, deps.Repos.APIKey),
+		Payment:     NewPaymentService(deps.Repos.User, deps.Repos.Item, deps.Repos.Ledger, deps.Repos.Sale, deps.Repos.Purchase, deps.Repos.PendingTransfer, deps.Repos.PaymentRequest, deps.Transactor, deps.PaymentConfig),
+		Cart:        NewCartService(deps.Repos.Cart, deps.Repos.Item, deps.Repos.User, deps.Repos.Sale, deps.Repos.Ledger, deps.Repos.Purchase, deps.Transactor),
+		Return:      NewReturnService(deps.Repos.Purchase, deps.Repos.Return, deps.Repos.Sale, deps.Repos.Item, deps.Repos.User, deps.Repos.Ledger, deps.Transactor, deps.ReturnConfig),
+		Item:        NewItemService(deps.Repos.Item),
+		UserReport:  NewUserReportService(deps.Repos.UserReport),
+		Idempotency: NewIdempotencyService(deps.Repos.IdempotencyKey, deps.Transactor, deps.IdempotencyConfig),
 	}
 }
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spanwalla/merch-store/internal/entity"
+	"github.com/spanwalla/merch-store/internal/repository"
+	"sync"
+	"time"
+)
+
+// TokenRevocationStore is an in-process cache of revoked token ids and users in front of
+// the revoked_tokens and revoked_users tables. Revocations made by this instance are visible
+// immediately, revocations made by other instances are picked up on the next Sync.
+type TokenRevocationStore struct {
+	revokedTokenRepo repository.RevokedToken
+	revokedUserRepo  repository.RevokedUser
+
+	mu           sync.RWMutex
+	revoked      map[string]time.Time
+	revokedUsers map[int]entity.RevokedUser
+}
+
+func NewTokenRevocationStore(revokedTokenRepo repository.RevokedToken, revokedUserRepo repository.RevokedUser) *TokenRevocationStore {
+	return &TokenRevocationStore{
+		revokedTokenRepo: revokedTokenRepo,
+		revokedUserRepo:  revokedUserRepo,
+		revoked:          make(map[string]time.Time),
+		revokedUsers:     make(map[int]entity.RevokedUser),
+	}
+}
+
+// Revoke denies the token until its expiration time.
+func (s *TokenRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	err := s.revokedTokenRepo.Add(ctx, entity.RevokedToken{Jti: jti, ExpiresAt: expiresAt})
+	if err != nil {
+		return fmt.Errorf("TokenRevocationStore.Revoke - revokedTokenRepo.Add: %w", err)
+	}
+
+	s.mu.Lock()
+	s.revoked[jti] = expiresAt
+	s.mu.Unlock()
+
+	return nil
+}
+
+// RevokeUser denies all tokens of the user issued before revokedAt. The entry is kept
+// until expiresAt, after which all such tokens have expired on their own.
+func (s *TokenRevocationStore) RevokeUser(ctx context.Context, userId int, revokedAt, expiresAt time.Time) error {
+	user := entity.RevokedUser{UserId: userId, RevokedAt: revokedAt, ExpiresAt: expiresAt}
+	err := s.revokedUserRepo.Upsert(ctx, user)
+	if err != nil {
+		return fmt.Errorf("TokenRevocationStore.RevokeUser - revokedUserRepo.Upsert: %w", err)
+	}
+
+	s.mu.Lock()
+	s.revokedUsers[userId] = user
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *TokenRevocationStore) IsRevoked(jti string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	expiresAt, ok := s.revoked[jti]
+	return ok && time.Now().Before(expiresAt)
+}
+
+// IsUserRevoked reports whether a token of the user issued at issuedAt was revoked by RevokeUser.
+// JWT timestamps have a second precision, so tokens issued in the same second are kept valid.
+func (s *TokenRevocationStore) IsUserRevoked(userId int, issuedAt time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.revokedUsers[userId]
+	return ok && time.Now().Before(user.ExpiresAt) && issuedAt.Before(user.RevokedAt.Truncate(time.Second))
+}
+
+// Sync deletes expired entries from the table and replaces the cache with the active ones.
+func (s *TokenRevocationStore) Sync(ctx context.Context) error {
+	deleted, err := s.revokedTokenRepo.DeleteExpired(ctx)
+	if err != nil {
+		return fmt.Errorf("TokenRevocationStore.Sync - revokedTokenRepo.DeleteExpired: %w", err)
+	}
+	if deleted > 0 {
+		log.Debugf("TokenRevocationStore.Sync: %d expired entries deleted", deleted)
+	}
+
+	deleted, err = s.revokedUserRepo.DeleteExpired(ctx)
+	if err != nil {
+		return fmt.Errorf("TokenRevocationStore.Sync - revokedUserRepo.DeleteExpired: %w", err)
+	}
+	if deleted > 0 {
+		log.Debugf("TokenRevocationStore.Sync: %d expired user entries deleted", deleted)
+	}
+
+	tokens, err := s.revokedTokenRepo.GetActive(ctx)
+	if err != nil {
+		return fmt.Errorf("TokenRevocationStore.Sync - revokedTokenRepo.GetActive: %w", err)
+	}
+
+	users, err := s.revokedUserRepo.GetActive(ctx)
+	if err != nil {
+		return fmt.Errorf("TokenRevocationStore.Sync - revokedUserRepo.GetActive: %w", err)
+	}
+
+	revoked := make(map[string]time.Time, len(tokens))
+	for _, token := range tokens {
+		revoked[token.Jti] = token.ExpiresAt
+	}
+
+	revokedUsers := make(map[int]entity.RevokedUser, len(users))
+	for _, user := range users {
+		revokedUsers[user.UserId] = user
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Keep local entries added after GetActive was read, drop expired ones.
+	now := time.Now()
+	for jti, expiresAt := range s.revoked {
+		if now.Before(expiresAt) {
+			revoked[jti] = expiresAt
+		}
+	}
+	s.revoked = revoked
+
+	for userId, user := range s.revokedUsers {
+		if remote, ok := revokedUsers[userId]; now.Before(user.ExpiresAt) && (!ok || remote.RevokedAt.Before(user.RevokedAt)) {
+			revokedUsers[userId] = user
+		}
+	}
+	s.revokedUsers = revokedUsers
+
+	return nil
+}
+
+// Run calls Sync every interval until ctx is done.
+func (s *TokenRevocationStore) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Sync(ctx); err != nil {
+				log.Errorf("TokenRevocationStore.Run - Sync: %v", err)
+			}
+		}
+	}
+}
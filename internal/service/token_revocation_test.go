@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/spanwalla/merch-store/internal/entity"
+	repomocks "github.com/spanwalla/merch-store/internal/mocks/repository"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestTokenRevocationStore_Sync(t *testing.T) {
+	type MockBehavior func(r *repomocks.MockRevokedToken, u *repomocks.MockRevokedUser)
+
+	testCases := []struct {
+		name         string
+		mockBehavior MockBehavior
+		revoked      []string
+		notRevoked   []string
+		revokedUsers []int
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			mockBehavior: func(r *repomocks.MockRevokedToken, u *repomocks.MockRevokedUser) {
+				r.EXPECT().DeleteExpired(gomock.Any()).Return(int64(2), nil)
+				u.EXPECT().DeleteExpired(gomock.Any()).Return(int64(1), nil)
+				r.EXPECT().GetActive(gomock.Any()).Return([]entity.RevokedToken{
+					{Jti: "remote", ExpiresAt: time.Now().Add(time.Hour)},
+				}, nil)
+				u.EXPECT().GetActive(gomock.Any()).Return([]entity.RevokedUser{
+					{UserId: 2, RevokedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)},
+				}, nil)
+			},
+			revoked:      []string{"remote", "local"},
+			notRevoked:   []string{"expired"},
+			revokedUsers: []int{1, 2},
+			wantErr:      false,
+		},
+		{
+			name: "delete expired failed",
+			mockBehavior: func(r *repomocks.MockRevokedToken, u *repomocks.MockRevokedUser) {
+				r.EXPECT().DeleteExpired(gomock.Any()).Return(int64(0), errors.New("some error"))
+			},
+			revoked: []string{"local"},
+			wantErr: true,
+		},
+		{
+			name: "get active failed",
+			mockBehavior: func(r *repomocks.MockRevokedToken, u *repomocks.MockRevokedUser) {
+				r.EXPECT().DeleteExpired(gomock.Any()).Return(int64(0), nil)
+				u.EXPECT().DeleteExpired(gomock.Any()).Return(int64(0), nil)
+				r.EXPECT().GetActive(gomock.Any()).Return(nil, errors.New("some error"))
+			},
+			revoked: []string{"local"},
+			wantErr: true,
+		},
+		{
+			name: "get active users failed",
+			mockBehavior: func(r *repomocks.MockRevokedToken, u *repomocks.MockRevokedUser) {
+				r.EXPECT().DeleteExpired(gomock.Any()).Return(int64(0), nil)
+				u.EXPECT().DeleteExpired(gomock.Any()).Return(int64(0), nil)
+				r.EXPECT().GetActive(gomock.Any()).Return(nil, nil)
+				u.EXPECT().GetActive(gomock.Any()).Return(nil, errors.New("some error"))
+			},
+			revoked:      []string{"local"},
+			revokedUsers: []int{1},
+			wantErr:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			revokedTokenRepo := repomocks.NewMockRevokedToken(ctrl)
+			revokedTokenRepo.EXPECT().Add(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+			revokedUserRepo := repomocks.NewMockRevokedUser(ctrl)
+			revokedUserRepo.EXPECT().Upsert(gomock.Any(), gomock.Any()).Return(nil)
+			tc.mockBehavior(revokedTokenRepo, revokedUserRepo)
+
+			s := NewTokenRevocationStore(revokedTokenRepo, revokedUserRepo)
+			_ = s.Revoke(context.Background(), "local", time.Now().Add(time.Hour))
+			_ = s.Revoke(context.Background(), "expired", time.Now().Add(-time.Second))
+			_ = s.RevokeUser(context.Background(), 1, time.Now(), time.Now().Add(time.Hour))
+
+			err := s.Sync(context.Background())
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			for _, jti := range tc.revoked {
+				assert.True(t, s.IsRevoked(jti), jti)
+			}
+			for _, jti := range tc.notRevoked {
+				assert.False(t, s.IsRevoked(jti), jti)
+			}
+			for _, userId := range tc.revokedUsers {
+				assert.True(t, s.IsUserRevoked(userId, time.Now().Add(-time.Minute)), userId)
+			}
+		})
+	}
+}
+
+func TestTokenRevocationStore_IsUserRevoked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	revokedUserRepo := repomocks.NewMockRevokedUser(ctrl)
+	revokedUserRepo.EXPECT().Upsert(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	s := NewTokenRevocationStore(repomocks.NewMockRevokedToken(ctrl), revokedUserRepo)
+	revokedAt := time.Now()
+	_ = s.RevokeUser(context.Background(), 1, revokedAt, revokedAt.Add(time.Hour))
+	_ = s.RevokeUser(context.Background(), 2, revokedAt.Add(-2*time.Hour), revokedAt.Add(-time.Hour))
+
+	assert.True(t, s.IsUserRevoked(1, revokedAt.Add(-time.Minute)), "issued before revocation")
+	assert.False(t, s.IsUserRevoked(1, revokedAt.Add(time.Minute)), "issued after revocation")
+	assert.False(t, s.IsUserRevoked(2, revokedAt.Add(-3*time.Hour)), "expired entry")
+	assert.False(t, s.IsUserRevoked(3, revokedAt.Add(-time.Minute)), "unknown user")
+}
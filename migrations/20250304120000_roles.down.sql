@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS revoked_users;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
\ No newline at end of file
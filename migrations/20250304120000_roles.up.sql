@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN role VARCHAR(32) NOT NULL DEFAULT 'user';
+
+CREATE TABLE revoked_users(
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    revoked_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX revoked_users_expires_at_idx ON revoked_users(expires_at);
@@ -0,0 +1,6 @@
+CREATE TABLE login_attempts(
+    subject VARCHAR(128) PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_until TIMESTAMPTZ
+);
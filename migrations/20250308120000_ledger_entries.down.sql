@@ -0,0 +1,15 @@
+CREATE TABLE operations(
+    id SERIAL PRIMARY KEY,
+    sender_id INT NOT NULL REFERENCES users(id),
+    receiver_id INT NOT NULL REFERENCES users(id),
+    amount INT NOT NULL,
+    UNIQUE (sender_id, receiver_id)
+);
+
+INSERT INTO operations(sender_id, receiver_id, amount)
+SELECT sender_id, receiver_id, SUM(amount) FROM ledger_entries
+WHERE kind = 'transfer'
+GROUP BY sender_id, receiver_id;
+
+DROP TABLE IF EXISTS ledger_entries;
+DROP FUNCTION IF EXISTS ledger_entries_append_only();
\ No newline at end of file
@@ -0,0 +1,28 @@
+CREATE TABLE ledger_entries(
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    sender_id INT REFERENCES users(id),
+    receiver_id INT REFERENCES users(id),
+    amount INT NOT NULL CHECK (amount > 0),
+    kind VARCHAR(16) NOT NULL,
+    CHECK (sender_id IS NOT NULL OR receiver_id IS NOT NULL)
+);
+
+CREATE INDEX ledger_entries_sender_id_idx ON ledger_entries(sender_id, created_at);
+CREATE INDEX ledger_entries_receiver_id_idx ON ledger_entries(receiver_id, created_at);
+
+CREATE FUNCTION ledger_entries_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger_entries is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_entries_append_only
+    BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_entries_append_only();
+
+-- Individual transfers were not kept, so every aggregated row becomes one entry.
+INSERT INTO ledger_entries(sender_id, receiver_id, amount, kind)
+SELECT sender_id, receiver_id, amount, 'transfer' FROM operations WHERE amount > 0;
+
+DROP TABLE operations;
@@ -0,0 +1,6 @@
+CREATE TABLE cart_items(
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_id INT NOT NULL REFERENCES items(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (user_id, item_id)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS returns;
+DROP TABLE IF EXISTS purchases;
\ No newline at end of file
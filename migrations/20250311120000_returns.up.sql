@@ -0,0 +1,30 @@
+CREATE TABLE purchases(
+    id SERIAL PRIMARY KEY,
+    ledger_entry_id BIGINT NOT NULL REFERENCES ledger_entries(id),
+    user_id INT NOT NULL REFERENCES users(id),
+    item_id INT NOT NULL REFERENCES items(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    price INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (ledger_entry_id, item_id)
+);
+
+CREATE INDEX purchases_user_id_idx ON purchases(user_id);
+
+CREATE TABLE returns(
+    id SERIAL PRIMARY KEY,
+    purchase_id INT NOT NULL REFERENCES purchases(id),
+    user_id INT NOT NULL REFERENCES users(id),
+    item_id INT NOT NULL REFERENCES items(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    amount INT NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    decided_at TIMESTAMPTZ,
+    decided_by INT REFERENCES users(id)
+);
+
+CREATE INDEX returns_purchase_id_idx ON returns(purchase_id);
+CREATE INDEX returns_user_id_idx ON returns(user_id);
+CREATE INDEX returns_status_idx ON returns(status) WHERE status = 'pending';
@@ -0,0 +1,13 @@
+ALTER TABLE ledger_entries DISABLE TRIGGER ledger_entries_append_only;
+-- Minted coins and allowances are both sent by the system account, its user row cannot go while they reference it.
+DELETE FROM ledger_entries
+WHERE sender_id IN (SELECT id FROM users WHERE role = 'system')
+   OR receiver_id IN (SELECT id FROM users WHERE role = 'system');
+ALTER TABLE ledger_entries ENABLE TRIGGER ledger_entries_append_only;
+
+DROP INDEX IF EXISTS users_system_role_idx;
+DELETE FROM users WHERE role = 'system';
+
+ALTER TABLE ledger_entries
+    DROP COLUMN created_by,
+    DROP COLUMN reason;
\ No newline at end of file
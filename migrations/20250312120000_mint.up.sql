@@ -0,0 +1,10 @@
+ALTER TABLE ledger_entries
+    ADD COLUMN reason VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN created_by INT REFERENCES users(id);
+
+-- Minted coins are sent by this account. It has no password and cannot log in. A user who already
+-- has the name keeps it, the account is found by its role.
+INSERT INTO users(name, password, balance, role)
+VALUES (CASE WHEN EXISTS (SELECT 1 FROM users WHERE name = 'system') THEN '~system' ELSE 'system' END, '', 0, 'system');
+
+CREATE UNIQUE INDEX users_system_role_idx ON users (role) WHERE role = 'system';
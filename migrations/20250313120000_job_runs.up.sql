@@ -0,0 +1,11 @@
+CREATE TABLE job_runs(
+    id BIGSERIAL PRIMARY KEY,
+    job VARCHAR(64) NOT NULL,
+    scheduled_at TIMESTAMPTZ NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ,
+    status VARCHAR(16) NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    -- Every scheduled run is done by one replica only.
+    UNIQUE (job, scheduled_at)
+);
@@ -0,0 +1,14 @@
+CREATE TABLE pending_transfers(
+    id SERIAL PRIMARY KEY,
+    sender_id INT NOT NULL REFERENCES users(id),
+    receiver_id INT NOT NULL REFERENCES users(id),
+    amount INT NOT NULL CHECK (amount > 0),
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    resolved_at TIMESTAMPTZ
+);
+
+CREATE INDEX pending_transfers_sender_id_idx ON pending_transfers(sender_id);
+CREATE INDEX pending_transfers_receiver_id_idx ON pending_transfers(receiver_id);
+CREATE INDEX pending_transfers_expires_at_idx ON pending_transfers(expires_at) WHERE status = 'pending';
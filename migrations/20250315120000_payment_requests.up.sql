@@ -0,0 +1,13 @@
+CREATE TABLE payment_requests(
+    id SERIAL PRIMARY KEY,
+    requester_id INT NOT NULL REFERENCES users(id),
+    payer_id INT NOT NULL REFERENCES users(id),
+    amount INT NOT NULL CHECK (amount > 0),
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    paid_at TIMESTAMPTZ
+);
+
+CREATE INDEX payment_requests_requester_id_idx ON payment_requests(requester_id);
+CREATE INDEX payment_requests_payer_id_idx ON payment_requests(payer_id);
@@ -0,0 +1 @@
+ALTER TABLE items DROP COLUMN stock;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- NULL stock means the item is not limited, so the items on sale stay unlimited until an admin sets their stock.
+ALTER TABLE items ADD COLUMN stock INT CHECK (stock >= 0);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idempotency_keys_expires_at_idx;
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS expires_at;
\ No newline at end of file
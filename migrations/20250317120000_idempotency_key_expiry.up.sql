@@ -0,0 +1,4 @@
+-- Keys saved before the column existed are kept for a day more, new keys get expires_at from the service.
+ALTER TABLE idempotency_keys ADD COLUMN expires_at TIMESTAMPTZ NOT NULL DEFAULT now() + INTERVAL '24 hours';
+ALTER TABLE idempotency_keys ALTER COLUMN expires_at DROP DEFAULT;
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys(expires_at);
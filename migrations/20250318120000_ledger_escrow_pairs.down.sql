@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS ledger_entries_pending_transfer_id_idx;
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS pending_transfer_id;
\ No newline at end of file
@@ -0,0 +1,29 @@
+-- Escrow entries of a pending transfer share its id, so the hold is paired with the release or the
+-- refund and the ledger shows who sent the released coins to whom.
+ALTER TABLE ledger_entries ADD COLUMN pending_transfer_id INT REFERENCES pending_transfers(id);
+
+CREATE INDEX ledger_entries_pending_transfer_id_idx ON ledger_entries(pending_transfer_id) WHERE pending_transfer_id IS NOT NULL;
+
+-- Existing escrow entries were written in the transaction that created or resolved the transfer, so they
+-- match it by user, amount and time. Entries that match several transfers are paired in id order.
+ALTER TABLE ledger_entries DISABLE TRIGGER ledger_entries_append_only;
+WITH transfers AS (
+    SELECT id, 'escrow_hold' AS kind, sender_id AS user_id, amount, created_at AS at FROM pending_transfers
+    UNION ALL
+    SELECT id, 'escrow_release', receiver_id, amount, resolved_at FROM pending_transfers WHERE status = 'accepted'
+    UNION ALL
+    SELECT id, 'escrow_refund', sender_id, amount, resolved_at FROM pending_transfers WHERE status IN ('declined', 'expired')
+), numbered_transfers AS (
+    SELECT *, row_number() OVER (PARTITION BY kind, user_id, amount, at ORDER BY id) AS n FROM transfers
+), numbered_entries AS (
+    SELECT id, kind, COALESCE(sender_id, receiver_id) AS user_id, amount, created_at AS at,
+           row_number() OVER (PARTITION BY kind, COALESCE(sender_id, receiver_id), amount, created_at ORDER BY id) AS n
+    FROM ledger_entries
+    WHERE kind IN ('escrow_hold', 'escrow_release', 'escrow_refund')
+)
+UPDATE ledger_entries e
+SET pending_transfer_id = t.id
+FROM numbered_entries l
+JOIN numbered_transfers t ON (t.kind, t.user_id, t.amount, t.at, t.n) = (l.kind, l.user_id, l.amount, l.at, l.n)
+WHERE e.id = l.id;
+ALTER TABLE ledger_entries ENABLE TRIGGER ledger_entries_append_only;
@@ -0,0 +1,182 @@
+// Package cron parses the standard five field cron expressions: minute, hour, day of month, month and day of week.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLookahead bounds Next for expressions that never match, such as "0 0 30 2 *".
+const maxLookahead = 5 * 366 * 24 * time.Hour
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type bounds struct {
+	min, max int
+}
+
+var (
+	minutes     = bounds{0, 59}
+	hours       = bounds{0, 23}
+	daysOfMonth = bounds{1, 31}
+	months      = bounds{1, 12}
+	// Both 0 and 7 are Sunday.
+	daysOfWeek = bounds{0, 7}
+)
+
+// Schedule keeps the allowed values of every field as a bit set.
+type Schedule struct {
+	spec                          string
+	minute, hour, dom, month, dow uint64
+	// Like in cron, a day matches either field when both are restricted.
+	domStar, dowStar bool
+}
+
+// Parse parses an expression like "0 0 1 * *" or a descriptor like "@monthly". Fields support
+// "*", lists, ranges and steps, e.g. "1,15", "9-17" and "*/10". Names of months and days are not supported.
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if expr, ok := descriptors[spec]; ok {
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron - Parse: expected 5 fields, got %d in %q", len(fields), spec)
+	}
+
+	s := Schedule{spec: spec}
+	var err error
+	for i, field := range []struct {
+		set *uint64
+		b   bounds
+	}{
+		{&s.minute, minutes},
+		{&s.hour, hours},
+		{&s.dom, daysOfMonth},
+		{&s.month, months},
+		{&s.dow, daysOfWeek},
+	} {
+		*field.set, err = parseField(fields[i], field.b)
+		if err != nil {
+			return nil, fmt.Errorf("cron - Parse: field %d of %q: %w", i+1, spec, err)
+		}
+	}
+
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+
+	return &s, nil
+}
+
+func parseField(field string, b bounds) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+		}
+
+		low, high := b.min, b.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			lowPart, highPart, _ := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = parseValue(lowPart, b); err != nil {
+				return 0, err
+			}
+			if high, err = parseValue(highPart, b); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			var err error
+			if low, err = parseValue(rangePart, b); err != nil {
+				return 0, err
+			}
+			// "5/15" means from 5 to the end with step 15.
+			if !hasStep {
+				high = low
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			set |= 1 << v
+		}
+	}
+
+	return set, nil
+}
+
+func parseValue(value string, b bounds) (int, error) {
+	v, err := strconv.Atoi(value)
+	if err != nil || v < b.min || v > b.max {
+		return 0, fmt.Errorf("value %q is out of range %d-%d", value, b.min, b.max)
+	}
+	return v, nil
+}
+
+// Next returns the first time after t that matches the schedule, in the location of t.
+// It returns the zero time if the schedule matches nothing within five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxLookahead)
+
+	for t.Before(limit) {
+		switch {
+		case !has(s.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !has(s.hour, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !has(s.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) matchDay(t time.Time) bool {
+	dom := has(s.dom, t.Day())
+	dow := has(s.dow, int(t.Weekday()))
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<v) != 0
+}
+
+// String returns the expression the schedule was parsed from.
+func (s *Schedule) String() string {
+	return s.spec
+}
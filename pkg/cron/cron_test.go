@@ -0,0 +1,250 @@
+package cron
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func setOf(values ...int) uint64 {
+	var set uint64
+	for _, v := range values {
+		set |= 1 << v
+	}
+	return set
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		spec     string
+		wantSpec string
+		check    func(t *testing.T, s *Schedule)
+	}{
+		{
+			name:     "every minute",
+			spec:     "* * * * *",
+			wantSpec: "* * * * *",
+			check: func(t *testing.T, s *Schedule) {
+				assert.Equal(t, setOf(0, 1, 58, 59), s.minute&setOf(0, 1, 58, 59))
+				assert.False(t, has(s.minute, 60))
+				assert.True(t, s.domStar)
+				assert.True(t, s.dowStar)
+			},
+		},
+		{
+			name:     "list",
+			spec:     "0 0 1,15 * *",
+			wantSpec: "0 0 1,15 * *",
+			check: func(t *testing.T, s *Schedule) {
+				assert.Equal(t, setOf(0), s.minute)
+				assert.Equal(t, setOf(0), s.hour)
+				assert.Equal(t, setOf(1, 15), s.dom)
+				assert.False(t, s.domStar)
+			},
+		},
+		{
+			name:     "range",
+			spec:     "0 9-17 * * 1-5",
+			wantSpec: "0 9-17 * * 1-5",
+			check: func(t *testing.T, s *Schedule) {
+				assert.Equal(t, setOf(9, 10, 11, 12, 13, 14, 15, 16, 17), s.hour)
+				assert.Equal(t, setOf(1, 2, 3, 4, 5), s.dow)
+			},
+		},
+		{
+			name:     "step over all values",
+			spec:     "*/15 * * * *",
+			wantSpec: "*/15 * * * *",
+			check: func(t *testing.T, s *Schedule) {
+				assert.Equal(t, setOf(0, 15, 30, 45), s.minute)
+			},
+		},
+		{
+			name:     "step over range",
+			spec:     "10-20/5 * * * *",
+			wantSpec: "10-20/5 * * * *",
+			check: func(t *testing.T, s *Schedule) {
+				assert.Equal(t, setOf(10, 15, 20), s.minute)
+			},
+		},
+		{
+			name:     "step from value",
+			spec:     "5/20 * * * *",
+			wantSpec: "5/20 * * * *",
+			check: func(t *testing.T, s *Schedule) {
+				assert.Equal(t, setOf(5, 25, 45), s.minute)
+			},
+		},
+		{
+			name:     "sunday as 7",
+			spec:     "0 0 * * 7",
+			wantSpec: "0 0 * * 7",
+			check: func(t *testing.T, s *Schedule) {
+				assert.True(t, has(s.dow, 0))
+			},
+		},
+		{
+			name:     "descriptor",
+			spec:     "@monthly",
+			wantSpec: "0 0 1 * *",
+			check: func(t *testing.T, s *Schedule) {
+				assert.Equal(t, setOf(1), s.dom)
+				assert.Equal(t, setOf(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12), s.month)
+			},
+		},
+		{
+			name:     "surrounding spaces",
+			spec:     "  0 1 1 * *\n",
+			wantSpec: "0 1 1 * *",
+			check: func(t *testing.T, s *Schedule) {
+				assert.Equal(t, setOf(1), s.hour)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := Parse(tc.spec)
+			assert.NoError(t, err)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, tc.wantSpec, s.String())
+			tc.check(t, s)
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		spec string
+	}{
+		{name: "empty", spec: ""},
+		{name: "too few fields", spec: "0 0 1 *"},
+		{name: "too many fields", spec: "0 0 1 * * 2025"},
+		{name: "unknown descriptor", spec: "@fortnightly"},
+		{name: "minute out of range", spec: "60 * * * *"},
+		{name: "hour out of range", spec: "0 24 * * *"},
+		{name: "day of month zero", spec: "0 0 0 * *"},
+		{name: "month out of range", spec: "0 0 1 13 *"},
+		{name: "day of week out of range", spec: "0 0 * * 8"},
+		{name: "negative value", spec: "-1 * * * *"},
+		{name: "range end out of range", spec: "0 20-25 * * *"},
+		{name: "inverted range", spec: "0 17-9 * * *"},
+		{name: "zero step", spec: "*/0 * * * *"},
+		{name: "non-numeric step", spec: "*/x * * * *"},
+		{name: "names are not supported", spec: "0 0 * jan mon"},
+		{name: "empty list item", spec: "0 0 1, * *"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := Parse(tc.spec)
+			assert.Error(t, err)
+			assert.Nil(t, s)
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	testCases := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "next month",
+			spec: "0 0 1 * *",
+			from: time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC),
+			want: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "strictly after a matching time",
+			spec: "*/10 * * * *",
+			from: time.Date(2025, 3, 15, 12, 10, 0, 0, time.UTC),
+			want: time.Date(2025, 3, 15, 12, 20, 0, 0, time.UTC),
+		},
+		{
+			name: "seconds are dropped",
+			spec: "*/10 * * * *",
+			from: time.Date(2025, 3, 15, 12, 5, 30, 0, time.UTC),
+			want: time.Date(2025, 3, 15, 12, 10, 0, 0, time.UTC),
+		},
+		{
+			name: "next hour",
+			spec: "30 * * * *",
+			from: time.Date(2025, 3, 15, 12, 45, 0, 0, time.UTC),
+			want: time.Date(2025, 3, 15, 13, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "next year",
+			spec: "0 0 1 1 *",
+			from: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "skips short months",
+			spec: "0 0 31 * *",
+			from: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "leap day",
+			spec: "0 0 29 2 *",
+			from: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "day of week",
+			spec: "30 9 * * 1-5",
+			from: time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC), // Friday
+			want: time.Date(2025, 3, 17, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "sunday as 7",
+			spec: "30 9 * * 7",
+			from: time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC), // Wednesday
+			want: time.Date(2025, 3, 16, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "day of month or day of week",
+			spec: "0 0 13 * 5",
+			from: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), // Saturday
+			want: time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "location of the time",
+			spec: "0 9 * * *",
+			from: time.Date(2025, 3, 12, 10, 0, 0, 0, moscow),
+			want: time.Date(2025, 3, 13, 9, 0, 0, 0, moscow),
+		},
+		{
+			name: "never matches",
+			spec: "0 0 30 2 *",
+			from: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Time{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := Parse(tc.spec)
+			assert.NoError(t, err)
+			if err != nil {
+				return
+			}
+
+			got := s.Next(tc.from)
+			assert.True(t, tc.want.Equal(got), "want %v, got %v", tc.want, got)
+			if !got.IsZero() {
+				assert.Equal(t, tc.from.Location(), got.Location())
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package hasher
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// testArgon2idParams keep the tests fast, the defaults take tens of milliseconds per hash.
+var testArgon2idParams = Argon2idParams{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestArgon2idHasher_HashVerify(t *testing.T) {
+	h := NewArgon2idHasher(testArgon2idParams)
+
+	hash, err := h.Hash("simplePa66!")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	assert.True(t, h.Identify(hash))
+
+	ok, err := h.Verify("simplePa66!", hash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = h.Verify("simplePa66?", hash)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	other, err := h.Hash("simplePa66!")
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, other, "every hash gets its own salt")
+}
+
+func TestArgon2idHasher_VerifyMalformed(t *testing.T) {
+	h := NewArgon2idHasher(testArgon2idParams)
+
+	testCases := []struct {
+		name string
+		hash string
+	}{
+		{name: "empty", hash: ""},
+		{name: "other algorithm", hash: "$2a$10$abcdefghijklmnopqrstuv"},
+		{name: "wrong version", hash: "$argon2id$v=16$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5"},
+		{name: "bad params", hash: "$argon2id$v=19$m=x,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5"},
+		{name: "bad salt", hash: "$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5"},
+		{name: "bad key", hash: "$argon2id$v=19$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$!!!"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := h.Verify("simplePa66!", tc.hash)
+			assert.ErrorIs(t, err, ErrUnknownHashFormat)
+			assert.False(t, ok)
+			assert.True(t, h.NeedsRehash(tc.hash))
+		})
+	}
+}
+
+func TestArgon2idHasher_NeedsRehash(t *testing.T) {
+	hash, err := NewArgon2idHasher(testArgon2idParams).Hash("simplePa66!")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		modify func(p *Argon2idParams)
+		want   bool
+	}{
+		{name: "same params", modify: func(p *Argon2idParams) {}, want: false},
+		{name: "memory", modify: func(p *Argon2idParams) { p.Memory *= 2 }, want: true},
+		{name: "iterations", modify: func(p *Argon2idParams) { p.Iterations++ }, want: true},
+		{name: "parallelism", modify: func(p *Argon2idParams) { p.Parallelism++ }, want: true},
+		{name: "salt length", modify: func(p *Argon2idParams) { p.SaltLength = 32 }, want: true},
+		{name: "key length", modify: func(p *Argon2idParams) { p.KeyLength = 64 }, want: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			params := testArgon2idParams
+			tc.modify(&params)
+
+			h := NewArgon2idHasher(params)
+			assert.Equal(t, tc.want, h.NeedsRehash(hash))
+
+			// Hashes with other params still verify until they are replaced.
+			ok, err := h.Verify("simplePa66!", hash)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+		})
+	}
+}
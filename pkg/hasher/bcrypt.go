@@ -0,0 +1,58 @@
+package hasher
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+// BcryptMaxPasswordBytes is the longest password bcrypt accepts.
+const BcryptMaxPasswordBytes = 72
+
+// BcryptHasher -.
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher -.
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &BcryptHasher{cost: cost}
+}
+
+// Hash -.
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", fmt.Errorf("BcryptHasher.Hash - bcrypt.GenerateFromPassword: %w", err)
+	}
+	return string(hash), nil
+}
+
+// Verify -.
+func (h *BcryptHasher) Verify(password, encodedHash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return false, fmt.Errorf("BcryptHasher.Verify - bcrypt.CompareHashAndPassword: %w", err)
+	}
+	return true, nil
+}
+
+// NeedsRehash -.
+func (h *BcryptHasher) NeedsRehash(encodedHash string) bool {
+	cost, err := bcrypt.Cost([]byte(encodedHash))
+	return err != nil || cost != h.cost
+}
+
+// Identify -.
+func (h *BcryptHasher) Identify(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$2a$") ||
+		strings.HasPrefix(encodedHash, "$2b$") ||
+		strings.HasPrefix(encodedHash, "$2y$")
+}
@@ -0,0 +1,50 @@
+package hasher
+
+import (
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"testing"
+)
+
+func TestBcryptHasher_HashVerify(t *testing.T) {
+	h := NewBcryptHasher(bcrypt.MinCost)
+
+	hash, err := h.Hash("simplePa66!")
+	assert.NoError(t, err)
+	assert.True(t, h.Identify(hash))
+	assert.False(t, h.NeedsRehash(hash))
+
+	ok, err := h.Verify("simplePa66!", hash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = h.Verify("simplePa66?", hash)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = h.Verify("simplePa66!", "$2a$04$malformed")
+	assert.Error(t, err)
+	assert.False(t, ok)
+
+	// The password policy keeps passwords within this limit when bcrypt is the primary algorithm.
+	_, err = h.Hash(strings.Repeat("a", BcryptMaxPasswordBytes+1))
+	assert.Error(t, err)
+	_, err = h.Hash(strings.Repeat("a", BcryptMaxPasswordBytes))
+	assert.NoError(t, err)
+}
+
+func TestBcryptHasher_NeedsRehash(t *testing.T) {
+	hash, err := NewBcryptHasher(bcrypt.MinCost).Hash("simplePa66!")
+	assert.NoError(t, err)
+
+	assert.False(t, NewBcryptHasher(bcrypt.MinCost).NeedsRehash(hash))
+	assert.True(t, NewBcryptHasher(bcrypt.MinCost+1).NeedsRehash(hash))
+	assert.True(t, NewBcryptHasher(bcrypt.MinCost).NeedsRehash("not a bcrypt hash"))
+}
+
+func TestNewBcryptHasher_InvalidCost(t *testing.T) {
+	assert.Equal(t, bcrypt.DefaultCost, NewBcryptHasher(0).cost)
+	assert.Equal(t, bcrypt.DefaultCost, NewBcryptHasher(bcrypt.MaxCost+1).cost)
+	assert.Equal(t, bcrypt.MinCost, NewBcryptHasher(bcrypt.MinCost).cost)
+}
@@ -1,25 +1,65 @@
 package hasher
 
-import (
-	"crypto/sha1"
-	"fmt"
-)
+import "errors"
 
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// PasswordHasher -.
 type PasswordHasher interface {
-	Hash(password string) string
+	Hash(password string) (string, error)
+	Verify(password, encodedHash string) (bool, error)
+	NeedsRehash(encodedHash string) bool
+}
+
+// Algorithm is a PasswordHasher that can recognize its own hashes.
+type Algorithm interface {
+	PasswordHasher
+	Identify(encodedHash string) bool
+}
+
+// VersionedHasher hashes new passwords with the primary algorithm and verifies
+// hashes produced by the primary or any of the legacy algorithms.
+type VersionedHasher struct {
+	primary Algorithm
+	legacy  []Algorithm
+}
+
+// NewVersionedHasher -.
+func NewVersionedHasher(primary Algorithm, legacy ...Algorithm) *VersionedHasher {
+	return &VersionedHasher{primary: primary, legacy: legacy}
 }
 
-type SHA1Hasher struct {
-	salt string
+// Hash -.
+func (h *VersionedHasher) Hash(password string) (string, error) {
+	return h.primary.Hash(password)
 }
 
-func NewSHA1Hasher(salt string) *SHA1Hasher {
-	return &SHA1Hasher{salt: salt}
+// Verify -.
+func (h *VersionedHasher) Verify(password, encodedHash string) (bool, error) {
+	algorithm, ok := h.identify(encodedHash)
+	if !ok {
+		return false, ErrUnknownHashFormat
+	}
+	return algorithm.Verify(password, encodedHash)
 }
 
-func (h *SHA1Hasher) Hash(password string) string {
-	hash := sha1.New()
-	hash.Write([]byte(password))
+// NeedsRehash reports whether the hash was produced by a legacy algorithm
+// or by the primary one with outdated parameters.
+func (h *VersionedHasher) NeedsRehash(encodedHash string) bool {
+	if h.primary.Identify(encodedHash) {
+		return h.primary.NeedsRehash(encodedHash)
+	}
+	return true
+}
 
-	return fmt.Sprintf("%x", hash.Sum([]byte(h.salt)))
+func (h *VersionedHasher) identify(encodedHash string) (Algorithm, bool) {
+	if h.primary.Identify(encodedHash) {
+		return h.primary, true
+	}
+	for _, algorithm := range h.legacy {
+		if algorithm.Identify(encodedHash) {
+			return algorithm, true
+		}
+	}
+	return nil, false
 }
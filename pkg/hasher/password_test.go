@@ -0,0 +1,102 @@
+package hasher
+
+import (
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"testing"
+)
+
+const testSalt = "test_salt"
+
+func TestVersionedHasher_RoundTrip(t *testing.T) {
+	testCases := []struct {
+		name    string
+		primary Algorithm
+	}{
+		{name: "argon2id", primary: NewArgon2idHasher(testArgon2idParams)},
+		{name: "bcrypt", primary: NewBcryptHasher(bcrypt.MinCost)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewVersionedHasher(tc.primary, NewSHA1Hasher(testSalt))
+
+			hash, err := h.Hash("simplePa66!")
+			assert.NoError(t, err)
+			assert.True(t, tc.primary.Identify(hash))
+			assert.False(t, h.NeedsRehash(hash))
+
+			ok, err := h.Verify("simplePa66!", hash)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = h.Verify("wrongPa66!", hash)
+			assert.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestVersionedHasher_RehashLegacy(t *testing.T) {
+	legacyHash, err := NewSHA1Hasher(testSalt).Hash("simplePa66!")
+	assert.NoError(t, err)
+	bcryptHash, err := NewBcryptHasher(bcrypt.MinCost).Hash("simplePa66!")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name string
+		hash string
+	}{
+		{name: "sha1", hash: legacyHash},
+		{name: "bcrypt", hash: bcryptHash},
+	}
+
+	primary := NewArgon2idHasher(testArgon2idParams)
+	h := NewVersionedHasher(primary, NewBcryptHasher(bcrypt.MinCost), NewSHA1Hasher(testSalt))
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := h.Verify("wrongPa66!", tc.hash)
+			assert.NoError(t, err)
+			assert.False(t, ok)
+
+			// This is what login does: verify with the legacy algorithm, then store a new hash.
+			ok, err = h.Verify("simplePa66!", tc.hash)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.True(t, h.NeedsRehash(tc.hash))
+
+			newHash, err := h.Hash("simplePa66!")
+			assert.NoError(t, err)
+			assert.True(t, primary.Identify(newHash))
+			assert.False(t, h.NeedsRehash(newHash))
+
+			ok, err = h.Verify("simplePa66!", newHash)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+		})
+	}
+}
+
+func TestVersionedHasher_OutdatedParams(t *testing.T) {
+	oldHash, err := NewArgon2idHasher(testArgon2idParams).Hash("simplePa66!")
+	assert.NoError(t, err)
+
+	params := testArgon2idParams
+	params.Iterations++
+	h := NewVersionedHasher(NewArgon2idHasher(params))
+
+	ok, err := h.Verify("simplePa66!", oldHash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, h.NeedsRehash(oldHash))
+}
+
+func TestVersionedHasher_UnknownFormat(t *testing.T) {
+	h := NewVersionedHasher(NewArgon2idHasher(testArgon2idParams), NewSHA1Hasher(testSalt))
+
+	ok, err := h.Verify("simplePa66!", "plaintext")
+	assert.ErrorIs(t, err, ErrUnknownHashFormat)
+	assert.False(t, ok)
+	assert.True(t, h.NeedsRehash("plaintext"))
+}
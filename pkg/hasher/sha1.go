@@ -0,0 +1,41 @@
+package hasher
+
+import (
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// SHA1Hasher is the legacy algorithm. It is kept only to verify hashes
+// stored before the migration to argon2id/bcrypt.
+type SHA1Hasher struct {
+	salt string
+}
+
+func NewSHA1Hasher(salt string) *SHA1Hasher {
+	return &SHA1Hasher{salt: salt}
+}
+
+func (h *SHA1Hasher) Hash(password string) (string, error) {
+	hash := sha1.New()
+	hash.Write([]byte(password))
+
+	return fmt.Sprintf("%x", hash.Sum([]byte(h.salt))), nil
+}
+
+func (h *SHA1Hasher) Verify(password, encodedHash string) (bool, error) {
+	hash, _ := h.Hash(password)
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(encodedHash)) == 1, nil
+}
+
+func (h *SHA1Hasher) NeedsRehash(_ string) bool {
+	return true
+}
+
+// Identify recognizes hex(salt) followed by hex(sha1(password)).
+func (h *SHA1Hasher) Identify(encodedHash string) bool {
+	prefix := hex.EncodeToString([]byte(h.salt))
+	return len(encodedHash) == len(prefix)+2*sha1.Size && strings.HasPrefix(encodedHash, prefix)
+}
@@ -0,0 +1,120 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const minRSAKeyBits = 2048
+
+var (
+	ErrInvalidPEM     = errors.New("invalid PEM data")
+	ErrUnsupportedKey = errors.New("unsupported key type")
+	ErrWeakKey        = errors.New("RSA key must be at least 2048 bits")
+)
+
+// Key is a signing key identified by kid. PrivateKey is nil for keys that are
+// only used to verify tokens, e.g. a retired key during rotation.
+type Key struct {
+	Id         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.PrivateKey
+	PublicKey  crypto.PublicKey
+}
+
+// ParsePEM accepts PKCS#8 and PKCS#1 private keys and PKIX and PKCS#1 public keys, RSA or Ed25519.
+func ParsePEM(id string, data []byte) (Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, ErrInvalidPEM
+	}
+
+	var (
+		parsed any
+		err    error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return Key{}, fmt.Errorf("%w: PEM block %q", ErrUnsupportedKey, block.Type)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("jwks - ParsePEM: %w", err)
+	}
+
+	return newKey(id, parsed)
+}
+
+// GenerateEd25519 -.
+func GenerateEd25519(id string) (Key, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return Key{}, fmt.Errorf("jwks - GenerateEd25519 - ed25519.GenerateKey: %w", err)
+	}
+	return newKey(id, privateKey)
+}
+
+// LoadDir reads every *.pem file in dir, the file name without extension is used as kid.
+func LoadDir(dir string) ([]Key, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("jwks - LoadDir - filepath.Glob: %w", err)
+	}
+	sort.Strings(paths)
+
+	keys := make([]Key, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("jwks - LoadDir - os.ReadFile: %w", err)
+		}
+
+		id := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		key, err := ParsePEM(id, data)
+		if err != nil {
+			return nil, fmt.Errorf("jwks - LoadDir - %s: %w", filepath.Base(path), err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func newKey(id string, parsed any) (Key, error) {
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return Key{}, ErrWeakKey
+		}
+		return Key{Id: id, Method: jwt.SigningMethodRS256, PrivateKey: k, PublicKey: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return Key{}, ErrWeakKey
+		}
+		return Key{Id: id, Method: jwt.SigningMethodRS256, PublicKey: k}, nil
+	case ed25519.PrivateKey:
+		return Key{Id: id, Method: jwt.SigningMethodEdDSA, PrivateKey: k, PublicKey: k.Public()}, nil
+	case ed25519.PublicKey:
+		return Key{Id: id, Method: jwt.SigningMethodEdDSA, PublicKey: k}, nil
+	default:
+		return Key{}, fmt.Errorf("%w: %T", ErrUnsupportedKey, parsed)
+	}
+}
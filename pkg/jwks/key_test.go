@@ -0,0 +1,175 @@
+package jwks
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func encodePEM(t *testing.T, blockType string, der []byte, err error) []byte {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+func TestParsePEM(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weakRSAKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublicKey, edPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkcs8 := func(key any) []byte {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		return encodePEM(t, "PRIVATE KEY", der, err)
+	}
+	pkix := func(key any) []byte {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		return encodePEM(t, "PUBLIC KEY", der, err)
+	}
+
+	testCases := []struct {
+		name        string
+		data        []byte
+		wantMethod  jwt.SigningMethod
+		wantPrivate bool
+		wantErr     error
+	}{
+		{
+			name:        "ed25519 private key",
+			data:        pkcs8(edPrivateKey),
+			wantMethod:  jwt.SigningMethodEdDSA,
+			wantPrivate: true,
+		},
+		{
+			name:       "ed25519 public key",
+			data:       pkix(edPublicKey),
+			wantMethod: jwt.SigningMethodEdDSA,
+		},
+		{
+			name:        "rsa pkcs8 private key",
+			data:        pkcs8(rsaKey),
+			wantMethod:  jwt.SigningMethodRS256,
+			wantPrivate: true,
+		},
+		{
+			name:        "rsa pkcs1 private key",
+			data:        encodePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey), nil),
+			wantMethod:  jwt.SigningMethodRS256,
+			wantPrivate: true,
+		},
+		{
+			name:       "rsa pkix public key",
+			data:       pkix(&rsaKey.PublicKey),
+			wantMethod: jwt.SigningMethodRS256,
+		},
+		{
+			name:       "rsa pkcs1 public key",
+			data:       encodePEM(t, "RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey), nil),
+			wantMethod: jwt.SigningMethodRS256,
+		},
+		{
+			name:    "weak rsa private key",
+			data:    pkcs8(weakRSAKey),
+			wantErr: ErrWeakKey,
+		},
+		{
+			name:    "weak rsa public key",
+			data:    pkix(&weakRSAKey.PublicKey),
+			wantErr: ErrWeakKey,
+		},
+		{
+			name:    "ecdsa key",
+			data:    pkcs8(ecKey),
+			wantErr: ErrUnsupportedKey,
+		},
+		{
+			name:    "unsupported block",
+			data:    encodePEM(t, "CERTIFICATE", []byte("certificate"), nil),
+			wantErr: ErrUnsupportedKey,
+		},
+		{
+			name:    "not pem",
+			data:    []byte("not a key"),
+			wantErr: ErrInvalidPEM,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := ParsePEM("2025-03", tc.data)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "2025-03", key.Id)
+			assert.Equal(t, tc.wantMethod, key.Method)
+			assert.NotNil(t, key.PublicKey)
+			assert.Equal(t, tc.wantPrivate, key.PrivateKey != nil)
+		})
+	}
+
+	t.Run("malformed key", func(t *testing.T) {
+		_, err := ParsePEM("2025-03", encodePEM(t, "PRIVATE KEY", []byte("garbage"), nil))
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, id := range []string{"2025-03", "2025-01"} {
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		if err = os.WriteFile(filepath.Join(dir, id+".pem"), encodePEM(t, "PRIVATE KEY", der, err), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := LoadDir(dir)
+	assert.NoError(t, err)
+	if assert.Len(t, keys, 2) {
+		assert.Equal(t, "2025-01", keys[0].Id)
+		assert.Equal(t, "2025-03", keys[1].Id)
+	}
+
+	empty, err := LoadDir(t.TempDir())
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+
+	if err = os.WriteFile(filepath.Join(dir, "broken.pem"), []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadDir(dir)
+	assert.ErrorIs(t, err, ErrInvalidPEM)
+}
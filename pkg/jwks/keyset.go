@@ -0,0 +1,109 @@
+package jwks
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+var (
+	ErrNoSigningKey = errors.New("signing key not found")
+	ErrDuplicateKey = errors.New("duplicate key id")
+)
+
+// KeySet signs with a single key and verifies with all of them, so a new key
+// can be rolled out while tokens signed by the previous one are still valid.
+type KeySet struct {
+	signing Key
+	keys    map[string]Key
+}
+
+// NewKeySet -. If signingKeyId is empty, the only private key in keys is used for signing.
+func NewKeySet(signingKeyId string, keys ...Key) (*KeySet, error) {
+	s := &KeySet{keys: make(map[string]Key, len(keys))}
+
+	var privateKeys []Key
+	for _, key := range keys {
+		if _, ok := s.keys[key.Id]; ok {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateKey, key.Id)
+		}
+		s.keys[key.Id] = key
+		if key.PrivateKey != nil {
+			privateKeys = append(privateKeys, key)
+		}
+	}
+
+	if signingKeyId == "" {
+		if len(privateKeys) != 1 {
+			return nil, fmt.Errorf("%w: %d private keys, signing key id must be set", ErrNoSigningKey, len(privateKeys))
+		}
+		s.signing = privateKeys[0]
+		return s, nil
+	}
+
+	signing, ok := s.keys[signingKeyId]
+	if !ok || signing.PrivateKey == nil {
+		return nil, fmt.Errorf("%w: %q", ErrNoSigningKey, signingKeyId)
+	}
+	s.signing = signing
+
+	return s, nil
+}
+
+// SigningKey -.
+func (s *KeySet) SigningKey() Key {
+	return s.signing
+}
+
+// Key -.
+func (s *KeySet) Key(id string) (Key, bool) {
+	key, ok := s.keys[id]
+	return key, ok
+}
+
+// JWK is a public key in the RFC 7517 format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKS -.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns public parts of all keys ordered by kid.
+func (s *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(s.keys))}
+
+	for _, key := range s.keys {
+		jwk := JWK{Use: "sig", Alg: key.Method.Alg(), Kid: key.Id}
+		switch k := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(k)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+
+	return set
+}
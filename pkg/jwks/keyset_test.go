@@ -0,0 +1,248 @@
+package jwks
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func generateRSA(t *testing.T, id string) Key {
+	t.Helper()
+	privateKey, err := rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := newKey(id, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func generateEd25519(t *testing.T, id string) Key {
+	t.Helper()
+	key, err := GenerateEd25519(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newTestKeySet(t *testing.T, signingKeyId string, keys ...Key) *KeySet {
+	t.Helper()
+	set, err := NewKeySet(signingKeyId, keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return set
+}
+
+// publicOnly is a retired key: tokens it signed are still accepted, new ones are not signed with it.
+func publicOnly(key Key) Key {
+	key.PrivateKey = nil
+	return key
+}
+
+// sign and verify use the key set the way the auth service does.
+func sign(t *testing.T, set *KeySet) string {
+	t.Helper()
+	key := set.SigningKey()
+	token := jwt.NewWithClaims(key.Method, jwt.StandardClaims{Subject: "marcus"})
+	token.Header["kid"] = key.Id
+
+	signed, err := token.SignedString(key.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func verify(set *KeySet, tokenString string) error {
+	_, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := set.Key(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %q", kid)
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.PublicKey, nil
+	})
+	return err
+}
+
+func TestNewKeySet(t *testing.T) {
+	first := generateEd25519(t, "first")
+	second := generateEd25519(t, "second")
+
+	testCases := []struct {
+		name         string
+		signingKeyId string
+		keys         []Key
+		wantSigning  string
+		wantErr      error
+	}{
+		{
+			name:        "only private key",
+			keys:        []Key{first, publicOnly(second)},
+			wantSigning: "first",
+		},
+		{
+			name:         "explicit signing key",
+			signingKeyId: "second",
+			keys:         []Key{first, second},
+			wantSigning:  "second",
+		},
+		{
+			name:    "several private keys without id",
+			keys:    []Key{first, second},
+			wantErr: ErrNoSigningKey,
+		},
+		{
+			name:    "no private keys",
+			keys:    []Key{publicOnly(first)},
+			wantErr: ErrNoSigningKey,
+		},
+		{
+			name:    "no keys",
+			wantErr: ErrNoSigningKey,
+		},
+		{
+			name:         "unknown signing key",
+			signingKeyId: "third",
+			keys:         []Key{first, second},
+			wantErr:      ErrNoSigningKey,
+		},
+		{
+			name:         "signing key without private part",
+			signingKeyId: "second",
+			keys:         []Key{first, publicOnly(second)},
+			wantErr:      ErrNoSigningKey,
+		},
+		{
+			name:         "duplicate key id",
+			signingKeyId: "first",
+			keys:         []Key{first, first},
+			wantErr:      ErrDuplicateKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			set, err := NewKeySet(tc.signingKeyId, tc.keys...)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantSigning, set.SigningKey().Id)
+		})
+	}
+}
+
+func TestKeySet_SignVerify(t *testing.T) {
+	testCases := []struct {
+		name    string
+		key     Key
+		wantAlg string
+	}{
+		{name: "RS256", key: generateRSA(t, "rsa"), wantAlg: "RS256"},
+		{name: "EdDSA", key: generateEd25519(t, "ed25519"), wantAlg: "EdDSA"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			set := newTestKeySet(t, "", tc.key)
+
+			signed := sign(t, set)
+			token, _, err := new(jwt.Parser).ParseUnverified(signed, &jwt.StandardClaims{})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantAlg, token.Header["alg"])
+			assert.Equal(t, tc.key.Id, token.Header["kid"])
+
+			assert.NoError(t, verify(set, signed))
+
+			// Flip a character of the signature.
+			tampered := []byte(signed)
+			last := len(tampered) - 2
+			if tampered[last] == 'A' {
+				tampered[last] = 'B'
+			} else {
+				tampered[last] = 'A'
+			}
+			assert.Error(t, verify(set, string(tampered)))
+		})
+	}
+}
+
+func TestKeySet_UnknownKey(t *testing.T) {
+	set := newTestKeySet(t, "", generateEd25519(t, "current"))
+
+	t.Run("unknown kid", func(t *testing.T) {
+		other := newTestKeySet(t, "", generateEd25519(t, "other"))
+		assert.Error(t, verify(set, sign(t, other)))
+
+		_, ok := set.Key("other")
+		assert.False(t, ok)
+	})
+
+	t.Run("known kid of another key", func(t *testing.T) {
+		other := newTestKeySet(t, "", generateEd25519(t, "current"))
+		assert.Error(t, verify(set, sign(t, other)))
+	})
+
+	t.Run("algorithm of another key", func(t *testing.T) {
+		other := newTestKeySet(t, "", generateRSA(t, "current"))
+		assert.Error(t, verify(set, sign(t, other)))
+	})
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	retiredKey := generateEd25519(t, "2025-01")
+	currentKey := generateRSA(t, "2025-03")
+
+	before := newTestKeySet(t, "", retiredKey)
+	oldToken := sign(t, before)
+
+	// The new key signs, the old one only verifies the tokens issued before the rotation.
+	during := newTestKeySet(t, "2025-03", publicOnly(retiredKey), currentKey)
+	newToken := sign(t, during)
+	assert.Equal(t, "2025-03", during.SigningKey().Id)
+	assert.NoError(t, verify(during, oldToken))
+	assert.NoError(t, verify(during, newToken))
+	assert.Error(t, verify(before, newToken))
+
+	// Once the old tokens expired, the old key is removed.
+	after := newTestKeySet(t, "", currentKey)
+	assert.Error(t, verify(after, oldToken))
+	assert.NoError(t, verify(after, newToken))
+}
+
+func TestKeySet_JWKS(t *testing.T) {
+	edKey := generateEd25519(t, "b-ed25519")
+	rsaKey := generateRSA(t, "a-rsa")
+	set := newTestKeySet(t, "b-ed25519", edKey, publicOnly(rsaKey))
+
+	got := set.JWKS()
+	if !assert.Len(t, got.Keys, 2) {
+		return
+	}
+
+	assert.Equal(t, "a-rsa", got.Keys[0].Kid)
+	assert.Equal(t, "RSA", got.Keys[0].Kty)
+	assert.Equal(t, "RS256", got.Keys[0].Alg)
+	assert.Equal(t, "sig", got.Keys[0].Use)
+	assert.Equal(t, "AQAB", got.Keys[0].E)
+	assert.NotEmpty(t, got.Keys[0].N)
+
+	assert.Equal(t, "b-ed25519", got.Keys[1].Kid)
+	assert.Equal(t, "OKP", got.Keys[1].Kty)
+	assert.Equal(t, "Ed25519", got.Keys[1].Crv)
+	assert.Equal(t, "EdDSA", got.Keys[1].Alg)
+	assert.NotEmpty(t, got.Keys[1].X)
+}
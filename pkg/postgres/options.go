@@ -1,6 +1,9 @@
 package postgres
 
-import "time"
+import (
+	"github.com/jackc/pgx/v5"
+	"time"
+)
 
 // Option -.
 type Option func(*Postgres)
@@ -25,3 +28,24 @@ func ConnTimeout(timeout time.Duration) Option {
 		p.connTimeout = timeout
 	}
 }
+
+// IsoLevel sets the isolation level of transactions started by WithinTransaction.
+func IsoLevel(level pgx.TxIsoLevel) Option {
+	return func(p *Postgres) {
+		p.isoLevel = level
+	}
+}
+
+// TxRetries sets how many times a transaction is retried after a serialization failure or a deadlock.
+func TxRetries(retries int) Option {
+	return func(p *Postgres) {
+		p.txRetries = retries
+	}
+}
+
+// TxRetryDelay sets the delay before the first retry, it doubles with each retry.
+func TxRetryDelay(delay time.Duration) Option {
+	return func(p *Postgres) {
+		p.txRetryDelay = delay
+	}
+}
@@ -2,12 +2,14 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	log "github.com/sirupsen/logrus"
+	"math/rand/v2"
 	"time"
 )
 
@@ -15,6 +17,16 @@ const (
 	defaultMaxPoolSize  = 1
 	defaultConnAttempts = 10
 	defaultConnTimeout  = time.Second
+	defaultIsoLevel     = pgx.ReadCommitted
+	defaultTxRetries    = 3
+	defaultTxRetryDelay = 10 * time.Millisecond
+	maxTxRetryDelay     = time.Second
+)
+
+// Коды ошибок, после которых транзакцию можно повторить целиком.
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
 )
 
 // txKey используется для хранения транзакции в контексте.
@@ -44,6 +56,7 @@ type PgxPool interface {
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 	Begin(ctx context.Context) (pgx.Tx, error)
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
 	Ping(ctx context.Context) error
 }
 
@@ -52,6 +65,9 @@ type Postgres struct {
 	maxPoolSize  int
 	connAttempts int
 	connTimeout  time.Duration
+	isoLevel     pgx.TxIsoLevel
+	txRetries    int
+	txRetryDelay time.Duration
 
 	Builder squirrel.StatementBuilderType
 	Pool    PgxPool
@@ -63,6 +79,9 @@ func New(url string, opts ...Option) (*Postgres, error) {
 		maxPoolSize:  defaultMaxPoolSize,
 		connAttempts: defaultConnAttempts,
 		connTimeout:  defaultConnTimeout,
+		isoLevel:     defaultIsoLevel,
+		txRetries:    defaultTxRetries,
+		txRetryDelay: defaultTxRetryDelay,
 	}
 
 	// Custom options
@@ -70,6 +89,18 @@ func New(url string, opts ...Option) (*Postgres, error) {
 		opt(pg)
 	}
 
+	switch pg.isoLevel {
+	case pgx.ReadCommitted, pgx.RepeatableRead, pgx.Serializable:
+	default:
+		return nil, fmt.Errorf("postgres - NewPostgres: unsupported isolation level %q", pg.isoLevel)
+	}
+	if pg.txRetries < 0 {
+		return nil, fmt.Errorf("postgres - NewPostgres: negative transaction retries %d", pg.txRetries)
+	}
+	if pg.txRetryDelay <= 0 {
+		return nil, fmt.Errorf("postgres - NewPostgres: transaction retry delay %s must be positive", pg.txRetryDelay)
+	}
+
 	pg.Builder = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 
 	poolConfig, err := pgxpool.ParseConfig(url)
@@ -117,19 +148,128 @@ func (pg *Postgres) GetQueryRunner(ctx context.Context) QueryRunner {
 	return pg.Pool
 }
 
-// WithinTransaction выполняет функцию fn в рамках транзакции.
+// WithinTransaction выполняет функцию fn в рамках транзакции с уровнем изоляции из настроек. Если в контексте
+// уже есть транзакция, fn выполняется в ней, а фиксирует её внешний вызов. Если транзакция прервана из-за
+// ошибки сериализации (40001) или взаимной блокировки (40P01), внешний вызов откатывает её и выполняет fn
+// заново, но не больше txRetries раз, поэтому fn не должна иметь побочных эффектов вне базы данных.
 func (pg *Postgres) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
-	tx, err := pg.Pool.Begin(ctx)
+	if _, ok := extractTx(ctx); ok {
+		return fn(ctx)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := pg.runTransaction(ctx, fn)
+		if err == nil || !isRetryable(err) || attempt >= pg.txRetries {
+			return err
+		}
+
+		delay := pg.retryDelay(attempt)
+		log.Debugf("postgres - WithinTransaction: retrying in %s after %v", delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// runTransaction выполняет одну попытку транзакции. Если до ошибки fn или фиксации запрос в транзакции
+// завершился ошибкой, после которой транзакцию можно повторить, она добавляется к возвращаемой ошибке.
+func (pg *Postgres) runTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := pg.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pg.isoLevel})
 	if err != nil {
-		return fmt.Errorf("postgres - WithinTransaction - Begin: %w", err)
+		return fmt.Errorf("postgres - WithinTransaction - BeginTx: %w", err)
 	}
 
 	defer func() {
 		_ = tx.Rollback(ctx)
 	}()
 
-	if err = fn(injectTx(ctx, tx)); err != nil {
-		return err
+	tracked := &trackedTx{Tx: tx}
+	err = fn(injectTx(ctx, tracked))
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil && tracked.failure != nil && !isRetryable(err) {
+		return fmt.Errorf("%w: %w", err, tracked.failure)
+	}
+	return err
+}
+
+// retryDelay растёт вдвое с каждой попыткой, но не больше maxTxRetryDelay, а случайная добавка разводит
+// по времени повторы транзакций, которые помешали друг другу.
+func (pg *Postgres) retryDelay(attempt int) time.Duration {
+	delay := min(pg.txRetryDelay, maxTxRetryDelay)
+	for range attempt {
+		if delay >= maxTxRetryDelay/2 {
+			delay = maxTxRetryDelay
+			break
+		}
+		delay *= 2
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// isRetryable проверяет, что транзакция прервана из-за ошибки сериализации или взаимной блокировки.
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected)
+}
+
+// trackedTx запоминает первую ошибку запроса, после которой транзакцию можно повторить. Сервисы заменяют
+// ошибки репозиториев своими, поэтому по ошибке fn причину узнать нельзя.
+type trackedTx struct {
+	pgx.Tx
+	failure *pgconn.PgError
+}
+
+func (t *trackedTx) track(err error) {
+	var pgErr *pgconn.PgError
+	if t.failure == nil && isRetryable(err) && errors.As(err, &pgErr) {
+		t.failure = pgErr
+	}
+}
+
+func (t *trackedTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tag, err := t.Tx.Exec(ctx, sql, args...)
+	t.track(err)
+	return tag, err
+}
+
+func (t *trackedTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	rows, err := t.Tx.Query(ctx, sql, args...)
+	t.track(err)
+	if rows == nil {
+		return nil, err
 	}
-	return tx.Commit(ctx)
+	return &trackedRows{Rows: rows, tx: t}, err
+}
+
+func (t *trackedTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return &trackedRow{Row: t.Tx.QueryRow(ctx, sql, args...), tx: t}
+}
+
+type trackedRows struct {
+	pgx.Rows
+	tx *trackedTx
+}
+
+func (r *trackedRows) Err() error {
+	err := r.Rows.Err()
+	r.tx.track(err)
+	return err
+}
+
+type trackedRow struct {
+	pgx.Row
+	tx *trackedTx
+}
+
+func (r *trackedRow) Scan(dest ...any) error {
+	err := r.Row.Scan(dest...)
+	r.tx.track(err)
+	return err
 }
@@ -0,0 +1,202 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNew_InvalidOptions(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts []Option
+	}{
+		{
+			name: "unsupported isolation level",
+			opts: []Option{IsoLevel(pgx.ReadUncommitted)},
+		},
+		{
+			name: "negative retries",
+			opts: []Option{TxRetries(-1)},
+		},
+		{
+			name: "zero retry delay",
+			opts: []Option{TxRetryDelay(0)},
+		},
+		{
+			name: "negative retry delay",
+			opts: []Option{TxRetryDelay(-time.Second)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pg, err := New("postgres://localhost:1/db", tc.opts...)
+			assert.Error(t, err)
+			assert.Nil(t, pg)
+		})
+	}
+}
+
+func TestPostgres_WithinTransaction(t *testing.T) {
+	const query = "UPDATE users SET balance = balance - 1"
+
+	serializationErr := &pgconn.PgError{Code: serializationFailure}
+	deadlockErr := &pgconn.PgError{Code: deadlockDetected}
+	uniqueErr := &pgconn.PgError{Code: "23505"}
+	errService := errors.New("service error")
+
+	expectAttempt := func(m pgxmock.PgxPoolIface, err error) {
+		m.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+		if err != nil {
+			m.ExpectExec(query).WillReturnError(err)
+			m.ExpectRollback()
+			return
+		}
+		m.ExpectExec(query).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		m.ExpectCommit()
+	}
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(m pgxmock.PgxPoolIface)
+		// replaceErr makes fn return its own error instead of the error of the query, as services do.
+		replaceErr   bool
+		wantAttempts int
+		wantErr      error
+	}{
+		{
+			name: "success",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				expectAttempt(m, nil)
+			},
+			wantAttempts: 1,
+		},
+		{
+			name: "retried after serialization failure",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				expectAttempt(m, serializationErr)
+				expectAttempt(m, nil)
+			},
+			wantAttempts: 2,
+		},
+		{
+			name: "retried after deadlock",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				expectAttempt(m, deadlockErr)
+				expectAttempt(m, deadlockErr)
+				expectAttempt(m, nil)
+			},
+			wantAttempts: 3,
+		},
+		{
+			name: "retried when fn replaces the error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				expectAttempt(m, serializationErr)
+				expectAttempt(m, nil)
+			},
+			replaceErr:   true,
+			wantAttempts: 2,
+		},
+		{
+			name: "other error is not retried",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				expectAttempt(m, uniqueErr)
+			},
+			wantAttempts: 1,
+			wantErr:      uniqueErr,
+		},
+		{
+			name: "retries run out",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				for range 3 {
+					expectAttempt(m, serializationErr)
+				}
+			},
+			replaceErr:   true,
+			wantAttempts: 3,
+			wantErr:      errService,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			pg := &Postgres{
+				isoLevel:     pgx.ReadCommitted,
+				txRetries:    2,
+				txRetryDelay: time.Millisecond,
+				Pool:         poolMock,
+			}
+
+			var attempts int
+			err := pg.WithinTransaction(context.Background(), func(ctx context.Context) error {
+				attempts++
+				_, err := pg.GetQueryRunner(ctx).Exec(ctx, query)
+				if err != nil && tc.replaceErr {
+					return errService
+				}
+				return err
+			})
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantAttempts, attempts)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPostgres_WithinTransaction_Nested(t *testing.T) {
+	poolMock, _ := pgxmock.NewPool()
+	defer poolMock.Close()
+
+	poolMock.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	poolMock.ExpectCommit()
+
+	pg := &Postgres{isoLevel: pgx.ReadCommitted, txRetryDelay: time.Millisecond, Pool: poolMock}
+
+	err := pg.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		outer, _ := extractTx(ctx)
+		return pg.WithinTransaction(ctx, func(ctx context.Context) error {
+			inner, _ := extractTx(ctx)
+			assert.Same(t, outer, inner)
+			return nil
+		})
+	})
+	assert.NoError(t, err)
+
+	err = poolMock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestPostgres_retryDelay(t *testing.T) {
+	pg := &Postgres{txRetryDelay: 10 * time.Millisecond}
+
+	for attempt, want := range []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond} {
+		delay := pg.retryDelay(attempt)
+		assert.GreaterOrEqual(t, delay, want/2)
+		assert.LessOrEqual(t, delay, want)
+	}
+
+	for _, attempt := range []int{10, 64, 1000} {
+		delay := pg.retryDelay(attempt)
+		assert.Positive(t, delay)
+		assert.LessOrEqual(t, delay, maxTxRetryDelay)
+	}
+
+	pg.txRetryDelay = time.Hour
+	assert.LessOrEqual(t, pg.retryDelay(0), maxTxRetryDelay)
+}
@@ -1,38 +1,28 @@
 package validator
 
 import (
+	"errors"
 	"fmt"
 	"github.com/go-playground/validator/v10"
 	"reflect"
-	"regexp"
 	"strings"
 )
 
-const (
-	passwordMinLength = 8
-	passwordMaxLength = 32
-	passwordMinLower  = 1
-	passwordMinUpper  = 1
-	passwordMinDigit  = 1
-	passwordMinSymbol = 1
-)
-
-var (
-	lengthRegexp    = regexp.MustCompile(fmt.Sprintf(`^.{%d,%d}$`, passwordMinLength, passwordMaxLength))
-	lowerCaseRegexp = regexp.MustCompile(fmt.Sprintf(`[a-z]{%d,}`, passwordMinLower))
-	upperCaseRegexp = regexp.MustCompile(fmt.Sprintf(`[A-Z]{%d,}`, passwordMinUpper))
-	digitRegexp     = regexp.MustCompile(fmt.Sprintf(`[0-9]{%d,}`, passwordMinDigit))
-	symbolRegexp    = regexp.MustCompile(fmt.Sprintf(`[!@#$%%^&*]{%d,}`, passwordMinSymbol))
-)
-
+// CustomValidator is safe for concurrent use: it keeps no per-request state.
 type CustomValidator struct {
-	v         *validator.Validate
-	passwdErr error
+	v        *validator.Validate
+	policy   PasswordPolicy
+	denylist map[string]struct{}
 }
 
-func NewCustomValidator() *CustomValidator {
+func NewCustomValidator(opts ...Option) *CustomValidator {
 	v := validator.New()
-	cv := &CustomValidator{v: v}
+	cv := &CustomValidator{v: v, policy: DefaultPasswordPolicy()}
+
+	// Custom options
+	for _, opt := range opts {
+		opt(cv)
+	}
 
 	v.RegisterTagNameFunc(func(fld reflect.StructField) string {
 		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
@@ -50,60 +40,80 @@ func NewCustomValidator() *CustomValidator {
 	return cv
 }
 
+// Validate returns *ValidationError listing every failed field.
 func (cv *CustomValidator) Validate(i any) error {
 	err := cv.v.Struct(i)
-	if err != nil {
-		fieldErr := err.(validator.ValidationErrors)[0]
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
 
-		return cv.newValidationError(fieldErr.Field(), fieldErr.Value(), fieldErr.Tag(), fieldErr.Param())
+	fields := make([]FieldError, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		fields = append(fields, cv.newFieldError(fieldErr))
 	}
-	return nil
+
+	return &ValidationError{Fields: fields}
 }
 
-func (cv *CustomValidator) newValidationError(field string, _ any, tag string, param string) error {
-	switch tag {
+func (cv *CustomValidator) newFieldError(fieldErr validator.FieldError) FieldError {
+	field := fieldErr.Field()
+	fieldError := FieldError{
+		Field: field,
+		Code:  fieldErr.Tag(),
+		Param: fieldErr.Param(),
+	}
+
+	switch fieldErr.Tag() {
 	case "required":
-		return fmt.Errorf("field %s is required", field)
+		fieldError.Message = fmt.Sprintf("field %s is required", field)
 	case "email":
-		return fmt.Errorf("field %s must be a valid email address", field)
+		fieldError.Message = fmt.Sprintf("field %s must be a valid email address", field)
 	case "password":
-		return cv.passwdErr
+		// The reason is derived from the value again, the validation func cannot return it.
+		value, ok := fieldErr.Value().(string)
+		if !ok {
+			fieldError.Message = fmt.Sprintf("field %s must be a string", field)
+			break
+		}
+		fieldError.Param, fieldError.Message = cv.passwordViolation(field, value)
 	case "min":
-		return fmt.Errorf("field %s must be at least %s characters", field, param)
+		fieldError.Message = fmt.Sprintf("field %s must be at least %s%s", field, fieldErr.Param(), unit(fieldErr.Kind()))
 	case "max":
-		return fmt.Errorf("field %s must be at most %s characters", field, param)
+		fieldError.Message = fmt.Sprintf("field %s must be at most %s%s", field, fieldErr.Param(), unit(fieldErr.Kind()))
+	case "gt":
+		fieldError.Message = fmt.Sprintf("field %s must be greater than %s", field, fieldErr.Param())
+	case "oneof":
+		fieldError.Message = fmt.Sprintf("field %s must be one of: %s", field, fieldErr.Param())
 	default:
-		return fmt.Errorf("field %s is invalid", field)
+		fieldError.Message = fmt.Sprintf("field %s is invalid", field)
+	}
+
+	return fieldError
+}
+
+// unit returns the unit min and max are measured in for the kind of field.
+func unit(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return " items"
+	default:
+		return ""
 	}
 }
 
 func (cv *CustomValidator) passwordValidate(fl validator.FieldLevel) bool {
 	// check if the field is a string
 	if fl.Field().Kind() != reflect.String {
-		cv.passwdErr = fmt.Errorf("field %s must be a string", fl.FieldName())
-		return false
-	}
-
-	// get the value of the field
-	fieldValue := fl.Field().String()
-
-	// check regexp matching
-	if ok := lengthRegexp.MatchString(fieldValue); !ok {
-		cv.passwdErr = fmt.Errorf("field %s must be between %d and %d characters", fl.FieldName(), passwordMinLength, passwordMaxLength)
-		return false
-	} else if ok = lowerCaseRegexp.MatchString(fieldValue); !ok {
-		cv.passwdErr = fmt.Errorf("field %s must contain at least %d lowercase letter(s)", fl.FieldName(), passwordMinLower)
-		return false
-	} else if ok = upperCaseRegexp.MatchString(fieldValue); !ok {
-		cv.passwdErr = fmt.Errorf("field %s must contain at least %d uppercase letter(s)", fl.FieldName(), passwordMinUpper)
-		return false
-	} else if ok = digitRegexp.MatchString(fieldValue); !ok {
-		cv.passwdErr = fmt.Errorf("field %s must contain at least %d digit(s)", fl.FieldName(), passwordMinDigit)
-		return false
-	} else if ok = symbolRegexp.MatchString(fieldValue); !ok {
-		cv.passwdErr = fmt.Errorf("field %s must contain at least %d special character(s)", fl.FieldName(), passwordMinSymbol)
 		return false
 	}
 
-	return true
+	rule, _ := cv.passwordViolation(fl.FieldName(), fl.Field().String())
+	return len(rule) == 0
 }
@@ -0,0 +1,151 @@
+package validator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+type testInput struct {
+	Name     string   `json:"name" validate:"required,min=4,max=64"`
+	Password string   `json:"password" validate:"required,password"`
+	Users    []string `json:"users" validate:"min=1"`
+	Role     string   `json:"role" validate:"oneof=user admin"`
+}
+
+func TestCustomValidator_Validate(t *testing.T) {
+	testCases := []struct {
+		name       string
+		input      testInput
+		wantFields []FieldError
+	}{
+		{
+			name: "valid",
+			input: testInput{
+				Name:     "marcus",
+				Password: "simplePa66!",
+				Users:    []string{"alice"},
+				Role:     "user",
+			},
+			wantFields: nil,
+		},
+		{
+			name: "every field fails",
+			input: testInput{
+				Name:     "bob",
+				Password: "short",
+				Role:     "owner",
+			},
+			wantFields: []FieldError{
+				{Field: "name", Code: "min", Param: "4", Message: "field name must be at least 4 characters"},
+				{Field: "password", Code: "password", Param: "length", Message: "field password must be between 8 and 32 characters"},
+				{Field: "users", Code: "min", Param: "1", Message: "field users must be at least 1 items"},
+				{Field: "role", Code: "oneof", Param: "user admin", Message: "field role must be one of: user admin"},
+			},
+		},
+		{
+			name: "required",
+			input: testInput{
+				Users: []string{"alice"},
+				Role:  "admin",
+			},
+			wantFields: []FieldError{
+				{Field: "name", Code: "required", Message: "field name is required"},
+				{Field: "password", Code: "required", Message: "field password is required"},
+			},
+		},
+		{
+			name: "password rule",
+			input: testInput{
+				Name:     "marcus",
+				Password: "simplepa66!",
+				Users:    []string{"alice"},
+				Role:     "user",
+			},
+			wantFields: []FieldError{
+				{Field: "password", Code: "password", Param: "upper", Message: "field password must contain at least 1 uppercase letter(s)"},
+			},
+		},
+	}
+
+	cv := NewCustomValidator()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := cv.Validate(tc.input)
+			if tc.wantFields == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var validationErr *ValidationError
+			if !assert.True(t, errors.As(err, &validationErr)) {
+				return
+			}
+			assert.Equal(t, tc.wantFields, validationErr.Fields)
+		})
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	err := &ValidationError{Fields: []FieldError{
+		{Field: "name", Code: "required", Message: "field name is required"},
+		{Field: "amount", Code: "gt", Param: "0", Message: "field amount must be greater than 0"},
+	}}
+
+	assert.Equal(t, "field name is required; field amount must be greater than 0", err.Error())
+
+	got, marshalErr := json.Marshal(err.Fields)
+	assert.NoError(t, marshalErr)
+	assert.JSONEq(t, `[
+		{"field": "name", "code": "required", "message": "field name is required"},
+		{"field": "amount", "code": "gt", "param": "0", "message": "field amount must be greater than 0"}
+	]`, string(got))
+}
+
+// Run with -race: a single validator serves all requests.
+func TestCustomValidator_ValidateConcurrent(t *testing.T) {
+	cv := NewCustomValidator(Denylist([]string{"Password1!"}))
+
+	const goroutines = 32
+	const iterations = 50
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < iterations; j++ {
+				valid := testInput{
+					Name:     fmt.Sprintf("user-%d-%d", i, j),
+					Password: fmt.Sprintf("simplePa%d!", i),
+					Users:    []string{"alice"},
+					Role:     "user",
+				}
+				assert.NoError(t, cv.Validate(valid))
+
+				invalid := valid
+				invalid.Password = "password1!"
+				if i%2 == 0 {
+					invalid.Password = "Password1!"
+				}
+
+				var validationErr *ValidationError
+				if assert.True(t, errors.As(cv.Validate(invalid), &validationErr)) && assert.Len(t, validationErr.Fields, 1) {
+					want := "upper"
+					if i%2 == 0 {
+						want = "denylist"
+					}
+					assert.Equal(t, want, validationErr.Fields[0].Param)
+				}
+
+				assert.NoError(t, cv.CheckPassword("password", valid.Password))
+			}
+		}(i)
+	}
+	wg.Wait()
+}
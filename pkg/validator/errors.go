@@ -0,0 +1,25 @@
+package validator
+
+import "strings"
+
+// FieldError describes a failed rule of a single field. Code is the name of the rule,
+// such as required or password, and Param is its argument or the part of it that failed.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned by CustomValidator.Validate and holds all failed fields.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Message)
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,23 @@
+package validator
+
+import "strings"
+
+// Option -.
+type Option func(*CustomValidator)
+
+// Policy -.
+func Policy(policy PasswordPolicy) Option {
+	return func(cv *CustomValidator) {
+		cv.policy = policy
+	}
+}
+
+// Denylist rejects the passwords regardless of case.
+func Denylist(passwords []string) Option {
+	return func(cv *CustomValidator) {
+		cv.denylist = make(map[string]struct{}, len(passwords))
+		for _, password := range passwords {
+			cv.denylist[strings.ToLower(password)] = struct{}{}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package validator
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordPolicy describes the rules of the password tag.
+type PasswordPolicy struct {
+	MinLength int `json:"minLength"`
+	// MaxLength of 0 means no limit.
+	MaxLength int `json:"maxLength"`
+	// MaxBytes limits the UTF-8 encoded length, e.g. to what the password hasher accepts. 0 means no limit.
+	MaxBytes  int `json:"maxBytes,omitempty"`
+	MinLower  int `json:"minLower"`
+	MinUpper  int `json:"minUpper"`
+	MinDigit  int `json:"minDigit"`
+	MinSymbol int `json:"minSymbol"`
+	// Symbols counted by MinSymbol. If empty, any punctuation or symbol character is counted.
+	Symbols string `json:"symbols"`
+	// PassphraseMinLength waives the character class requirements for passwords of at least
+	// this length, so long passphrases need not contain digits or symbols. 0 disables it.
+	PassphraseMinLength int `json:"passphraseMinLength"`
+}
+
+// DefaultPasswordPolicy returns the rules used unless the validator is given a policy.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength: 8,
+		MaxLength: 32,
+		MinLower:  1,
+		MinUpper:  1,
+		MinDigit:  1,
+		MinSymbol: 1,
+		Symbols:   "!@#$%^&*",
+	}
+}
+
+// PasswordRules is the password policy as shown to clients.
+type PasswordRules struct {
+	PasswordPolicy
+	// Denylist reports whether commonly used passwords are rejected.
+	Denylist bool `json:"denylist"`
+}
+
+// PasswordRules returns the rules the password tag checks.
+func (cv *CustomValidator) PasswordRules() PasswordRules {
+	return PasswordRules{PasswordPolicy: cv.policy, Denylist: len(cv.denylist) > 0}
+}
+
+// ReadDenylist reads passwords from a file, one per line. Empty lines and lines starting with # are skipped.
+func ReadDenylist(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("validator - ReadDenylist - os.Open: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var passwords []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords = append(passwords, line)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("validator - ReadDenylist - scanner.Err: %w", err)
+	}
+
+	return passwords, nil
+}
+
+// CheckPassword checks a password outside of a struct, e.g. one submitted to log in that creates an account.
+// It returns *ValidationError for the given field name if the password breaks the policy.
+func (cv *CustomValidator) CheckPassword(field, password string) error {
+	rule, message := cv.passwordViolation(field, password)
+	if len(rule) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: []FieldError{{Field: field, Code: "password", Param: rule, Message: message}}}
+}
+
+// passwordViolation returns the first password rule the value breaks and a message
+// describing it, or empty strings if the password is valid.
+func (cv *CustomValidator) passwordViolation(field, value string) (string, string) {
+	policy := cv.policy
+
+	length := utf8.RuneCountInString(value)
+	if length < policy.MinLength || (policy.MaxLength > 0 && length > policy.MaxLength) {
+		if policy.MaxLength > 0 {
+			return "length", fmt.Sprintf("field %s must be between %d and %d characters", field, policy.MinLength, policy.MaxLength)
+		}
+		return "length", fmt.Sprintf("field %s must be at least %d characters", field, policy.MinLength)
+	}
+	if policy.MaxBytes > 0 && len(value) > policy.MaxBytes {
+		return "length", fmt.Sprintf("field %s must be at most %d bytes", field, policy.MaxBytes)
+	}
+
+	if policy.PassphraseMinLength == 0 || length < policy.PassphraseMinLength {
+		var lower, upper, digit, symbol int
+		for _, r := range value {
+			switch {
+			case unicode.IsLower(r):
+				lower++
+			case unicode.IsUpper(r):
+				upper++
+			case unicode.IsDigit(r):
+				digit++
+			case cv.isSymbol(r):
+				symbol++
+			}
+		}
+
+		switch {
+		case lower < policy.MinLower:
+			return "lower", fmt.Sprintf("field %s must contain at least %d lowercase letter(s)", field, policy.MinLower)
+		case upper < policy.MinUpper:
+			return "upper", fmt.Sprintf("field %s must contain at least %d uppercase letter(s)", field, policy.MinUpper)
+		case digit < policy.MinDigit:
+			return "digit", fmt.Sprintf("field %s must contain at least %d digit(s)", field, policy.MinDigit)
+		case symbol < policy.MinSymbol:
+			if len(policy.Symbols) > 0 {
+				return "symbol", fmt.Sprintf("field %s must contain at least %d special character(s) of %s", field, policy.MinSymbol, policy.Symbols)
+			}
+			return "symbol", fmt.Sprintf("field %s must contain at least %d special character(s)", field, policy.MinSymbol)
+		}
+	}
+
+	if _, ok := cv.denylist[strings.ToLower(value)]; ok {
+		return "denylist", fmt.Sprintf("field %s is a commonly used password", field)
+	}
+
+	return "", ""
+}
+
+func (cv *CustomValidator) isSymbol(r rune) bool {
+	if len(cv.policy.Symbols) == 0 {
+		return unicode.IsPunct(r) || unicode.IsSymbol(r)
+	}
+	return strings.ContainsRune(cv.policy.Symbols, r)
+}
@@ -0,0 +1,244 @@
+package validator
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCustomValidator_passwordViolation(t *testing.T) {
+	passphrasePolicy := DefaultPasswordPolicy()
+	passphrasePolicy.MaxLength = 64
+	passphrasePolicy.PassphraseMinLength = 20
+
+	anySymbolPolicy := DefaultPasswordPolicy()
+	anySymbolPolicy.Symbols = ""
+
+	unlimitedPolicy := DefaultPasswordPolicy()
+	unlimitedPolicy.MaxLength = 0
+
+	bcryptPolicy := passphrasePolicy
+	bcryptPolicy.MaxLength = 128
+	bcryptPolicy.MaxBytes = 72
+	passphrase := strings.Repeat("correct horse battery staple ", 4)
+
+	testCases := []struct {
+		name     string
+		policy   PasswordPolicy
+		denylist []string
+		password string
+		wantRule string
+	}{
+		{
+			name:     "valid",
+			policy:   DefaultPasswordPolicy(),
+			password: "simplePa66!",
+			wantRule: "",
+		},
+		{
+			name:     "too short",
+			policy:   DefaultPasswordPolicy(),
+			password: "sPa6!",
+			wantRule: "length",
+		},
+		{
+			name:     "shortest allowed",
+			policy:   DefaultPasswordPolicy(),
+			password: "sPa6!xyz",
+			wantRule: "",
+		},
+		{
+			name:     "too long",
+			policy:   DefaultPasswordPolicy(),
+			password: "simplePa66!" + strings.Repeat("x", 22),
+			wantRule: "length",
+		},
+		{
+			name:     "longest allowed",
+			policy:   DefaultPasswordPolicy(),
+			password: "simplePa66!" + strings.Repeat("x", 21),
+			wantRule: "",
+		},
+		{
+			name:     "length is counted in characters",
+			policy:   DefaultPasswordPolicy(),
+			password: "Пароль66!" + strings.Repeat("ж", 23),
+			wantRule: "",
+		},
+		{
+			name:     "no length limit",
+			policy:   unlimitedPolicy,
+			password: "simplePa66!" + strings.Repeat("x", 200),
+			wantRule: "",
+		},
+		{
+			name:     "100-byte passphrase over the byte limit",
+			policy:   bcryptPolicy,
+			password: passphrase[:100],
+			wantRule: "length",
+		},
+		{
+			name:     "passphrase at the byte limit",
+			policy:   bcryptPolicy,
+			password: passphrase[:72],
+			wantRule: "",
+		},
+		{
+			name:     "byte limit counts encoded characters",
+			policy:   bcryptPolicy,
+			password: strings.Repeat("ж", 37),
+			wantRule: "length",
+		},
+		{
+			name:     "no lowercase letter",
+			policy:   DefaultPasswordPolicy(),
+			password: "SIMPLEPA66!",
+			wantRule: "lower",
+		},
+		{
+			name:     "no uppercase letter",
+			policy:   DefaultPasswordPolicy(),
+			password: "simplepa66!",
+			wantRule: "upper",
+		},
+		{
+			name:     "no digit",
+			policy:   DefaultPasswordPolicy(),
+			password: "simplePass!",
+			wantRule: "digit",
+		},
+		{
+			name:     "no symbol",
+			policy:   DefaultPasswordPolicy(),
+			password: "simplePa666",
+			wantRule: "symbol",
+		},
+		{
+			name:     "symbol not in the list",
+			policy:   DefaultPasswordPolicy(),
+			password: "simplePa66?",
+			wantRule: "symbol",
+		},
+		{
+			name:     "any symbol counts without a list",
+			policy:   anySymbolPolicy,
+			password: "simplePa66?",
+			wantRule: "",
+		},
+		{
+			name:     "non-latin letters count",
+			policy:   DefaultPasswordPolicy(),
+			password: "простойП66!",
+			wantRule: "",
+		},
+		{
+			name:     "passphrase waives character classes",
+			policy:   passphrasePolicy,
+			password: "correct horse battery staple",
+			wantRule: "",
+		},
+		{
+			name:     "short passphrase needs character classes",
+			policy:   passphrasePolicy,
+			password: "correct horse",
+			wantRule: "upper",
+		},
+		{
+			name:     "passphrase is still checked against the denylist",
+			policy:   passphrasePolicy,
+			denylist: []string{"correct horse battery staple"},
+			password: "Correct Horse Battery Staple",
+			wantRule: "denylist",
+		},
+		{
+			name:     "denylisted",
+			policy:   DefaultPasswordPolicy(),
+			denylist: []string{"Password1!"},
+			password: "Password1!",
+			wantRule: "denylist",
+		},
+		{
+			name:     "denylist ignores case",
+			policy:   DefaultPasswordPolicy(),
+			denylist: []string{"Password1!"},
+			password: "pASSWORD1!",
+			wantRule: "denylist",
+		},
+		{
+			name:     "length is checked before the denylist",
+			policy:   DefaultPasswordPolicy(),
+			denylist: []string{"Pa1!"},
+			password: "Pa1!",
+			wantRule: "length",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cv := NewCustomValidator(Policy(tc.policy), Denylist(tc.denylist))
+
+			rule, message := cv.passwordViolation("password", tc.password)
+			assert.Equal(t, tc.wantRule, rule)
+			if len(tc.wantRule) == 0 {
+				assert.Empty(t, message)
+				return
+			}
+			assert.Contains(t, message, "field password")
+		})
+	}
+}
+
+func TestCustomValidator_CheckPassword(t *testing.T) {
+	cv := NewCustomValidator()
+
+	assert.NoError(t, cv.CheckPassword("newPassword", "simplePa66!"))
+
+	var validationErr *ValidationError
+	err := cv.CheckPassword("newPassword", "simplePa66")
+	if assert.True(t, errors.As(err, &validationErr)) {
+		assert.Equal(t, []FieldError{{
+			Field:   "newPassword",
+			Code:    "password",
+			Param:   "symbol",
+			Message: "field newPassword must contain at least 1 special character(s) of !@#$%^&*",
+		}}, validationErr.Fields)
+	}
+}
+
+func TestCustomValidator_CheckPasswordMaxBytes(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+	policy.MaxLength = 128
+	policy.MaxBytes = 72
+	policy.PassphraseMinLength = 20
+	cv := NewCustomValidator(Policy(policy))
+
+	passphrase := strings.Repeat("correct horse battery staple ", 4)[:100]
+
+	var validationErr *ValidationError
+	err := cv.CheckPassword("password", passphrase)
+	if assert.True(t, errors.As(err, &validationErr)) {
+		assert.Equal(t, []FieldError{{
+			Field:   "password",
+			Code:    "password",
+			Param:   "length",
+			Message: "field password must be at most 72 bytes",
+		}}, validationErr.Fields)
+	}
+	assert.Equal(t, 72, cv.PasswordRules().MaxBytes)
+}
+
+func TestReadDenylist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "denylist.txt")
+	err := os.WriteFile(path, []byte("# common passwords\n\nPassword1!\n  qwerty123  \n#comment\nletmein\n"), 0o600)
+	assert.NoError(t, err)
+
+	got, err := ReadDenylist(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Password1!", "qwerty123", "letmein"}, got)
+
+	_, err = ReadDenylist(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
@@ -34,6 +34,11 @@ function getToken(user) {
         return tokens[user.username];
     }
 
+    // Users are registered explicitly, 409 means the user already exists
+    http.post(`${baseUrl}/auth/register`, JSON.stringify(user), {
+        headers: { 'Content-Type': 'application/json' },
+    });
+
     let loginRes = http.post(`${baseUrl}/auth`, JSON.stringify(user), {
         headers: { 'Content-Type': 'application/json' },
     });